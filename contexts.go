@@ -0,0 +1,139 @@
+package fractals
+
+import (
+	stdcontext "context"
+	"reflect"
+	"sync"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/faux/reflection"
+)
+
+// stdContextKey defines the key with which a standard library context.Context
+// is stored within a faux context.Context.
+const stdContextKey = "fractals.stdcontext"
+
+var stdCtxType = reflect.TypeOf((*stdcontext.Context)(nil)).Elem()
+
+// NewContext returns a new context.Context which carries the provided standard
+// library context, making its deadline and cancellation visible to the Handlers
+// receiving it.
+func NewContext(std stdcontext.Context) context.Context {
+	return WithContext(context.New(), std)
+}
+
+// WithContext stores the standard library context into the provided
+// context.Context, returning it. If ctx is nil, then a new context.Context is
+// created. Since context.Context is shared between the Handlers of a pipeline,
+// the stored context applies to all Handlers using ctx.
+func WithContext(ctx context.Context, std stdcontext.Context) context.Context {
+	if ctx == nil {
+		ctx = context.New()
+	}
+
+	if std != nil {
+		ctx.Set(stdContextKey, std)
+	}
+
+	return ctx
+}
+
+// StdContext returns the standard library context associated with the
+// provided context.Context. If none was stored through WithContext or
+// NewContext, then the context.Context itself is returned if it implements the
+// standard library interface, else context.Background() is returned.
+func StdContext(ctx context.Context) stdcontext.Context {
+	if std := storedContext(ctx); std != nil {
+		return std
+	}
+
+	return stdcontext.Background()
+}
+
+// ContextErr returns the error of the standard library context associated with
+// ctx, which is non-nil once that context has been cancelled or its deadline
+// has passed.
+func ContextErr(ctx context.Context) error {
+	if std := storedContext(ctx); std != nil {
+		return std.Err()
+	}
+
+	return nil
+}
+
+// storedContext returns the standard library context associated with ctx or nil.
+func storedContext(ctx context.Context) stdcontext.Context {
+	if ctx == nil {
+		return nil
+	}
+
+	if item, ok := ctx.Get(stdContextKey); ok {
+		if std, ok := item.(stdcontext.Context); ok {
+			return std
+		}
+	}
+
+	if std, ok := ctx.(stdcontext.Context); ok {
+		return std
+	}
+
+	return nil
+}
+
+// StdHandler returns a function which uses the standard library context.Context
+// which calls the provided Handler with a context.Context carrying the received
+// standard library context.
+func StdHandler(h Handler) func(stdcontext.Context, error, interface{}) (interface{}, error) {
+	return func(std stdcontext.Context, err error, data interface{}) (interface{}, error) {
+		return h(NewContext(std), err, data)
+	}
+}
+
+// SubscribeWithContext subscribes the observer to the source, ending the
+// subscription once the standard library context is cancelled or it's
+// deadline passes. This allows pipelines serving a request to be torn down
+// when the request ends.
+func SubscribeWithContext(std stdcontext.Context, source Observable, observer Observable, finalizers ...func()) *Subscription {
+	stop := make(chan struct{})
+
+	var once sync.Once
+	finalizers = append(finalizers, func() {
+		once.Do(func() { close(stop) })
+	})
+
+	sub := source.Subscribe(observer, finalizers...)
+
+	go func() {
+		select {
+		case <-std.Done():
+			sub.EndWith(std.Err())
+		case <-stop:
+		}
+	}()
+
+	return sub
+}
+
+// contextArgument returns true/false if the giving argument type is a context
+// type and true/false if that type is the standard library context.Context.
+func contextArgument(arg reflect.Type) (bool, bool) {
+	if ok, _ := reflection.CanSetForType(ctxType, arg); ok {
+		return true, false
+	}
+
+	if arg == stdCtxType {
+		return true, true
+	}
+
+	return false, false
+}
+
+// contextValue returns the reflect.Value of the context to be passed to a
+// function, converting ctx into a standard library context if required.
+func contextValue(ctx context.Context, std bool) reflect.Value {
+	if std {
+		return reflect.ValueOf(StdContext(ctx))
+	}
+
+	return reflect.ValueOf(ctx)
+}
@@ -0,0 +1,187 @@
+package fhttp
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/influx6/faux/context"
+)
+
+var (
+	// ErrMissingAPIKey is returned when a request carries no API key.
+	ErrMissingAPIKey = errors.New("Missing API key")
+
+	// ErrInvalidAPIKey is returned when a request's API key is not valid.
+	ErrInvalidAPIKey = errors.New("Invalid API key")
+)
+
+// identityKey defines the key the Identity is stored under within the
+// context.
+const identityKey = "fhttp.identity"
+
+// Identity defines the client an API key belongs to.
+type Identity struct {
+	Subject string                 `json:"sub"`
+	Scopes  []string               `json:"scopes,omitempty"`
+	Extras  map[string]interface{} `json:"extras,omitempty"`
+}
+
+// HasScope returns true/false if the identity was granted the scope.
+func (i Identity) HasScope(scope string) bool {
+	for _, item := range i.Scopes {
+		if item == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// APIKeyValidator defines the interface of the validators which check API
+// keys, returning the Identity the key belongs to or ErrInvalidAPIKey.
+type APIKeyValidator interface {
+	Validate(key string) (Identity, error)
+}
+
+// APIKeyValidatorFunc defines a function which implements APIKeyValidator.
+type APIKeyValidatorFunc func(key string) (Identity, error)
+
+// Validate calls the function with the key.
+func (fn APIKeyValidatorFunc) Validate(key string) (Identity, error) {
+	return fn(key)
+}
+
+// StaticKeys returns an APIKeyValidator which accepts only the keys within
+// the map, returning the Identity they map to.
+func StaticKeys(keys map[string]Identity) APIKeyValidator {
+	return APIKeyValidatorFunc(func(key string) (Identity, error) {
+		// Compare against every key so the time taken leaks nothing.
+		var found Identity
+		var ok bool
+
+		for candidate, identity := range keys {
+			if subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+				found, ok = identity, true
+			}
+		}
+
+		if !ok {
+			return Identity{}, ErrInvalidAPIKey
+		}
+
+		return found, nil
+	})
+}
+
+// IntrospectionValidator returns an APIKeyValidator which checks keys with
+// the introspection endpoint at endpoint, in the manner of OAuth2 token
+// introspection: the key is posted as the "token" form value and a JSON
+// response with "active", "sub" and a space separated "scope" is expected.
+// If client is nil, http.DefaultClient is used.
+func IntrospectionValidator(endpoint string, client *http.Client) APIKeyValidator {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return APIKeyValidatorFunc(func(key string) (Identity, error) {
+		res, err := client.PostForm(endpoint, url.Values{"token": {key}})
+		if err != nil {
+			return Identity{}, err
+		}
+
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			return Identity{}, fmt.Errorf("Introspection failed with status %d", res.StatusCode)
+		}
+
+		var result struct {
+			Active  bool   `json:"active"`
+			Subject string `json:"sub"`
+			Scope   string `json:"scope"`
+		}
+
+		if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+			return Identity{}, err
+		}
+
+		if !result.Active {
+			return Identity{}, ErrInvalidAPIKey
+		}
+
+		return Identity{Subject: result.Subject, Scopes: strings.Fields(result.Scope)}, nil
+	})
+}
+
+// APIKeyOptions defines where APIKey looks for the API key of requests.
+type APIKeyOptions struct {
+	// Header sets the header holding the key, defaulting to "X-API-Key". A
+	// "Bearer " prefix is stripped, so "Authorization" may be used.
+	Header string
+
+	// Query sets the URL query parameter holding the key, which is checked
+	// if the header is missing. If empty, the query is not checked.
+	Query string
+}
+
+// APIKey returns a DriveMiddleware which validates the API key of every
+// request through the validator, storing the Identity it belongs to within
+// the context, where it is available through Request.Identity. Requests
+// without a valid key are responded to with a 401 status, and requests which
+// could not be validated with a 500 status, stopping them from being handled
+// any further.
+func APIKey(validator APIKeyValidator, opts APIKeyOptions) DriveMiddleware {
+	if opts.Header == "" {
+		opts.Header = "X-API-Key"
+	}
+
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		key := strings.TrimSpace(rw.Req.Header.Get(opts.Header))
+		if len(key) > 7 && strings.EqualFold(key[:7], "Bearer ") {
+			key = strings.TrimSpace(key[7:])
+		}
+
+		if key == "" && opts.Query != "" {
+			key = rw.Req.URL.Query().Get(opts.Query)
+		}
+
+		if key == "" {
+			rw.RespondError(http.StatusUnauthorized, ErrMissingAPIKey)
+			return nil, ErrMissingAPIKey
+		}
+
+		identity, err := validator.Validate(key)
+		if err == ErrInvalidAPIKey {
+			rw.RespondError(http.StatusUnauthorized, err)
+			return nil, err
+		}
+
+		if err != nil {
+			rw.RespondError(http.StatusInternalServerError, err)
+			return nil, err
+		}
+
+		ctx.Set(identityKey, identity)
+		return rw, nil
+	}
+}
+
+// Identity returns the Identity added to the context by the APIKey
+// middleware, returning false if none was.
+func (r *Request) Identity(ctx context.Context) (Identity, bool) {
+	if ctx == nil {
+		return Identity{}, false
+	}
+
+	identity, ok := ctx.Get(identityKey)
+	if !ok {
+		return Identity{}, false
+	}
+
+	return identity.(Identity), true
+}
@@ -0,0 +1,327 @@
+package fhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influx6/faux/context"
+)
+
+var (
+	// ErrInvalidBindTarget is returned when values are bound into anything
+	// other than a pointer to a struct.
+	ErrInvalidBindTarget = errors.New("Expected a pointer to a struct")
+
+	// ErrUnsupportedBody is returned when a request body's Content-Type can
+	// not be bound.
+	ErrUnsupportedBody = errors.New("Unsupported request body Content-Type")
+)
+
+// MaxBindBodySize defines the size in bytes of the largest request body
+// BindBody reads.
+var MaxBindBodySize int64 = 10 << 20
+
+// BindError defines the error returned when values fail to bind into the
+// fields of a struct, holding the details of every failed field. It is
+// rendered with it's fields by RenderResponseError.
+type BindError struct {
+	Fields []Field
+}
+
+// Error returns the names of the fields which failed to bind.
+func (b *BindError) Error() string {
+	names := make([]string, 0, len(b.Fields))
+	for _, field := range b.Fields {
+		names = append(names, field.Name)
+	}
+
+	return fmt.Sprintf("Invalid fields: %s", strings.Join(names, ", "))
+}
+
+// BindParams populates the fields of the struct dst points to from the path
+// parameters of the request. Fields are matched to parameters through their
+// "param" tag, where a ",required" option fails the bind if the parameter is
+// missing, and values are converted to the type of their field. Fields which
+// fail to bind are returned within a *BindError.
+func BindParams(rw *Request, dst interface{}) error {
+	return bindValues(dst, "param", func(name string) ([]string, bool) {
+		val, ok := rw.Params.Get(name)
+		if !ok {
+			return nil, false
+		}
+
+		return []string{val}, true
+	})
+}
+
+// BindParamsMW returns a DriveMiddleware which binds the path parameters of
+// every request into a new value of the struct type sample is or points to,
+// storing a pointer to it within the context under key.
+func BindParamsMW(key string, sample interface{}) DriveMiddleware {
+	return bindMW(key, sample, BindParams)
+}
+
+// BindQuery populates the fields of the struct dst points to from the URL
+// query parameters of the request. Fields are matched to parameters through
+// their "query" tag, where a ",required" option fails the bind if the
+// parameter is missing, and a "default" tag provides the value of missing
+// parameters. Slice fields receive every value of repeated parameters, with
+// defaults split by commas, and time.Time fields expect RFC3339 timestamps or
+// dates. Fields which fail to bind are returned within a *BindError.
+func BindQuery(rw *Request, dst interface{}) error {
+	query := rw.Req.URL.Query()
+
+	return bindValues(dst, "query", func(name string) ([]string, bool) {
+		values, ok := query[name]
+		return values, ok
+	})
+}
+
+// BindQueryMW returns a DriveMiddleware which binds the URL query parameters
+// of every request into a new value of the struct type sample is or points
+// to, storing a pointer to it within the context under key.
+func BindQueryMW(key string, sample interface{}) DriveMiddleware {
+	return bindMW(key, sample, BindQuery)
+}
+
+// BindBody populates the fields of the struct dst points to from the body of
+// the request, reading at most MaxBindBodySize bytes. JSON bodies are decoded
+// with the encoding/json package, while urlencoded and multipart form values
+// are matched to fields through their "form" tag, just as BindQuery does with
+// query parameters. Bodies of other Content-Types fail with
+// ErrUnsupportedBody.
+func BindBody(rw *Request, dst interface{}) error {
+	mediaType, _, err := mime.ParseMediaType(rw.Req.Header.Get("Content-Type"))
+	if err != nil {
+		return ErrUnsupportedBody
+	}
+
+	rw.Req.Body = http.MaxBytesReader(rw.Res, rw.Req.Body, MaxBindBodySize)
+
+	var form map[string][]string
+
+	switch mediaType {
+	case "application/json":
+		if err := json.NewDecoder(rw.Req.Body).Decode(dst); err != nil && err != io.EOF {
+			return err
+		}
+
+		return nil
+	case "application/x-www-form-urlencoded":
+		if err := rw.Req.ParseForm(); err != nil {
+			return err
+		}
+
+		form = rw.Req.PostForm
+	case "multipart/form-data":
+		if err := rw.Req.ParseMultipartForm(MaxBindBodySize); err != nil {
+			return err
+		}
+
+		form = rw.Req.MultipartForm.Value
+	default:
+		return ErrUnsupportedBody
+	}
+
+	return bindValues(dst, "form", func(name string) ([]string, bool) {
+		values, ok := form[name]
+		return values, ok
+	})
+}
+
+// BindBodyMW returns a DriveMiddleware which binds the body of every request
+// into a new value of the struct type sample is or points to, storing a
+// pointer to it within the context under key.
+func BindBodyMW(key string, sample interface{}) DriveMiddleware {
+	return bindMW(key, sample, BindBody)
+}
+
+// bindMW returns a DriveMiddleware which binds into a new value of the struct
+// type sample is or points to, storing a pointer to it within the context
+// under key.
+func bindMW(key string, sample interface{}, bind func(*Request, interface{}) error) DriveMiddleware {
+	tm := reflect.TypeOf(sample)
+	if tm.Kind() == reflect.Ptr {
+		tm = tm.Elem()
+	}
+
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		dst := reflect.New(tm).Interface()
+
+		if err := bind(rw, dst); err != nil {
+			return nil, err
+		}
+
+		ctx.Set(key, dst)
+		return rw, nil
+	}
+}
+
+// bindValues populates the fields of the struct dst points to with the values
+// returned by lookup for the names within their tag.
+func bindValues(dst interface{}, tag string, lookup func(string) ([]string, bool)) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidBindTarget
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	var failed []Field
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		// Unexported fields can not be set.
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, opts := parseBindTag(field.Tag.Get(tag))
+		if name == "" || name == "-" {
+			continue
+		}
+
+		values, ok := lookup(name)
+		if !ok || len(values) == 0 {
+			if def, hasDefault := field.Tag.Lookup("default"); hasDefault {
+				values = []string{def}
+				if field.Type.Kind() == reflect.Slice {
+					values = strings.Split(def, ",")
+				}
+			}
+		}
+
+		if len(values) == 0 {
+			if opts["required"] {
+				failed = append(failed, Field{
+					Name:     name,
+					Error:    "Field is required",
+					Expected: field.Type.String(),
+				})
+			}
+
+			continue
+		}
+
+		if err := setBindValue(rv.Field(i), values); err != nil {
+			failed = append(failed, Field{
+				Name:     name,
+				Value:    strings.Join(values, ","),
+				Error:    err.Error(),
+				Expected: field.Type.String(),
+			})
+		}
+	}
+
+	if len(failed) != 0 {
+		return &BindError{Fields: failed}
+	}
+
+	return nil
+}
+
+// parseBindTag returns the name and options held by a binding tag.
+func parseBindTag(tag string) (string, map[string]bool) {
+	parts := strings.Split(tag, ",")
+
+	opts := make(map[string]bool, len(parts)-1)
+	for _, opt := range parts[1:] {
+		opts[strings.TrimSpace(opt)] = true
+	}
+
+	return strings.TrimSpace(parts[0]), opts
+}
+
+// setBindValue converts the values into the type of the field, setting it.
+// Slices receive all the values, while other fields receive the first.
+func setBindValue(field reflect.Value, values []string) error {
+	if field.Kind() != reflect.Slice {
+		return setBindScalar(field, values[0])
+	}
+
+	items := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for index, value := range values {
+		if err := setBindScalar(items.Index(index), value); err != nil {
+			return err
+		}
+	}
+
+	field.Set(items)
+	return nil
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// setBindScalar converts the value into the type of the field, setting it.
+func setBindScalar(field reflect.Value, value string) error {
+	switch field.Type() {
+	case timeType:
+		item, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if item, err = time.Parse("2006-01-02", value); err != nil {
+				return fmt.Errorf("Expected RFC3339 timestamp or date but got %q", value)
+			}
+		}
+
+		field.Set(reflect.ValueOf(item))
+		return nil
+	case durationType:
+		item, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(item))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		item, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(item)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		item, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(item)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		item, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(item)
+	case reflect.Float32, reflect.Float64:
+		item, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(item)
+	default:
+		return fmt.Errorf("Unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,359 @@
+// Package client provides fractals Handlers which call other HTTP services,
+// with timeouts, retries and pooled connections, so pipelines can call
+// services the same way fhttp serves them.
+package client
+
+import (
+	"bytes"
+	stdcontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+	"github.com/influx6/fractals/fhttp"
+)
+
+// ErrBodyTooLarge is returned when a service responds with a body larger than
+// the allowed size.
+var ErrBodyTooLarge = errors.New("Response body exceeds the allowed size")
+
+// StatusError is returned when a service responds with a status outside of
+// the 2xx range.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Error returns the status of the failed response.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Request failed with status %d: %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Response defines the response of a service, whoes body has been read in
+// full.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// JSON decodes the body of the response as JSON into dst.
+func (r *Response) JSON(dst interface{}) error {
+	return json.Unmarshal(r.Body, dst)
+}
+
+// RequestBuilder defines a function which builds the request made for the
+// data a Handler receives. It is called for every attempt, so bodies are
+// created afresh for retries.
+type RequestBuilder func(ctx context.Context, data interface{}) (*http.Request, error)
+
+// Options defines the configuration of a Client.
+type Options struct {
+	// HTTPClient sets the client making requests, defaulting to one sharing
+	// a pooled transport.
+	HTTPClient *http.Client
+
+	// Timeout sets how long every attempt may take, defaulting to
+	// DefaultTimeout.
+	Timeout time.Duration
+
+	// Retries sets how many times failed requests are retried, where a zero
+	// value makes no retries. Requests whoes bodies are not safe to send
+	// twice should not be retried.
+	Retries int
+
+	// Backoff returns how long to wait before every retry, defaulting to an
+	// ExponentialBackoff from 100ms to 5s. A Retry-After header of the
+	// failed response extends the wait, up to MaxRetryAfter.
+	Backoff func(attempt int) time.Duration
+
+	// MaxRetryAfter sets the longest wait a Retry-After header may ask for,
+	// defaulting to DefaultMaxRetryAfter, so services can not stall the
+	// client for long.
+	MaxRetryAfter time.Duration
+
+	// MaxBodySize sets the size response bodies are limited to, reading past
+	// it fails with ErrBodyTooLarge. It defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+
+	// RetryOn returns true/false if the outcome of an attempt should be
+	// retried, defaulting to retrying network errors and 429, 502, 503 and
+	// 504 responses.
+	RetryOn func(res *http.Response, err error) bool
+
+	// Header sets headers sent with every request.
+	Header http.Header
+}
+
+// DefaultTimeout defines how long every attempt may take when Options
+// provides no timeout.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultMaxRetryAfter defines the longest wait a Retry-After header may ask
+// for when Options provides no limit.
+const DefaultMaxRetryAfter = 30 * time.Second
+
+// DefaultMaxBodySize defines the size response bodies are limited to when
+// Options provides no limit.
+const DefaultMaxBodySize = 10 << 20
+
+// pooledClient defines the client shared by Clients given none, whoes
+// transport keeps connections to every host open for reuse.
+var pooledClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	},
+}
+
+// Client defines a maker of Handlers calling HTTP services.
+type Client struct {
+	opts Options
+}
+
+// New returns a new Client configured with the options.
+func New(opts Options) *Client {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = pooledClient
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	if opts.Backoff == nil {
+		opts.Backoff = fractals.ExponentialBackoff(100*time.Millisecond, 5*time.Second)
+	}
+
+	if opts.RetryOn == nil {
+		opts.RetryOn = RetryTemporary
+	}
+
+	if opts.MaxRetryAfter == 0 {
+		opts.MaxRetryAfter = DefaultMaxRetryAfter
+	}
+
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+
+	return &Client{opts: opts}
+}
+
+// defaultClient defines the Client used by the package level Handlers.
+var defaultClient = New(Options{})
+
+// GetJSON returns a Handler which makes a GET request to target using the
+// default Client. See Client.GetJSON.
+func GetJSON(target string) fractals.Handler {
+	return defaultClient.GetJSON(target)
+}
+
+// PostJSON returns a Handler which posts what it receives as JSON to target
+// using the default Client. See Client.PostJSON.
+func PostJSON(target string) fractals.Handler {
+	return defaultClient.PostJSON(target)
+}
+
+// Do returns a Handler which makes the request built for what it receives
+// using the default Client. See Client.Do.
+func Do(build RequestBuilder) fractals.Handler {
+	return defaultClient.Do(build)
+}
+
+// GetJSON returns a Handler which makes a GET request to target, passing down
+// the response's body decoded from JSON. If it receives url.Values, they are
+// sent as the request's query.
+func (c *Client) GetJSON(target string) fractals.Handler {
+	return decodeJSON(c.Do(func(ctx context.Context, data interface{}) (*http.Request, error) {
+		req, err := http.NewRequest("GET", target, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if query, ok := data.(url.Values); ok {
+			req.URL.RawQuery = query.Encode()
+		}
+
+		req.Header.Set("Accept", "application/json")
+		return req, nil
+	}))
+}
+
+// PostJSON returns a Handler which posts what it receives encoded as JSON to
+// target, passing down the response's body decoded from JSON.
+func (c *Client) PostJSON(target string) fractals.Handler {
+	return decodeJSON(c.Do(func(ctx context.Context, data interface{}) (*http.Request, error) {
+		body, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequest("POST", target, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		return req, nil
+	}))
+}
+
+// Do returns a Handler which makes the request built for what it receives,
+// passing down the *Response. Every attempt is bound to the standard library
+// context carried by the context it receives and the client's timeout, and
+// carries the request id added by fhttp.RequestID. Responses outside of the
+// 2xx range fail with a *StatusError once the retries are exhausted.
+func (c *Client) Do(build RequestBuilder) fractals.Handler {
+	return func(ctx context.Context, err error, data interface{}) (interface{}, error) {
+		if err != nil {
+			return nil, err
+		}
+
+		std := fractals.StdContext(ctx)
+
+		for attempt := 0; ; attempt++ {
+			req, err := build(ctx, data)
+			if err != nil {
+				return nil, err
+			}
+
+			res, retryAfter, err := c.attempt(ctx, std, req)
+
+			if attempt >= c.opts.Retries || !c.opts.RetryOn(res.raw, err) {
+				if err != nil {
+					return nil, err
+				}
+
+				if res.StatusCode < 200 || res.StatusCode > 299 {
+					return nil, &StatusError{StatusCode: res.StatusCode, Body: res.Body}
+				}
+
+				return res.Response, nil
+			}
+
+			wait := c.opts.Backoff(attempt + 1)
+			if retryAfter > wait {
+				wait = retryAfter
+			}
+
+			timer := time.NewTimer(wait)
+
+			select {
+			case <-std.Done():
+				timer.Stop()
+				return nil, std.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// attemptResult defines the outcome of a single attempt.
+type attemptResult struct {
+	*Response
+	raw *http.Response
+}
+
+// attempt makes a single request, reading it's response in full, returning
+// how long the service asked to wait before retrying, if at all.
+func (c *Client) attempt(ctx context.Context, std stdcontext.Context, req *http.Request) (attemptResult, time.Duration, error) {
+	std, cancel := stdcontext.WithTimeout(std, c.opts.Timeout)
+	defer cancel()
+
+	req = req.WithContext(std)
+
+	for key, values := range c.opts.Header {
+		if _, ok := req.Header[key]; !ok {
+			req.Header[key] = values
+		}
+	}
+
+	fhttp.PropagateRequestID(ctx, req)
+
+	res, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return attemptResult{}, 0, err
+	}
+
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, c.opts.MaxBodySize+1))
+	if err != nil {
+		return attemptResult{}, 0, err
+	}
+
+	if int64(len(body)) > c.opts.MaxBodySize {
+		return attemptResult{}, 0, ErrBodyTooLarge
+	}
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = c.opts.MaxRetryAfter
+		if seconds < int(c.opts.MaxRetryAfter/time.Second) {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return attemptResult{
+		Response: &Response{StatusCode: res.StatusCode, Header: res.Header, Body: body},
+		raw:      res,
+	}, retryAfter, nil
+}
+
+// RetryTemporary returns true/false if the outcome of an attempt is worth
+// retrying, being a network error or a 429, 502, 503 or 504 response. Bodies
+// exceeding the allowed size are not retried.
+func RetryTemporary(res *http.Response, err error) bool {
+	if err != nil {
+		return err != ErrBodyTooLarge
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeJSON returns a Handler which decodes the body of the *Response the
+// handler passes down from JSON.
+func decodeJSON(h fractals.Handler) fractals.Handler {
+	return func(ctx context.Context, err error, data interface{}) (interface{}, error) {
+		res, err := h(ctx, err, data)
+		if err != nil {
+			return nil, err
+		}
+
+		response := res.(*Response)
+		if len(response.Body) == 0 {
+			return nil, nil
+		}
+
+		var decoded interface{}
+		if err := response.JSON(&decoded); err != nil {
+			return nil, err
+		}
+
+		return decoded, nil
+	}
+}
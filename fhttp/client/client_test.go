@@ -0,0 +1,155 @@
+package client_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals/fhttp/client"
+)
+
+// succeedMark is the Unicode codepoint for a check mark.
+const succeedMark = "\u2713"
+
+// failedMark is the Unicode codepoint for an X mark.
+const failedMark = "\u2717"
+
+func TestGetJSON(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"name": r.URL.Query().Get("name")})
+	}))
+	defer server.Close()
+
+	fetch := client.New(client.Options{
+		Retries: 2,
+		Backoff: func(int) time.Duration { return time.Millisecond },
+	}).GetJSON(server.URL)
+
+	res, err := fetch(context.New(), nil, url.Values{"name": {"alex"}})
+	if err != nil {
+		t.Fatalf("%s Expected request to succeed after retries: %s", failedMark, err)
+	}
+
+	if res.(map[string]interface{})["name"] != "alex" || atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("%s Expected decoded response after 3 calls but got %+v after %d", failedMark, res, calls)
+	}
+	t.Logf("%s Expected request to succeed after retries", succeedMark)
+
+	atomic.StoreInt32(&calls, 0)
+
+	_, err = client.New(client.Options{Retries: 1, Backoff: func(int) time.Duration { return 0 }}).GetJSON(server.URL)(context.New(), nil, nil)
+	if serr, ok := err.(*client.StatusError); !ok || serr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("%s Expected StatusError once retries are exhausted but got %v", failedMark, err)
+	}
+	t.Logf("%s Expected StatusError once retries are exhausted", succeedMark)
+}
+
+func TestPostJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		body["id"] = 1
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+
+	res, err := client.PostJSON(server.URL)(context.New(), nil, map[string]string{"name": "alex"})
+	if err != nil {
+		t.Fatalf("%s Expected post to succeed: %s", failedMark, err)
+	}
+
+	if item := res.(map[string]interface{}); item["name"] != "alex" || item["id"] != float64(1) {
+		t.Fatalf("%s Expected posted body echoed back but got %+v", failedMark, item)
+	}
+	t.Logf("%s Expected posted body echoed back", succeedMark)
+}
+
+func TestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+
+	_, err := client.New(client.Options{Timeout: 20 * time.Millisecond}).Do(func(ctx context.Context, _ interface{}) (*http.Request, error) {
+		return http.NewRequest("GET", server.URL, nil)
+	})(context.New(), nil, nil)
+
+	if err == nil || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("%s Expected attempt to time out but got %v", failedMark, err)
+	}
+	t.Logf("%s Expected attempt to time out", succeedMark)
+}
+
+func TestResponseLimits(t *testing.T) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large" {
+			atomic.AddInt32(&calls, 1)
+			w.Write([]byte(strings.Repeat("x", 64)))
+			return
+		}
+
+		if r.Header.Get("X-Retried") == "" {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var attempts int
+	limited := client.New(client.Options{
+		Retries:       1,
+		Backoff:       func(int) time.Duration { return 0 },
+		MaxRetryAfter: 10 * time.Millisecond,
+		MaxBodySize:   16,
+	})
+
+	start := time.Now()
+
+	res, err := limited.Do(func(ctx context.Context, _ interface{}) (*http.Request, error) {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if attempts++; attempts > 1 && err == nil {
+			req.Header.Set("X-Retried", "1")
+		}
+
+		return req, err
+	})(context.New(), nil, nil)
+
+	if err != nil || string(res.(*client.Response).Body) != "ok" || time.Since(start) > time.Second {
+		t.Fatalf("%s Expected Retry-After to be capped but got %v after %s", failedMark, err, time.Since(start))
+	}
+	t.Logf("%s Expected Retry-After to be capped", succeedMark)
+
+	_, err = limited.Do(func(ctx context.Context, _ interface{}) (*http.Request, error) {
+		return http.NewRequest("GET", server.URL+"/large", nil)
+	})(context.New(), nil, nil)
+
+	if err != client.ErrBodyTooLarge || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("%s Expected ErrBodyTooLarge without retries but got %v after %d calls", failedMark, err, calls)
+	}
+	t.Logf("%s Expected ErrBodyTooLarge without retries", succeedMark)
+}
@@ -0,0 +1,373 @@
+package fhttp
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influx6/faux/context"
+)
+
+// CompressEncoder defines a function which returns a writer compressing all
+// data written into w using the provided level.
+type CompressEncoder func(w io.Writer, level int) (io.WriteCloser, error)
+
+// DefaultCompressMinSize defines the size in bytes of the smallest response
+// body compressed when CompressOptions provides none.
+const DefaultCompressMinSize = 1024
+
+// DefaultCompressSkipTypes defines the prefixes of the Content-Types which are
+// already compressed and so are not compressed again.
+var DefaultCompressSkipTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"text/event-stream",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-bzip2",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/octet-stream",
+}
+
+// CompressOptions defines the configuration used by Compress.
+type CompressOptions struct {
+	// Level sets the compression level used by the encoders, where a zero
+	// value uses their default level.
+	Level int
+
+	// MinSize sets the size in bytes of the smallest response body which is
+	// compressed, defaulting to DefaultCompressMinSize.
+	MinSize int
+
+	// SkipTypes sets the prefixes of Content-Types which are not compressed,
+	// defaulting to DefaultCompressSkipTypes.
+	SkipTypes []string
+
+	// Encoders adds encoders for other Content-Encodings, such as "br" for
+	// brotli, which are preferred over the built in gzip and deflate encoders
+	// when accepted by the client.
+	Encoders map[string]CompressEncoder
+}
+
+// Compress returns a DriveMiddleware which compresses the response body using
+// gzip, deflate or any of the provided encoders as accepted by the request's
+// Accept-Encoding header. Bodies smaller than the minimum size or of already
+// compressed Content-Types are written as they are. The compressed data is
+// flushed once the request has been handled.
+func Compress(opts CompressOptions) DriveMiddleware {
+	if opts.MinSize <= 0 {
+		opts.MinSize = DefaultCompressMinSize
+	}
+
+	if opts.SkipTypes == nil {
+		opts.SkipTypes = DefaultCompressSkipTypes
+	}
+
+	encoders := map[string]CompressEncoder{
+		"gzip":    gzipEncoder,
+		"deflate": deflateEncoder,
+	}
+
+	var preferred []string
+	for name, encoder := range opts.Encoders {
+		encoders[name] = encoder
+		preferred = append(preferred, name)
+	}
+
+	sort.Strings(preferred)
+	preferred = append(preferred, "gzip", "deflate")
+
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		rw.Res.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(rw.Req.Header.Get("Accept-Encoding"), preferred)
+		if encoding == "" {
+			return rw, nil
+		}
+
+		rw.Res = &compressWriter{
+			ResponseWriter: rw.Res,
+			opts:           opts,
+			encoding:       encoding,
+			encoder:        encoders[encoding],
+		}
+
+		return rw, nil
+	}
+}
+
+// gzipEncoder returns a gzip writer using the provided level.
+func gzipEncoder(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	return gzip.NewWriterLevel(w, level)
+}
+
+// deflateEncoder returns a deflate writer using the provided level.
+func deflateEncoder(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+
+	return flate.NewWriter(w, level)
+}
+
+// negotiateEncoding returns the encoding from the preferred ones with the
+// highest quality within the Accept-Encoding header, or an empty string if
+// none is accepted.
+func negotiateEncoding(header string, preferred []string) string {
+	accepted := make(map[string]float64)
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, field := range fields[1:] {
+			field = strings.TrimSpace(field)
+			if strings.HasPrefix(field, "q=") {
+				if val, err := strconv.ParseFloat(field[2:], 64); err == nil {
+					q = val
+				}
+			}
+		}
+
+		accepted[name] = q
+	}
+
+	var best string
+	var bestQ float64
+
+	for _, name := range preferred {
+		q, ok := accepted[name]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+
+		if ok && q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+
+	return best
+}
+
+// compressWriter defines a ResponseWriter which buffers the start of the body
+// till it knows if the response should be compressed.
+type compressWriter struct {
+	ResponseWriter
+	opts     CompressOptions
+	encoding string
+	encoder  CompressEncoder
+
+	status   int
+	size     int
+	written  bool
+	decided  bool
+	buffered []byte
+	enc      io.WriteCloser
+}
+
+// WriteHeader stores the status till the body is written, as the headers may
+// still change depending on the body.
+func (c *compressWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+}
+
+// Write buffers the data till the response is large enough to be compressed,
+// writing it compressed afterwards.
+func (c *compressWriter) Write(data []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+
+	c.written = true
+	c.size += len(data)
+
+	if !c.decided {
+		c.buffered = append(c.buffered, data...)
+
+		if len(c.buffered) < c.opts.MinSize {
+			return len(data), nil
+		}
+
+		if err := c.decide(); err != nil {
+			return 0, err
+		}
+
+		return len(data), nil
+	}
+
+	if c.enc != nil {
+		return c.enc.Write(data)
+	}
+
+	return c.ResponseWriter.Write(data)
+}
+
+// Flush writes out all buffered data to the client.
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		c.decide()
+	}
+
+	if flusher, ok := c.enc.(interface {
+		Flush() error
+	}); ok {
+		flusher.Flush()
+	}
+
+	c.ResponseWriter.Flush()
+}
+
+// Close writes out all buffered data, ending the compressed stream, and
+// closes the inner writer.
+func (c *compressWriter) Close() error {
+	if !c.decided {
+		if err := c.decide(); err != nil {
+			return err
+		}
+	}
+
+	if c.enc != nil {
+		if err := c.enc.Close(); err != nil {
+			return err
+		}
+	}
+
+	// The inner writer, such as that of ETag, may hold back the compressed
+	// body till it is closed.
+	if closer, ok := c.ResponseWriter.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Hijack hands over the connection, as needed by WebSocket endpoints. Nothing
+// buffered is written out for hijacked requests.
+func (c *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := c.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrHijackUnsupported
+	}
+
+	c.decided = true
+	c.buffered = nil
+
+	return hijacker.Hijack()
+}
+
+// Status returns the status code of the response or 0 if none was written.
+func (c *compressWriter) Status() int {
+	return c.status
+}
+
+// StatusWritten returns true/false if the status was written.
+func (c *compressWriter) StatusWritten() bool {
+	return c.status != 0
+}
+
+// DataWritten returns true/false if Write was called.
+func (c *compressWriter) DataWritten() bool {
+	return c.written
+}
+
+// Size returns the size of the uncompressed response body.
+func (c *compressWriter) Size() int {
+	return c.size
+}
+
+// decide writes out the headers and buffered data, compressing them if the
+// response qualifies.
+func (c *compressWriter) decide() error {
+	c.decided = true
+
+	buffered := c.buffered
+	c.buffered = nil
+
+	if c.shouldCompress(buffered) {
+		enc, err := c.encoder(c.ResponseWriter, c.opts.Level)
+		if err != nil {
+			return err
+		}
+
+		c.enc = enc
+
+		// net/http does not sniff the type of encoded bodies, so it is
+		// sniffed from the uncompressed start of the body instead.
+		header := c.ResponseWriter.Header()
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", http.DetectContentType(buffered))
+		}
+
+		header.Set("Content-Encoding", c.encoding)
+		header.Del("Content-Length")
+	}
+
+	if c.status != 0 {
+		c.ResponseWriter.WriteHeader(c.status)
+	}
+
+	if len(buffered) == 0 {
+		return nil
+	}
+
+	if c.enc != nil {
+		_, err := c.enc.Write(buffered)
+		return err
+	}
+
+	_, err := c.ResponseWriter.Write(buffered)
+	return err
+}
+
+// shouldCompress returns true/false if the response with the provided start
+// of it's body should be compressed.
+func (c *compressWriter) shouldCompress(buffered []byte) bool {
+	if len(buffered) < c.opts.MinSize {
+		return false
+	}
+
+	// Partial content can not be compressed without changing the ranges
+	// it's headers describe.
+	if c.status == http.StatusNoContent || c.status == http.StatusNotModified || c.status == http.StatusPartialContent {
+		return false
+	}
+
+	header := c.ResponseWriter.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(buffered)
+	}
+
+	for _, skip := range c.opts.SkipTypes {
+		if strings.HasPrefix(contentType, skip) {
+			return false
+		}
+	}
+
+	return true
+}
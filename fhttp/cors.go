@@ -0,0 +1,283 @@
+package fhttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// ErrPreflightHandled is returned by CORSWith once it has responded to a CORS
+// preflight request, which stops the request from being handled any further.
+var ErrPreflightHandled = errors.New("CORS preflight request handled")
+
+// contains the default values used by CORSWith when CORSOptions provides none.
+var (
+	DefaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	DefaultCORSHeaders = []string{"Content-Type", "Authorization"}
+)
+
+// CORSOptions defines the configuration used by CORSWith.
+type CORSOptions struct {
+	// AllowedOrigins sets the origins allowed to make requests, where "*"
+	// allows all origins and a single "*" within an origin matches any part,
+	// as in "https://*.example.com". If empty, all origins are allowed.
+	AllowedOrigins []string
+
+	// AllowOriginFunc, if set, decides which origins are allowed in place of
+	// AllowedOrigins.
+	AllowOriginFunc func(origin string) bool
+
+	// AllowedMethods sets the methods allowed within preflight requests,
+	// defaulting to DefaultCORSMethods.
+	AllowedMethods []string
+
+	// AllowedHeaders sets the request headers allowed within preflight
+	// requests, where "*" allows whatever headers are requested. Defaults to
+	// DefaultCORSHeaders.
+	AllowedHeaders []string
+
+	// ExposedHeaders sets the response headers clients are allowed to read.
+	ExposedHeaders []string
+
+	// AllowCredentials allows requests to carry cookies and authorization
+	// headers, in which case the request's origin is sent back in place of
+	// "*".
+	AllowCredentials bool
+
+	// MaxAge sets how long clients may cache the results of a preflight
+	// request. A zero value sends no Access-Control-Max-Age header.
+	MaxAge time.Duration
+
+	// Overrides sets the options used for requests whose path starts with
+	// the key, where the longest matching key wins.
+	Overrides map[string]CORSOptions
+}
+
+// CORSWith returns a DriveMiddleware which adds the CORS headers allowed by
+// the options to responses for cross origin requests. Preflight requests are
+// responded to with a 204 status, returning ErrPreflightHandled to stop them
+// from being handled any further. It can be used globally, per Endpoint as
+// it's LocalMW, or through HTTPDrive.CORS which also routes preflight
+// requests for paths without their own OPTIONS Endpoint.
+func CORSWith(opts CORSOptions) DriveMiddleware {
+	base := newCORSPolicy(opts)
+
+	overrides := make(map[string]*corsPolicy, len(opts.Overrides))
+	for prefix, override := range opts.Overrides {
+		overrides[prefix] = newCORSPolicy(override)
+	}
+
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		policy := base
+
+		var matched string
+		for prefix, override := range overrides {
+			if strings.HasPrefix(rw.Req.URL.Path, prefix) && len(prefix) > len(matched) {
+				policy, matched = override, prefix
+			}
+		}
+
+		return policy.apply(rw)
+	}
+}
+
+// CORS setup a generic CORS hader within the response for recieved request
+// response, allowing all origins. Use CORSWith to configure the allowed
+// origins, methods and headers.
+func CORS() fractals.Handler {
+	cors := CORSWith(CORSOptions{MaxAge: 24 * time.Hour})
+
+	return fractals.MustWrap(func(ctx context.Context, wm *Request) (*Request, error) {
+		return cors(ctx, wm)
+	})
+}
+
+// CORS adds CORSWith using the provided options before the drive's global
+// middleware, and routes preflight requests for paths without their own
+// OPTIONS Endpoint through the drive's global middleware. It must be called
+// before any Endpoint is registered, as they keep the global middleware they
+// were registered with.
+func (hd *HTTPDrive) CORS(opts CORSOptions) {
+	cors := CORSWith(opts)
+
+	hd.globalMW = LiftWM(cors, hd.globalMW)
+	hd.beforeNames = append(funcNames(cors), hd.beforeNames...)
+
+	hd.OptionsHandler = Endpoint{
+		Action: func(ctx context.Context, rw *Request) error {
+			rw.Res.WriteHeader(http.StatusNoContent)
+			return nil
+		},
+	}.handlerFunc(hd.globalMW, nil)
+}
+
+// corsPolicy defines the prepared form of CORSOptions.
+type corsPolicy struct {
+	opts         CORSOptions
+	methods      string
+	headers      string
+	exposed      string
+	allowMethods map[string]bool
+	anyHeader    bool
+}
+
+// newCORSPolicy returns the corsPolicy for the options, filling in defaults.
+func newCORSPolicy(opts CORSOptions) *corsPolicy {
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = DefaultCORSMethods
+	}
+
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = DefaultCORSHeaders
+	}
+
+	policy := &corsPolicy{
+		opts:         opts,
+		methods:      strings.Join(opts.AllowedMethods, ", "),
+		headers:      strings.Join(opts.AllowedHeaders, ", "),
+		exposed:      strings.Join(opts.ExposedHeaders, ", "),
+		allowMethods: make(map[string]bool, len(opts.AllowedMethods)),
+	}
+
+	for _, method := range opts.AllowedMethods {
+		policy.allowMethods[strings.ToUpper(method)] = true
+	}
+
+	for _, header := range opts.AllowedHeaders {
+		if header == "*" {
+			policy.anyHeader = true
+		}
+	}
+
+	return policy
+}
+
+// apply adds the CORS headers to the response, responding to preflight
+// requests.
+func (c *corsPolicy) apply(rw *Request) (*Request, error) {
+	origin := rw.Req.Header.Get("Origin")
+	header := rw.Res.Header()
+
+	preflight := rw.Req.Method == "OPTIONS" && rw.Req.Header.Get("Access-Control-Request-Method") != ""
+
+	header.Add("Vary", "Origin")
+	if preflight {
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+	}
+
+	if origin == "" {
+		return rw, nil
+	}
+
+	allowed := c.allowOrigin(origin)
+
+	if preflight {
+		method := strings.ToUpper(rw.Req.Header.Get("Access-Control-Request-Method"))
+
+		if allowed && c.allowMethods[method] {
+			c.setOrigin(header, origin)
+			header.Set("Access-Control-Allow-Methods", c.methods)
+
+			if c.anyHeader {
+				if requested := rw.Req.Header.Get("Access-Control-Request-Headers"); requested != "" {
+					header.Set("Access-Control-Allow-Headers", requested)
+				}
+			} else {
+				header.Set("Access-Control-Allow-Headers", c.headers)
+			}
+
+			if c.opts.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(c.opts.MaxAge/time.Second)))
+			}
+		}
+
+		rw.Res.WriteHeader(http.StatusNoContent)
+		return nil, ErrPreflightHandled
+	}
+
+	if !allowed {
+		return rw, nil
+	}
+
+	c.setOrigin(header, origin)
+
+	if c.exposed != "" {
+		header.Set("Access-Control-Expose-Headers", c.exposed)
+	}
+
+	return rw, nil
+}
+
+// setOrigin sets the allowed origin and credentials headers.
+func (c *corsPolicy) setOrigin(header http.Header, origin string) {
+	if c.opts.AllowCredentials {
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Allow-Credentials", "true")
+		return
+	}
+
+	if c.allowsAny() {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	header.Set("Access-Control-Allow-Origin", origin)
+}
+
+// allowsAny returns true/false if every origin is allowed.
+func (c *corsPolicy) allowsAny() bool {
+	if c.opts.AllowOriginFunc != nil {
+		return false
+	}
+
+	if len(c.opts.AllowedOrigins) == 0 {
+		return true
+	}
+
+	for _, allowed := range c.opts.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowOrigin returns true/false if the origin is allowed.
+func (c *corsPolicy) allowOrigin(origin string) bool {
+	if c.opts.AllowOriginFunc != nil {
+		return c.opts.AllowOriginFunc(origin)
+	}
+
+	if c.allowsAny() {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+
+	for _, allowed := range c.opts.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+
+		star := strings.Index(allowed, "*")
+		if star == -1 {
+			if allowed == origin {
+				return true
+			}
+
+			continue
+		}
+
+		prefix, suffix := allowed[:star], allowed[star+1:]
+		if len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,292 @@
+package fhttp
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals/fs"
+)
+
+// ErrNotModified is returned by Conditional once it has responded with a 304
+// status, which stops the request from being handled any further.
+var ErrNotModified = errors.New("Resource not modified")
+
+// Validator defines a function which returns the ETag and modification time of
+// the resource requested, either of which may be left empty if unknown.
+type Validator func(context.Context, *Request) (etag string, modified time.Time, err error)
+
+// ETag returns a DriveMiddleware which computes an ETag from the body of every
+// successful GET or HEAD response, responding with a 304 status and no body
+// if it matches the request's If-None-Match header. If weak is true, weak
+// ETags are produced. As the whole body is needed, it is held back till the
+// request has been handled, unless the response is flushed early.
+func ETag(weak bool) DriveMiddleware {
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		if rw.Req.Method != "GET" && rw.Req.Method != "HEAD" {
+			return rw, nil
+		}
+
+		rw.Res = &etagWriter{
+			ResponseWriter: rw.Res,
+			req:            rw.Req,
+			weak:           weak,
+		}
+
+		return rw, nil
+	}
+}
+
+// Conditional returns a DriveMiddleware which sets the ETag and Last-Modified
+// headers from the values returned by the validator, responding with a 304
+// status and returning ErrNotModified if the request's If-None-Match or
+// If-Modified-Since headers show the client already holds the resource. This
+// allows validators using cheap details, such as a file's size and
+// modification time, to spare the handlers which follow.
+func Conditional(validator Validator) DriveMiddleware {
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		if rw.Req.Method != "GET" && rw.Req.Method != "HEAD" {
+			return rw, nil
+		}
+
+		etag, modified, err := validator(ctx, rw)
+		if err != nil {
+			return nil, err
+		}
+
+		header := rw.Res.Header()
+
+		if etag != "" {
+			header.Set("ETag", etag)
+		}
+
+		if !modified.IsZero() {
+			header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+		}
+
+		if notModified(rw.Req, etag, modified) {
+			rw.Res.WriteHeader(http.StatusNotModified)
+			return nil, ErrNotModified
+		}
+
+		return rw, nil
+	}
+}
+
+// FileValidator returns a Validator which uses the size and modification time
+// of the file within dir which the request's path resolves to, once prefix is
+// stripped from it, producing weak ETags. Files which can not be found are left
+// for the handlers which follow to deal with.
+func FileValidator(dir string, prefix string) Validator {
+	resolve := fs.ResolvePathStringIn(dir)
+
+	return func(ctx context.Context, rw *Request) (string, time.Time, error) {
+		path, err := resolve(ctx, nil, strings.TrimPrefix(rw.Req.URL.Path, prefix))
+		if err != nil {
+			return "", time.Time{}, nil
+		}
+
+		info, err := os.Stat(path.(string))
+		if err != nil || info.IsDir() {
+			return "", time.Time{}, nil
+		}
+
+		return FileETag(info), info.ModTime(), nil
+	}
+}
+
+// FileETag returns a weak ETag built from the size and modification time of
+// the file.
+func FileETag(info os.FileInfo) string {
+	return fmt.Sprintf("W/\"%x-%x\"", info.Size(), info.ModTime().UnixNano())
+}
+
+// notModified returns true/false if the request's conditional headers match
+// the provided ETag or modification time, where If-None-Match takes
+// precedence over If-Modified-Since.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		return etag != "" && etagMatches(match, etag)
+	}
+
+	if since := r.Header.Get("If-Modified-Since"); since != "" && !modified.IsZero() {
+		t, err := http.ParseTime(since)
+		return err == nil && !modified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
+
+// etagMatches returns true/false if the If-None-Match header holds the ETag,
+// using the weak comparison it requires.
+func etagMatches(header string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// etagWriter defines a ResponseWriter which holds back the body till closed,
+// to compute it's ETag.
+type etagWriter struct {
+	ResponseWriter
+	req  *http.Request
+	weak bool
+
+	status   int
+	size     int
+	written  bool
+	flushed  bool
+	buffered []byte
+}
+
+// WriteHeader stores the status till the body is complete.
+func (e *etagWriter) WriteHeader(status int) {
+	if e.flushed {
+		e.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	if e.status == 0 {
+		e.status = status
+	}
+}
+
+// Write holds back the data till the body is complete.
+func (e *etagWriter) Write(data []byte) (int, error) {
+	if e.status == 0 {
+		e.status = http.StatusOK
+	}
+
+	e.written = true
+	e.size += len(data)
+
+	if e.flushed {
+		return e.ResponseWriter.Write(data)
+	}
+
+	e.buffered = append(e.buffered, data...)
+	return len(data), nil
+}
+
+// Flush gives up on the ETag, writing out the held back data to the client.
+func (e *etagWriter) Flush() {
+	if !e.flushed {
+		e.flushed = true
+		e.writeOut()
+	}
+
+	e.ResponseWriter.Flush()
+}
+
+// Close computes the ETag of the complete body, responding with a 304 status
+// if the client already holds it, else writing out the held back data.
+func (e *etagWriter) Close() error {
+	if !e.flushed {
+		e.flushed = true
+
+		if e.status == http.StatusOK {
+			header := e.ResponseWriter.Header()
+
+			etag := header.Get("ETag")
+			if etag == "" {
+				etag = bodyETag(e.buffered, e.weak)
+				header.Set("ETag", etag)
+			}
+
+			if notModified(e.req, etag, time.Time{}) {
+				e.buffered = nil
+				e.status = http.StatusNotModified
+			}
+		}
+
+		if err := e.writeOut(); err != nil {
+			return err
+		}
+	}
+
+	if closer, ok := e.ResponseWriter.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Hijack hands over the connection, as needed by WebSocket endpoints. Nothing
+// held back is written out for hijacked requests.
+func (e *etagWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := e.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrHijackUnsupported
+	}
+
+	e.flushed = true
+	e.buffered = nil
+
+	return hijacker.Hijack()
+}
+
+// Status returns the status code of the response or 0 if none was written.
+func (e *etagWriter) Status() int {
+	return e.status
+}
+
+// StatusWritten returns true/false if the status was written.
+func (e *etagWriter) StatusWritten() bool {
+	return e.status != 0
+}
+
+// DataWritten returns true/false if Write was called.
+func (e *etagWriter) DataWritten() bool {
+	return e.written
+}
+
+// Size returns the size of the response body.
+func (e *etagWriter) Size() int {
+	return e.size
+}
+
+// writeOut writes the status and held back data to the client.
+func (e *etagWriter) writeOut() error {
+	if e.status != 0 {
+		e.ResponseWriter.WriteHeader(e.status)
+	}
+
+	buffered := e.buffered
+	e.buffered = nil
+
+	if len(buffered) == 0 {
+		return nil
+	}
+
+	_, err := e.ResponseWriter.Write(buffered)
+	return err
+}
+
+// bodyETag returns the ETag for the body.
+func bodyETag(body []byte, weak bool) string {
+	sum := sha1.Sum(body)
+	etag := "\"" + hex.EncodeToString(sum[:]) + "\""
+
+	if weak {
+		return "W/" + etag
+	}
+
+	return etag
+}
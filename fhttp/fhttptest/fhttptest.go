@@ -0,0 +1,313 @@
+// Package fhttptest provides a client for testing HTTPDrive handlers, which
+// runs requests against a handler without a network listener and asserts on
+// their responses.
+package fhttptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/influx6/fractals/maps"
+)
+
+// failedMark is the Unicode codepoint for an X mark.
+const failedMark = "\u2717"
+
+// baseURL defines the URL requests are made against, which only matters for
+// the cookies kept by the client.
+var baseURL, _ = url.Parse("http://fhttptest.local")
+
+// Client defines a client which runs requests against a handler, such as a
+// HTTPDrive, in memory. It keeps the cookies set by responses, sending them
+// with later requests as a browser would.
+type Client struct {
+	handler http.Handler
+	header  http.Header
+	jar     http.CookieJar
+}
+
+// NewClient returns a new Client running requests against the handler.
+func NewClient(handler http.Handler) *Client {
+	jar, _ := cookiejar.New(nil)
+
+	return &Client{
+		handler: handler,
+		header:  make(http.Header),
+		jar:     jar,
+	}
+}
+
+// SetHeader sets a header sent with every request made by the client.
+func (c *Client) SetHeader(key string, value string) *Client {
+	c.header.Set(key, value)
+	return c
+}
+
+// Get returns a RequestBuilder for a GET request to path.
+func (c *Client) Get(path string) *RequestBuilder {
+	return c.Request("GET", path)
+}
+
+// Post returns a RequestBuilder for a POST request to path.
+func (c *Client) Post(path string) *RequestBuilder {
+	return c.Request("POST", path)
+}
+
+// Put returns a RequestBuilder for a PUT request to path.
+func (c *Client) Put(path string) *RequestBuilder {
+	return c.Request("PUT", path)
+}
+
+// Patch returns a RequestBuilder for a PATCH request to path.
+func (c *Client) Patch(path string) *RequestBuilder {
+	return c.Request("PATCH", path)
+}
+
+// Delete returns a RequestBuilder for a DELETE request to path.
+func (c *Client) Delete(path string) *RequestBuilder {
+	return c.Request("DELETE", path)
+}
+
+// Request returns a RequestBuilder for a request with the method to path.
+func (c *Client) Request(method string, path string) *RequestBuilder {
+	return &RequestBuilder{
+		client: c,
+		method: method,
+		path:   path,
+		header: make(http.Header),
+		query:  make(url.Values),
+	}
+}
+
+// RequestBuilder defines a fluent builder of a single request, which is run
+// with Do.
+type RequestBuilder struct {
+	client *Client
+	method string
+	path   string
+	header http.Header
+	query  url.Values
+	body   io.Reader
+	err    error
+}
+
+// Header sets a header of the request.
+func (b *RequestBuilder) Header(key string, value string) *RequestBuilder {
+	b.header.Set(key, value)
+	return b
+}
+
+// Query adds a query parameter to the request's URL.
+func (b *RequestBuilder) Query(key string, value string) *RequestBuilder {
+	b.query.Add(key, value)
+	return b
+}
+
+// Cookie adds the cookie to the request.
+func (b *RequestBuilder) Cookie(cookie *http.Cookie) *RequestBuilder {
+	b.header.Add("Cookie", cookie.String())
+	return b
+}
+
+// Body sets the body of the request along with it's content type.
+func (b *RequestBuilder) Body(body io.Reader, contentType string) *RequestBuilder {
+	b.body = body
+	b.header.Set("Content-Type", contentType)
+	return b
+}
+
+// JSON sets the body of the request to the data encoded as JSON.
+func (b *RequestBuilder) JSON(data interface{}) *RequestBuilder {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		b.err = err
+	}
+
+	return b.Body(bytes.NewReader(encoded), "application/json")
+}
+
+// Form sets the body of the request to the values encoded as a urlencoded
+// form.
+func (b *RequestBuilder) Form(values url.Values) *RequestBuilder {
+	return b.Body(strings.NewReader(values.Encode()), "application/x-www-form-urlencoded")
+}
+
+// Do runs the request against the client's handler, returning it's
+// response.
+func (b *RequestBuilder) Do() *Response {
+	if b.err != nil {
+		return &Response{err: b.err}
+	}
+
+	target, err := baseURL.Parse(b.path)
+	if err != nil {
+		return &Response{err: err}
+	}
+
+	if len(b.query) != 0 {
+		query := target.Query()
+		for key, values := range b.query {
+			query[key] = append(query[key], values...)
+		}
+
+		target.RawQuery = query.Encode()
+	}
+
+	req := httptest.NewRequest(b.method, target.RequestURI(), b.body)
+	req.Host = target.Host
+
+	for key, values := range b.client.header {
+		req.Header[key] = values
+	}
+
+	for key, values := range b.header {
+		req.Header[key] = values
+	}
+
+	for _, cookie := range b.client.jar.Cookies(target) {
+		req.AddCookie(cookie)
+	}
+
+	record := httptest.NewRecorder()
+	b.client.handler.ServeHTTP(record, req)
+
+	res := record.Result()
+	b.client.jar.SetCookies(target, res.Cookies())
+
+	return &Response{
+		Response: res,
+		body:     record.Body.Bytes(),
+	}
+}
+
+// Response defines the response of a request run by a Client, which provides
+// assertions failing the test they are given.
+type Response struct {
+	*http.Response
+	body []byte
+	err  error
+}
+
+// Err returns the error which stopped the request from being run, if any.
+func (r *Response) Err() error {
+	return r.err
+}
+
+// Bytes returns the body of the response.
+func (r *Response) Bytes() []byte {
+	return r.body
+}
+
+// String returns the body of the response as a string.
+func (r *Response) String() string {
+	return string(r.body)
+}
+
+// Decode decodes the body of the response as JSON into dst.
+func (r *Response) Decode(dst interface{}) error {
+	return json.Unmarshal(r.body, dst)
+}
+
+// Path returns the value at the period delimited path, such as
+// "data.users.0.name", within the body of the response decoded as JSON, as
+// found by maps.Find.
+func (r *Response) Path(path string) (interface{}, error) {
+	var data interface{}
+	if err := r.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	return maps.Find(path)(nil, nil, data)
+}
+
+// ExpectStatus fails the test if the response's status is not the one
+// expected.
+func (r *Response) ExpectStatus(t testing.TB, status int) *Response {
+	t.Helper()
+	r.expectRun(t)
+
+	if r.StatusCode != status {
+		t.Fatalf("%s Expected status %d but got %d: %s", failedMark, status, r.StatusCode, r.body)
+	}
+
+	return r
+}
+
+// ExpectHeader fails the test if the response's header does not hold the
+// value expected.
+func (r *Response) ExpectHeader(t testing.TB, key string, value string) *Response {
+	t.Helper()
+	r.expectRun(t)
+
+	if actual := r.Header.Get(key); actual != value {
+		t.Fatalf("%s Expected header %q to be %q but got %q", failedMark, key, value, actual)
+	}
+
+	return r
+}
+
+// ExpectBody fails the test if the response's body does not contain the
+// text expected.
+func (r *Response) ExpectBody(t testing.TB, text string) *Response {
+	t.Helper()
+	r.expectRun(t)
+
+	if !strings.Contains(string(r.body), text) {
+		t.Fatalf("%s Expected body to contain %q but got %q", failedMark, text, r.body)
+	}
+
+	return r
+}
+
+// ExpectJSON fails the test if the value at the path within the response's
+// JSON body does not equal the value expected. Numbers are compared by value,
+// regardless of their type.
+func (r *Response) ExpectJSON(t testing.TB, path string, expected interface{}) *Response {
+	t.Helper()
+	r.expectRun(t)
+
+	actual, err := r.Path(path)
+	if err != nil {
+		t.Fatalf("%s Expected body to have %q: %s: %s", failedMark, path, err, r.body)
+	}
+
+	if !reflect.DeepEqual(normalize(expected), actual) {
+		t.Fatalf("%s Expected %q to be %#v but got %#v", failedMark, path, expected, actual)
+	}
+
+	return r
+}
+
+// expectRun fails the test if the request could not be run.
+func (r *Response) expectRun(t testing.TB) {
+	t.Helper()
+
+	if r.err != nil {
+		t.Fatalf("%s Expected request to be run: %s", failedMark, r.err)
+	}
+}
+
+// normalize returns the value as it would be decoded from JSON, so it can be
+// compared with decoded values.
+func normalize(value interface{}) interface{} {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return value
+	}
+
+	return decoded
+}
@@ -0,0 +1,73 @@
+package fhttptest_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals/fhttp"
+	"github.com/influx6/fractals/fhttp/fhttptest"
+)
+
+// succeedMark is the Unicode codepoint for a check mark.
+const succeedMark = "\u2713"
+
+func TestClient(t *testing.T) {
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/users/:id",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.Res.Header().Set("X-Page", rw.Req.URL.Query().Get("page"))
+			rw.Respond(http.StatusOK, map[string]interface{}{
+				"id":    rw.Params["id"],
+				"roles": []string{"admin", "editor"},
+				"age":   30,
+			})
+			return nil
+		},
+	})
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/login",
+		Method: "POST",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			http.SetCookie(rw.Res, &http.Cookie{Name: "user", Value: rw.Req.PostFormValue("user"), Path: "/"})
+			rw.RespondAny(http.StatusNoContent, "", nil)
+			return nil
+		},
+	})
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/me",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			cookie, err := rw.Req.Cookie("user")
+			if err != nil {
+				rw.RespondError(http.StatusUnauthorized, err)
+				return nil
+			}
+
+			rw.RespondAny(http.StatusOK, "text/plain", []byte(cookie.Value))
+			return nil
+		},
+	})
+
+	client := fhttptest.NewClient(drive)
+
+	client.Get("/users/7").Query("page", "2").Do().
+		ExpectStatus(t, http.StatusOK).
+		ExpectHeader(t, "X-Page", "2").
+		ExpectJSON(t, "id", "7").
+		ExpectJSON(t, "roles.1", "editor").
+		ExpectJSON(t, "age", 30)
+	t.Logf("%s Expected to assert status, headers and JSON paths", succeedMark)
+
+	client.Get("/me").Do().ExpectStatus(t, http.StatusUnauthorized)
+
+	client.Post("/login").Form(url.Values{"user": {"alex"}}).Do().ExpectStatus(t, http.StatusNoContent)
+
+	client.Get("/me").Do().
+		ExpectStatus(t, http.StatusOK).
+		ExpectBody(t, "alex")
+	t.Logf("%s Expected to keep cookies between requests", succeedMark)
+}
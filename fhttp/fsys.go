@@ -0,0 +1,27 @@
+//go:build go1.16
+// +build go1.16
+
+package fhttp
+
+import (
+	iofs "io/fs"
+
+	"github.com/influx6/fractals"
+	"github.com/influx6/fractals/fs"
+)
+
+// FSFileServer works like DirFileServer, serving the files within dir of the
+// provided filesystem, such as a embed.FS, which allows serving assets built
+// into the binary.
+func FSFileServer(fsys iofs.FS, dir string, prefix string) fractals.Handler {
+	var stripper fractals.Handler
+
+	if prefix != "" {
+		stripper = fs.StripPrefix(prefix)
+	} else {
+		stripper = fractals.IdentityHandler()
+	}
+
+	return fractals.SubLift(serveDataApplier, IdentityMiddlewareHandler(), MimeWriter(),
+		PathName(), stripper, fs.ResolvePathStringInFS(dir), fs.ReadFileFS(fsys))
+}
@@ -0,0 +1,43 @@
+//go:build go1.16
+// +build go1.16
+
+package fhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals/fhttp"
+)
+
+func TestFSFileServer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"public/app.js": {Data: []byte("console.log(1)")},
+	}
+
+	server := fhttp.FSFileServer(fsys, "public", "/static")
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/static/app.js", nil)
+	if err != nil {
+		fatalFailed(t, "Should have created request for '/static/app.js': %s", err)
+	}
+
+	if _, err := server(context.New(), nil, &fhttp.Request{Req: request, Res: fhttp.NewResponseWriter(record)}); err != nil {
+		fatalFailed(t, "Should have served embedded file: %s", err)
+	}
+
+	if body := record.Body.String(); body != "console.log(1)" {
+		fatalFailed(t, "Should have served embedded file contents but got %q", body)
+	}
+	logPassed(t, "Should have served embedded file")
+
+	request, _ = http.NewRequest("GET", "/static/missing.js", nil)
+	if _, err := server(context.New(), nil, &fhttp.Request{Req: request, Res: fhttp.NewResponseWriter(httptest.NewRecorder())}); err == nil {
+		fatalFailed(t, "Should have failed serving missing file")
+	}
+	logPassed(t, "Should have failed serving missing file")
+}
@@ -0,0 +1,18 @@
+//go:build go1.24
+// +build go1.24
+
+package fhttp
+
+import "net/http"
+
+// enableH2C allows the server to serve HTTP/2 over unencrypted connections,
+// alongside the protocols it already serves.
+func enableH2C(server *http.Server) error {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(server.TLSNextProto == nil)
+	protocols.SetUnencryptedHTTP2(true)
+
+	server.Protocols = protocols
+	return nil
+}
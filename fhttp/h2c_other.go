@@ -0,0 +1,12 @@
+//go:build !go1.24
+// +build !go1.24
+
+package fhttp
+
+import "net/http"
+
+// enableH2C fails as h2c is only supported by the net/http package from
+// go1.24.
+func enableH2C(server *http.Server) error {
+	return ErrH2CUnsupported
+}
@@ -0,0 +1,54 @@
+//go:build go1.24
+// +build go1.24
+
+package fhttp_test
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals/fhttp"
+)
+
+func TestNewServerH2C(t *testing.T) {
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/proto",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.RespondAny(http.StatusOK, "text/plain", []byte(rw.Req.Proto))
+			return nil
+		},
+	})
+
+	server, err := fhttp.NewServer("", drive, fhttp.ServerOptions{H2C: true})
+	if err != nil {
+		fatalFailed(t, "Should have created h2c server: %s", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fatalFailed(t, "Should have created listener: %s", err)
+	}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	res, err := client.Get("http://" + listener.Addr().String() + "/proto")
+	if err != nil {
+		fatalFailed(t, "Should have made h2c request: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.ProtoMajor != 2 {
+		fatalFailed(t, "Should have served over HTTP/2 but got %s", res.Proto)
+	}
+	logPassed(t, "Should have served over HTTP/2")
+}
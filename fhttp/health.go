@@ -0,0 +1,136 @@
+package fhttp
+
+import (
+	stdcontext "context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+)
+
+// DefaultHealthTimeout defines how long a HealthCheck may run when it sets no
+// timeout of it's own.
+const DefaultHealthTimeout = 5 * time.Second
+
+// HealthCheck defines a named check of a dependency of the application, such
+// as a database ping.
+type HealthCheck struct {
+	Name  string
+	Check func(stdcontext.Context) error
+
+	// Timeout sets how long the check may run before failing, defaulting to
+	// DefaultHealthTimeout.
+	Timeout time.Duration
+
+	// Liveness includes the check within /healthz, which otherwise only
+	// reports the process is running. All checks are included within
+	// /readyz.
+	Liveness bool
+}
+
+// HealthStatus defines the JSON response of the health endpoints.
+type HealthStatus struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckStatus `json:"checks,omitempty"`
+}
+
+// HealthCheckStatus defines the result of a single HealthCheck.
+type HealthCheckStatus struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Health registers GET /healthz and /readyz endpoints on the drive, which
+// respond with the results of the checks. See HealthEndpoint.
+func (hd *HTTPDrive) Health(checks ...HealthCheck) {
+	var liveness []HealthCheck
+	for _, check := range checks {
+		if check.Liveness {
+			liveness = append(liveness, check)
+		}
+	}
+
+	Route(hd)(Endpoint{Path: "/healthz", Method: "GET", Action: HealthEndpoint(liveness...)})
+	Route(hd)(Endpoint{Path: "/readyz", Method: "GET", Action: HealthEndpoint(checks...)})
+}
+
+// HealthEndpoint returns an Endpoint action which runs the checks
+// concurrently, each within it's own timeout, responding with their results
+// as a HealthStatus with a 200 status if all passed, else a 503 status.
+func HealthEndpoint(checks ...HealthCheck) func(context.Context, *Request) error {
+	return func(ctx context.Context, rw *Request) error {
+		status := HealthStatus{Status: "ok"}
+
+		if len(checks) != 0 {
+			status.Checks = make(map[string]HealthCheckStatus, len(checks))
+		}
+
+		var ml sync.Mutex
+		var wg sync.WaitGroup
+
+		for _, check := range checks {
+			wg.Add(1)
+
+			go func(check HealthCheck) {
+				defer wg.Done()
+
+				result := runHealthCheck(rw.Req.Context(), check)
+
+				ml.Lock()
+				defer ml.Unlock()
+
+				status.Checks[check.Name] = result
+				if result.Status != "ok" {
+					status.Status = "fail"
+				}
+			}(check)
+		}
+
+		wg.Wait()
+
+		code := http.StatusOK
+		if status.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+
+		rw.Res.Header().Set("Cache-Control", "no-cache")
+		Render(code, rw.Req, rw.Res, status)
+		return nil
+	}
+}
+
+// runHealthCheck runs the check within it's timeout, returning it's result.
+func runHealthCheck(parent stdcontext.Context, check HealthCheck) HealthCheckStatus {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthTimeout
+	}
+
+	ctx, cancel := stdcontext.WithTimeout(parent, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+
+	go func() {
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := HealthCheckStatus{Status: "ok", Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+
+	return result
+}
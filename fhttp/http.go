@@ -1,10 +1,13 @@
 package fhttp
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"time"
 )
 
 // LaunchHTTP lunches a http server, setting up the signal handler needed.
@@ -32,3 +35,79 @@ func LaunchHTTPS(addr string, tlsKey string, tlsCert string, mux http.Handler) {
 	signal.Notify(sigChan, os.Interrupt)
 	<-sigChan
 }
+
+// ErrH2CUnsupported is returned by NewServer when h2c is enabled on versions
+// of Go whose net/http package can not serve it.
+var ErrH2CUnsupported = errors.New("h2c requires go1.24 or newer")
+
+// ServerOptions defines the configuration of the http.Server created by
+// NewServer, where zero values keep the defaults of the net/http package.
+type ServerOptions struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+
+	// TLSConfig sets the TLS configuration used when serving over TLS, which
+	// may provide the certificates in place of certificate files.
+	TLSConfig *tls.Config
+
+	// DisableHTTP2 turns off HTTP/2 over TLS, which is otherwise negotiated
+	// with clients supporting it.
+	DisableHTTP2 bool
+
+	// H2C enables HTTP/2 over unencrypted connections, as used by services
+	// behind a TLS terminating proxy. It requires go1.24 or newer, with
+	// NewServer failing with ErrH2CUnsupported otherwise.
+	H2C bool
+}
+
+// NewServer returns a new http.Server serving the handler on addr, configured
+// using the provided options.
+func NewServer(addr string, handler http.Handler, opts ServerOptions) (*http.Server, error) {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       opts.ReadTimeout,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+		TLSConfig:         opts.TLSConfig,
+	}
+
+	// A non-nil empty map stops the server from negotiating HTTP/2.
+	if opts.DisableHTTP2 {
+		server.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+
+	if opts.H2C {
+		if err := enableH2C(server); err != nil {
+			return nil, err
+		}
+	}
+
+	return server, nil
+}
+
+// LaunchServer lunches the http server, serving over TLS if certificate files
+// are provided or the server has a TLS configuration, and setting up the
+// signal handler needed.
+func LaunchServer(server *http.Server, tlsCert string, tlsKey string) {
+	go func() {
+		if tlsCert != "" || server.TLSConfig != nil {
+			fmt.Printf("HTTPS Server starting... {Addr: %q}", server.Addr)
+			server.ListenAndServeTLS(tlsCert, tlsKey)
+			return
+		}
+
+		fmt.Printf("HTTP Server starting... {Addr: %q}", server.Addr)
+		server.ListenAndServe()
+	}()
+
+	// Listen for an interrupt signal from the OS.
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	<-sigChan
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -14,17 +15,6 @@ import (
 	"github.com/influx6/fractals/fs"
 )
 
-// CORS setup a generic CORS hader within the response for recieved request response.
-func CORS() fractals.Handler {
-	return fractals.MustWrap(func(wm *Request) *Request {
-		wm.Res.Header().Set("Access-Control-Allow-Origin", "*")
-		wm.Res.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		wm.Res.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		wm.Res.Header().Set("Access-Control-Max-Age", "86400")
-		return wm
-	})
-}
-
 // JSONDecoder decodes the data it recieves into an map type and returns the values.
 func JSONDecoder() fractals.Handler {
 	return fractals.MustWrap(func(ctx context.Context, data []byte) (map[string]interface{}, error) {
@@ -76,6 +66,41 @@ func JSONEncoder() fractals.Handler {
 	})
 }
 
+// XMLNode defines a generic XML element, holding it's attributes, text and
+// child elements.
+type XMLNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []XMLNode  `xml:",any"`
+}
+
+// XMLDecoder decodes the XML data it recieves into a XMLNode and returns it.
+func XMLDecoder() fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, data []byte) (XMLNode, error) {
+		var node XMLNode
+
+		if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&node); err != nil {
+			return node, err
+		}
+
+		return node, nil
+	})
+}
+
+// XMLEncoder encodes the data it recieves into XML and returns the values.
+func XMLEncoder() fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, data interface{}) ([]byte, error) {
+		var d bytes.Buffer
+
+		if err := xml.NewEncoder(&d).Encode(data); err != nil {
+			return nil, err
+		}
+
+		return d.Bytes(), nil
+	})
+}
+
 // Headers returns a fractals.Handler which hads the provided values into the
 // response headers.
 func Headers(h map[string]string) fractals.Handler {
@@ -135,7 +160,7 @@ func ResponseLogger(w io.Writer) fractals.Handler {
 	return LogWith(w, func(ws io.Writer, rw *Request) {
 		now := time.Now().UTC()
 		content := rw.Res.Header().Get("Content-Type")
-		fmt.Fprintf(ws, "HTTP : %q : Content{%s} : Status{%d} : URI{%s} : DataSize{%d}\n", now, content, rw.Res.Status(), rw.Req.URL, rw.Res.Size())
+		fmt.Fprintf(ws, "HTTP : %q : Content{%s} : Status{%d} : URI{%s} : DataSize{%d}%s\n", now, content, rw.Res.Status(), rw.Req.URL, rw.Res.Size(), logRequestID(rw))
 	})
 }
 
@@ -146,13 +171,24 @@ func RequestLogger(w io.Writer) fractals.Handler {
 		now := time.Now().UTC()
 		content := rw.Req.Header.Get("Accept")
 		if !rw.Res.StatusWritten() {
-			fmt.Fprintf(ws, "HTTP : %q : Content{%s} : Method{%s} : URI{%s}\n", now, content, rw.Req.Method, rw.Req.URL)
+			fmt.Fprintf(ws, "HTTP : %q : Content{%s} : Method{%s} : URI{%s}%s\n", now, content, rw.Req.Method, rw.Req.URL, logRequestID(rw))
 		} else {
-			fmt.Fprintf(ws, "HTTP : %q : Status{%d} : Content{%s} : Method{%s} : URI{%s}\n", now, rw.Res.Status(), rw.Res.Header().Get("Content-Type"), rw.Req.Method, rw.Req.URL)
+			fmt.Fprintf(ws, "HTTP : %q : Status{%d} : Content{%s} : Method{%s} : URI{%s}%s\n", now, rw.Res.Status(), rw.Res.Header().Get("Content-Type"), rw.Req.Method, rw.Req.URL, logRequestID(rw))
 		}
 	})
 }
 
+// logRequestID returns the request id set by the RequestID middleware, in the
+// format used by the loggers, or an empty string if none was set.
+func logRequestID(rw *Request) string {
+	id := rw.Res.Header().Get(RequestIDHeader)
+	if id == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" : RequestID{%s}", id)
+}
+
 // PathName returns the path of the received *Request.
 func PathName() fractals.Handler {
 	return fractals.MustWrap(func(rw *Request) string {
@@ -169,7 +205,8 @@ func JoinPathName(file string) fractals.Handler {
 
 // IndexServer returns a handler capable of serving a specific file from the provided
 // directores which it recieves but using combining the filename with the giving
-// path from the reequest.
+// path from the reequest. Files are streamed using ServeFile, supporting HEAD
+// and Range requests.
 func IndexServer(dir string, index string, prefix string) fractals.Handler {
 	var stripper fractals.Handler
 
@@ -179,31 +216,21 @@ func IndexServer(dir string, index string, prefix string) fractals.Handler {
 		stripper = fractals.IdentityHandler()
 	}
 
-	return fractals.SubLift(func(rw *Request, data []byte) (*Request, error) {
-		if _, err := rw.Res.Write(data); err != nil {
-			return nil, err
-		}
-
-		return rw, nil
-	}, IdentityMiddlewareHandler(), MimeWriterFor(index),
-		JoinPathName(index), stripper, fs.ResolvePathStringIn(dir), fs.ReadFile())
+	return fractals.SubLift(serveFileApplier, IdentityMiddlewareHandler(), MimeWriterFor(index),
+		JoinPathName(index), stripper, fs.ResolvePathStringIn(dir))
 }
 
 // FileServer returns a handler capable of serving different files from the provided
-// directory but using inputed URL path.
+// directory but using inputed URL path. Files are streamed using ServeFile,
+// supporting HEAD and Range requests.
 func FileServer(file string) fractals.Handler {
-	return fractals.SubLift(func(rw *Request, data []byte) (*Request, error) {
-		if _, err := rw.Res.Write(data); err != nil {
-			return nil, err
-		}
-
-		return rw, nil
-	}, IdentityMiddlewareHandler(), MimeWriterFor(file), fractals.Replay(file),
-		fs.ReadFile())
+	return fractals.SubLift(serveFileApplier, IdentityMiddlewareHandler(),
+		MimeWriterFor(file), fractals.Replay(file))
 }
 
 // DirFileServer returns a handler capable of serving different files from the provided
-// directory but using inputed URL path.
+// directory but using inputed URL path. Files are streamed using ServeFile,
+// supporting HEAD and Range requests.
 func DirFileServer(dir string, prefix string) fractals.Handler {
 	var stripper fractals.Handler
 
@@ -213,14 +240,41 @@ func DirFileServer(dir string, prefix string) fractals.Handler {
 		stripper = fractals.IdentityHandler()
 	}
 
-	return fractals.SubLift(func(rw *Request, data []byte) (*Request, error) {
-		if _, err := rw.Res.Write(data); err != nil {
-			return nil, err
-		}
+	return fractals.SubLift(serveFileApplier, IdentityMiddlewareHandler(), MimeWriter(),
+		PathName(), stripper, fs.ResolvePathStringIn(dir))
+}
 
-		return rw, nil
-	}, IdentityMiddlewareHandler(), MimeWriter(),
-		PathName(), stripper, fs.ResolvePathStringIn(dir), fs.ReadFile())
+// CachedDirFileServer works like DirFileServer, serving the files through the
+// provided cache so frequently requested files are not read from disk on
+// every request.
+func CachedDirFileServer(dir string, prefix string, cache *fs.FileCache) fractals.Handler {
+	var stripper fractals.Handler
+
+	if prefix != "" {
+		stripper = fs.StripPrefix(prefix)
+	} else {
+		stripper = fractals.IdentityHandler()
+	}
+
+	return fractals.SubLift(serveDataApplier, IdentityMiddlewareHandler(), MimeWriter(),
+		PathName(), stripper, fs.ResolvePathStringIn(dir), cache.ReadFile())
+}
+
+// serveFileApplier serves the file at the path resolved by the file serving
+// handlers.
+func serveFileApplier(rw *Request, path string) (*Request, error) {
+	if err := ServeFile(rw, path); err != nil {
+		return nil, err
+	}
+
+	return rw, nil
+}
+
+// serveDataApplier serves the file contents read by the file serving
+// handlers.
+func serveDataApplier(rw *Request, data []byte) (*Request, error) {
+	serveData(rw, filepath.Base(rw.Req.URL.Path), data)
+	return rw, nil
 }
 
 // DirServer returns a fractals.Handler which servers a giving directory
@@ -0,0 +1,415 @@
+package fhttp
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/influx6/faux/context"
+)
+
+// EndpointDoc defines the documentation of an Endpoint used when generating
+// it's OpenAPI operation.
+type EndpointDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+
+	// Request sets a sample of the type the request's body is decoded into,
+	// such as a struct or a pointer to one.
+	Request interface{}
+
+	// Response sets a sample of the type the response is rendered from,
+	// sent with Status, which defaults to 200.
+	Response interface{}
+	Status   int
+
+	// Params documents the params of the endpoint, where the params of it's
+	// path are documented as strings unless listed.
+	Params []ParamDoc
+
+	Deprecated bool
+}
+
+// ParamDoc defines the documentation of a single param of an Endpoint.
+type ParamDoc struct {
+	Name string
+
+	// In sets where the param is sent, being one of "path", "query",
+	// "header" or "cookie", defaulting to "query".
+	In string
+
+	Description string
+	Required    bool
+
+	// Type sets the JSON schema type of the param, defaulting to "string".
+	Type string
+}
+
+// OpenAPIInfo defines the details of the API described by the document
+// generated with HTTPDrive.OpenAPI.
+type OpenAPIInfo struct {
+	Title       string
+	Version     string
+	Description string
+
+	// Servers lists the URLs the API is served from.
+	Servers []string
+}
+
+// OpenAPI returns an OpenAPI 3 document describing every Endpoint registered
+// with the drive, using the documentation of those which provide it. The
+// schemas of the request and response samples are generated from their types,
+// following their json tags, with named structs placed within the document's
+// components.
+func (hd *HTTPDrive) OpenAPI(info OpenAPIInfo) map[string]interface{} {
+	gen := schemaGenerator{schemas: make(map[string]interface{})}
+	paths := make(map[string]interface{})
+
+	for _, route := range hd.Routes() {
+		path, params := openAPIPath(route.Path)
+
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[path] = item
+		}
+
+		item[strings.ToLower(route.Method)] = gen.operation(route, params)
+	}
+
+	apiInfo := map[string]interface{}{
+		"title":   info.Title,
+		"version": info.Version,
+	}
+
+	if info.Description != "" {
+		apiInfo["description"] = info.Description
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    apiInfo,
+		"paths":   paths,
+	}
+
+	if len(info.Servers) != 0 {
+		var servers []map[string]string
+		for _, server := range info.Servers {
+			servers = append(servers, map[string]string{"url": server})
+		}
+
+		doc["servers"] = servers
+	}
+
+	gen.schema(reflect.TypeOf(JSONError{}))
+	doc["components"] = map[string]interface{}{"schemas": gen.schemas}
+
+	return doc
+}
+
+// ServeOpenAPI registers a GET endpoint at path serving the drive's OpenAPI
+// document as JSON, generated when first requested so routes registered
+// afterwards are included. If ui is not empty, a GET endpoint serving a
+// Swagger UI page for the document, which loads it's assets from a CDN, is
+// registered at ui.
+func (hd *HTTPDrive) ServeOpenAPI(path string, ui string, info OpenAPIInfo) error {
+	err := Route(hd)(Endpoint{
+		Path:   path,
+		Method: "GET",
+		Action: func(ctx context.Context, rw *Request) error {
+			data, err := json.Marshal(hd.OpenAPI(info))
+			if err != nil {
+				return err
+			}
+
+			rw.RespondAny(http.StatusOK, "application/json", data)
+			return nil
+		},
+	})
+
+	if err != nil || ui == "" {
+		return err
+	}
+
+	page := strings.Replace(swaggerUIPage, "{{spec}}", strconv.Quote(path), 1)
+	page = strings.Replace(page, "{{title}}", html.EscapeString(info.Title), 1)
+
+	return Route(hd)(Endpoint{
+		Path:   ui,
+		Method: "GET",
+		Action: func(ctx context.Context, rw *Request) error {
+			rw.RespondAny(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+			return nil
+		},
+	})
+}
+
+// swaggerUIPage defines the page serving the Swagger UI.
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>{{title}}</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>SwaggerUIBundle({url: {{spec}}, dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+// openAPIPath returns the route's path in the OpenAPI form, where params are
+// wrapped in braces, along with the names of it's params.
+func openAPIPath(path string) (string, []string) {
+	var params []string
+
+	segments := strings.Split(path, "/")
+	for index, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+
+		params = append(params, segment[1:])
+		segments[index] = "{" + segment[1:] + "}"
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+// schemaGenerator defines the generator of the schemas of an OpenAPI
+// document, holding the schemas of named structs.
+type schemaGenerator struct {
+	schemas map[string]interface{}
+}
+
+// operation returns the OpenAPI operation of the route.
+func (s *schemaGenerator) operation(route RouteInfo, pathParams []string) map[string]interface{} {
+	doc := route.Doc
+	if doc == nil {
+		doc = &EndpointDoc{}
+	}
+
+	op := make(map[string]interface{})
+
+	if route.Name != "" {
+		op["operationId"] = route.Name
+	}
+
+	if doc.Summary != "" {
+		op["summary"] = doc.Summary
+	}
+
+	if doc.Description != "" {
+		op["description"] = doc.Description
+	}
+
+	if len(doc.Tags) != 0 {
+		op["tags"] = doc.Tags
+	}
+
+	if doc.Deprecated {
+		op["deprecated"] = true
+	}
+
+	documented := make(map[string]ParamDoc)
+	for _, param := range doc.Params {
+		if param.In == "" {
+			param.In = "query"
+		}
+
+		documented[param.In+":"+param.Name] = param
+	}
+
+	var params []map[string]interface{}
+
+	for _, name := range pathParams {
+		param, ok := documented["path:"+name]
+		if !ok {
+			param = ParamDoc{Name: name, In: "path"}
+		}
+
+		delete(documented, "path:"+name)
+		param.Required = true
+		params = append(params, paramObject(param))
+	}
+
+	for _, param := range doc.Params {
+		if param.In == "" {
+			param.In = "query"
+		}
+
+		if _, ok := documented[param.In+":"+param.Name]; ok {
+			params = append(params, paramObject(param))
+		}
+	}
+
+	if len(params) != 0 {
+		op["parameters"] = params
+	}
+
+	if doc.Request != nil {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": s.schema(reflect.TypeOf(doc.Request)),
+				},
+			},
+		}
+	}
+
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	response := map[string]interface{}{"description": http.StatusText(status)}
+	if doc.Response != nil {
+		response["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": s.schema(reflect.TypeOf(doc.Response)),
+			},
+		}
+	}
+
+	op["responses"] = map[string]interface{}{
+		strconv.Itoa(status): response,
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{"$ref": "#/components/schemas/JSONError"},
+				},
+			},
+		},
+	}
+
+	return op
+}
+
+// paramObject returns the OpenAPI parameter of the param.
+func paramObject(param ParamDoc) map[string]interface{} {
+	kind := param.Type
+	if kind == "" {
+		kind = "string"
+	}
+
+	object := map[string]interface{}{
+		"name":     param.Name,
+		"in":       param.In,
+		"required": param.Required,
+		"schema":   map[string]interface{}{"type": kind},
+	}
+
+	if param.Description != "" {
+		object["description"] = param.Description
+	}
+
+	return object
+}
+
+// schema returns the JSON schema of the type, placing named structs within
+// the generator's schemas and referencing them.
+func (s *schemaGenerator) schema(tm reflect.Type) map[string]interface{} {
+	for tm.Kind() == reflect.Ptr {
+		tm = tm.Elem()
+	}
+
+	if tm == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch tm.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if tm.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+
+		return map[string]interface{}{"type": "array", "items": s.schema(tm.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(tm.Elem())}
+	case reflect.Struct:
+		if tm.Name() == "" {
+			return s.structSchema(tm)
+		}
+
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + tm.Name()}
+
+		// The reference is claimed before the fields are walked, so
+		// recursive types end at it.
+		if _, ok := s.schemas[tm.Name()]; !ok {
+			s.schemas[tm.Name()] = map[string]interface{}{}
+			s.schemas[tm.Name()] = s.structSchema(tm)
+		}
+
+		return ref
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the JSON schema of the struct type's exported fields,
+// named after their json tags.
+func (s *schemaGenerator) structSchema(tm reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+
+	for i := 0; i < tm.NumField(); i++ {
+		field := tm.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		omitempty := false
+
+		if tag := field.Tag.Get("json"); tag != "" {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+
+			if parts[0] != "" {
+				name = parts[0]
+			}
+
+			for _, option := range parts[1:] {
+				if option == "omitempty" {
+					omitempty = true
+				}
+			}
+		}
+
+		properties[name] = s.schema(field.Type)
+
+		if !omitempty && field.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+
+	if len(required) != 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
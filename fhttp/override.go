@@ -0,0 +1,77 @@
+package fhttp
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrMethodOverride is returned when a request asks for it's method to be
+// overridden to one which is not allowed.
+var ErrMethodOverride = errors.New("Method override not allowed")
+
+// MethodOverrideHeader defines the header requests ask for their method to be
+// overridden with.
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// DefaultOverrideMethods defines the methods requests may be overridden to
+// when MethodOverride is given none.
+var DefaultOverrideMethods = []string{"PUT", "PATCH", "DELETE"}
+
+// MethodOverride returns a middleware which overrides the method of POST
+// requests with the X-HTTP-Method-Override header or, for urlencoded forms,
+// the "_method" field, for clients which can only send POST, before the
+// wrapped handler routes them. Only the allowed methods, defaulting to
+// DefaultOverrideMethods, may be overridden to, other requests asking for an
+// override are responded to with a 400 status. Drives created by DriveWith
+// can do the same through RouterOptions.MethodOverride.
+func MethodOverride(allowed ...string) func(http.Handler) http.Handler {
+	if len(allowed) == 0 {
+		allowed = DefaultOverrideMethods
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := overrideMethod(r, allowed); err != nil {
+				RenderErrorWithStatus(http.StatusBadRequest, err, r, w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// overrideMethod overrides the method of the POST request with the one it
+// asks for, failing with ErrMethodOverride if that is not allowed.
+func overrideMethod(r *http.Request, allowed []string) error {
+	if r.Method != "POST" {
+		return nil
+	}
+
+	method := r.Header.Get(MethodOverrideHeader)
+
+	if method == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == "application/x-www-form-urlencoded" {
+			method = r.PostFormValue("_method")
+		}
+	}
+
+	if method == "" {
+		return nil
+	}
+
+	method = strings.ToUpper(strings.TrimSpace(method))
+
+	for _, item := range allowed {
+		if strings.EqualFold(item, method) {
+			r.Method = method
+			r.Header.Del(MethodOverrideHeader)
+			return nil
+		}
+	}
+
+	return ErrMethodOverride
+}
@@ -0,0 +1,176 @@
+package fhttp
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	// DefaultPageLimit defines the limit used by Paginate when the request
+	// provides none.
+	DefaultPageLimit = 20
+
+	// MaxPageLimit defines the maximum limit Paginate allows a request to ask
+	// for.
+	MaxPageLimit = 100
+)
+
+// Pagination defines the paging details for a list response, as parsed from
+// the `page`, `limit` and `cursor` query parameters of a request.
+type Pagination struct {
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Total      int    `json:"total"`
+	Pages      int    `json:"pages"`
+	Cursor     string `json:"cursor,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	url *url.URL
+}
+
+// Paginate returns the Pagination for the giving request and total count of
+// items. Page numbers start at 1, and both page and limit are clamped to valid
+// values, where limit is capped at MaxPageLimit.
+func Paginate(r *Request, total int) Pagination {
+	query := r.Req.URL.Query()
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = DefaultPageLimit
+	}
+
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	if total < 0 {
+		total = 0
+	}
+
+	pages := (total + limit - 1) / limit
+	if pages < 1 {
+		pages = 1
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	if page > pages {
+		page = pages
+	}
+
+	return Pagination{
+		Page:   page,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+		Total:  total,
+		Pages:  pages,
+		Cursor: query.Get("cursor"),
+		url:    r.Req.URL,
+	}
+}
+
+// HasNext returns true/false if there is a page after the current page.
+func (p Pagination) HasNext() bool {
+	return p.Page < p.Pages || p.NextCursor != ""
+}
+
+// HasPrev returns true/false if there is a page before the current page.
+func (p Pagination) HasPrev() bool {
+	return p.Page > 1
+}
+
+// Links returns the URLs for the first, last, previous and next pages keyed
+// by their relation name, using the request URL the Pagination was created
+// from. If a NextCursor is set, then the next link uses the cursor instead of
+// a page number.
+func (p Pagination) Links() map[string]string {
+	links := make(map[string]string)
+	if p.url == nil {
+		return links
+	}
+
+	links["self"] = p.pageURL(p.Page, p.Cursor)
+	links["first"] = p.pageURL(1, "")
+	links["last"] = p.pageURL(p.Pages, "")
+
+	if p.HasPrev() {
+		links["prev"] = p.pageURL(p.Page-1, "")
+	}
+
+	if p.NextCursor != "" {
+		links["next"] = p.pageURL(0, p.NextCursor)
+	} else if p.HasNext() {
+		links["next"] = p.pageURL(p.Page+1, "")
+	}
+
+	return links
+}
+
+// LinkHeader returns the value of the Link header for the pagination links as
+// described in RFC 5988.
+func (p Pagination) LinkHeader() string {
+	links := p.Links()
+
+	var parts []string
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		if link, ok := links[rel]; ok {
+			parts = append(parts, fmt.Sprintf("<%s>; rel=%q", link, rel))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (p Pagination) pageURL(page int, cursor string) string {
+	target := *p.url
+	query := target.Query()
+
+	query.Set("limit", strconv.Itoa(p.Limit))
+	query.Del("page")
+	query.Del("cursor")
+
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	} else {
+		query.Set("page", strconv.Itoa(page))
+	}
+
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+// Envelope defines the standard response body for JSON APIs, where Data holds
+// the response, Meta any extra details like the pagination and Links the
+// related URLs.
+type Envelope struct {
+	Data  interface{}            `json:"data"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+	Links map[string]string      `json:"links,omitempty"`
+}
+
+// RenderEnvelope renders the giving data wrapped in an Envelope with the
+// pagination details as meta and links, setting the Link header for the
+// response.
+func RenderEnvelope(code int, r *Request, data interface{}, page Pagination) {
+	if header := page.LinkHeader(); header != "" {
+		r.Res.Header().Set("Link", header)
+	}
+
+	Render(code, r.Req, r.Res, Envelope{
+		Data:  data,
+		Meta:  map[string]interface{}{"pagination": page},
+		Links: page.Links(),
+	})
+}
+
+// RespondPage renders out the data within an Envelope along with the provided
+// pagination using RenderEnvelope.
+func (r *Request) RespondPage(code int, data interface{}, page Pagination) {
+	RenderEnvelope(code, r, data, page)
+}
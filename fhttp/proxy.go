@@ -0,0 +1,117 @@
+package fhttp
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/influx6/faux/context"
+)
+
+// ProxyOptions defines the configuration used by Proxy.
+type ProxyOptions struct {
+	// StripPrefix sets the prefix removed from request paths before they are
+	// joined with the target's path.
+	StripPrefix string
+
+	// Rewrite, if set, rewrites request paths once the prefix is stripped.
+	Rewrite func(path string) string
+
+	// Headers sets headers added to every upstream request.
+	Headers map[string]string
+
+	// PreserveHost sends the request's Host header upstream in place of the
+	// target's host.
+	PreserveHost bool
+
+	// Transport sets the transport of upstream requests, defaulting to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// FlushInterval sets how often the response is flushed to the client
+	// while copying it, where a negative value flushes after every write.
+	FlushInterval time.Duration
+
+	// ModifyResponse, if set, is called with every upstream response before
+	// it is copied to the client.
+	ModifyResponse func(*http.Response) error
+}
+
+// Proxy returns an Endpoint action which forwards requests to the upstream
+// target URL using httputil.ReverseProxy, which also passes WebSocket
+// upgrades through. The X-Forwarded-For, X-Forwarded-Host and
+// X-Forwarded-Proto headers are set on upstream requests, and failures to
+// reach the upstream are responded to with a 502 status. It panics if target
+// is not a valid URL.
+func Proxy(target string, opts ProxyOptions) func(context.Context, *Request) error {
+	upstream, err := url.Parse(target)
+	if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+		panic("Expected valid proxy target URL: " + target)
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Transport:      opts.Transport,
+		FlushInterval:  opts.FlushInterval,
+		ModifyResponse: opts.ModifyResponse,
+		Director: func(r *http.Request) {
+			path := strings.TrimPrefix(r.URL.Path, opts.StripPrefix)
+			if opts.Rewrite != nil {
+				path = opts.Rewrite(path)
+			}
+
+			r.Header.Set("X-Forwarded-Host", r.Host)
+			r.Header.Set("X-Forwarded-Proto", "http")
+			if r.TLS != nil {
+				r.Header.Set("X-Forwarded-Proto", "https")
+			}
+
+			r.URL.Scheme = upstream.Scheme
+			r.URL.Host = upstream.Host
+			r.URL.Path = joinProxyPath(upstream.Path, path)
+			r.URL.RawPath = ""
+
+			if upstream.RawQuery != "" {
+				if r.URL.RawQuery != "" {
+					r.URL.RawQuery = upstream.RawQuery + "&" + r.URL.RawQuery
+				} else {
+					r.URL.RawQuery = upstream.RawQuery
+				}
+			}
+
+			if !opts.PreserveHost {
+				r.Host = upstream.Host
+			}
+
+			for key, value := range opts.Headers {
+				r.Header.Set(key, value)
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			RenderErrorWithStatus(http.StatusBadGateway, err, r, w)
+		},
+	}
+
+	return func(ctx context.Context, rw *Request) error {
+		proxy.ServeHTTP(rw.Res, rw.Req)
+		return nil
+	}
+}
+
+// joinProxyPath joins the target's path with the request's path.
+func joinProxyPath(base string, path string) string {
+	if path == "" {
+		path = "/"
+	}
+
+	if base == "" || base == "/" {
+		if !strings.HasPrefix(path, "/") {
+			return "/" + path
+		}
+
+		return path
+	}
+
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,278 @@
+package fhttp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidProxyHeader is returned by connections accepted through a
+// ProxyProtocolListener when they begin with an invalid PROXY protocol
+// header, or with none when one is required.
+var ErrInvalidProxyHeader = errors.New("Invalid PROXY protocol header")
+
+// proxySignature begins version 2 PROXY protocol headers.
+var proxySignature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// ProxyProtocolOptions defines the configuration used by
+// ProxyProtocolListener.
+type ProxyProtocolOptions struct {
+	// HeaderTimeout sets how long connections have to send their header,
+	// where zero waits for as long as reads are allowed to.
+	HeaderTimeout time.Duration
+
+	// Optional accepts connections which do not begin with a header, which
+	// keep their own remote address.
+	Optional bool
+}
+
+// ProxyProtocolListener returns a net.Listener accepting connections through
+// the listener which begin with the version 1 or 2 PROXY protocol header sent
+// by load balancers such as HAProxy, whoes RemoteAddr reports the client the
+// header names, so servers behind them, such as those made by NewServer,
+// report the client's address. Headers are read on the first call to Read or
+// RemoteAddr, not when connections are accepted. Any client may send a
+// header, so the listener must only be reachable through the load balancer.
+func ProxyProtocolListener(l net.Listener, opts ProxyProtocolOptions) net.Listener {
+	return &proxyListener{Listener: l, opts: opts}
+}
+
+// proxyListener defines a net.Listener whoes connections begin with a PROXY
+// protocol header.
+type proxyListener struct {
+	net.Listener
+	opts ProxyProtocolOptions
+}
+
+// Accept returns the next connection accepted by the listener.
+func (p *proxyListener) Accept() (net.Conn, error) {
+	conn, err := p.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &proxyConn{Conn: conn, reader: bufio.NewReader(conn), opts: p.opts}, nil
+}
+
+// proxyConn defines a net.Conn which reads it's PROXY protocol header before
+// it's data.
+type proxyConn struct {
+	net.Conn
+	opts   ProxyProtocolOptions
+	reader *bufio.Reader
+	once   sync.Once
+	remote net.Addr
+	err    error
+}
+
+// Read reads data following the header.
+func (p *proxyConn) Read(b []byte) (int, error) {
+	p.once.Do(p.readHeader)
+	if p.err != nil {
+		return 0, p.err
+	}
+
+	return p.reader.Read(b)
+}
+
+// RemoteAddr returns the address of the client named by the header, or the
+// address of the connection if it names none.
+func (p *proxyConn) RemoteAddr() net.Addr {
+	p.once.Do(p.readHeader)
+	if p.remote != nil {
+		return p.remote
+	}
+
+	return p.Conn.RemoteAddr()
+}
+
+// readHeader reads the header, if any, beginning the connection.
+func (p *proxyConn) readHeader() {
+	if p.opts.HeaderTimeout > 0 {
+		p.Conn.SetReadDeadline(time.Now().Add(p.opts.HeaderTimeout))
+		defer p.Conn.SetReadDeadline(time.Time{})
+	}
+
+	p.remote, p.err = readProxyHeader(p.reader, p.opts.Optional)
+}
+
+// readProxyHeader reads a version 1 or 2 header from the reader, returning the
+// source address it names, if any.
+func readProxyHeader(r *bufio.Reader, optional bool) (net.Addr, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch first[0] {
+	case 'P':
+		if prefix, err := r.Peek(6); err == nil && string(prefix) == "PROXY " {
+			return readProxyV1(r)
+		}
+	case '\r':
+		if prefix, err := r.Peek(len(proxySignature)); err == nil && bytes.Equal(prefix, proxySignature) {
+			return readProxyV2(r)
+		}
+	}
+
+	if optional {
+		return nil, nil
+	}
+
+	return nil, ErrInvalidProxyHeader
+}
+
+// readProxyV1 reads a version 1 header, being a line of at most 107 bytes
+// such as "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n".
+func readProxyV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+
+	for len(line) < 107 {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		line = append(line, c)
+		if c == '\n' {
+			break
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, ErrInvalidProxyHeader
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, ErrInvalidProxyHeader
+	}
+
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, ErrInvalidProxyHeader
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2 reads a version 2 header, being the signature followed by the
+// version and command, the address family and protocol, and the length of the
+// addresses and any TLVs following them, which are skipped.
+func readProxyV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, len(proxySignature)+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	command := header[12]
+	family := header[13]
+
+	body := make([]byte, binary.BigEndian.Uint16(header[14:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	if command>>4 != 2 {
+		return nil, ErrInvalidProxyHeader
+	}
+
+	switch command & 0x0F {
+	case 0x0:
+		// LOCAL connections, such as health checks, are made by the load
+		// balancer itself.
+		return nil, nil
+	case 0x1:
+	default:
+		return nil, ErrInvalidProxyHeader
+	}
+
+	var size int
+	switch family >> 4 {
+	case 0x1:
+		size = net.IPv4len
+	case 0x2:
+		size = net.IPv6len
+	default:
+		return nil, nil
+	}
+
+	if len(body) < size*2+4 {
+		return nil, ErrInvalidProxyHeader
+	}
+
+	ip := net.IP(body[:size])
+	port := int(binary.BigEndian.Uint16(body[size*2:]))
+
+	if family&0x0F == 0x2 {
+		return &net.UDPAddr{IP: ip, Port: port}, nil
+	}
+
+	return &net.TCPAddr{IP: ip, Port: port}, nil
+}
+
+// WriteProxyHeader writes the version 1 or 2 PROXY protocol header naming the
+// source and destination addresses of a connection to w, such as a connection
+// dialed to a server behind a ProxyProtocolListener. Addresses other than TCP
+// ones are written as unknown, for version 1, or local, for version 2.
+func WriteProxyHeader(w io.Writer, version int, src net.Addr, dst net.Addr) error {
+	source, sok := src.(*net.TCPAddr)
+	dest, dok := dst.(*net.TCPAddr)
+
+	known := sok && dok && (source.IP.To4() != nil) == (dest.IP.To4() != nil)
+
+	switch version {
+	case 1:
+		if !known {
+			_, err := io.WriteString(w, "PROXY UNKNOWN\r\n")
+			return err
+		}
+
+		family := "TCP6"
+		if source.IP.To4() != nil {
+			family = "TCP4"
+		}
+
+		_, err := fmt.Fprintf(w, "PROXY %s %s %s %d %d\r\n", family, source.IP, dest.IP, source.Port, dest.Port)
+		return err
+
+	case 2:
+		header := append([]byte{}, proxySignature...)
+
+		if !known {
+			header = append(header, 0x20, 0x00, 0x00, 0x00)
+			_, err := w.Write(header)
+			return err
+		}
+
+		srcIP, dstIP, family := source.IP.To4(), dest.IP.To4(), byte(0x11)
+		if srcIP == nil {
+			srcIP, dstIP, family = source.IP.To16(), dest.IP.To16(), 0x21
+		}
+
+		header = append(header, 0x21, family, 0, 0)
+		binary.BigEndian.PutUint16(header[14:], uint16(len(srcIP)*2+4))
+
+		header = append(header, srcIP...)
+		header = append(header, dstIP...)
+		header = append(header, byte(source.Port>>8), byte(source.Port), byte(dest.Port>>8), byte(dest.Port))
+
+		_, err := w.Write(header)
+		return err
+	}
+
+	return fmt.Errorf("Unsupported PROXY protocol version %d", version)
+}
@@ -0,0 +1,206 @@
+package fhttp
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+)
+
+// ErrRateLimited is returned when a request exceeds it's rate limit.
+var ErrRateLimited = errors.New("Rate limit exceeded")
+
+// RateLimit defines a token bucket limit of Requests per the Per duration,
+// allowing bursts of up to Burst requests, which defaults to Requests.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// rate returns the tokens added to the bucket per second.
+func (r RateLimit) rate() float64 {
+	return float64(r.Requests) / r.Per.Seconds()
+}
+
+// burst returns the size of the bucket.
+func (r RateLimit) burst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+
+	return r.Requests
+}
+
+// RateLimitStore defines the interface of the stores holding the token
+// buckets of RateLimiter, which allows instances sharing a store, such as one
+// backed by Redis, to share their limits.
+type RateLimitStore interface {
+	// Take removes a token from the bucket for key, returning true/false if
+	// one was available, the tokens left and how long till the next token
+	// is added.
+	Take(key string, limit RateLimit) (ok bool, remaining int, retryAfter time.Duration, err error)
+}
+
+// RateLimitOptions defines the configuration used by RateLimiter.
+type RateLimitOptions struct {
+	Limit RateLimit
+
+	// Key returns the key requests are limited by, defaulting to the
+	// client's IP through RateLimitByIP(false).
+	Key func(context.Context, *Request) string
+
+	// Store sets the store of the token buckets, defaulting to a new
+	// MemoryRateStore.
+	Store RateLimitStore
+}
+
+// RateLimiter returns a DriveMiddleware which limits requests using a token
+// bucket per key, setting the X-RateLimit-Limit and X-RateLimit-Remaining
+// headers. Requests exceeding the limit are responded to with a 429 status
+// and a Retry-After header, stopping them from being handled any further. It
+// panics if the limit's Requests or Per are not positive.
+func RateLimiter(opts RateLimitOptions) DriveMiddleware {
+	if opts.Limit.Requests <= 0 || opts.Limit.Per <= 0 {
+		panic("Expected RateLimitOptions.Limit to have positive Requests and Per")
+	}
+
+	if opts.Key == nil {
+		opts.Key = RateLimitByIP(false)
+	}
+
+	if opts.Store == nil {
+		opts.Store = NewMemoryRateStore()
+	}
+
+	limit := strconv.Itoa(opts.Limit.burst())
+
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		ok, remaining, retryAfter, err := opts.Store.Take(opts.Key(ctx, rw), opts.Limit)
+		if err != nil {
+			rw.RespondError(http.StatusInternalServerError, err)
+			return nil, err
+		}
+
+		header := rw.Res.Header()
+		header.Set("X-RateLimit-Limit", limit)
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+		if !ok {
+			header.Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			rw.RespondError(http.StatusTooManyRequests, ErrRateLimited)
+			return nil, ErrRateLimited
+		}
+
+		return rw, nil
+	}
+}
+
+// RateLimitByIP returns a key function which limits requests by the client's
+// IP. If trustForwarded is true, the first address of the X-Forwarded-For
+// header is used, which should only be done behind a proxy setting it.
+func RateLimitByIP(trustForwarded bool) func(context.Context, *Request) string {
+	return func(ctx context.Context, rw *Request) string {
+		return ClientIP(rw.Req, trustForwarded)
+	}
+}
+
+// RateLimitByIdentity returns a key function which limits requests by the
+// subject of the Identity added by the APIKey middleware, falling back to the
+// client's IP. Requests are only limited by validated values, as clients
+// could change any other value to escape their limit.
+func RateLimitByIdentity() func(context.Context, *Request) string {
+	return func(ctx context.Context, rw *Request) string {
+		if identity, ok := rw.Identity(ctx); ok {
+			return "identity:" + identity.Subject
+		}
+
+		return ClientIP(rw.Req, false)
+	}
+}
+
+// ClientIP returns the IP of the client making the request. If
+// trustForwarded is true, the first address of the X-Forwarded-For header is
+// used when present.
+func ClientIP(r *http.Request, trustForwarded bool) string {
+	if trustForwarded {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// MemoryRateStore defines a RateLimitStore which holds the token buckets in
+// memory, limiting a single instance.
+type MemoryRateStore struct {
+	ml      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+// tokenBucket defines the state of a single token bucket.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemoryRateStore returns a new instance of a MemoryRateStore.
+func NewMemoryRateStore() *MemoryRateStore {
+	return &MemoryRateStore{
+		buckets: make(map[string]*tokenBucket),
+		swept:   time.Now(),
+	}
+}
+
+// Take removes a token from the bucket for key.
+func (m *MemoryRateStore) Take(key string, limit RateLimit) (bool, int, time.Duration, error) {
+	now := time.Now()
+	rate := limit.rate()
+	burst := float64(limit.burst())
+
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	bucket, ok := m.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		m.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.last).Seconds()*rate)
+	bucket.last = now
+
+	// Full buckets hold nothing worth keeping, so idle ones are removed
+	// periodically.
+	if now.Sub(m.swept) > time.Minute {
+		m.swept = now
+
+		for name, item := range m.buckets {
+			if item != bucket && item.tokens+now.Sub(item.last).Seconds()*rate >= burst {
+				delete(m.buckets, name)
+			}
+		}
+	}
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+		return false, 0, wait, nil
+	}
+
+	bucket.tokens--
+
+	return true, int(bucket.tokens), 0, nil
+}
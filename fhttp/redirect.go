@@ -0,0 +1,261 @@
+package fhttp
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dimfeld/httptreemux"
+	"github.com/influx6/faux/context"
+)
+
+// TrailingSlashPolicy defines how requests whoes path differs from a route's
+// by a trailing slash are handled.
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashRedirect redirects requests to the route's path.
+	TrailingSlashRedirect TrailingSlashPolicy = iota
+
+	// TrailingSlashIgnore serves requests with the route's handler as if
+	// their paths matched.
+	TrailingSlashIgnore
+
+	// TrailingSlashStrict responds to requests with a 404 status.
+	TrailingSlashStrict
+)
+
+// RouterOptions defines the routing and redirect behaviour of a HTTPDrive.
+type RouterOptions struct {
+	TrailingSlash TrailingSlashPolicy
+
+	// RedirectStatus sets the status of the redirects issued by the drive,
+	// being one of 301, 307 or 308, defaulting to 301.
+	RedirectStatus int
+
+	// CaseInsensitive matches the static segments of routes regardless of
+	// case, while params keep the case they were requested with. Redirects
+	// issued for such requests are to the lower cased path.
+	CaseInsensitive bool
+
+	// CanonicalHost, if set, redirects requests for any other host to it.
+	CanonicalHost string
+
+	// RedirectHTTPS redirects requests not made over TLS to https.
+	RedirectHTTPS bool
+
+	// TrustForwardedProto takes the X-Forwarded-Proto header as the scheme
+	// requests were made with, which should only be done behind a proxy
+	// setting it.
+	TrustForwardedProto bool
+
+	// MethodOverride, if set, lists the methods POST requests may be
+	// overridden to before being routed. See MethodOverride.
+	MethodOverride []string
+}
+
+// DriveWith returns a HTTPDrive constructor like Drive, whoes drive routes
+// and redirects requests as configured by the options.
+func DriveWith(opts RouterOptions, before ...DriveMiddleware) func(...DriveMiddleware) *HTTPDrive {
+	return func(after ...DriveMiddleware) *HTTPDrive {
+		drive := Drive(before...)(after...)
+		drive.options = opts
+
+		mux := drive.TreeMux
+		mux.RedirectTrailingSlash = opts.TrailingSlash != TrailingSlashStrict
+
+		switch {
+		case opts.TrailingSlash == TrailingSlashIgnore:
+			mux.RedirectBehavior = httptreemux.UseHandler
+		case opts.RedirectStatus == http.StatusTemporaryRedirect:
+			mux.RedirectBehavior = httptreemux.Redirect307
+		case opts.RedirectStatus == http.StatusPermanentRedirect:
+			mux.RedirectBehavior = httptreemux.Redirect308
+		default:
+			mux.RedirectBehavior = httptreemux.Redirect301
+		}
+
+		return drive
+	}
+}
+
+// ServeHTTP serves the request, redirecting it to the canonical host and to
+// https and overriding it's method first if the drive is configured to.
+func (hd *HTTPDrive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if target, ok := hd.canonicalURL(r); ok {
+		http.Redirect(w, r, target, hd.redirectStatus())
+		return
+	}
+
+	if len(hd.options.MethodOverride) != 0 {
+		if err := overrideMethod(r, hd.options.MethodOverride); err != nil {
+			RenderErrorWithStatus(http.StatusBadRequest, err, r, w)
+			return
+		}
+	}
+
+	if !hd.options.CaseInsensitive {
+		hd.TreeMux.ServeHTTP(w, r)
+		return
+	}
+
+	if hd.PanicHandler != nil {
+		defer func() {
+			if err := recover(); err != nil {
+				hd.PanicHandler(w, r, err)
+			}
+		}()
+	}
+
+	// Routes are looked up with a lower cased copy of the request, while
+	// their handlers receive the request as is.
+	lower := *r
+	lowerURL := *r.URL
+	lowerURL.Path = strings.ToLower(lowerURL.Path)
+	lowerURL.RawPath = strings.ToLower(lowerURL.RawPath)
+	lower.URL = &lowerURL
+	lower.RequestURI = strings.ToLower(r.RequestURI)
+
+	result, _ := hd.Lookup(w, &lower)
+	hd.ServeLookupResult(w, r, result)
+}
+
+// canonicalURL returns the URL the request should be redirected to, returning
+// false if it is already at it's canonical URL.
+func (hd *HTTPDrive) canonicalURL(r *http.Request) (string, bool) {
+	if hd.options.CanonicalHost == "" && !hd.options.RedirectHTTPS {
+		return "", false
+	}
+
+	scheme := "http"
+	if r.TLS != nil || (hd.options.TrustForwardedProto && strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")) {
+		scheme = "https"
+	}
+
+	host := r.Host
+	redirect := false
+
+	if hd.options.CanonicalHost != "" && !strings.EqualFold(host, hd.options.CanonicalHost) {
+		host = hd.options.CanonicalHost
+		redirect = true
+	}
+
+	if hd.options.RedirectHTTPS && scheme != "https" {
+		scheme = "https"
+		redirect = true
+	}
+
+	if !redirect {
+		return "", false
+	}
+
+	target := url.URL{
+		Scheme:   scheme,
+		Host:     host,
+		Path:     r.URL.Path,
+		RawPath:  r.URL.RawPath,
+		RawQuery: r.URL.RawQuery,
+	}
+
+	return target.String(), true
+}
+
+// redirectStatus returns the status of the redirects issued by the drive.
+func (hd *HTTPDrive) redirectStatus() int {
+	switch hd.options.RedirectStatus {
+	case http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return hd.options.RedirectStatus
+	default:
+		return http.StatusMovedPermanently
+	}
+}
+
+// routePath returns the path the route is registered under, lower casing it's
+// static segments if the drive matches routes regardless of case.
+func (hd *HTTPDrive) routePath(path string) string {
+	if !hd.options.CaseInsensitive {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	for index, segment := range segments {
+		if segment != "" && segment[0] != ':' && segment[0] != '*' {
+			segments[index] = strings.ToLower(segment)
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// routeParams returns the params of the request's path for the route's path,
+// which keep the case they were requested with unlike those of the lower
+// cased path the route was looked up with.
+func routeParams(route string, r *http.Request) map[string]string {
+	params := make(map[string]string)
+	segments := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+
+	for index, segment := range strings.Split(strings.TrimPrefix(route, "/"), "/") {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') || index >= len(segments) {
+			continue
+		}
+
+		value := segments[index]
+		if segment[0] == '*' {
+			value = strings.Join(segments[index:], "/")
+		}
+
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+
+		params[segment[1:]] = value
+	}
+
+	return params
+}
+
+// RedirectTo returns an Endpoint action which redirects requests to the URL
+// with the provided status code, replacing any ":param" segments of it with
+// the request's params.
+func RedirectTo(target string, code int) func(context.Context, *Request) error {
+	return func(ctx context.Context, rw *Request) error {
+		http.Redirect(rw.Res, rw.Req, fillParams(target, rw.Params), code)
+		return nil
+	}
+}
+
+// RedirectToRoute returns an Endpoint action which redirects requests to the
+// URL of the route registered under name with the provided status code,
+// filling it's params from the request's params.
+func (hd *HTTPDrive) RedirectToRoute(name string, code int) func(context.Context, *Request) error {
+	return func(ctx context.Context, rw *Request) error {
+		target, err := hd.URL(name, rw.Params)
+		if err != nil {
+			return err
+		}
+
+		http.Redirect(rw.Res, rw.Req, target, code)
+		return nil
+	}
+}
+
+// fillParams replaces the ":param" segments of the target's path with the
+// provided params, leaving those without a param as is.
+func fillParams(target string, params Param) string {
+	if len(params) == 0 || !strings.Contains(target, "/:") {
+		return target
+	}
+
+	segments := strings.Split(target, "/")
+	for index, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		if value, ok := params.Get(segment[1:]); ok {
+			segments[index] = url.PathEscape(value)
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
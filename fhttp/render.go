@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"strconv"
 	"sync/atomic"
+
+	"github.com/influx6/fractals/maps"
 )
 
 // Field defines a struct for collating fields errors that occur.
@@ -84,10 +86,11 @@ type Request struct {
 	Res    ResponseWriter
 }
 
-// Respond renders out a JSON response and status code giving using the Render
-// function.
+// Respond renders out a response and status code in the content type the
+// request's Accept header prefers, as chosen by Negotiate from the registered
+// serializers, defaulting to JSON.
 func (r *Request) Respond(code int, data interface{}) {
-	Render(code, r.Req, r.Res, data)
+	RenderAs(code, r.Req, r.Res, Negotiate(r.Req), data)
 }
 
 // RespondAny renders out a JSON response and status code giving using the Render
@@ -146,6 +149,22 @@ func Render(code int, r *http.Request, w ResponseWriter, data interface{}) {
 	io.WriteString(w, string(jsd))
 }
 
+// RenderXML writes the giving data into the response as XML.
+func RenderXML(code int, r *http.Request, w ResponseWriter, data interface{}) {
+	RenderAs(code, r, w, "application/xml", data)
+}
+
+// AcceptsXML returns true/false if the Accept header of the request prefers a
+// XML response over a JSON one.
+func AcceptsXML(r *http.Request) bool {
+	switch Negotiate(r) {
+	case "application/xml", "text/xml":
+		return true
+	default:
+		return false
+	}
+}
+
 // RenderResponse writes the giving data into the response as JSON to the passed
 // ResponseRequest.
 func RenderResponse(code int, r *Request, data interface{}) {
@@ -155,23 +174,42 @@ func RenderResponse(code int, r *Request, data interface{}) {
 // RenderResponseErrorWithStatus renders the giving error as a json response to
 // the ResponseRequest.
 func RenderResponseErrorWithStatus(status int, err error, r *Request) {
-	Render(status, r.Req, r.Res, JSONError{Error: err.Error()})
+	Render(status, r.Req, r.Res, errorResponse(err))
 }
 
 // RenderErrorWithStatus renders the giving error as a json response.
 func RenderErrorWithStatus(status int, err error, r *http.Request, w http.ResponseWriter) {
-	Render(status, r, NewResponseWriter(w), JSONError{Error: err.Error()})
+	Render(status, r, NewResponseWriter(w), errorResponse(err))
 }
 
 // RenderError renders the giving error as a json response.
 func RenderError(err error, r *http.Request, w http.ResponseWriter) {
-	Render(http.StatusBadRequest, r, NewResponseWriter(w), JSONError{Error: err.Error()})
+	Render(http.StatusBadRequest, r, NewResponseWriter(w), errorResponse(err))
 }
 
 // RenderResponseError renders the giving error as a json response to the
 // passed ResponseRequest object.
 func RenderResponseError(err error, r *Request) {
-	Render(http.StatusBadRequest, r.Req, r.Res, JSONError{Error: err.Error()})
+	Render(http.StatusBadRequest, r.Req, r.Res, errorResponse(err))
+}
+
+// errorResponse returns the JSONError rendered for err, holding the details of
+// the failed fields of a *BindError or *maps.ValidationError.
+func errorResponse(err error) JSONError {
+	if berr, ok := err.(*BindError); ok {
+		return JSONError{Error: berr.Error(), Fields: berr.Fields}
+	}
+
+	if verr, ok := err.(*maps.ValidationError); ok {
+		fields := make([]Field, 0, len(verr.Fields))
+		for _, field := range verr.Fields {
+			fields = append(fields, Field(field))
+		}
+
+		return JSONError{Error: verr.Error(), Fields: fields}
+	}
+
+	return JSONError{Error: err.Error()}
 }
 
 // ResponseWriter is a wrapper around http.ResponseWriter that provides extra information about
@@ -252,7 +290,13 @@ func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 }
 
 func (rw *responseWriter) CloseNotify() <-chan bool {
-	return rw.ResponseWriter.(http.CloseNotifier).CloseNotify()
+	// Writers unable to notify, such as httptest.ResponseRecorder, never do.
+	notifier, ok := rw.ResponseWriter.(http.CloseNotifier)
+	if !ok {
+		return make(chan bool)
+	}
+
+	return notifier.CloseNotify()
 }
 
 func (rw *responseWriter) Flush() {
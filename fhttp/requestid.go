@@ -0,0 +1,99 @@
+package fhttp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/influx6/faux/context"
+)
+
+// RequestIDHeader defines the header carrying the id of a request.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey defines the key the request id is stored under within the
+// context.
+const requestIDKey = "fhttp.request_id"
+
+// maxRequestIDSize defines the size of the largest incoming request id which
+// is propagated instead of replaced.
+const maxRequestIDSize = 128
+
+// RequestID returns a DriveMiddleware which assigns every request an id,
+// propagating the one within the incoming X-Request-ID header if valid, else
+// generating a new one. The id is stored within the context, where it is
+// available through Request.RequestID, set on the request and response
+// headers, which the logging middlewares include, and can be passed on to
+// outbound requests through PropagateRequestID.
+func RequestID() DriveMiddleware {
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		id := rw.Req.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			var err error
+			if id, err = newRequestID(); err != nil {
+				return nil, err
+			}
+		}
+
+		ctx.Set(requestIDKey, id)
+		rw.Req.Header.Set(RequestIDHeader, id)
+		rw.Res.Header().Set(RequestIDHeader, id)
+
+		return rw, nil
+	}
+}
+
+// RequestID returns the id added to the context by the RequestID middleware,
+// or an empty string if none was.
+func (r *Request) RequestID(ctx context.Context) string {
+	return RequestIDFrom(ctx)
+}
+
+// RequestIDFrom returns the id added to the context by the RequestID
+// middleware, or an empty string if none was.
+func RequestIDFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	id, ok := ctx.Get(requestIDKey)
+	if !ok {
+		return ""
+	}
+
+	return id.(string)
+}
+
+// PropagateRequestID sets the id held by the context on the outbound request,
+// so the services it calls can correlate their logs with the request.
+func PropagateRequestID(ctx context.Context, out *http.Request) {
+	if id := RequestIDFrom(ctx); id != "" {
+		out.Header.Set(RequestIDHeader, id)
+	}
+}
+
+// validRequestID returns true/false if the incoming id is short and printable
+// enough to be propagated.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDSize {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID returns a new random request id.
+func newRequestID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
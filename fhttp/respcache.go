@@ -0,0 +1,381 @@
+package fhttp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+)
+
+// errCacheHit is returned by ResponseCache once it has responded from the
+// cache, stopping the request from being handled any further.
+var errCacheHit = errors.New("Responded from cache")
+
+// DefaultCacheBodySize defines the size of the largest response cached when
+// CacheOptions provides none.
+const DefaultCacheBodySize = 1 << 20
+
+// CachedResponse defines a response stored by ResponseCache.
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	Stored time.Time
+}
+
+// CacheStore defines the interface of the stores holding the responses of
+// ResponseCache, which allows instances sharing a store, such as one backed
+// by Redis, to share their cache.
+type CacheStore interface {
+	// Get returns the response stored under key, returning false if none
+	// is.
+	Get(key string) (CachedResponse, bool, error)
+
+	// Set stores the response under key, keeping it for at least ttl.
+	Set(key string, res CachedResponse, ttl time.Duration) error
+}
+
+// CacheOptions defines the configuration used by ResponseCache.
+type CacheOptions struct {
+	// TTL sets how long responses are fresh for.
+	TTL time.Duration
+
+	// StaleWhileRevalidate sets how long responses are served once stale,
+	// while a single request refreshes them in the background.
+	StaleWhileRevalidate time.Duration
+
+	// Vary lists the request headers responses differ by, such as
+	// "Accept", which are part of the cache key.
+	Vary []string
+
+	// Methods lists the methods whoes responses are cached, defaulting to
+	// GET and HEAD.
+	Methods []string
+
+	// MaxBodySize sets the size of the largest response cached, defaulting
+	// to DefaultCacheBodySize.
+	MaxBodySize int
+
+	// Store sets the store of the responses, defaulting to a new
+	// MemoryCacheStore.
+	Store CacheStore
+}
+
+// ResponseCache returns a DriveMiddleware which caches full 200 responses,
+// keyed by the request's method, host, path, query and the headers it's
+// options vary by, serving them for the options' TTL and, once stale, for the
+// StaleWhileRevalidate duration while a single request refreshes them with
+// the client already answered. Responses setting cookies or marked no-store
+// or private are not cached, nor are responses to requests carrying an
+// Authorization or Cookie header unless marked public, as they may be
+// personalised. The X-Cache header reports whether the cache
+// was a HIT, STALE or MISS. Used alongside Compress, it should come after it
+// so uncompressed responses are cached.
+func ResponseCache(opts CacheOptions) DriveMiddleware {
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{"GET", "HEAD"}
+	}
+
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = DefaultCacheBodySize
+	}
+
+	if opts.Store == nil {
+		opts.Store = NewMemoryCacheStore()
+	}
+
+	var ml sync.Mutex
+	refreshing := make(map[string]bool)
+
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		if !hasMethod(opts.Methods, rw.Req.Method) {
+			return rw, nil
+		}
+
+		key := cacheKey(rw.Req, opts.Vary)
+		credentialed := rw.Req.Header.Get("Authorization") != "" || rw.Req.Header.Get("Cookie") != ""
+
+		cached, ok, err := opts.Store.Get(key)
+		if err != nil {
+			return rw, nil
+		}
+
+		age := time.Since(cached.Stored)
+
+		if ok && age < opts.TTL {
+			serveCached(rw, cached, "HIT", age)
+			return nil, errCacheHit
+		}
+
+		if ok && age < opts.TTL+opts.StaleWhileRevalidate {
+			ml.Lock()
+			refresh := !refreshing[key]
+			refreshing[key] = true
+			ml.Unlock()
+
+			serveCached(rw, cached, "STALE", age)
+
+			if !refresh {
+				return nil, errCacheHit
+			}
+
+			// The client has it's response, so the request carries on
+			// only to refresh the cache, with the response finished as
+			// it would be once handled.
+			if closer, ok := rw.Res.(io.Closer); ok {
+				closer.Close()
+			}
+
+			rw.Res = &cacheWriter{
+				ResponseWriter: NewResponseWriter(discardWriter{header: make(http.Header)}),
+				opts:           opts,
+				key:            key,
+				credentialed:   credentialed,
+				done: func() {
+					ml.Lock()
+					delete(refreshing, key)
+					ml.Unlock()
+				},
+			}
+
+			return rw, nil
+		}
+
+		rw.Res.Header().Set("X-Cache", "MISS")
+		rw.Res = &cacheWriter{ResponseWriter: rw.Res, opts: opts, key: key, credentialed: credentialed}
+
+		return rw, nil
+	}
+}
+
+// hasMethod returns true/false if the method is within the list.
+func hasMethod(methods []string, method string) bool {
+	for _, item := range methods {
+		if strings.EqualFold(item, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cacheKey returns the key the response of the request is cached under.
+func cacheKey(r *http.Request, vary []string) string {
+	key := r.Method + " " + r.Host + r.URL.RequestURI()
+
+	for _, name := range vary {
+		key += "\n" + name + ": " + r.Header.Get(name)
+	}
+
+	return key
+}
+
+// serveCached writes the cached response.
+func serveCached(rw *Request, cached CachedResponse, state string, age time.Duration) {
+	// The values are copied, so the stored response is not changed by later
+	// middlewares.
+	header := rw.Res.Header()
+	for key, values := range cached.Header {
+		header[key] = append([]string(nil), values...)
+	}
+
+	header.Set("X-Cache", state)
+	header.Set("Age", strconv.Itoa(int(age.Seconds())))
+	header.Set("Content-Length", strconv.Itoa(len(cached.Body)))
+
+	rw.Res.WriteHeader(cached.Status)
+
+	if rw.Req.Method != "HEAD" {
+		rw.Res.Write(cached.Body)
+	}
+
+	rw.Res.Flush()
+}
+
+// cacheWriter defines a ResponseWriter which records the response written
+// through it, storing it once the response is finished if it can be cached.
+type cacheWriter struct {
+	ResponseWriter
+	opts         CacheOptions
+	key          string
+	credentialed bool
+	done         func()
+
+	header   http.Header
+	body     bytes.Buffer
+	overflow bool
+}
+
+// WriteHeader records the headers of the response, writing the status.
+func (c *cacheWriter) WriteHeader(status int) {
+	if c.header == nil {
+		c.header = c.ResponseWriter.Header().Clone()
+	}
+
+	c.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the data, writing it.
+func (c *cacheWriter) Write(data []byte) (int, error) {
+	if c.header == nil {
+		c.header = c.ResponseWriter.Header().Clone()
+	}
+
+	if !c.overflow {
+		if c.body.Len()+len(data) > c.opts.MaxBodySize {
+			c.overflow = true
+			c.body.Reset()
+		} else {
+			c.body.Write(data)
+		}
+	}
+
+	return c.ResponseWriter.Write(data)
+}
+
+// Close stores the recorded response if it can be cached and closes the inner
+// writer.
+func (c *cacheWriter) Close() error {
+	if c.done != nil {
+		defer c.done()
+	}
+
+	if c.cacheable() {
+		header := c.header.Clone()
+		header.Del("X-Cache")
+		header.Del("Age")
+		header.Del("Content-Length")
+
+		c.opts.Store.Set(c.key, CachedResponse{
+			Status: c.ResponseWriter.Status(),
+			Header: header,
+			Body:   append([]byte(nil), c.body.Bytes()...),
+			Stored: time.Now(),
+		}, c.opts.TTL+c.opts.StaleWhileRevalidate)
+	}
+
+	if closer, ok := c.ResponseWriter.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Hijack hands over the connection, as needed by WebSocket endpoints. The
+// response is not cached for hijacked requests.
+func (c *cacheWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := c.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrHijackUnsupported
+	}
+
+	c.overflow = true
+
+	return hijacker.Hijack()
+}
+
+// cacheable returns true/false if the recorded response can be cached.
+func (c *cacheWriter) cacheable() bool {
+	if c.overflow || c.header == nil || c.ResponseWriter.Status() != http.StatusOK {
+		return false
+	}
+
+	if len(c.header["Set-Cookie"]) != 0 {
+		return false
+	}
+
+	control := strings.ToLower(c.header.Get("Cache-Control"))
+	if c.credentialed && !strings.Contains(control, "public") {
+		return false
+	}
+
+	return !strings.Contains(control, "no-store") && !strings.Contains(control, "private")
+}
+
+// discardWriter defines a http.ResponseWriter which discards what is written,
+// used to refresh cached responses without a client.
+type discardWriter struct {
+	header http.Header
+}
+
+// Header returns the header of the response.
+func (d discardWriter) Header() http.Header {
+	return d.header
+}
+
+// Write discards the data.
+func (d discardWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+// WriteHeader discards the status.
+func (d discardWriter) WriteHeader(int) {}
+
+// Flush does nothing.
+func (d discardWriter) Flush() {}
+
+// MemoryCacheStore defines a CacheStore which holds the responses in memory,
+// caching for a single instance.
+type MemoryCacheStore struct {
+	ml      sync.Mutex
+	entries map[string]memoryCacheEntry
+	swept   time.Time
+}
+
+// memoryCacheEntry defines a response held by a MemoryCacheStore.
+type memoryCacheEntry struct {
+	res     CachedResponse
+	expires time.Time
+}
+
+// NewMemoryCacheStore returns a new instance of a MemoryCacheStore.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{
+		entries: make(map[string]memoryCacheEntry),
+		swept:   time.Now(),
+	}
+}
+
+// Get returns the response stored under key.
+func (m *MemoryCacheStore) Get(key string) (CachedResponse, bool, error) {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return CachedResponse{}, false, nil
+	}
+
+	return entry.res, true, nil
+}
+
+// Set stores the response under key for ttl.
+func (m *MemoryCacheStore) Set(key string, res CachedResponse, ttl time.Duration) error {
+	now := time.Now()
+
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	m.entries[key] = memoryCacheEntry{res: res, expires: now.Add(ttl)}
+
+	// Expired entries are removed periodically.
+	if now.Sub(m.swept) > time.Minute {
+		m.swept = now
+
+		for name, entry := range m.entries {
+			if now.After(entry.expires) {
+				delete(m.entries, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package fhttp
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Serializer defines the interface of the encoders which render responses of
+// a given content type.
+type Serializer interface {
+	Marshal(data interface{}) ([]byte, error)
+}
+
+// SerializerFunc defines a function which implements Serializer.
+type SerializerFunc func(data interface{}) ([]byte, error)
+
+// Marshal calls the function with the data.
+func (fn SerializerFunc) Marshal(data interface{}) ([]byte, error) {
+	return fn(data)
+}
+
+// JSONSerializer renders responses as JSON.
+var JSONSerializer = SerializerFunc(json.Marshal)
+
+// XMLSerializer renders responses as XML, prefixed with the XML header.
+var XMLSerializer = SerializerFunc(func(data interface{}) ([]byte, error) {
+	xsd, err := xml.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), xsd...), nil
+})
+
+// serializers holds the registered serializers keyed by content type.
+var serializers = struct {
+	ml    sync.RWMutex
+	items map[string]Serializer
+}{
+	items: map[string]Serializer{
+		"application/json": JSONSerializer,
+		"application/xml":  XMLSerializer,
+		"text/xml":         XMLSerializer,
+	},
+}
+
+// RegisterSerializer registers the serializer for the content type, such as
+// "application/msgpack", "application/cbor" or "application/yaml", replacing
+// any registered before, which makes it available to Negotiate and in turn
+// to Request.Respond.
+func RegisterSerializer(contentType string, serializer Serializer) {
+	serializers.ml.Lock()
+	defer serializers.ml.Unlock()
+
+	serializers.items[strings.ToLower(contentType)] = serializer
+}
+
+// SerializerFor returns the serializer registered for the content type,
+// returning false if none was.
+func SerializerFor(contentType string) (Serializer, bool) {
+	serializers.ml.RLock()
+	defer serializers.ml.RUnlock()
+
+	serializer, ok := serializers.items[strings.ToLower(contentType)]
+	return serializer, ok
+}
+
+// acceptedMedia defines a single media range of an Accept header.
+type acceptedMedia struct {
+	media string
+	q     float64
+}
+
+// Negotiate returns the content type of the registered serializer the
+// request's Accept header prefers, defaulting to "application/json" when the
+// header is missing or accepts nothing registered.
+func Negotiate(r *http.Request) string {
+	if r == nil {
+		return "application/json"
+	}
+
+	var accepted []acceptedMedia
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		fields := strings.Split(part, ";")
+		media := strings.ToLower(strings.TrimSpace(fields[0]))
+		if media == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, field := range fields[1:] {
+			field = strings.TrimSpace(field)
+			if strings.HasPrefix(field, "q=") {
+				if val, err := strconv.ParseFloat(field[2:], 64); err == nil {
+					q = val
+				}
+			}
+		}
+
+		if q > 0 {
+			accepted = append(accepted, acceptedMedia{media: media, q: q})
+		}
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].q > accepted[j].q
+	})
+
+	serializers.ml.RLock()
+	defer serializers.ml.RUnlock()
+
+	for _, item := range accepted {
+		if _, ok := serializers.items[item.media]; ok {
+			return item.media
+		}
+
+		if item.media == "*/*" {
+			return "application/json"
+		}
+
+		// Ranges like "application/*" take JSON if it's within them or
+		// else the first registered type within them.
+		if strings.HasSuffix(item.media, "/*") {
+			prefix := strings.TrimSuffix(item.media, "*")
+			if strings.HasPrefix("application/json", prefix) {
+				return "application/json"
+			}
+
+			var matches []string
+			for media := range serializers.items {
+				if strings.HasPrefix(media, prefix) {
+					matches = append(matches, media)
+				}
+			}
+
+			if len(matches) != 0 {
+				sort.Strings(matches)
+				return matches[0]
+			}
+		}
+	}
+
+	return "application/json"
+}
+
+// RenderAs writes the giving data into the response using the serializer
+// registered for the content type, rendering JSON through Render. Failures to
+// serialize the data are responded to with a 500 status.
+func RenderAs(code int, r *http.Request, w ResponseWriter, contentType string, data interface{}) {
+	serializer, ok := SerializerFor(contentType)
+	if !ok {
+		RenderErrorWithStatus(http.StatusInternalServerError, fmt.Errorf("No serializer registered for %q", contentType), r, w)
+		return
+	}
+
+	if contentType == "application/json" {
+		Render(code, r, w, data)
+		return
+	}
+
+	if !w.StatusWritten() && code == http.StatusNoContent {
+		w.WriteHeader(code)
+		return
+	}
+
+	if w.DataWritten() {
+		return
+	}
+
+	encoded, err := serializer.Marshal(data)
+	if err != nil {
+		RenderErrorWithStatus(http.StatusInternalServerError, err, r, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	w.Write(encoded)
+}
@@ -1,14 +1,40 @@
 package fhttp_test
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	stdcontext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
 	"github.com/influx6/fractals/fhttp"
+	"github.com/influx6/fractals/fs"
+	"github.com/influx6/fractals/maps"
 )
 
 func TestHTTPDrive(t *testing.T) {
@@ -123,6 +149,39 @@ func TestHTTPDriveWithFractalHandlers(t *testing.T) {
 
 }
 
+func TestPaginate(t *testing.T) {
+	request, err := http.NewRequest("GET", "/names?page=2&limit=500", nil)
+	if err != nil {
+		fatalFailed(t, "Should have created requests for '/names': %s", err)
+	}
+
+	record := httptest.NewRecorder()
+	rw := &fhttp.Request{Req: request, Res: fhttp.NewResponseWriter(record)}
+
+	page := fhttp.Paginate(rw, 250)
+	if page.Limit != fhttp.MaxPageLimit || page.Page != 2 || page.Offset != 100 || page.Pages != 3 {
+		fatalFailed(t, "Should have capped pagination values: %+v", page)
+	}
+	logPassed(t, "Should have capped pagination values")
+
+	rw.RespondPage(http.StatusOK, []string{"fall-out"}, page)
+
+	if link := record.Header().Get("Link"); !strings.Contains(link, `rel="next"`) || !strings.Contains(link, `rel="prev"`) {
+		fatalFailed(t, "Should have set Link header with next and prev: %q", link)
+	}
+	logPassed(t, "Should have set Link header with next and prev")
+
+	var envelope fhttp.Envelope
+	if err := json.Unmarshal(record.Body.Bytes(), &envelope); err != nil {
+		fatalFailed(t, "Should have rendered envelope: %s", err)
+	}
+
+	if envelope.Links["last"] == "" || envelope.Meta["pagination"] == nil {
+		fatalFailed(t, "Should have rendered envelope links and meta: %+q", record.Body.Bytes())
+	}
+	logPassed(t, "Should have rendered envelope links and meta")
+}
+
 const succeedMark = "\u2713"
 const failedMark = "\u2717"
 
@@ -133,3 +192,1931 @@ func logPassed(t *testing.T, msg string, data ...interface{}) {
 func fatalFailed(t *testing.T, msg string, data ...interface{}) {
 	t.Fatalf("%s %s", fmt.Sprintf(msg, data...), failedMark)
 }
+
+func TestSSEFromObservable(t *testing.T) {
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/events",
+		Method: "GET",
+		Action: fhttp.SSEFromObservable(fractals.FromSlice([]interface{}{
+			"hello",
+			map[string]int{"count": 1},
+		}), fhttp.SSEOptions{Event: "update"}),
+	})
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/events", nil)
+	if err != nil {
+		fatalFailed(t, "Should have created requests for '/events': %s", err)
+	}
+
+	drive.ServeHTTP(record, request)
+
+	if ct := record.Header().Get("Content-Type"); ct != "text/event-stream" {
+		fatalFailed(t, "Should have responded with event stream content type but got %q", ct)
+	}
+	logPassed(t, "Should have responded with event stream content type")
+
+	expected := "event: update\ndata: hello\n\nevent: update\ndata: {\"count\":1}\n\n"
+	if body := record.Body.String(); body != expected {
+		fatalFailed(t, "Should have streamed events %q but got %q", expected, body)
+	}
+	logPassed(t, "Should have streamed events")
+}
+
+func TestSSE(t *testing.T) {
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/events",
+		Method: "GET",
+		Action: fhttp.SSE(func(ctx context.Context, w *fhttp.SSEWriter) error {
+			if err := w.Retry(3 * time.Second); err != nil {
+				return err
+			}
+
+			if err := w.Comment("welcome"); err != nil {
+				return err
+			}
+
+			return w.Send(fhttp.SSEEvent{ID: "1", Event: "update", Data: "line1\nline2"})
+		}),
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/events", nil)
+	drive.ServeHTTP(record, request)
+
+	expected := "retry: 3000\n\n: welcome\n\nid: 1\nevent: update\ndata: line1\ndata: line2\n\n"
+	if body := record.Body.String(); body != expected {
+		fatalFailed(t, "Should have streamed events %q but got %q", expected, body)
+	}
+	logPassed(t, "Should have streamed events")
+
+	cancelled, cancel := stdcontext.WithCancel(stdcontext.Background())
+	cancel()
+
+	var sendErr error
+
+	action := fhttp.SSE(func(ctx context.Context, w *fhttp.SSEWriter) error {
+		<-w.Done()
+		sendErr = w.Send(fhttp.SSEEvent{Data: "late"})
+		return nil
+	})
+
+	request, _ = http.NewRequest("GET", "/events", nil)
+	record = httptest.NewRecorder()
+
+	if err := action(context.New(), &fhttp.Request{Req: request.WithContext(cancelled), Res: fhttp.NewResponseWriter(record)}); err != nil {
+		fatalFailed(t, "Should have returned without error: %s", err)
+	}
+
+	if sendErr == nil || record.Body.Len() != 0 {
+		fatalFailed(t, "Should have failed sending to disconnected client but got %v %q", sendErr, record.Body.String())
+	}
+	logPassed(t, "Should have failed sending to disconnected client")
+}
+
+func TestRouteGroup(t *testing.T) {
+	drive := fhttp.Drive()()
+
+	api := drive.Group("/api", func(ctx context.Context, rw *fhttp.Request) (*fhttp.Request, error) {
+		if rw.Req.Header.Get("Authorization") == "" {
+			return nil, errors.New("Unauthorized")
+		}
+
+		ctx.Set("trail", []string{"api"})
+		return rw, nil
+	})
+
+	v1 := api.Group("/v1/", func(ctx context.Context, rw *fhttp.Request) (*fhttp.Request, error) {
+		trail, _ := ctx.Get("trail")
+		ctx.Set("trail", append(trail.([]string), "v1"))
+		return rw, nil
+	})
+
+	v1.Route()(fhttp.Endpoint{
+		Path:   "/users",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			trail, _ := ctx.Get("trail")
+			rw.Respond(http.StatusOK, trail)
+			return nil
+		},
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/v1/users", nil)
+	request.Header.Set("Authorization", "Bearer token")
+
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusOK || !strings.Contains(record.Body.String(), `["api","v1"]`) {
+		fatalFailed(t, "Should have run group middleware in order for '/api/v1/users': %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have run group middleware in order for '/api/v1/users'")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v1/users", nil)
+
+	drive.ServeHTTP(record, request)
+
+	if record.Code == http.StatusOK {
+		fatalFailed(t, "Should have stopped request failing group middleware")
+	}
+	logPassed(t, "Should have stopped request failing group middleware")
+}
+
+func TestBindParams(t *testing.T) {
+	type userParams struct {
+		ID     int     `param:"id,required"`
+		Active bool    `param:"active"`
+		Score  float64 `param:"score"`
+		Name   string  `param:"name"`
+	}
+
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:    "/users/:id/:name",
+		Method:  "GET",
+		LocalMW: fhttp.BindParamsMW("params", userParams{}),
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			params, _ := ctx.Get("params")
+			rw.Respond(http.StatusOK, params)
+			return nil
+		},
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/users/12/alex", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusOK || record.Body.String() != `{"ID":12,"Active":false,"Score":0,"Name":"alex"}` {
+		fatalFailed(t, "Should have bound path params into struct: %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have bound path params into struct")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/users/twelve/alex", nil)
+	drive.ServeHTTP(record, request)
+
+	var res fhttp.JSONError
+	if err := json.Unmarshal(record.Body.Bytes(), &res); err != nil || record.Code != http.StatusBadRequest {
+		fatalFailed(t, "Should have rendered bind error: %d %q", record.Code, record.Body.String())
+	}
+
+	if len(res.Fields) != 1 || res.Fields[0].Name != "id" || res.Fields[0].Value != "twelve" {
+		fatalFailed(t, "Should have rendered failed field details: %+v", res)
+	}
+	logPassed(t, "Should have rendered failed field details")
+
+	var missing userParams
+	err := fhttp.BindParams(&fhttp.Request{Params: fhttp.Param{}}, &missing)
+	if berr, ok := err.(*fhttp.BindError); !ok || berr.Fields[0].Name != "id" {
+		fatalFailed(t, "Should have failed binding missing required param: %v", err)
+	}
+	logPassed(t, "Should have failed binding missing required param")
+}
+
+func TestBindQuery(t *testing.T) {
+	type search struct {
+		Term    string        `query:"q,required"`
+		Tags    []string      `query:"tag"`
+		Limit   int           `query:"limit" default:"20"`
+		Since   time.Time     `query:"since"`
+		Timeout time.Duration `query:"timeout" default:"5s"`
+		Ratings []int         `query:"rating" default:"4,5"`
+	}
+
+	request, _ := http.NewRequest("GET", "/search?q=go&tag=http&tag=fs&since=2017-03-01", nil)
+
+	var dst search
+	if err := fhttp.BindQuery(&fhttp.Request{Req: request}, &dst); err != nil {
+		fatalFailed(t, "Should have bound query into struct: %s", err)
+	}
+
+	if dst.Term != "go" || fmt.Sprint(dst.Tags) != "[http fs]" || dst.Limit != 20 || dst.Since.Day() != 1 || dst.Timeout != 5*time.Second || fmt.Sprint(dst.Ratings) != "[4 5]" {
+		fatalFailed(t, "Should have bound query values and defaults: %+v", dst)
+	}
+	logPassed(t, "Should have bound query values and defaults")
+
+	request, _ = http.NewRequest("GET", "/search?limit=many&since=yesterday", nil)
+
+	err := fhttp.BindQuery(&fhttp.Request{Req: request}, &dst)
+	if berr, ok := err.(*fhttp.BindError); !ok || len(berr.Fields) != 3 {
+		fatalFailed(t, "Should have failed binding invalid query fields: %v", err)
+	}
+	logPassed(t, "Should have failed binding invalid query fields")
+}
+
+func TestBindBody(t *testing.T) {
+	type signup struct {
+		Email  string   `json:"email" form:"email,required"`
+		Age    int      `json:"age" form:"age"`
+		Topics []string `json:"topics" form:"topic"`
+	}
+
+	bind := func(contentType string, body string) (signup, error) {
+		request, _ := http.NewRequest("POST", "/signup", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+
+		var dst signup
+		err := fhttp.BindBody(&fhttp.Request{Req: request, Res: fhttp.NewResponseWriter(httptest.NewRecorder())}, &dst)
+		return dst, err
+	}
+
+	dst, err := bind("application/json", `{"email":"alex@example.com","age":30,"topics":["go"]}`)
+	if err != nil || dst.Email != "alex@example.com" || dst.Age != 30 || len(dst.Topics) != 1 {
+		fatalFailed(t, "Should have bound JSON body: %+v %v", dst, err)
+	}
+	logPassed(t, "Should have bound JSON body")
+
+	dst, err = bind("application/x-www-form-urlencoded", "email=sam%40example.com&age=25&topic=fs&topic=http")
+	if err != nil || dst.Email != "sam@example.com" || dst.Age != 25 || fmt.Sprint(dst.Topics) != "[fs http]" {
+		fatalFailed(t, "Should have bound form body: %+v %v", dst, err)
+	}
+	logPassed(t, "Should have bound form body")
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("email", "kim@example.com")
+	writer.WriteField("age", "40")
+	writer.Close()
+
+	dst, err = bind(writer.FormDataContentType(), body.String())
+	if err != nil || dst.Email != "kim@example.com" || dst.Age != 40 {
+		fatalFailed(t, "Should have bound multipart body: %+v %v", dst, err)
+	}
+	logPassed(t, "Should have bound multipart body")
+
+	if _, err := bind("text/plain", "email"); err != fhttp.ErrUnsupportedBody {
+		fatalFailed(t, "Should have failed binding unsupported body: %v", err)
+	}
+	logPassed(t, "Should have failed binding unsupported body")
+
+	limit := fhttp.MaxBindBodySize
+	fhttp.MaxBindBodySize = 8
+	defer func() { fhttp.MaxBindBodySize = limit }()
+
+	if _, err := bind("application/json", `{"email":"alex@example.com"}`); err == nil {
+		fatalFailed(t, "Should have failed binding body over size limit")
+	}
+	logPassed(t, "Should have failed binding body over size limit")
+}
+
+func TestXMLRendering(t *testing.T) {
+	type game struct {
+		XMLName xml.Name `xml:"game"`
+		Title   string   `xml:"title"`
+	}
+
+	request, _ := http.NewRequest("GET", "/games", nil)
+	request.Header.Set("Accept", "application/json;q=0.8, application/xml")
+
+	record := httptest.NewRecorder()
+	rw := &fhttp.Request{Req: request, Res: fhttp.NewResponseWriter(record)}
+	rw.Respond(http.StatusOK, game{Title: "final-fantasy"})
+
+	if ct := record.Header().Get("Content-Type"); ct != "application/xml" {
+		fatalFailed(t, "Should have responded with XML content type but got %q", ct)
+	}
+
+	if !strings.HasSuffix(record.Body.String(), "<game><title>final-fantasy</title></game>") {
+		fatalFailed(t, "Should have rendered XML body but got %q", record.Body.String())
+	}
+	logPassed(t, "Should have rendered XML for XML preferring client")
+
+	request.Header.Set("Accept", "text/xml;q=0.5, */*")
+	if fhttp.AcceptsXML(request) {
+		fatalFailed(t, "Should have preferred JSON for client accepting anything")
+	}
+	logPassed(t, "Should have preferred JSON for client accepting anything")
+
+	encoded, err := fhttp.XMLEncoder()(context.New(), nil, game{Title: "reckless"})
+	if err != nil {
+		fatalFailed(t, "Should have encoded XML: %s", err)
+	}
+
+	decoded, err := fhttp.XMLDecoder()(context.New(), nil, encoded)
+	if err != nil {
+		fatalFailed(t, "Should have decoded XML: %s", err)
+	}
+
+	node := decoded.(fhttp.XMLNode)
+	if node.XMLName.Local != "game" || len(node.Nodes) != 1 || node.Nodes[0].Content != "reckless" {
+		fatalFailed(t, "Should have decoded XML nodes: %+v", node)
+	}
+	logPassed(t, "Should have decoded XML nodes")
+}
+
+func TestCompress(t *testing.T) {
+	payload := strings.Repeat("final-fantasy ", 200)
+
+	drive := fhttp.Drive(fhttp.Compress(fhttp.CompressOptions{}))()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/games",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.RespondAny(http.StatusOK, "text/plain", []byte(payload))
+			return nil
+		},
+	})
+
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/small",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.RespondAny(http.StatusOK, "text/plain", []byte("small"))
+			return nil
+		},
+	})
+
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/page",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.Res.Write([]byte("<!DOCTYPE html><html><body>" + payload + "</body></html>"))
+			return nil
+		},
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/games", nil)
+	request.Header.Set("Accept-Encoding", "deflate;q=0.5, gzip")
+	drive.ServeHTTP(record, request)
+
+	if record.Header().Get("Content-Encoding") != "gzip" {
+		fatalFailed(t, "Should have compressed response with gzip: %+v", record.Header())
+	}
+
+	reader, err := gzip.NewReader(record.Body)
+	if err != nil {
+		fatalFailed(t, "Should have written gzip body: %s", err)
+	}
+
+	body, err := ioutil.ReadAll(reader)
+	if err != nil || string(body) != payload {
+		fatalFailed(t, "Should have written complete gzip body: %v", err)
+	}
+	logPassed(t, "Should have compressed response with gzip")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/small", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	drive.ServeHTTP(record, request)
+
+	if record.Header().Get("Content-Encoding") != "" || record.Body.String() != "small" {
+		fatalFailed(t, "Should have left small response uncompressed: %+v %q", record.Header(), record.Body.String())
+	}
+	logPassed(t, "Should have left small response uncompressed")
+
+	server := httptest.NewServer(drive)
+	defer server.Close()
+
+	request, _ = http.NewRequest("GET", server.URL+"/page", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+
+	res, err := http.DefaultTransport.RoundTrip(request)
+	if err != nil {
+		fatalFailed(t, "Should have requested page: %s", err)
+	}
+	res.Body.Close()
+
+	if res.Header.Get("Content-Encoding") != "gzip" || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		fatalFailed(t, "Should have set sniffed type of compressed response: %+v", res.Header)
+	}
+	logPassed(t, "Should have set sniffed type of compressed response")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/games", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Header().Get("Content-Encoding") != "" || record.Body.String() != payload {
+		fatalFailed(t, "Should have left response uncompressed without Accept-Encoding")
+	}
+	logPassed(t, "Should have left response uncompressed without Accept-Encoding")
+
+	stacked := fhttp.Drive(fhttp.ETag(false), fhttp.Compress(fhttp.CompressOptions{}))()
+	fhttp.Route(stacked)(fhttp.Endpoint{
+		Path:   "/games",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.RespondAny(http.StatusOK, "text/plain", []byte(payload))
+			return nil
+		},
+	})
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/games", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	stacked.ServeHTTP(record, request)
+
+	if record.Header().Get("Content-Encoding") != "gzip" || record.Header().Get("ETag") == "" {
+		fatalFailed(t, "Should have compressed response with ETag: %+v", record.Header())
+	}
+
+	reader, err = gzip.NewReader(record.Body)
+	if err != nil {
+		fatalFailed(t, "Should have written gzip body behind ETag: %s", err)
+	}
+
+	if body, err = ioutil.ReadAll(reader); err != nil || string(body) != payload {
+		fatalFailed(t, "Should have written complete gzip body behind ETag: %v", err)
+	}
+	logPassed(t, "Should have compressed response behind ETag")
+}
+
+func TestETag(t *testing.T) {
+	drive := fhttp.Drive(fhttp.ETag(false))()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/games",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.Respond(http.StatusOK, []string{"final-fantasy"})
+			return nil
+		},
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/games", nil)
+	drive.ServeHTTP(record, request)
+
+	etag := record.Header().Get("ETag")
+	if record.Code != http.StatusOK || etag == "" || record.Body.String() != `["final-fantasy"]` {
+		fatalFailed(t, "Should have responded with ETag: %d %q", record.Code, etag)
+	}
+	logPassed(t, "Should have responded with ETag")
+
+	record = httptest.NewRecorder()
+	request.Header.Set("If-None-Match", etag)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusNotModified || record.Body.Len() != 0 {
+		fatalFailed(t, "Should have responded with 304 for matching ETag: %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have responded with 304 for matching ETag")
+}
+
+func TestConditional(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fhttp-conditional")
+	if err != nil {
+		fatalFailed(t, "Should have created temp directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("console.log(1)"), 0600); err != nil {
+		fatalFailed(t, "Should have written file: %s", err)
+	}
+
+	var served int
+
+	drive := fhttp.Drive(fhttp.Conditional(fhttp.FileValidator(dir, "/static")))()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/static/app.js",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			served++
+			rw.RespondAny(http.StatusOK, "application/javascript", []byte("console.log(1)"))
+			return nil
+		},
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/static/app.js", nil)
+	drive.ServeHTTP(record, request)
+
+	modified := record.Header().Get("Last-Modified")
+	if record.Code != http.StatusOK || modified == "" || record.Header().Get("ETag") == "" {
+		fatalFailed(t, "Should have responded with validators: %d %+v", record.Code, record.Header())
+	}
+	logPassed(t, "Should have responded with validators")
+
+	record = httptest.NewRecorder()
+	request.Header.Set("If-Modified-Since", modified)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusNotModified || served != 1 {
+		fatalFailed(t, "Should have responded with 304 without running the action: %d %d", record.Code, served)
+	}
+	logPassed(t, "Should have responded with 304 without running the action")
+}
+
+func TestDirFileServerRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fhttp-range")
+	if err != nil {
+		fatalFailed(t, "Should have created temp directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "video.txt"), []byte("0123456789"), 0600); err != nil {
+		fatalFailed(t, "Should have written file: %s", err)
+	}
+
+	server := fhttp.DirFileServer(dir, "/static")
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/static/video.txt", nil)
+	request.Header.Set("Range", "bytes=2-5")
+
+	if _, err := server(context.New(), nil, &fhttp.Request{Req: request, Res: fhttp.NewResponseWriter(record)}); err != nil {
+		fatalFailed(t, "Should have served range of file: %s", err)
+	}
+
+	if record.Code != http.StatusPartialContent || record.Body.String() != "2345" {
+		fatalFailed(t, "Should have served partial content but got %d %q", record.Code, record.Body.String())
+	}
+
+	if record.Header().Get("Content-Range") != "bytes 2-5/10" || record.Header().Get("Last-Modified") == "" {
+		fatalFailed(t, "Should have set range headers: %+v", record.Header())
+	}
+	logPassed(t, "Should have served range of file")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("HEAD", "/static/video.txt", nil)
+
+	if _, err := server(context.New(), nil, &fhttp.Request{Req: request, Res: fhttp.NewResponseWriter(record)}); err != nil {
+		fatalFailed(t, "Should have served HEAD request: %s", err)
+	}
+
+	if record.Code != http.StatusOK || record.Body.Len() != 0 || record.Header().Get("Content-Length") != "10" {
+		fatalFailed(t, "Should have served headers without body but got %d %q %+v", record.Code, record.Body.String(), record.Header())
+	}
+	logPassed(t, "Should have served HEAD request")
+
+	request, _ = http.NewRequest("GET", "/static/", nil)
+	if _, err := server(context.New(), nil, &fhttp.Request{Req: request, Res: fhttp.NewResponseWriter(httptest.NewRecorder())}); err != fhttp.ErrIsDirectory {
+		fatalFailed(t, "Should have failed serving directory but got %v", err)
+	}
+	logPassed(t, "Should have failed serving directory")
+}
+
+func TestNewServer(t *testing.T) {
+	drive := fhttp.Drive()()
+
+	server, err := fhttp.NewServer(":0", drive, fhttp.ServerOptions{
+		ReadTimeout:  2 * time.Second,
+		WriteTimeout: 3 * time.Second,
+		IdleTimeout:  4 * time.Second,
+		DisableHTTP2: true,
+	})
+	if err != nil {
+		fatalFailed(t, "Should have created server: %s", err)
+	}
+
+	if server.ReadTimeout != 2*time.Second || server.WriteTimeout != 3*time.Second || server.IdleTimeout != 4*time.Second {
+		fatalFailed(t, "Should have configured server timeouts: %+v", server)
+	}
+	logPassed(t, "Should have configured server timeouts")
+
+	if server.TLSNextProto == nil || len(server.TLSNextProto) != 0 {
+		fatalFailed(t, "Should have disabled HTTP/2: %+v", server.TLSNextProto)
+	}
+	logPassed(t, "Should have disabled HTTP/2")
+}
+
+func TestCORSWith(t *testing.T) {
+	var served int
+
+	drive := fhttp.Drive()()
+	drive.CORS(fhttp.CORSOptions{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		ExposedHeaders:   []string{"X-Total"},
+		AllowCredentials: true,
+		MaxAge:           time.Minute,
+		Overrides: map[string]fhttp.CORSOptions{
+			"/public": {},
+		},
+	})
+
+	action := func(ctx context.Context, rw *fhttp.Request) error {
+		served++
+		rw.RespondAny(http.StatusOK, "text/plain", []byte("ok"))
+		return nil
+	}
+
+	fhttp.Route(drive)(fhttp.Endpoint{Path: "/api/items", Method: "GET", Action: action})
+	fhttp.Route(drive)(fhttp.Endpoint{Path: "/public/items", Method: "GET", Action: action})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/items", nil)
+	request.Header.Set("Origin", "https://app.example.com")
+	drive.ServeHTTP(record, request)
+
+	header := record.Header()
+	if header.Get("Access-Control-Allow-Origin") != "https://app.example.com" || header.Get("Access-Control-Allow-Credentials") != "true" || header.Get("Access-Control-Expose-Headers") != "X-Total" {
+		fatalFailed(t, "Should have allowed matching origin: %+v", header)
+	}
+	logPassed(t, "Should have allowed matching origin")
+
+	record = httptest.NewRecorder()
+	request.Header.Set("Origin", "https://evil.com")
+	drive.ServeHTTP(record, request)
+
+	if origin := record.Header().Get("Access-Control-Allow-Origin"); origin != "" || record.Code != http.StatusOK {
+		fatalFailed(t, "Should have left out headers for other origins but got %q %d", origin, record.Code)
+	}
+	logPassed(t, "Should have left out headers for other origins")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("OPTIONS", "/api/items", nil)
+	request.Header.Set("Origin", "https://app.example.com")
+	request.Header.Set("Access-Control-Request-Method", "PUT")
+	drive.ServeHTTP(record, request)
+
+	header = record.Header()
+	if record.Code != http.StatusNoContent || header.Get("Access-Control-Allow-Methods") == "" || header.Get("Access-Control-Max-Age") != "60" || served != 2 {
+		fatalFailed(t, "Should have responded to preflight request: %d %d %+v", record.Code, served, header)
+	}
+	logPassed(t, "Should have responded to preflight request")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/public/items", nil)
+	request.Header.Set("Origin", "https://evil.com")
+	drive.ServeHTTP(record, request)
+
+	if origin := record.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		fatalFailed(t, "Should have used route override but got %q", origin)
+	}
+	logPassed(t, "Should have used route override")
+}
+
+func TestSessions(t *testing.T) {
+	if _, err := fhttp.NewCookieStore([]byte("short")); err != fhttp.ErrWeakSecret {
+		fatalFailed(t, "Should have rejected weak cookie secret: %+v", err)
+	}
+	logPassed(t, "Should have rejected weak cookie secret")
+
+	cookieStore, err := fhttp.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		fatalFailed(t, "Should have created cookie store: %+v", err)
+	}
+
+	stores := map[string]fhttp.SessionStore{
+		"memory": fhttp.NewMemoryStore(),
+		"cookie": cookieStore,
+	}
+
+	for name, store := range stores {
+		drive := fhttp.Drive(fhttp.Sessions(store, fhttp.SessionOptions{MaxAge: time.Hour}))()
+		fhttp.Route(drive)(fhttp.Endpoint{
+			Path:   "/login",
+			Method: "GET",
+			Action: func(ctx context.Context, rw *fhttp.Request) error {
+				rw.Session(ctx).Set("user", "bob")
+				rw.RespondAny(http.StatusOK, "text/plain", []byte("ok"))
+				return nil
+			},
+		})
+		fhttp.Route(drive)(fhttp.Endpoint{
+			Path:   "/user",
+			Method: "GET",
+			Action: func(ctx context.Context, rw *fhttp.Request) error {
+				user, _ := rw.Session(ctx).Get("user")
+				rw.RespondAny(http.StatusOK, "text/plain", []byte(fmt.Sprint(user)))
+				return nil
+			},
+		})
+		fhttp.Route(drive)(fhttp.Endpoint{
+			Path:   "/stream",
+			Method: "GET",
+			Action: func(ctx context.Context, rw *fhttp.Request) error {
+				rw.Session(ctx).Set("user", "ann")
+				rw.Res.Flush()
+				rw.Res.Write([]byte("streamed"))
+				return nil
+			},
+		})
+		fhttp.Route(drive)(fhttp.Endpoint{
+			Path:   "/logout",
+			Method: "GET",
+			Action: func(ctx context.Context, rw *fhttp.Request) error {
+				rw.Session(ctx).Destroy()
+				return nil
+			},
+		})
+
+		record := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/login", nil)
+		drive.ServeHTTP(record, request)
+
+		cookies := record.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != "session" || !cookies[0].HttpOnly {
+			fatalFailed(t, "Should have set session cookie with %s store: %+v", name, cookies)
+		}
+		logPassed(t, "Should have set session cookie with %s store", name)
+
+		record = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/stream", nil)
+		drive.ServeHTTP(record, request)
+
+		if streamed := record.Result().Cookies(); len(streamed) != 1 || record.Body.String() != "streamed" {
+			fatalFailed(t, "Should have set session cookie before flushing with %s store: %+v", name, streamed)
+		}
+		logPassed(t, "Should have set session cookie before flushing with %s store", name)
+
+		record = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/user", nil)
+		request.AddCookie(cookies[0])
+		drive.ServeHTTP(record, request)
+
+		if body := record.Body.String(); body != "bob" || len(record.Result().Cookies()) != 0 {
+			fatalFailed(t, "Should have loaded session with %s store but got %q", name, body)
+		}
+		logPassed(t, "Should have loaded session with %s store", name)
+
+		record = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/user", nil)
+		request.AddCookie(&http.Cookie{Name: "session", Value: cookies[0].Value + "x"})
+		drive.ServeHTTP(record, request)
+
+		if body := record.Body.String(); body != "<nil>" {
+			fatalFailed(t, "Should have ignored tampered cookie with %s store but got %q", name, body)
+		}
+		logPassed(t, "Should have ignored tampered cookie with %s store", name)
+
+		record = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/logout", nil)
+		request.AddCookie(cookies[0])
+		drive.ServeHTTP(record, request)
+
+		if removed := record.Result().Cookies(); len(removed) != 1 || removed[0].MaxAge >= 0 {
+			fatalFailed(t, "Should have removed session cookie with %s store: %+v", name, removed)
+		}
+		logPassed(t, "Should have removed session cookie with %s store", name)
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	introspect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("token") == "remote-key" {
+			fmt.Fprint(w, `{"active":true,"sub":"svc","scope":"read write"}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"active":false}`)
+	}))
+	defer introspect.Close()
+
+	validators := map[string]fhttp.APIKeyValidator{
+		"secret":     fhttp.StaticKeys(map[string]fhttp.Identity{"secret": {Subject: "svc", Scopes: []string{"read"}}}),
+		"remote-key": fhttp.IntrospectionValidator(introspect.URL, nil),
+	}
+
+	for key, validator := range validators {
+		var served int
+
+		drive := fhttp.Drive(fhttp.APIKey(validator, fhttp.APIKeyOptions{Query: "api_key"}))()
+		fhttp.Route(drive)(fhttp.Endpoint{
+			Path:   "/data",
+			Method: "GET",
+			Action: func(ctx context.Context, rw *fhttp.Request) error {
+				served++
+				identity, _ := rw.Identity(ctx)
+				rw.RespondAny(http.StatusOK, "text/plain", []byte(fmt.Sprintf("%s:%t", identity.Subject, identity.HasScope("read"))))
+				return nil
+			},
+		})
+
+		record := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/data", nil)
+		request.Header.Set("X-API-Key", key)
+		drive.ServeHTTP(record, request)
+
+		if record.Code != http.StatusOK || record.Body.String() != "svc:true" {
+			fatalFailed(t, "Should have attached identity for %q: %d %q", key, record.Code, record.Body.String())
+		}
+		logPassed(t, "Should have attached identity for %q", key)
+
+		record = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/data?api_key="+key, nil)
+		drive.ServeHTTP(record, request)
+
+		if record.Code != http.StatusOK {
+			fatalFailed(t, "Should have accepted key from query for %q: %d", key, record.Code)
+		}
+		logPassed(t, "Should have accepted key from query for %q", key)
+
+		for _, bad := range []string{"", "wrong"} {
+			record = httptest.NewRecorder()
+			request, _ = http.NewRequest("GET", "/data", nil)
+			request.Header.Set("X-API-Key", bad)
+			drive.ServeHTTP(record, request)
+
+			if record.Code != http.StatusUnauthorized || served != 2 {
+				fatalFailed(t, "Should have rejected key %q: %d %d", bad, record.Code, served)
+			}
+		}
+		logPassed(t, "Should have rejected missing and invalid keys")
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	var outbound string
+
+	drive := fhttp.Drive(fhttp.RequestID())(fhttp.MW(fhttp.ResponseLogger(&logs)))
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/items",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			out, _ := http.NewRequest("GET", "http://example.com", nil)
+			fhttp.PropagateRequestID(ctx, out)
+			outbound = out.Header.Get(fhttp.RequestIDHeader)
+
+			rw.RespondAny(http.StatusOK, "text/plain", []byte(rw.RequestID(ctx)))
+			return nil
+		},
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/items", nil)
+	request.Header.Set(fhttp.RequestIDHeader, "abc-123")
+	drive.ServeHTTP(record, request)
+
+	if record.Header().Get(fhttp.RequestIDHeader) != "abc-123" || record.Body.String() != "abc-123" || outbound != "abc-123" {
+		fatalFailed(t, "Should have propagated incoming request id: %q %q %q", record.Header().Get(fhttp.RequestIDHeader), record.Body.String(), outbound)
+	}
+	logPassed(t, "Should have propagated incoming request id")
+
+	if !strings.Contains(logs.String(), "RequestID{abc-123}") {
+		fatalFailed(t, "Should have logged request id: %q", logs.String())
+	}
+	logPassed(t, "Should have logged request id")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/items", nil)
+	request.Header.Set(fhttp.RequestIDHeader, "bad id\n")
+	drive.ServeHTTP(record, request)
+
+	if id := record.Header().Get(fhttp.RequestIDHeader); len(id) != 32 || id != record.Body.String() {
+		fatalFailed(t, "Should have generated request id but got %q", id)
+	}
+	logPassed(t, "Should have generated request id")
+}
+
+func TestTimeout(t *testing.T) {
+	var aborted error
+
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:    "/slow",
+		Method:  "GET",
+		LocalMW: fhttp.Timeout(20 * time.Millisecond),
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			select {
+			case <-fractals.StdContext(ctx).Done():
+				aborted = fractals.ContextErr(ctx)
+			case <-time.After(time.Second):
+			}
+
+			rw.RespondAny(http.StatusOK, "text/plain", []byte("late"))
+			return nil
+		},
+	})
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:    "/fast",
+		Method:  "GET",
+		LocalMW: fhttp.TimeoutWithStatus(time.Second, http.StatusGatewayTimeout),
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.RespondAny(http.StatusOK, "text/plain", []byte("fast"))
+			return nil
+		},
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/slow", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusServiceUnavailable || strings.Contains(record.Body.String(), "late") || aborted == nil {
+		fatalFailed(t, "Should have timed out request: %d %q %v", record.Code, record.Body.String(), aborted)
+	}
+	logPassed(t, "Should have timed out request")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/fast", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusOK || record.Body.String() != "fast" {
+		fatalFailed(t, "Should have served request in time: %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have served request in time")
+}
+
+func TestRateLimiter(t *testing.T) {
+	keys := fhttp.StaticKeys(map[string]fhttp.Identity{"a": {Subject: "a"}, "b": {Subject: "b"}})
+
+	drive := fhttp.Drive(fhttp.APIKey(keys, fhttp.APIKeyOptions{}), fhttp.RateLimiter(fhttp.RateLimitOptions{
+		Limit: fhttp.RateLimit{Requests: 2, Per: time.Minute},
+		Key:   fhttp.RateLimitByIdentity(),
+	}))()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/items",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.RespondAny(http.StatusOK, "text/plain", []byte("ok"))
+			return nil
+		},
+	})
+
+	serve := func(key string) *httptest.ResponseRecorder {
+		record := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", "/items", nil)
+		request.Header.Set("X-API-Key", key)
+		drive.ServeHTTP(record, request)
+		return record
+	}
+
+	for i := 0; i < 2; i++ {
+		if record := serve("a"); record.Code != http.StatusOK || record.Header().Get("X-RateLimit-Remaining") != fmt.Sprint(1-i) {
+			fatalFailed(t, "Should have allowed request %d: %d %+v", i, record.Code, record.Header())
+		}
+	}
+	logPassed(t, "Should have allowed requests within limit")
+
+	record := serve("a")
+	if record.Code != http.StatusTooManyRequests || record.Header().Get("Retry-After") != "30" || record.Body.String() == "ok" {
+		fatalFailed(t, "Should have limited request: %d %+v", record.Code, record.Header())
+	}
+	logPassed(t, "Should have limited request")
+
+	if record := serve("b"); record.Code != http.StatusOK {
+		fatalFailed(t, "Should have limited keys separately: %d", record.Code)
+	}
+	logPassed(t, "Should have limited keys separately")
+
+	for _, limit := range []fhttp.RateLimit{{Requests: 2}, {Per: time.Minute}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					fatalFailed(t, "Should have rejected limit %+v", limit)
+				}
+			}()
+
+			fhttp.RateLimiter(fhttp.RateLimitOptions{Limit: limit})
+		}()
+	}
+	logPassed(t, "Should have rejected non-positive limits")
+}
+
+func TestProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s?%s %s %s %s", r.URL.Path, r.URL.RawQuery, r.Header.Get("X-Backend"), r.Header.Get("X-Forwarded-Host"), r.Header.Get("X-Forwarded-For"))
+	}))
+	defer upstream.Close()
+
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/api/*path",
+		Method: "GET",
+		Action: fhttp.Proxy(upstream.URL+"/v1", fhttp.ProxyOptions{
+			StripPrefix: "/api",
+			Headers:     map[string]string{"X-Backend": "users"},
+		}),
+	})
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/down/*path",
+		Method: "GET",
+		Action: fhttp.Proxy("http://127.0.0.1:1", fhttp.ProxyOptions{}),
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/users?page=2", nil)
+	request.Host = "app.example.com"
+	request.RemoteAddr = "10.0.0.1:1234"
+	drive.ServeHTTP(record, request)
+
+	if body := record.Body.String(); body != "/v1/users?page=2 users app.example.com 10.0.0.1" {
+		fatalFailed(t, "Should have proxied request upstream but got %d %q", record.Code, body)
+	}
+	logPassed(t, "Should have proxied request upstream")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/down/users", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusBadGateway {
+		fatalFailed(t, "Should have responded with 502 for unreachable upstream but got %d", record.Code)
+	}
+	logPassed(t, "Should have responded with 502 for unreachable upstream")
+}
+
+func TestHealth(t *testing.T) {
+	drive := fhttp.Drive()()
+	drive.Health(
+		fhttp.HealthCheck{Name: "self", Liveness: true, Check: func(ctx stdcontext.Context) error {
+			return nil
+		}},
+		fhttp.HealthCheck{Name: "db", Timeout: 10 * time.Millisecond, Check: func(ctx stdcontext.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/healthz", nil)
+	drive.ServeHTTP(record, request)
+
+	var status fhttp.HealthStatus
+	if err := json.Unmarshal(record.Body.Bytes(), &status); err != nil || record.Code != http.StatusOK || status.Status != "ok" || len(status.Checks) != 1 {
+		fatalFailed(t, "Should have reported healthy: %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have reported healthy")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/readyz", nil)
+	drive.ServeHTTP(record, request)
+
+	status = fhttp.HealthStatus{}
+	if err := json.Unmarshal(record.Body.Bytes(), &status); err != nil || record.Code != http.StatusServiceUnavailable || status.Checks["db"].Status != "fail" || status.Checks["self"].Status != "ok" {
+		fatalFailed(t, "Should have reported timed out check: %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have reported timed out check")
+}
+
+func TestRoutes(t *testing.T) {
+	drive := fhttp.Drive(fhttp.RequestID())()
+
+	action := func(ctx context.Context, rw *fhttp.Request) error {
+		return nil
+	}
+
+	fhttp.Route(drive)(fhttp.Endpoint{Path: "/users", Method: "POST", Action: action, LocalMW: fhttp.BindBodyMW("user", struct{}{})})
+	fhttp.Route(drive)(fhttp.Endpoint{Path: "/users", Method: "GET", Action: action})
+	drive.Group("/admin", fhttp.Timeout(time.Second)).Handle(fhttp.Endpoint{Path: "/stats", Method: "GET", Action: action})
+
+	routes := drive.Routes()
+	if len(routes) != 3 {
+		fatalFailed(t, "Should have listed 3 routes but got %+v", routes)
+	}
+
+	if routes[0].Path != "/admin/stats" || routes[1].Method != "GET" || routes[2].Method != "POST" {
+		fatalFailed(t, "Should have sorted routes by path and method: %+v", routes)
+	}
+	logPassed(t, "Should have listed sorted routes")
+
+	if len(routes[0].Middleware) != 2 || !strings.HasPrefix(routes[0].Middleware[0], "fhttp.RequestID") || !strings.HasPrefix(routes[0].Middleware[1], "fhttp.TimeoutWithStatus") {
+		fatalFailed(t, "Should have listed global and group middleware: %+v", routes[0].Middleware)
+	}
+
+	if len(routes[2].Middleware) != 2 || !strings.HasPrefix(routes[2].Middleware[1], "fhttp.bindMW") || !strings.HasPrefix(routes[2].Action, "fhttp_test.TestRoutes") {
+		fatalFailed(t, "Should have listed local middleware and action: %+v", routes[2])
+	}
+	logPassed(t, "Should have listed middleware chains")
+}
+
+func TestURL(t *testing.T) {
+	drive := fhttp.Drive()()
+
+	action := func(ctx context.Context, rw *fhttp.Request) error {
+		return nil
+	}
+
+	fhttp.Route(drive)(fhttp.Endpoint{Name: "user", Path: "/users/:id", Method: "GET", Action: action})
+	drive.Group("/files").Handle(fhttp.Endpoint{Name: "file", Path: "/*path", Method: "GET", Action: action})
+
+	if err := fhttp.Route(drive)(fhttp.Endpoint{Name: "user", Path: "/people/:id", Method: "GET", Action: action}); err == nil {
+		fatalFailed(t, "Should have rejected duplicate route name")
+	}
+	logPassed(t, "Should have rejected duplicate route name")
+
+	path, err := drive.URL("user", map[string]string{"id": "a b", "tab": "posts"})
+	if err != nil || path != "/users/a%20b?tab=posts" {
+		fatalFailed(t, "Should have built user URL but got %q: %v", path, err)
+	}
+	logPassed(t, "Should have built user URL")
+
+	path, err = drive.URL("file", map[string]string{"path": "docs/read me.txt"})
+	if err != nil || path != "/files/docs/read%20me.txt" {
+		fatalFailed(t, "Should have built catch all URL but got %q: %v", path, err)
+	}
+	logPassed(t, "Should have built catch all URL")
+
+	if _, err := drive.URL("user", nil); err == nil {
+		fatalFailed(t, "Should have failed on missing param")
+	}
+	logPassed(t, "Should have failed on missing param")
+
+	if _, err := drive.URL("unknown", nil); err == nil {
+		fatalFailed(t, "Should have failed on unknown route")
+	}
+	logPassed(t, "Should have failed on unknown route")
+
+	if routes := drive.Routes(); routes[0].Name != "file" {
+		fatalFailed(t, "Should have listed route names: %+v", routes)
+	}
+	logPassed(t, "Should have listed route names")
+}
+
+func TestDriveWith(t *testing.T) {
+	drive := fhttp.DriveWith(fhttp.RouterOptions{
+		TrailingSlash:   fhttp.TrailingSlashStrict,
+		CaseInsensitive: true,
+	})()
+
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/Users/:name",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.RespondAny(http.StatusOK, "text/plain", []byte(rw.Params["name"]))
+			return nil
+		},
+	})
+	fhttp.Route(drive)(fhttp.Endpoint{Path: "/old/:name", Method: "GET", Action: fhttp.RedirectTo("/users/:name", http.StatusFound)})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/USERS/Bob", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusOK || record.Body.String() != "Bob" {
+		fatalFailed(t, "Should have matched route regardless of case: %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have matched route regardless of case")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/users/bob/", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusNotFound {
+		fatalFailed(t, "Should have rejected trailing slash but got %d", record.Code)
+	}
+	logPassed(t, "Should have rejected trailing slash")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/old/a%20b", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusFound || record.Header().Get("Location") != "/users/a%20b" {
+		fatalFailed(t, "Should have redirected with params: %d %+v", record.Code, record.Header())
+	}
+	logPassed(t, "Should have redirected with params")
+
+	secure := fhttp.DriveWith(fhttp.RouterOptions{
+		CanonicalHost:  "example.com",
+		RedirectHTTPS:  true,
+		RedirectStatus: http.StatusPermanentRedirect,
+	})()
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "http://www.example.com/users?page=2", nil)
+	secure.ServeHTTP(record, request)
+
+	if record.Code != http.StatusPermanentRedirect || record.Header().Get("Location") != "https://example.com/users?page=2" {
+		fatalFailed(t, "Should have redirected to canonical URL: %d %+v", record.Code, record.Header())
+	}
+	logPassed(t, "Should have redirected to canonical URL")
+}
+
+func TestSerializers(t *testing.T) {
+	fhttp.RegisterSerializer("application/x-csv", fhttp.SerializerFunc(func(data interface{}) ([]byte, error) {
+		return []byte(strings.Join(data.([]string), ",")), nil
+	}))
+
+	request, _ := http.NewRequest("GET", "/games", nil)
+	request.Header.Set("Accept", "application/json;q=0.5, application/x-csv")
+
+	if ct := fhttp.Negotiate(request); ct != "application/x-csv" {
+		fatalFailed(t, "Should have negotiated registered content type but got %q", ct)
+	}
+	logPassed(t, "Should have negotiated registered content type")
+
+	record := httptest.NewRecorder()
+	rw := &fhttp.Request{Req: request, Res: fhttp.NewResponseWriter(record)}
+	rw.Respond(http.StatusOK, []string{"mario", "zelda"})
+
+	if record.Header().Get("Content-Type") != "application/x-csv" || record.Body.String() != "mario,zelda" {
+		fatalFailed(t, "Should have responded with registered serializer: %+v %q", record.Header(), record.Body.String())
+	}
+	logPassed(t, "Should have responded with registered serializer")
+
+	request.Header.Set("Accept", "image/png")
+	if ct := fhttp.Negotiate(request); ct != "application/json" {
+		fatalFailed(t, "Should have defaulted to JSON but got %q", ct)
+	}
+	logPassed(t, "Should have defaulted to JSON")
+}
+
+func TestStream(t *testing.T) {
+	release := make(chan struct{})
+	gone := make(chan error, 1)
+
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/export",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			return rw.StreamWith(fhttp.StreamOptions{ContentType: "text/plain"}, func(w io.Writer, flush func()) error {
+				io.WriteString(w, "first\n")
+				flush()
+
+				<-release
+				io.WriteString(w, "second\n")
+				return nil
+			})
+		},
+	})
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/tail",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			return rw.StreamWith(fhttp.StreamOptions{FlushInterval: time.Millisecond}, func(w io.Writer, flush func()) error {
+				for {
+					if _, err := io.WriteString(w, "line\n"); err != nil {
+						gone <- err
+						return err
+					}
+
+					time.Sleep(time.Millisecond)
+				}
+			})
+		},
+	})
+
+	server := httptest.NewServer(drive)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/export")
+	if err != nil {
+		fatalFailed(t, "Should have made request: %s", err)
+	}
+	defer res.Body.Close()
+
+	reader := bufio.NewReader(res.Body)
+	if line, _ := reader.ReadString('\n'); line != "first\n" || res.Header.Get("Content-Type") != "text/plain" {
+		fatalFailed(t, "Should have flushed first line before handler finished: %q %+v", line, res.Header)
+	}
+	logPassed(t, "Should have flushed first line before handler finished")
+
+	close(release)
+
+	if line, _ := reader.ReadString('\n'); line != "second\n" {
+		fatalFailed(t, "Should have streamed second line but got %q", line)
+	}
+	logPassed(t, "Should have streamed second line")
+
+	tail, err := http.Get(server.URL + "/tail")
+	if err != nil {
+		fatalFailed(t, "Should have made request: %s", err)
+	}
+
+	if line, _ := bufio.NewReader(tail.Body).ReadString('\n'); line != "line\n" {
+		fatalFailed(t, "Should have flushed periodically but got %q", line)
+	}
+	tail.Body.Close()
+
+	select {
+	case err := <-gone:
+		if err != fhttp.ErrClientGone {
+			fatalFailed(t, "Should have failed with ErrClientGone but got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		fatalFailed(t, "Should have detected client disconnect")
+	}
+	logPassed(t, "Should have detected client disconnect")
+}
+
+func TestMultipartUpload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fhttp-upload")
+	if err != nil {
+		fatalFailed(t, "Should have created temp directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/upload",
+		Method: "POST",
+		Action: fhttp.MultipartUpload(fhttp.UploadOptions{
+			MaxFileSize: 10,
+			Checksum:    fs.SHA256,
+			Pipeline: func(part *fhttp.UploadPart) fractals.Handler {
+				return fs.WriteFileAtomic(filepath.Join(dir, part.Filename), fs.WriteOptions{})
+			},
+		}),
+	})
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("album", "holiday")
+
+	small, _ := writer.CreateFormFile("photos", "small.txt")
+	io.WriteString(small, "tiny")
+
+	large, _ := writer.CreateFormFile("photos", "large.txt")
+	io.WriteString(large, strings.Repeat("x", 64))
+	writer.Close()
+
+	request, _ := http.NewRequest("POST", "/upload", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	record := httptest.NewRecorder()
+	drive.ServeHTTP(record, request)
+
+	var result fhttp.UploadResult
+	if err := json.Unmarshal(record.Body.Bytes(), &result); err != nil || len(result.Files) != 2 {
+		fatalFailed(t, "Should have responded with per file results: %q %v", record.Body.String(), err)
+	}
+
+	if record.Code != http.StatusBadRequest || result.Fields["album"] != "holiday" {
+		fatalFailed(t, "Should have responded with failure status and fields: %d %+v", record.Code, result)
+	}
+	logPassed(t, "Should have responded with per file results")
+
+	if first := result.Files[0]; first.Error != "" || first.Size != 4 || first.Checksum != "8950abfda7b727630760dd35bcf5c3daa7631aff223a90f7728c0d2521dde10c" {
+		fatalFailed(t, "Should have streamed small file with checksum: %+v", first)
+	}
+
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "small.txt")); string(data) != "tiny" {
+		fatalFailed(t, "Should have written small file but got %q", data)
+	}
+	logPassed(t, "Should have streamed small file with checksum")
+
+	if second := result.Files[1]; second.Error != fhttp.ErrUploadTooLarge.Error() {
+		fatalFailed(t, "Should have rejected large file: %+v", second)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "large.txt")); !os.IsNotExist(err) {
+		fatalFailed(t, "Should have left no partial large file")
+	}
+	logPassed(t, "Should have rejected large file")
+
+	var failures []error
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/failing",
+		Method: "POST",
+		Action: fhttp.MultipartUpload(fhttp.UploadOptions{
+			MaxFields:     2,
+			MaxFieldsSize: 8,
+			OnError: func(part *fhttp.UploadPart, err error) {
+				failures = append(failures, err)
+			},
+			Pipeline: func(part *fhttp.UploadPart) fractals.Handler {
+				return fractals.MustWrap(func(interface{}) (interface{}, error) {
+					return nil, errors.New("open /srv/uploads: permission denied")
+				})
+			},
+		}),
+	})
+
+	upload := func(fields ...string) (*httptest.ResponseRecorder, fhttp.UploadResult) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		for _, value := range fields {
+			writer.WriteField("note", value)
+		}
+
+		file, _ := writer.CreateFormFile("photos", "photo.txt")
+		io.WriteString(file, "data")
+		writer.Close()
+
+		request, _ := http.NewRequest("POST", "/failing", &body)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+
+		record := httptest.NewRecorder()
+		drive.ServeHTTP(record, request)
+
+		var result fhttp.UploadResult
+		json.Unmarshal(record.Body.Bytes(), &result)
+		return record, result
+	}
+
+	record, result = upload("a")
+	if len(result.Files) != 1 || result.Files[0].Error != fhttp.ErrUploadFailed.Error() || strings.Contains(record.Body.String(), "/srv/uploads") {
+		fatalFailed(t, "Should have hidden pipeline error: %q", record.Body.String())
+	}
+
+	if len(failures) != 1 || failures[0].Error() != "open /srv/uploads: permission denied" {
+		fatalFailed(t, "Should have passed pipeline error to hook: %+v", failures)
+	}
+	logPassed(t, "Should have hidden pipeline error behind hook")
+
+	if record, _ = upload("a", "b", "c"); record.Code != http.StatusRequestEntityTooLarge || !strings.Contains(record.Body.String(), fhttp.ErrTooManyFields.Error()) {
+		fatalFailed(t, "Should have limited field count: %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have limited field count")
+
+	if record, _ = upload("12345", "67890"); record.Code != http.StatusRequestEntityTooLarge || !strings.Contains(record.Body.String(), fhttp.ErrFieldsTooLarge.Error()) {
+		fatalFailed(t, "Should have limited total field size: %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have limited total field size")
+}
+
+func TestMethodOverride(t *testing.T) {
+	drive := fhttp.DriveWith(fhttp.RouterOptions{MethodOverride: []string{"DELETE"}})()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/items/:id",
+		Method: "DELETE",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.RespondAny(http.StatusOK, "text/plain", []byte("deleted "+rw.Params["id"]))
+			return nil
+		},
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/items/4", nil)
+	request.Header.Set(fhttp.MethodOverrideHeader, "delete")
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusOK || record.Body.String() != "deleted 4" {
+		fatalFailed(t, "Should have overridden method with header: %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have overridden method with header")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/items/5", strings.NewReader("_method=DELETE"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	fhttp.MethodOverride("DELETE")(drive).ServeHTTP(record, request)
+
+	if record.Code != http.StatusOK || record.Body.String() != "deleted 5" {
+		fatalFailed(t, "Should have overridden method with form field: %d %q", record.Code, record.Body.String())
+	}
+	logPassed(t, "Should have overridden method with form field")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/items/6", nil)
+	request.Header.Set(fhttp.MethodOverrideHeader, "CONNECT")
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusBadRequest {
+		fatalFailed(t, "Should have rejected disallowed override but got %d", record.Code)
+	}
+	logPassed(t, "Should have rejected disallowed override")
+}
+
+func TestVersion(t *testing.T) {
+	drive := fhttp.Drive()()
+
+	api := []fhttp.Endpoint{{
+		Name:   "user",
+		Path:   "/users/:id",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.RespondAny(http.StatusOK, "text/plain", []byte(rw.APIVersion(ctx)+":"+rw.Params["id"]))
+			return nil
+		},
+	}}
+
+	sunset := time.Now().Add(-time.Hour)
+
+	if err := drive.Version("v1", fhttp.VersionOptions{Deprecated: true, Sunset: sunset, Link: "https://example.com/migrate", RejectAfterSunset: true}).HandleAll(api...); err != nil {
+		fatalFailed(t, "Should have mounted v1: %s", err)
+	}
+
+	if err := drive.Version("v2", fhttp.VersionOptions{}).HandleAll(api...); err != nil {
+		fatalFailed(t, "Should have mounted v2: %s", err)
+	}
+	logPassed(t, "Should have mounted same endpoints for several versions")
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/v2/users/7", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusOK || record.Body.String() != "v2:7" || record.Header().Get("Deprecation") != "" {
+		fatalFailed(t, "Should have served current version: %d %q %+v", record.Code, record.Body.String(), record.Header())
+	}
+	logPassed(t, "Should have served current version")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/v1/users/7", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusGone || record.Header().Get("Deprecation") != "true" || record.Header().Get("Sunset") != sunset.UTC().Format(http.TimeFormat) {
+		fatalFailed(t, "Should have retired sunset version: %d %+v", record.Code, record.Header())
+	}
+
+	if record.Header().Get("Link") != `<https://example.com/migrate>; rel="deprecation"` {
+		fatalFailed(t, "Should have linked deprecation docs: %+v", record.Header())
+	}
+	logPassed(t, "Should have retired sunset version")
+
+	if path, err := drive.URL("v1.user", map[string]string{"id": "3"}); err != nil || path != "/v1/users/3" {
+		fatalFailed(t, "Should have namespaced route names: %q %v", path, err)
+	}
+	logPassed(t, "Should have namespaced route names")
+}
+
+func TestOpenAPI(t *testing.T) {
+	type user struct {
+		ID      int       `json:"id"`
+		Name    string    `json:"name"`
+		Email   string    `json:"email,omitempty"`
+		Created time.Time `json:"created"`
+		Friends []*user   `json:"friends,omitempty"`
+	}
+
+	drive := fhttp.Drive()()
+
+	action := func(ctx context.Context, rw *fhttp.Request) error {
+		return nil
+	}
+
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Name:   "user",
+		Path:   "/users/:id",
+		Method: "GET",
+		Action: action,
+		Doc: &fhttp.EndpointDoc{
+			Summary:  "Get a user",
+			Response: user{},
+			Params:   []fhttp.ParamDoc{{Name: "fields", Description: "Fields to include"}},
+		},
+	})
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/users",
+		Method: "POST",
+		Action: action,
+		Doc:    &fhttp.EndpointDoc{Request: &user{}, Response: user{}, Status: http.StatusCreated},
+	})
+
+	if err := drive.ServeOpenAPI("/openapi.json", "/docs", fhttp.OpenAPIInfo{Title: "Users", Version: "1.0"}); err != nil {
+		fatalFailed(t, "Should have registered OpenAPI endpoints: %s", err)
+	}
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/openapi.json", nil)
+	drive.ServeHTTP(record, request)
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID string                     `json:"operationId"`
+			Summary     string                     `json:"summary"`
+			Parameters  []map[string]interface{}   `json:"parameters"`
+			Responses   map[string]json.RawMessage `json:"responses"`
+			RequestBody json.RawMessage            `json:"requestBody"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+				Required   []string               `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(record.Body.Bytes(), &doc); err != nil {
+		fatalFailed(t, "Should have served OpenAPI document: %s", err)
+	}
+
+	get := doc.Paths["/users/{id}"]["get"]
+	if get.OperationID != "user" || get.Summary != "Get a user" || len(get.Parameters) != 2 || get.Parameters[0]["in"] != "path" || get.Parameters[1]["in"] != "query" {
+		fatalFailed(t, "Should have documented operation and params: %+v", get)
+	}
+	logPassed(t, "Should have documented operation and params")
+
+	post := doc.Paths["/users"]["post"]
+	if _, ok := post.Responses["201"]; !ok || len(post.RequestBody) == 0 {
+		fatalFailed(t, "Should have documented request body and status: %+v", post)
+	}
+
+	schema := doc.Components.Schemas["user"]
+	if len(schema.Properties) != 5 || len(schema.Required) != 3 {
+		fatalFailed(t, "Should have generated schema from type: %+v", schema)
+	}
+	logPassed(t, "Should have generated schemas from types")
+
+	record = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/docs", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusOK || !strings.Contains(record.Body.String(), `"/openapi.json"`) {
+		fatalFailed(t, "Should have served UI page: %d", record.Code)
+	}
+	logPassed(t, "Should have served UI page")
+}
+
+func TestResponseCache(t *testing.T) {
+	var calls int32
+
+	drive := fhttp.Drive(fhttp.ResponseCache(fhttp.CacheOptions{
+		TTL:                  50 * time.Millisecond,
+		StaleWhileRevalidate: time.Minute,
+		Vary:                 []string{"Accept"},
+	}))()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/items",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			count := atomic.AddInt32(&calls, 1)
+			rw.Res.Header().Set("X-Count", strconv.Itoa(int(count)))
+			rw.RespondAny(http.StatusOK, "text/plain", []byte("items "+strconv.Itoa(int(count))))
+			return nil
+		},
+	})
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/private",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			atomic.AddInt32(&calls, 1)
+			rw.Res.Header().Set("Cache-Control", "private")
+			rw.RespondAny(http.StatusOK, "text/plain", []byte("private"))
+			return nil
+		},
+	})
+
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/account",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			atomic.AddInt32(&calls, 1)
+			rw.RespondAny(http.StatusOK, "text/plain", []byte(rw.Req.Header.Get("Authorization")))
+			return nil
+		},
+	})
+
+	send := func(path string, host string, header http.Header) *httptest.ResponseRecorder {
+		record := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", path, nil)
+		request.Host = host
+		request.Header = header
+		drive.ServeHTTP(record, request)
+		return record
+	}
+
+	get := func(path string, accept string) *httptest.ResponseRecorder {
+		return send(path, "", http.Header{"Accept": {accept}})
+	}
+
+	if record := get("/items", "text/plain"); record.Header().Get("X-Cache") != "MISS" || record.Body.String() != "items 1" {
+		fatalFailed(t, "Should have missed cache: %+v %q", record.Header(), record.Body.String())
+	}
+
+	if record := get("/items", "text/plain"); record.Header().Get("X-Cache") != "HIT" || record.Body.String() != "items 1" || record.Header().Get("X-Count") != "1" {
+		fatalFailed(t, "Should have served from cache: %+v %q", record.Header(), record.Body.String())
+	}
+	logPassed(t, "Should have served from cache")
+
+	if record := get("/items", "application/json"); record.Header().Get("X-Cache") != "MISS" || record.Body.String() != "items 2" {
+		fatalFailed(t, "Should have varied cache by Accept: %+v %q", record.Header(), record.Body.String())
+	}
+	logPassed(t, "Should have varied cache by Accept")
+
+	time.Sleep(60 * time.Millisecond)
+
+	if record := get("/items", "text/plain"); record.Header().Get("X-Cache") != "STALE" || record.Body.String() != "items 1" {
+		fatalFailed(t, "Should have served stale response: %+v %q", record.Header(), record.Body.String())
+	}
+
+	if record := get("/items", "text/plain"); record.Header().Get("X-Cache") != "HIT" || record.Body.String() != "items 3" {
+		fatalFailed(t, "Should have refreshed stale response: %+v %q", record.Header(), record.Body.String())
+	}
+	logPassed(t, "Should have refreshed stale response")
+
+	get("/private", "")
+	if record := get("/private", ""); record.Header().Get("X-Cache") != "MISS" {
+		fatalFailed(t, "Should not have cached private response: %+v", record.Header())
+	}
+	logPassed(t, "Should not have cached private response")
+
+	if record := send("/items", "other.example", http.Header{"Accept": {"text/plain"}}); record.Header().Get("X-Cache") != "MISS" {
+		fatalFailed(t, "Should have keyed cache by host: %+v", record.Header())
+	}
+	logPassed(t, "Should have keyed cache by host")
+
+	send("/account", "", http.Header{"Authorization": {"Bearer alice"}})
+	if record := send("/account", "", http.Header{"Authorization": {"Bearer bob"}}); record.Header().Get("X-Cache") != "MISS" || record.Body.String() != "Bearer bob" {
+		fatalFailed(t, "Should not have cached authorized response: %+v %q", record.Header(), record.Body.String())
+	}
+	logPassed(t, "Should not have cached authorized response")
+
+	send("/account", "", http.Header{"Cookie": {"session=alice"}})
+	if record := send("/account", "", http.Header{"Cookie": {"session=bob"}}); record.Header().Get("X-Cache") != "MISS" {
+		fatalFailed(t, "Should not have cached response to request with cookies: %+v", record.Header())
+	}
+	logPassed(t, "Should not have cached response to request with cookies")
+}
+
+func TestRequestCancellation(t *testing.T) {
+	var actions int32
+	var skipped error
+
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/gone",
+		Method: "GET",
+		LocalMW: func(ctx context.Context, rw *fhttp.Request) (*fhttp.Request, error) {
+			rw.Req.Context().Value(cancelKey{}).(stdcontext.CancelFunc)()
+			return rw, nil
+		},
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			atomic.AddInt32(&actions, 1)
+			return nil
+		},
+	})
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/pipeline",
+		Method: "GET",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			rw.Req.Context().Value(cancelKey{}).(stdcontext.CancelFunc)()
+
+			_, skipped = fractals.MustWrap(func(ctx context.Context, data interface{}) (interface{}, error) {
+				atomic.AddInt32(&actions, 1)
+				return data, nil
+			})(ctx, nil, rw)
+			return nil
+		},
+	})
+
+	serve := func(path string) {
+		std, cancel := stdcontext.WithCancel(stdcontext.Background())
+		defer cancel()
+
+		request, _ := http.NewRequest("GET", path, nil)
+		request = request.WithContext(stdcontext.WithValue(std, cancelKey{}, cancel))
+		drive.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	serve("/gone")
+	if atomic.LoadInt32(&actions) != 0 {
+		fatalFailed(t, "Should have skipped the action of a cancelled request")
+	}
+	logPassed(t, "Should have skipped the action of a cancelled request")
+
+	serve("/pipeline")
+	if atomic.LoadInt32(&actions) != 0 || skipped != stdcontext.Canceled {
+		fatalFailed(t, "Should have aborted the pipeline of a cancelled request: %v", skipped)
+	}
+	logPassed(t, "Should have aborted the pipeline of a cancelled request")
+}
+
+// cancelKey defines the key the cancel function of a request's context is
+// stored under.
+type cancelKey struct{}
+
+func TestValidationErrorResponse(t *testing.T) {
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/users",
+		Method: "POST",
+		Action: func(ctx context.Context, rw *fhttp.Request) error {
+			var body map[string]interface{}
+			json.NewDecoder(rw.Req.Body).Decode(&body)
+
+			_, err := maps.Validate(maps.Schema{
+				{Path: "name", Required: true, Type: maps.TypeString},
+			})(ctx, nil, body)
+			return err
+		},
+	})
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", "/users", strings.NewReader(`{"name":3}`))
+	drive.ServeHTTP(record, request)
+
+	var res fhttp.JSONError
+	if err := json.Unmarshal(record.Body.Bytes(), &res); err != nil || record.Code != http.StatusBadRequest {
+		fatalFailed(t, "Should have rendered validation error: %d %q", record.Code, record.Body.String())
+	}
+
+	if len(res.Fields) != 1 || res.Fields[0].Name != "name" || res.Fields[0].Value != "3" {
+		fatalFailed(t, "Should have rendered failed field details: %+v", res)
+	}
+	logPassed(t, "Should have rendered validation error fields")
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.RemoteAddr)
+	})
+
+	serve := func(opts fhttp.ProxyProtocolOptions) string {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			fatalFailed(t, "Should have created listener: %s", err)
+		}
+
+		server := &http.Server{Handler: handler}
+		go server.Serve(fhttp.ProxyProtocolListener(listener, opts))
+		t.Cleanup(func() { server.Close() })
+
+		return listener.Addr().String()
+	}
+
+	request := func(addr string, header func(io.Writer) error) (string, error) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+
+		if header != nil {
+			if err := header(conn); err != nil {
+				return "", err
+			}
+		}
+
+		io.WriteString(conn, "GET / HTTP/1.0\r\nHost: fractals\r\n\r\n")
+
+		res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+
+		body, err := ioutil.ReadAll(res.Body)
+		return string(body), err
+	}
+
+	addr := serve(fhttp.ProxyProtocolOptions{HeaderTimeout: time.Second})
+
+	client := &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 56324}
+	backend := &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 443}
+	client6 := &net.TCPAddr{IP: net.ParseIP("2001:db8::7"), Port: 40000}
+	backend6 := &net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 443}
+
+	for _, version := range []int{1, 2} {
+		remote, err := request(addr, func(w io.Writer) error {
+			return fhttp.WriteProxyHeader(w, version, client, backend)
+		})
+		if err != nil || remote != "203.0.113.7:56324" {
+			fatalFailed(t, "Should have reported client of version %d header: %q %+v", version, remote, err)
+		}
+
+		remote, err = request(addr, func(w io.Writer) error {
+			return fhttp.WriteProxyHeader(w, version, client6, backend6)
+		})
+		if err != nil || remote != "[2001:db8::7]:40000" {
+			fatalFailed(t, "Should have reported IPv6 client of version %d header: %q %+v", version, remote, err)
+		}
+	}
+	logPassed(t, "Should have reported client named by header")
+
+	if remote, err := request(addr, nil); err == nil && strings.HasPrefix(remote, "127.0.0.1:") {
+		fatalFailed(t, "Should have rejected connection without header: %q", remote)
+	}
+	logPassed(t, "Should have rejected connection without header")
+
+	optional := serve(fhttp.ProxyProtocolOptions{Optional: true})
+	if remote, err := request(optional, nil); err != nil || !strings.HasPrefix(remote, "127.0.0.1:") {
+		fatalFailed(t, "Should have kept own address without header: %q %+v", remote, err)
+	}
+	logPassed(t, "Should have kept own address without header")
+}
+
+func TestSNIConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := fhttp.SNIConfig(dir); err != fhttp.ErrNoCertificates {
+		fatalFailed(t, "Should have failed empty directory: %+v", err)
+	}
+	logPassed(t, "Should have failed empty directory")
+
+	for name, host := range map[string]string{"a": "a.example.com", "b": "*.b.example.com"} {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			DNSNames:     []string{host},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			fatalFailed(t, "Should have created certificate: %s", err)
+		}
+
+		keyDer, _ := x509.MarshalECPrivateKey(key)
+		ioutil.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+		ioutil.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	}
+
+	config, err := fhttp.SNIConfig(dir, "http/1.1")
+	if err != nil {
+		fatalFailed(t, "Should have loaded certificates: %s", err)
+	}
+
+	server, _ := fhttp.NewServer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, fhttp.NegotiatedProtocol(r))
+	}), fhttp.ServerOptions{TLSConfig: config})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fatalFailed(t, "Should have created listener: %s", err)
+	}
+
+	go server.ServeTLS(listener, "", "")
+	defer server.Close()
+
+	for serverName, expected := range map[string]string{
+		"a.example.com":     "a.example.com",
+		"api.b.example.com": "*.b.example.com",
+		"unknown.test":      "a.example.com",
+	} {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{ServerName: serverName, InsecureSkipVerify: true, NextProtos: []string{"http/1.1"}},
+		}}
+
+		res, err := client.Get("https://" + listener.Addr().String())
+		if err != nil {
+			fatalFailed(t, "Should have served %q over TLS: %s", serverName, err)
+		}
+
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+
+		if names := res.TLS.PeerCertificates[0].DNSNames; names[0] != expected {
+			fatalFailed(t, "Should have served certificate of %q to %q but got %v", expected, serverName, names)
+		}
+
+		if string(body) != "http/1.1" {
+			fatalFailed(t, "Should have negotiated http/1.1 but got %q", body)
+		}
+	}
+	logPassed(t, "Should have selected certificates by server name")
+}
@@ -1,9 +1,17 @@
 package fhttp
 
 import (
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/dimfeld/httptreemux"
 	"github.com/influx6/faux/context"
@@ -11,22 +19,38 @@ import (
 )
 
 // WrapFractalHandler returns a new http.HandlerFunc for recieving http request.
+// Every request is handled with a new context carrying the request's context,
+// so the handler is aborted once the client disconnects. See
+// requestContext.
 func WrapFractalHandler(handler fractals.Handler) func(http.ResponseWriter, *http.Request, map[string]string) {
-	return WrapFractalHandlerWith(context.New(), handler)
+	return wrapFractalHandler(requestContext, handler)
 }
 
 // WrapFractalHandlerWith returns a http.HandlerFunc which accepts an extra parameter and
 // passes the request objects to the handler. If no response was sent when
 // the handlers are runned and an error came back then we write the error
-// as response.
+// as response. The context is shared by all requests, so the request's
+// context is not stored within it, only being reachable through the
+// request.
 func WrapFractalHandlerWith(ctx context.Context, handler fractals.Handler) func(http.ResponseWriter, *http.Request, map[string]string) {
+	return wrapFractalHandler(func(*http.Request) context.Context {
+		return ctx
+	}, handler)
+}
+
+// wrapFractalHandler returns a http.HandlerFunc which passes the request
+// objects to the handler with the context made for the request.
+func wrapFractalHandler(makeCtx func(*http.Request) context.Context, handler fractals.Handler) func(http.ResponseWriter, *http.Request, map[string]string) {
 	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		ctx := makeCtx(r)
 		rw := &Request{
 			Params: Param(params),
 			Res:    NewResponseWriter(w),
 			Req:    r,
 		}
 
+		defer finishResponse(rw)
+
 		_, err := handler(ctx, nil, rw)
 		if err != nil && !rw.Res.DataWritten() {
 			RenderResponseError(err, rw)
@@ -34,6 +58,23 @@ func WrapFractalHandlerWith(ctx context.Context, handler fractals.Handler) func(
 	}
 }
 
+// requestContext returns a new context carrying the context of the request
+// through fractals.WithContext, which is cancelled once the client
+// disconnects or the server shuts down. Handlers wrapped with fractals.Wrap
+// are skipped once it is, so pipelines, such as those reading files or
+// calling other services, stop working on requests nobody awaits.
+func requestContext(r *http.Request) context.Context {
+	return fractals.WithContext(context.New(), r.Context())
+}
+
+// finishResponse closes the ResponseWriter of the request if it holds back
+// data till closed, as the one used by Compress does.
+func finishResponse(rw *Request) {
+	if closer, ok := rw.Res.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
 // WrapRequestFractalHandler returns a function which wraps a fractal.Handler
 // passing in the request object it receives.
 func WrapRequestFractalHandler(handler fractals.Handler) func(context.Context, *Request) error {
@@ -233,6 +274,193 @@ type HTTPDrive struct {
 	*httptreemux.TreeMux
 	globalMW      DriveMiddleware // global middleware.
 	globalMWAfter DriveMiddleware // global middleware.
+	options       RouterOptions
+
+	ml          sync.Mutex
+	routes      []RouteInfo
+	named       map[string]RouteInfo
+	beforeNames []string
+	afterNames  []string
+}
+
+// RouteInfo defines the details of a registered Endpoint, listing the names
+// of the functions handling it in the order they run.
+type RouteInfo struct {
+	Name       string   `json:"name,omitempty"`
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Middleware []string `json:"middleware,omitempty"`
+	Action     string   `json:"action"`
+	After      []string `json:"after,omitempty"`
+
+	Doc *EndpointDoc `json:"-"`
+}
+
+// Routes returns the details of every Endpoint registered with the drive,
+// sorted by path and method.
+func (hd *HTTPDrive) Routes() []RouteInfo {
+	hd.ml.Lock()
+	defer hd.ml.Unlock()
+
+	routes := make([]RouteInfo, len(hd.routes))
+	copy(routes, hd.routes)
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+// register registers the endpoint under path, recording it's details. It
+// fails if the endpoint's name is already taken.
+func (hd *HTTPDrive) register(end Endpoint, path string, before DriveMiddleware, groupNames []string) error {
+	hd.ml.Lock()
+	defer hd.ml.Unlock()
+
+	if end.Name != "" {
+		if _, ok := hd.named[end.Name]; ok {
+			return fmt.Errorf("Route name %q already registered", end.Name)
+		}
+	}
+
+	handler := end.handlerFunc(before, hd.globalMWAfter)
+	if hd.options.CaseInsensitive {
+		handle := handler
+		handler = func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			handle(w, r, routeParams(path, r))
+		}
+	}
+
+	hd.Handle(end.Method, hd.routePath(path), handler)
+
+	var middleware []string
+	middleware = append(middleware, hd.beforeNames...)
+	middleware = append(middleware, groupNames...)
+	middleware = append(middleware, funcNames(end.LocalMW)...)
+
+	var after []string
+	after = append(after, funcNames(end.AfterWM)...)
+	after = append(after, hd.afterNames...)
+
+	var action string
+	if names := funcNames(end.Action); len(names) != 0 {
+		action = names[0]
+	}
+
+	info := RouteInfo{
+		Name:       end.Name,
+		Method:     end.Method,
+		Path:       path,
+		Middleware: middleware,
+		Action:     action,
+		After:      after,
+		Doc:        end.Doc,
+	}
+
+	hd.routes = append(hd.routes, info)
+
+	if end.Name != "" {
+		if hd.named == nil {
+			hd.named = make(map[string]RouteInfo)
+		}
+
+		hd.named[end.Name] = info
+	}
+
+	return nil
+}
+
+// URL returns the URL of the Endpoint registered under name, filling in the
+// ":param" and "*catchall" segments of it's path from params. Params not
+// used by the path are added as the URL's query. It fails if no Endpoint has
+// the name or a segment's param is missing.
+func (hd *HTTPDrive) URL(name string, params map[string]string) (string, error) {
+	hd.ml.Lock()
+	info, ok := hd.named[name]
+	hd.ml.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("No route named %q", name)
+	}
+
+	used := make(map[string]bool)
+	segments := strings.Split(info.Path, "/")
+
+	for index, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+
+		key := segment[1:]
+
+		value, ok := params[key]
+		if !ok {
+			return "", fmt.Errorf("Missing param %q for route %q", key, name)
+		}
+
+		used[key] = true
+
+		if segment[0] == ':' {
+			segments[index] = url.PathEscape(value)
+			continue
+		}
+
+		// Catch alls span segments, so only the parts between slashes
+		// are escaped.
+		parts := strings.Split(strings.TrimPrefix(value, "/"), "/")
+		for i, part := range parts {
+			parts[i] = url.PathEscape(part)
+		}
+
+		segments[index] = strings.Join(parts, "/")
+	}
+
+	query := make(url.Values)
+	for key, value := range params {
+		if !used[key] {
+			query.Set(key, value)
+		}
+	}
+
+	path := strings.Join(segments, "/")
+	if len(query) != 0 {
+		path += "?" + query.Encode()
+	}
+
+	return path, nil
+}
+
+// funcNames returns the short names of the function or slice of functions.
+func funcNames(item interface{}) []string {
+	if item == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(item)
+
+	switch value.Kind() {
+	case reflect.Func:
+		if value.IsNil() {
+			return nil
+		}
+
+		name := runtime.FuncForPC(value.Pointer()).Name()
+		return []string{name[strings.LastIndex(name, "/")+1:]}
+	case reflect.Slice:
+		var names []string
+		for i := 0; i < value.Len(); i++ {
+			names = append(names, funcNames(value.Index(i).Interface())...)
+		}
+
+		return names
+	}
+
+	return nil
 }
 
 // Serve lunches the drive with a http server.
@@ -245,6 +473,35 @@ func (hd *HTTPDrive) ServeTLS(addr string, certFile string, keyFile string) {
 	LaunchHTTPS(addr, certFile, keyFile, hd)
 }
 
+// ServeWith lunches the drive with a http server configured using the
+// provided options.
+func (hd *HTTPDrive) ServeWith(addr string, opts ServerOptions) error {
+	server, err := NewServer(addr, hd, opts)
+	if err != nil {
+		return err
+	}
+
+	LaunchServer(server, "", "")
+	return nil
+}
+
+// ServeTLSWith lunches the drive with a http server serving over TLS,
+// configured using the provided options. The certificate files may be left
+// empty if the options' TLSConfig provides the certificates.
+func (hd *HTTPDrive) ServeTLSWith(addr string, certFile string, keyFile string, opts ServerOptions) error {
+	server, err := NewServer(addr, hd, opts)
+	if err != nil {
+		return err
+	}
+
+	if server.TLSConfig == nil {
+		server.TLSConfig = &tls.Config{}
+	}
+
+	LaunchServer(server, certFile, keyFile)
+	return nil
+}
+
 // DriveMW returns the giving lists of passed in middleware, it is provided as
 // as a convenience function.
 func DriveMW(md ...DriveMiddleware) []DriveMiddleware {
@@ -264,6 +521,8 @@ func Drive(before ...DriveMiddleware) func(...DriveMiddleware) *HTTPDrive {
 		drive.TreeMux = httptreemux.New()
 		drive.globalMW = LiftWM(before...)
 		drive.globalMWAfter = LiftWM(after...)
+		drive.beforeNames = funcNames(before)
+		drive.afterNames = funcNames(after)
 		return &drive
 	}
 }
@@ -275,6 +534,14 @@ type Endpoint struct {
 	Action  interface{}
 	LocalMW interface{}
 	AfterWM interface{}
+
+	// Name, if set, names the route so it's URL can be built with
+	// HTTPDrive.URL. Names must be unique within a HTTPDrive.
+	Name string
+
+	// Doc, if set, documents the route within the OpenAPI document generated
+	// by HTTPDrive.OpenAPI.
+	Doc *EndpointDoc
 }
 
 func (e Endpoint) handlerFunc(globalBeforeWM, globalAfterWM DriveMiddleware) func(w http.ResponseWriter, r *http.Request, params map[string]string) {
@@ -292,16 +559,22 @@ func (e Endpoint) handlerFunc(globalBeforeWM, globalAfterWM DriveMiddleware) fun
 	}
 
 	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
-		ctx := context.New()
+		ctx := requestContext(r)
 		rw := &Request{
 			Params: Param(params),
 			Res:    NewResponseWriter(w),
 			Req:    r,
 		}
 
+		defer finishResponse(rw)
+
 		// Run the global middleware first and recieve its returned values.
 		if globalBeforeWM != nil {
 			_, err := globalBeforeWM(ctx, rw)
+			if responded(err, rw) {
+				return
+			}
+
 			if err != nil && !rw.Res.DataWritten() {
 				RenderResponseError(err, rw)
 				return
@@ -311,12 +584,23 @@ func (e Endpoint) handlerFunc(globalBeforeWM, globalAfterWM DriveMiddleware) fun
 		// Run local middleware second and receive its return values.
 		if localWM != nil {
 			_, err := localWM(ctx, rw)
+			if responded(err, rw) {
+				return
+			}
+
 			if err != nil && !rw.Res.DataWritten() {
 				RenderResponseError(err, rw)
 				// return
 			}
 		}
 
+		// Skip the action and after middleware once the request is
+		// cancelled, as the client has gone or a deadline, such as the one
+		// of Timeout, has passed.
+		if fractals.ContextErr(ctx) != nil {
+			return
+		}
+
 		if werr := action(ctx, rw); werr != nil && !rw.Res.DataWritten() {
 			RenderResponseError(werr, rw)
 			// return
@@ -341,18 +625,103 @@ func (e Endpoint) handlerFunc(globalBeforeWM, globalAfterWM DriveMiddleware) fun
 	}
 }
 
+// responded returns true/false if the error was returned by a middleware
+// which has already responded to the request, such as one rejecting it.
+func responded(err error, rw *Request) bool {
+	if err == nil {
+		return false
+	}
+
+	return err == ErrNotModified || err == ErrPreflightHandled || rw.Res.StatusWritten()
+}
+
 // Route returns a functional register, which uses the same drive for registring
 // http endpoints.
 func Route(drive *HTTPDrive) func(Endpoint) error {
 	return func(end Endpoint) error {
-		drive.Handle(end.Method, end.Path, end.handlerFunc(drive.globalMW, drive.globalMWAfter))
-		return nil
+		return drive.register(end, end.Path, drive.globalMW, nil)
 	}
 }
 
 // RouteBy provides a more direct function that lets you specify the drive and
 // endpoint directly.
 func RouteBy(drive *HTTPDrive, end Endpoint) error {
-	drive.Handle(end.Method, end.Path, end.handlerFunc(drive.globalMW, drive.globalMWAfter))
+	return drive.register(end, end.Path, drive.globalMW, nil)
+}
+
+// RouteGroup defines a set of endpoints registered with a HTTPDrive which share
+// a path prefix and middleware.
+type RouteGroup struct {
+	drive     *HTTPDrive
+	prefix    string
+	mw        DriveMiddleware
+	mwNames   []string
+	namespace string
+}
+
+// Group returns a new RouteGroup whoes endpoints are registered with the drive
+// under the provided prefix, running the provided middleware after the
+// drive's global middleware and before their own.
+func (hd *HTTPDrive) Group(prefix string, mw ...DriveMiddleware) *RouteGroup {
+	return &RouteGroup{
+		drive:   hd,
+		prefix:  strings.TrimSuffix(prefix, "/"),
+		mw:      LiftWM(mw...),
+		mwNames: funcNames(mw),
+	}
+}
+
+// Group returns a new RouteGroup nested within the group, whoes prefix and
+// middleware are added to those of the group.
+func (g *RouteGroup) Group(prefix string, mw ...DriveMiddleware) *RouteGroup {
+	return &RouteGroup{
+		drive:     g.drive,
+		prefix:    joinRoute(g.prefix, strings.TrimSuffix(prefix, "/")),
+		mw:        LiftWM(append([]DriveMiddleware{g.mw}, mw...)...),
+		mwNames:   append(append([]string(nil), g.mwNames...), funcNames(mw)...),
+		namespace: g.namespace,
+	}
+}
+
+// Handle registers the endpoint with the group's drive, prefixing it's path
+// with the group's prefix, and it's name, if any, with the group's namespace.
+func (g *RouteGroup) Handle(end Endpoint) error {
+	if end.Name != "" && g.namespace != "" {
+		end.Name = g.namespace + "." + end.Name
+	}
+
+	before := LiftWM(g.drive.globalMW, g.mw)
+	return g.drive.register(end, joinRoute(g.prefix, end.Path), before, g.mwNames)
+}
+
+// HandleAll registers all the endpoints with the group's drive, stopping at
+// the first which fails, which allows the same endpoints to be mounted within
+// several groups.
+func (g *RouteGroup) HandleAll(ends ...Endpoint) error {
+	for _, end := range ends {
+		if err := g.Handle(end); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// Route returns a functional register, which uses the group for registring
+// http endpoints.
+func (g *RouteGroup) Route() func(Endpoint) error {
+	return g.Handle
+}
+
+// joinRoute returns the path prefixed with the provided prefix.
+func joinRoute(prefix string, path string) string {
+	if path == "" {
+		return prefix
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return prefix + path
+}
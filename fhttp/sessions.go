@@ -0,0 +1,553 @@
+package fhttp
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+)
+
+// ErrSessionTooLarge is returned by CookieStore when a session's values do not
+// fit within a cookie.
+var ErrSessionTooLarge = errors.New("Session too large for cookie")
+
+// ErrWeakSecret is returned by NewCookieStore when the secret is shorter than
+// 32 bytes.
+var ErrWeakSecret = errors.New("Secret must be at least 32 bytes")
+
+// sessionKey defines the key the Session is stored under within the context.
+const sessionKey = "fhttp.session"
+
+// maxCookieSize defines the size in bytes of the largest cookie value browsers
+// are expected to keep.
+const maxCookieSize = 4000
+
+// SessionStore defines the interface of the stores which persist the values
+// of sessions, where the cookie sent to clients holds whatever value the store
+// returns, such as a session id or the values themselves.
+type SessionStore interface {
+	// Load returns the id and values of the session for the cookie's value,
+	// returning an empty id and nil values if no valid session exists.
+	Load(value string) (id string, values map[string]interface{}, err error)
+
+	// Save persists the values of the session with id, which is empty for new
+	// sessions, for at least maxAge if non-zero, returning the cookie value.
+	Save(id string, values map[string]interface{}, maxAge time.Duration) (value string, err error)
+
+	// Delete removes the session with id.
+	Delete(id string) error
+}
+
+// SessionOptions defines the configuration of the cookie used by Sessions.
+type SessionOptions struct {
+	// CookieName sets the name of the cookie, defaulting to "session".
+	CookieName string
+
+	// Path sets the path of the cookie, defaulting to "/".
+	Path   string
+	Domain string
+
+	// MaxAge sets how long the session lasts, where a zero value lasts till
+	// the browser is closed.
+	MaxAge time.Duration
+
+	Secure   bool
+	SameSite http.SameSite
+
+	// ScriptAccess allows scripts to read the cookie, which is otherwise
+	// HttpOnly.
+	ScriptAccess bool
+}
+
+// Session defines the values stored for a single client between requests. It
+// is safe for concurrent use.
+type Session struct {
+	ml          sync.Mutex
+	id          string
+	values      map[string]interface{}
+	isNew       bool
+	changed     bool
+	destroyed   bool
+	regenerated bool
+}
+
+// ID returns the id of the session given by the store, which is empty for new
+// sessions and stores which keep no id.
+func (s *Session) ID() string {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	return s.id
+}
+
+// IsNew returns true/false if the session was created for this request.
+func (s *Session) IsNew() bool {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	return s.isNew
+}
+
+// Get returns the value stored under key.
+func (s *Session) Get(key string) (interface{}, bool) {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	val, ok := s.values[key]
+	return val, ok
+}
+
+// Set stores the value under key.
+func (s *Session) Set(key string, val interface{}) {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	s.values[key] = val
+	s.changed = true
+}
+
+// Delete removes the value stored under key.
+func (s *Session) Delete(key string) {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	delete(s.values, key)
+	s.changed = true
+}
+
+// Destroy removes the session from the store and the client.
+func (s *Session) Destroy() {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	s.values = make(map[string]interface{})
+	s.destroyed = true
+}
+
+// Regenerate moves the session's values to a new id, which should be done
+// whenever a user logs in to prevent session fixation.
+func (s *Session) Regenerate() {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	s.regenerated = true
+}
+
+// Session returns the Session added to the context by the Sessions
+// middleware, or nil if none was.
+func (r *Request) Session(ctx context.Context) *Session {
+	if ctx == nil {
+		return nil
+	}
+
+	session, ok := ctx.Get(sessionKey)
+	if !ok {
+		return nil
+	}
+
+	return session.(*Session)
+}
+
+// Sessions returns a DriveMiddleware which loads the session of every request
+// from the store through it's cookie, making it available through
+// Request.Session. Changes to the session are saved once the response starts
+// being written, and a failure to save them turns the response into a 500
+// error.
+func Sessions(store SessionStore, opts SessionOptions) DriveMiddleware {
+	if opts.CookieName == "" {
+		opts.CookieName = "session"
+	}
+
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		var value string
+		if cookie, err := rw.Req.Cookie(opts.CookieName); err == nil {
+			value = cookie.Value
+		}
+
+		id, values, err := store.Load(value)
+		if err != nil {
+			return nil, err
+		}
+
+		session := &Session{
+			id:     id,
+			values: values,
+			isNew:  id == "" && values == nil,
+		}
+
+		if session.values == nil {
+			session.values = make(map[string]interface{})
+		}
+
+		ctx.Set(sessionKey, session)
+
+		rw.Res = &sessionWriter{
+			ResponseWriter: rw.Res,
+			session:        session,
+			store:          store,
+			opts:           opts,
+			hadCookie:      value != "",
+		}
+
+		return rw, nil
+	}
+}
+
+// sessionWriter defines a ResponseWriter which saves the session before the
+// response headers are written.
+type sessionWriter struct {
+	ResponseWriter
+	session   *Session
+	store     SessionStore
+	opts      SessionOptions
+	hadCookie bool
+
+	once sync.Once
+	err  error
+}
+
+// WriteHeader saves the session before writing the status, writing a 500
+// status instead if the session failed to save.
+func (s *sessionWriter) WriteHeader(status int) {
+	if s.commit() != nil {
+		status = http.StatusInternalServerError
+	}
+
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Write saves the session before the first write, failing if the session
+// failed to save.
+func (s *sessionWriter) Write(data []byte) (int, error) {
+	if !s.ResponseWriter.StatusWritten() {
+		s.WriteHeader(http.StatusOK)
+	}
+
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	return s.ResponseWriter.Write(data)
+}
+
+// Flush saves the session before the headers are sent, flushing the written
+// data to the client.
+func (s *sessionWriter) Flush() {
+	if !s.ResponseWriter.StatusWritten() {
+		s.WriteHeader(http.StatusOK)
+	}
+
+	s.ResponseWriter.Flush()
+}
+
+// Close saves the session if nothing was written, closing the inner writer.
+func (s *sessionWriter) Close() error {
+	err := s.commit()
+
+	if closer, ok := s.ResponseWriter.(interface {
+		Close() error
+	}); ok {
+		if cerr := closer.Close(); cerr != nil {
+			return cerr
+		}
+	}
+
+	return err
+}
+
+// Hijack hands over the connection, as needed by WebSocket endpoints. The
+// session is not saved for hijacked requests.
+func (s *sessionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrHijackUnsupported
+	}
+
+	return hijacker.Hijack()
+}
+
+// commit saves, regenerates or destroys the session once.
+func (s *sessionWriter) commit() error {
+	s.once.Do(func() {
+		s.err = s.save()
+	})
+
+	return s.err
+}
+
+// save persists the changes to the session, setting or removing it's cookie.
+func (s *sessionWriter) save() error {
+	session := s.session
+
+	session.ml.Lock()
+	defer session.ml.Unlock()
+
+	if session.destroyed {
+		if session.id != "" {
+			if err := s.store.Delete(session.id); err != nil {
+				return err
+			}
+		}
+
+		if s.hadCookie {
+			cookie := s.cookie("")
+			cookie.MaxAge = -1
+			http.SetCookie(s.ResponseWriter, cookie)
+		}
+
+		return nil
+	}
+
+	if session.regenerated && session.id != "" {
+		if err := s.store.Delete(session.id); err != nil {
+			return err
+		}
+
+		session.id = ""
+	}
+
+	if !session.changed && !session.regenerated {
+		return nil
+	}
+
+	value, err := s.store.Save(session.id, session.values, s.opts.MaxAge)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(s.ResponseWriter, s.cookie(value))
+	return nil
+}
+
+// cookie returns the session cookie holding value.
+func (s *sessionWriter) cookie(value string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     s.opts.CookieName,
+		Value:    value,
+		Path:     s.opts.Path,
+		Domain:   s.opts.Domain,
+		Secure:   s.opts.Secure,
+		HttpOnly: !s.opts.ScriptAccess,
+		SameSite: s.opts.SameSite,
+	}
+
+	if s.opts.MaxAge > 0 {
+		cookie.MaxAge = int(s.opts.MaxAge / time.Second)
+		cookie.Expires = time.Now().Add(s.opts.MaxAge)
+	}
+
+	return cookie
+}
+
+// MemoryStore defines a SessionStore which keeps sessions in memory, sending
+// only their ids to clients. Sessions are lost when the process exits.
+type MemoryStore struct {
+	ml       sync.Mutex
+	sessions map[string]memorySession
+	swept    time.Time
+}
+
+// memorySession defines a session held by a MemoryStore.
+type memorySession struct {
+	values  map[string]interface{}
+	expires time.Time
+}
+
+// NewMemoryStore returns a new instance of a MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]memorySession),
+		swept:    time.Now(),
+	}
+}
+
+// Load returns the values of the session with the id held by value.
+func (m *MemoryStore) Load(value string) (string, map[string]interface{}, error) {
+	if value == "" {
+		return "", nil, nil
+	}
+
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	session, ok := m.sessions[value]
+	if !ok {
+		return "", nil, nil
+	}
+
+	if !session.expires.IsZero() && time.Now().After(session.expires) {
+		delete(m.sessions, value)
+		return "", nil, nil
+	}
+
+	// Copy the values so they only change once saved.
+	values := make(map[string]interface{}, len(session.values))
+	for key, val := range session.values {
+		values[key] = val
+	}
+
+	return value, values, nil
+}
+
+// Save stores the values under id, generating a new id for new sessions, and
+// returns the id as the cookie value.
+func (m *MemoryStore) Save(id string, values map[string]interface{}, maxAge time.Duration) (string, error) {
+	if id == "" {
+		var err error
+		if id, err = newSessionID(); err != nil {
+			return "", err
+		}
+	}
+
+	session := memorySession{values: make(map[string]interface{}, len(values))}
+	for key, val := range values {
+		session.values[key] = val
+	}
+
+	now := time.Now()
+	if maxAge > 0 {
+		session.expires = now.Add(maxAge)
+	}
+
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	m.sessions[id] = session
+
+	// Expired sessions which are never loaded again are removed periodically.
+	if now.Sub(m.swept) > time.Minute {
+		m.swept = now
+
+		for key, session := range m.sessions {
+			if !session.expires.IsZero() && now.After(session.expires) {
+				delete(m.sessions, key)
+			}
+		}
+	}
+
+	return id, nil
+}
+
+// Delete removes the session with id.
+func (m *MemoryStore) Delete(id string) error {
+	m.ml.Lock()
+	defer m.ml.Unlock()
+
+	delete(m.sessions, id)
+	return nil
+}
+
+// newSessionID returns a new random session id.
+func newSessionID() (string, error) {
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
+
+// CookieStore defines a SessionStore which keeps the values of sessions
+// within the cookie itself as signed JSON, so they can be read but not
+// altered by clients. As values pass through JSON, numbers are loaded back as
+// float64 and structs as maps.
+type CookieStore struct {
+	secret []byte
+}
+
+// cookieSession defines the signed content of a CookieStore cookie.
+type cookieSession struct {
+	Values  map[string]interface{} `json:"v"`
+	Expires int64                  `json:"e,omitempty"`
+}
+
+// NewCookieStore returns a new instance of a CookieStore, signing cookies
+// with the secret, which must be at least 32 random bytes, else anyone could
+// forge cookies.
+func NewCookieStore(secret []byte) (*CookieStore, error) {
+	if len(secret) < 32 {
+		return nil, ErrWeakSecret
+	}
+
+	return &CookieStore{secret: secret}, nil
+}
+
+// Load returns the values held by the cookie's value, ignoring values whose
+// signature does not match or which have expired.
+func (c *CookieStore) Load(value string) (string, map[string]interface{}, error) {
+	dot := strings.LastIndex(value, ".")
+	if dot == -1 {
+		return "", nil, nil
+	}
+
+	payload, signature := value[:dot], value[dot+1:]
+
+	expected := c.sign(payload)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, nil
+	}
+
+	var session cookieSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return "", nil, nil
+	}
+
+	if session.Expires != 0 && time.Now().Unix() > session.Expires {
+		return "", nil, nil
+	}
+
+	return "", session.Values, nil
+}
+
+// Save returns the signed cookie value holding the values.
+func (c *CookieStore) Save(id string, values map[string]interface{}, maxAge time.Duration) (string, error) {
+	session := cookieSession{Values: values}
+	if maxAge > 0 {
+		session.Expires = time.Now().Add(maxAge).Unix()
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	value := payload + "." + c.sign(payload)
+
+	if len(value) > maxCookieSize {
+		return "", ErrSessionTooLarge
+	}
+
+	return value, nil
+}
+
+// Delete does nothing, as the session is only held by the client's cookie.
+func (c *CookieStore) Delete(id string) error {
+	return nil
+}
+
+// sign returns the signature of the payload.
+func (c *CookieStore) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,101 @@
+package fhttp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNoCertificates is returned by SNIConfig when the directory holds no
+// certificates.
+var ErrNoCertificates = errors.New("No certificates found")
+
+// SNIConfig returns a tls.Config serving the certificates within the directory,
+// selected by the server name clients ask for through SNI, so one listener,
+// such as that of a server made by NewServer, can serve several hostnames.
+// Certificates are read from every "name.crt" file paired with a "name.key"
+// file, and serve the hostnames they are issued for, including wildcard ones.
+// Clients asking for no known hostname are served the certificate of the
+// first pair in the order of their names. The protocols, if any, are offered
+// to clients through ALPN in order of preference, where servers started with
+// ListenAndServeTLS add "h2" and "http/1.1" when not listed. See
+// NegotiatedProtocol.
+func SNIConfig(dir string, nextProtos ...string) (*tls.Config, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.crt"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	var fallback *tls.Certificate
+	certs := make(map[string]*tls.Certificate)
+
+	for _, certFile := range files {
+		cert, err := tls.LoadX509KeyPair(certFile, strings.TrimSuffix(certFile, ".crt")+".key")
+		if err != nil {
+			return nil, err
+		}
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, err
+		}
+
+		cert.Leaf = leaf
+
+		if fallback == nil {
+			fallback = &cert
+		}
+
+		names := leaf.DNSNames
+		if len(names) == 0 && leaf.Subject.CommonName != "" {
+			names = []string{leaf.Subject.CommonName}
+		}
+
+		for _, name := range names {
+			if _, exists := certs[strings.ToLower(name)]; !exists {
+				certs[strings.ToLower(name)] = &cert
+			}
+		}
+	}
+
+	if fallback == nil {
+		return nil, ErrNoCertificates
+	}
+
+	return &tls.Config{
+		NextProtos: nextProtos,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+
+			if cert, ok := certs[name]; ok {
+				return cert, nil
+			}
+
+			// Wildcard certificates cover a single label.
+			if dot := strings.IndexByte(name, '.'); dot != -1 {
+				if cert, ok := certs["*"+name[dot:]]; ok {
+					return cert, nil
+				}
+			}
+
+			return fallback, nil
+		},
+	}, nil
+}
+
+// NegotiatedProtocol returns the protocol negotiated through ALPN by the
+// client of the request, or an empty string if none was or the request was
+// not made over TLS.
+func NegotiatedProtocol(r *http.Request) string {
+	if r.TLS == nil {
+		return ""
+	}
+
+	return r.TLS.NegotiatedProtocol
+}
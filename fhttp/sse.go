@@ -0,0 +1,304 @@
+package fhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// SSEOptions defines the configuration used when streaming an Observable as
+// Server-Sent Events.
+type SSEOptions struct {
+	// Event sets the event name sent with every emission, if empty, no event
+	// field is sent and clients receive the emissions as "message" events.
+	Event string
+
+	// Serialize turns every emission into the event's data. If nil, strings
+	// and []byte are sent as they are and all other values as JSON.
+	Serialize func(interface{}) ([]byte, error)
+
+	// Heartbeat sets the interval with which comment lines are sent to keep
+	// the connection alive. A zero value disables heartbeats.
+	Heartbeat time.Duration
+
+	// Retry sets how long clients should wait before reconnecting once the
+	// connection is lost. A zero value leaves it to the client.
+	Retry time.Duration
+
+	// Buffer sets how many emissions can be pending while the client is being
+	// written to, further emissions block the Observable. Defaults to 16.
+	Buffer int
+}
+
+// SSEEvent defines a single Server-Sent Event sent through a SSEWriter.
+type SSEEvent struct {
+	// ID sets the event's id, which the client sends back within the
+	// Last-Event-ID header when reconnecting. If empty, no id is sent.
+	ID string
+
+	// Event sets the event's name, if empty, clients receive it as a
+	// "message" event.
+	Event string
+
+	// Data holds the value sent as the event's data.
+	Data interface{}
+}
+
+// SSEWriter defines a writer of Server-Sent Events to a single client, which
+// flushes every event written to the client immediately. It is safe for
+// concurrent use.
+type SSEWriter struct {
+	req       *Request
+	serialize func(interface{}) ([]byte, error)
+	ml        sync.Mutex
+}
+
+// Request returns the request the events are written for.
+func (s *SSEWriter) Request() *Request {
+	return s.req
+}
+
+// Done returns a channel which is closed once the client disconnects.
+func (s *SSEWriter) Done() <-chan struct{} {
+	return s.req.Req.Context().Done()
+}
+
+// Err returns a non-nil error once the client has disconnected.
+func (s *SSEWriter) Err() error {
+	return s.req.Req.Context().Err()
+}
+
+// Send writes the event to the client. Strings and []byte data are sent as
+// they are, with all other values sent as JSON.
+func (s *SSEWriter) Send(event SSEEvent) error {
+	data, err := s.serialize(event.Data)
+	if err != nil {
+		return err
+	}
+
+	return s.write(event.ID, event.Event, data)
+}
+
+// Retry tells the client how long to wait before reconnecting once the
+// connection is lost.
+func (s *SSEWriter) Retry(wait time.Duration) error {
+	return s.writeRaw([]byte(fmt.Sprintf("retry: %d\n\n", wait/time.Millisecond)))
+}
+
+// Comment writes the text as a comment, which clients ignore, making it
+// useful for keeping the connection alive.
+func (s *SSEWriter) Comment(text string) error {
+	var buf bytes.Buffer
+
+	for _, line := range strings.Split(text, "\n") {
+		buf.WriteString(": ")
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("\n")
+
+	return s.writeRaw(buf.Bytes())
+}
+
+// write writes the event in the Server-Sent Events format, splitting the data
+// into a data field per line.
+func (s *SSEWriter) write(id string, event string, data []byte) error {
+	var buf bytes.Buffer
+
+	if id != "" {
+		fmt.Fprintf(&buf, "id: %s\n", id)
+	}
+
+	if event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", event)
+	}
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("\n")
+
+	return s.writeRaw(buf.Bytes())
+}
+
+// writeRaw writes the data to the client and flushes it, failing once the
+// client has disconnected.
+func (s *SSEWriter) writeRaw(data []byte) error {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	if _, err := s.req.Res.Write(data); err != nil {
+		return err
+	}
+
+	s.req.Res.Flush()
+	return nil
+}
+
+// SSE returns an Endpoint action which sets up the response for streaming
+// Server-Sent Events and calls the handler with a SSEWriter for the client.
+// The handler should return once the SSEWriter's Done channel is closed, as
+// the client has disconnected.
+func SSE(handler func(context.Context, *SSEWriter) error) func(context.Context, *Request) error {
+	return func(ctx context.Context, r *Request) error {
+		header := r.Res.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+		r.Res.WriteHeader(http.StatusOK)
+		r.Res.Flush()
+
+		return handler(ctx, &SSEWriter{req: r, serialize: serializeSSE})
+	}
+}
+
+// sseEvent defines a single event pending to be written to the client.
+type sseEvent struct {
+	event string
+	data  interface{}
+	err   error
+}
+
+// SSEFromObservable returns an Endpoint action which streams every emission of
+// the Observable to the client as a Server-Sent Event, until the client
+// disconnects or the Observable completes. Errors received from the Observable
+// are sent as "error" events holding the error message.
+func SSEFromObservable(o fractals.Observable, opts SSEOptions) func(context.Context, *Request) error {
+	if opts.Serialize == nil {
+		opts.Serialize = serializeSSE
+	}
+
+	if opts.Buffer <= 0 {
+		opts.Buffer = 16
+	}
+
+	return SSE(func(ctx context.Context, w *SSEWriter) error {
+		events := make(chan sseEvent, opts.Buffer)
+		completed := make(chan struct{})
+		stop := make(chan struct{})
+		defer close(stop)
+
+		push := func(event sseEvent) {
+			select {
+			case events <- event:
+			case <-stop:
+			}
+		}
+
+		// Subscribe within a goroutine, as cold observables emit within the
+		// Subscribe call and may fill the buffer before the writer starts.
+		subscription := make(chan *fractals.Subscription, 1)
+		defer func() {
+			go func() {
+				(<-subscription).End()
+			}()
+		}()
+
+		observer := fractals.NewObservable(fractals.Behaviour{
+			Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+				if err != nil {
+					push(sseEvent{event: "error", err: err})
+					return nil, err
+				}
+
+				push(sseEvent{event: opts.Event, data: item})
+				return item, nil
+			},
+			Done: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+				close(completed)
+				return item, err
+			},
+			Error: func(ctx context.Context, err error, _ interface{}) (interface{}, error) {
+				push(sseEvent{event: "error", err: err})
+				return nil, err
+			},
+		}, false)
+
+		go func() {
+			subscription <- o.Subscribe(observer)
+		}()
+
+		if opts.Retry > 0 {
+			if err := w.Retry(opts.Retry); err != nil {
+				return nil
+			}
+		}
+
+		var heartbeat <-chan time.Time
+		if opts.Heartbeat > 0 {
+			ticker := time.NewTicker(opts.Heartbeat)
+			defer ticker.Stop()
+			heartbeat = ticker.C
+		}
+
+		for {
+			select {
+			case <-w.Done():
+				return nil
+			case <-heartbeat:
+				if err := w.Comment("heartbeat"); err != nil {
+					return nil
+				}
+			case event := <-events:
+				if err := writeSSEEvent(w, event, opts.Serialize); err != nil {
+					return nil
+				}
+			case <-completed:
+				// Deliver what was emitted before completion.
+				for {
+					select {
+					case event := <-events:
+						if err := writeSSEEvent(w, event, opts.Serialize); err != nil {
+							return nil
+						}
+					default:
+						return nil
+					}
+				}
+			}
+		}
+	})
+}
+
+// writeSSEEvent writes the pending event through the SSEWriter, sending
+// errors and failed serializations as "error" events.
+func writeSSEEvent(w *SSEWriter, event sseEvent, serialize func(interface{}) ([]byte, error)) error {
+	if event.err != nil {
+		return w.write("", "error", []byte(event.err.Error()))
+	}
+
+	data, err := serialize(event.data)
+	if err != nil {
+		return w.write("", "error", []byte(err.Error()))
+	}
+
+	return w.write("", event.event, data)
+}
+
+// serializeSSE returns strings and []byte as they are, with all other values
+// turned into JSON.
+func serializeSSE(item interface{}) ([]byte, error) {
+	switch data := item.(type) {
+	case string:
+		return []byte(data), nil
+	case []byte:
+		return data, nil
+	default:
+		return json.Marshal(data)
+	}
+}
@@ -0,0 +1,44 @@
+package fhttp
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrIsDirectory is returned when a directory is requested from the file
+// serving handlers.
+var ErrIsDirectory = errors.New("Expected file but got directory")
+
+// ServeFile streams the file at path to the client using http.ServeContent,
+// which answers HEAD and Range requests, sets the Last-Modified header and
+// honours the If-Modified-Since and If-Range headers. The file is read from
+// disk as the body is written, so large files are never held in memory.
+func ServeFile(rw *Request, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return ErrIsDirectory
+	}
+
+	http.ServeContent(rw.Res, rw.Req, info.Name(), info.ModTime(), file)
+	return nil
+}
+
+// serveData serves data already held in memory using http.ServeContent, so
+// it still answers HEAD and Range requests.
+func serveData(rw *Request, name string, data []byte) {
+	http.ServeContent(rw.Res, rw.Req, name, time.Time{}, bytes.NewReader(data))
+}
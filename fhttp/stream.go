@@ -0,0 +1,125 @@
+package fhttp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrClientGone is returned when writing a streamed response to a client
+// which has disconnected.
+var ErrClientGone = errors.New("Client disconnected")
+
+// StreamOptions defines the configuration used by Request.StreamWith.
+type StreamOptions struct {
+	// ContentType sets the content type of the response, defaulting to any
+	// set on the response already or else "application/octet-stream".
+	ContentType string
+
+	// FlushInterval sets how often written data is flushed to the client,
+	// where a zero value leaves flushing to the function streaming it.
+	FlushInterval time.Duration
+}
+
+// Stream streams the response written by fn to the client using chunked
+// transfer, where flush sends whatever was written so far. See StreamWith.
+func (r *Request) Stream(fn func(w io.Writer, flush func()) error) error {
+	return r.StreamWith(StreamOptions{}, fn)
+}
+
+// StreamWith streams the response written by fn to the client using chunked
+// transfer, responding with a 200 status unless one was written already.
+// Written data is flushed periodically if the options' FlushInterval is set
+// and once fn returns. Writes fail with ErrClientGone once the client
+// disconnects, which fn should return on, as should long running fns
+// watching the request's context.
+func (r *Request) StreamWith(opts StreamOptions, fn func(w io.Writer, flush func()) error) error {
+	header := r.Res.Header()
+	header.Del("Content-Length")
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	if opts.ContentType != "" {
+		header.Set("Content-Type", opts.ContentType)
+	} else if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/octet-stream")
+	}
+
+	if !r.Res.StatusWritten() {
+		r.Res.WriteHeader(http.StatusOK)
+	}
+
+	sw := &streamWriter{res: r.Res, done: r.Req.Context().Done()}
+
+	if opts.FlushInterval > 0 {
+		ticker := time.NewTicker(opts.FlushInterval)
+		stop := make(chan struct{})
+		stopped := make(chan struct{})
+
+		// The flusher must be done with the response before it is
+		// finished.
+		defer func() {
+			ticker.Stop()
+			close(stop)
+			<-stopped
+		}()
+
+		go func() {
+			defer close(stopped)
+
+			for {
+				select {
+				case <-ticker.C:
+					sw.Flush()
+				case <-stop:
+					return
+				case <-sw.done:
+					return
+				}
+			}
+		}()
+	}
+
+	err := fn(sw, sw.Flush)
+	sw.Flush()
+
+	return err
+}
+
+// streamWriter defines a writer of streamed responses, which guards the
+// response against concurrent flushes.
+type streamWriter struct {
+	res  ResponseWriter
+	done <-chan struct{}
+	ml   sync.Mutex
+}
+
+// Write writes the data, failing with ErrClientGone once the client has
+// disconnected.
+func (s *streamWriter) Write(data []byte) (int, error) {
+	select {
+	case <-s.done:
+		return 0, ErrClientGone
+	default:
+	}
+
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	return s.res.Write(data)
+}
+
+// Flush flushes the written data to the client, unless it has disconnected.
+func (s *streamWriter) Flush() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	s.res.Flush()
+}
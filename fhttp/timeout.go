@@ -0,0 +1,253 @@
+package fhttp
+
+import (
+	"bufio"
+	stdcontext "context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// ErrTimeout is returned when writing the response of a request which has
+// already been responded to by Timeout.
+var ErrTimeout = errors.New("Request timed out")
+
+// Timeout returns a DriveMiddleware which gives every request d to be handled,
+// responding with a 503 status if nothing was written by then. See
+// TimeoutWithStatus.
+func Timeout(d time.Duration) DriveMiddleware {
+	return TimeoutWithStatus(d, http.StatusServiceUnavailable)
+}
+
+// TimeoutWithStatus returns a DriveMiddleware which gives every request d to
+// be handled, responding with the provided status, such as 503 or 504, if
+// nothing was written by then. The deadline is set on the standard library
+// context stored within the context through fractals.WithContext and on the
+// request, so handlers watching either are aborted once it passes, while
+// anything written afterwards fails with ErrTimeout. It can be used globally
+// or per Endpoint as it's LocalMW, where the earliest deadline wins.
+func TimeoutWithStatus(d time.Duration, status int) DriveMiddleware {
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		std, cancel := stdcontext.WithTimeout(rw.Req.Context(), d)
+
+		fractals.WithContext(ctx, std)
+		rw.Req = rw.Req.WithContext(std)
+
+		tw := &timeoutWriter{
+			ResponseWriter: rw.Res,
+			req:            rw.Req,
+			ctx:            std,
+			header:         rw.Res.Header().Clone(),
+			status:         status,
+			cancel:         cancel,
+		}
+
+		tw.timer = time.AfterFunc(d, tw.timeout)
+		rw.Res = tw
+
+		return rw, nil
+	}
+}
+
+// timeoutWriter defines a ResponseWriter which responds with an error once
+// the deadline passes, if nothing was written by then. Handlers write their
+// headers into a header of their own, which is only copied over once they
+// write, as they may still be running while the timeout is responded to.
+type timeoutWriter struct {
+	ResponseWriter
+	req    *http.Request
+	ctx    stdcontext.Context
+	header http.Header
+	status int
+	cancel stdcontext.CancelFunc
+	timer  *time.Timer
+
+	ml       sync.Mutex
+	wrote    bool
+	timedOut bool
+	closed   bool
+}
+
+// WriteHeader writes the status, unless the request has timed out.
+func (t *timeoutWriter) WriteHeader(status int) {
+	t.ml.Lock()
+	defer t.ml.Unlock()
+
+	if t.expired() {
+		return
+	}
+
+	t.writeHeader(status)
+}
+
+// Header returns the header of the handlers' response.
+func (t *timeoutWriter) Header() http.Header {
+	return t.header
+}
+
+// Write writes the data, failing with ErrTimeout if the request has timed
+// out.
+func (t *timeoutWriter) Write(data []byte) (int, error) {
+	t.ml.Lock()
+	defer t.ml.Unlock()
+
+	if t.expired() {
+		return 0, ErrTimeout
+	}
+
+	if !t.wrote {
+		t.writeHeader(http.StatusOK)
+	}
+
+	return t.ResponseWriter.Write(data)
+}
+
+// writeHeader copies the handlers' header over, writing the status. It
+// expects the lock to be held.
+func (t *timeoutWriter) writeHeader(status int) {
+	if t.wrote {
+		return
+	}
+
+	t.wrote = true
+
+	header := t.ResponseWriter.Header()
+	for key := range header {
+		delete(header, key)
+	}
+
+	for key, values := range t.header {
+		header[key] = values
+	}
+
+	t.ResponseWriter.WriteHeader(status)
+}
+
+// Flush flushes the written data, unless the request has timed out.
+func (t *timeoutWriter) Flush() {
+	t.ml.Lock()
+	defer t.ml.Unlock()
+
+	if !t.timedOut {
+		t.ResponseWriter.Flush()
+	}
+}
+
+// Close stops the deadline, releasing it's resources, and closes the inner
+// writer.
+func (t *timeoutWriter) Close() error {
+	t.timer.Stop()
+	t.cancel()
+
+	t.ml.Lock()
+	defer t.ml.Unlock()
+
+	// The timer may have fired already, waiting on the lock to respond.
+	t.closed = true
+
+	if closer, ok := t.ResponseWriter.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// Hijack hands over the connection, as needed by WebSocket endpoints, unless
+// the request has timed out. No timeout response is written to hijacked
+// connections, though the request's context still ends at the deadline.
+func (t *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	t.ml.Lock()
+	defer t.ml.Unlock()
+
+	if t.expired() {
+		return nil, nil, ErrTimeout
+	}
+
+	hijacker, ok := t.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrHijackUnsupported
+	}
+
+	conn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The connection belongs to the caller now, so no timeout response may
+	// be written to it.
+	t.timer.Stop()
+	t.wrote = true
+
+	return conn, brw, nil
+}
+
+// timeout responds with the timeout status if nothing was written yet.
+func (t *timeoutWriter) timeout() {
+	t.cancel()
+
+	t.ml.Lock()
+	defer t.ml.Unlock()
+
+	t.respondTimeout()
+}
+
+// expired returns true/false if the request has timed out, responding with
+// the timeout status if the deadline passed before the timer got to. It
+// expects the lock to be held.
+func (t *timeoutWriter) expired() bool {
+	if !t.wrote && t.ctx.Err() == stdcontext.DeadlineExceeded {
+		t.respondTimeout()
+	}
+
+	return t.timedOut
+}
+
+// respondTimeout responds with the timeout status if nothing was written yet
+// and the response is not closed. It expects the lock to be held.
+func (t *timeoutWriter) respondTimeout() {
+	if t.wrote || t.timedOut || t.closed {
+		return
+	}
+
+	t.timedOut = true
+	RenderErrorWithStatus(t.status, ErrTimeout, t.req, t.ResponseWriter)
+	t.ResponseWriter.Flush()
+}
+
+// Status returns the status code of the response or 0 if none was written.
+func (t *timeoutWriter) Status() int {
+	t.ml.Lock()
+	defer t.ml.Unlock()
+
+	return t.ResponseWriter.Status()
+}
+
+// StatusWritten returns true/false if the status was written.
+func (t *timeoutWriter) StatusWritten() bool {
+	t.ml.Lock()
+	defer t.ml.Unlock()
+
+	return t.ResponseWriter.StatusWritten()
+}
+
+// DataWritten returns true/false if Write was called.
+func (t *timeoutWriter) DataWritten() bool {
+	t.ml.Lock()
+	defer t.ml.Unlock()
+
+	return t.ResponseWriter.DataWritten()
+}
+
+// Size returns the size of the response body.
+func (t *timeoutWriter) Size() int {
+	t.ml.Lock()
+	defer t.ml.Unlock()
+
+	return t.ResponseWriter.Size()
+}
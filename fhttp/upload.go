@@ -0,0 +1,302 @@
+package fhttp
+
+import (
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+	"github.com/influx6/fractals/fs"
+)
+
+var (
+	// ErrUploadTooLarge is returned when reading an uploaded file larger than
+	// the allowed size.
+	ErrUploadTooLarge = errors.New("Uploaded file exceeds the allowed size")
+
+	// ErrTooManyFiles is returned when a request uploads more files than
+	// allowed.
+	ErrTooManyFiles = errors.New("Too many uploaded files")
+
+	// ErrTooManyFields is returned when a request sends more non-file fields
+	// than allowed.
+	ErrTooManyFields = errors.New("Too many upload fields")
+
+	// ErrFieldsTooLarge is returned when the non-file fields of a request
+	// together exceed the allowed size.
+	ErrFieldsTooLarge = errors.New("Upload fields exceed the allowed size")
+
+	// ErrUploadFailed is reported for uploaded files whoes pipeline failed,
+	// in place of the pipeline's own error.
+	ErrUploadFailed = errors.New("Uploaded file could not be processed")
+)
+
+const (
+	// DefaultMaxFieldSize defines the size non-file fields of uploads are
+	// limited to when UploadOptions provides none.
+	DefaultMaxFieldSize = 1 << 20
+
+	// DefaultMaxFields defines how many non-file fields uploads are limited
+	// to when UploadOptions provides none.
+	DefaultMaxFields = 1000
+
+	// DefaultMaxFieldsSize defines the size all non-file fields of uploads
+	// are limited to together when UploadOptions provides none.
+	DefaultMaxFieldsSize = 10 << 20
+)
+
+// UploadOptions defines the configuration used by MultipartUpload.
+type UploadOptions struct {
+	// Pipeline returns the Handler each uploaded file is streamed into as a
+	// *UploadPart, such as one ending in fs.WriteFileAtomic, whoes result is
+	// responded with. It is required.
+	Pipeline func(part *UploadPart) fractals.Handler
+
+	// MaxFileSize sets the size uploaded files are limited to, reading past
+	// it fails with ErrUploadTooLarge. A zero value sets no limit.
+	MaxFileSize int64
+
+	// MaxFiles sets how many files a request may upload, where a zero value
+	// sets no limit.
+	MaxFiles int
+
+	// MaxFieldSize sets the size each non-file field is limited to,
+	// defaulting to DefaultMaxFieldSize.
+	MaxFieldSize int64
+
+	// MaxFields sets how many non-file fields a request may send, defaulting
+	// to DefaultMaxFields.
+	MaxFields int
+
+	// MaxFieldsSize sets the size all non-file fields of a request are
+	// limited to together, defaulting to DefaultMaxFieldsSize.
+	MaxFieldsSize int64
+
+	// OnError, if set, is called with the error of every file whoes pipeline
+	// failed. Such files are reported to the client with ErrUploadFailed, so
+	// the error's detail is only seen here.
+	OnError func(part *UploadPart, err error)
+
+	// Checksum, if set, has the digest of every file computed while it is
+	// streamed, which is reported within it's result.
+	Checksum fs.HashAlgorithm
+}
+
+// UploadPart defines a single file of a multipart upload, being read straight
+// from the request's body. It's Filename is the base name sent by the client,
+// which should be checked before being used as a path.
+type UploadPart struct {
+	Field       string
+	Filename    string
+	ContentType string
+
+	reader io.Reader
+	size   int64
+	limit  int64
+	hash   hash.Hash
+}
+
+// Read reads the file's contents, failing with ErrUploadTooLarge once it
+// exceeds the allowed size.
+func (p *UploadPart) Read(data []byte) (int, error) {
+	n, err := p.reader.Read(data)
+	p.size += int64(n)
+
+	if p.limit > 0 && p.size > p.limit {
+		return n, ErrUploadTooLarge
+	}
+
+	if p.hash != nil {
+		p.hash.Write(data[:n])
+	}
+
+	return n, err
+}
+
+// Size returns the bytes read of the file so far.
+func (p *UploadPart) Size() int64 {
+	return p.size
+}
+
+// UploadFileResult defines the outcome of streaming a single uploaded file.
+type UploadFileResult struct {
+	Field    string      `json:"field"`
+	Filename string      `json:"filename"`
+	Size     int64       `json:"size"`
+	Checksum string      `json:"checksum,omitempty"`
+	Result   interface{} `json:"result,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// UploadResult defines the response of MultipartUpload.
+type UploadResult struct {
+	Files  []UploadFileResult `json:"files"`
+	Fields map[string]string  `json:"fields,omitempty"`
+}
+
+// MultipartUpload returns an Endpoint action which streams every file of a
+// multipart/form-data request into the Handler returned by the options'
+// Pipeline, without buffering whole files in memory, collecting the other
+// fields as they are. It responds with an UploadResult holding the outcome of
+// every file, with a 200 status if all succeeded or a 400 status if any
+// failed. Requests which are not valid multipart bodies are responded to with
+// a 400 status.
+func MultipartUpload(opts UploadOptions) func(context.Context, *Request) error {
+	if opts.Pipeline == nil {
+		panic("Expected UploadOptions.Pipeline to be set")
+	}
+
+	if opts.MaxFieldSize == 0 {
+		opts.MaxFieldSize = DefaultMaxFieldSize
+	}
+
+	if opts.MaxFields == 0 {
+		opts.MaxFields = DefaultMaxFields
+	}
+
+	if opts.MaxFieldsSize == 0 {
+		opts.MaxFieldsSize = DefaultMaxFieldsSize
+	}
+
+	return func(ctx context.Context, rw *Request) error {
+		reader, err := rw.Req.MultipartReader()
+		if err != nil {
+			rw.RespondError(http.StatusBadRequest, err)
+			return nil
+		}
+
+		var result UploadResult
+		var fields int
+		var fieldsSize int64
+		failed := false
+
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				rw.RespondError(http.StatusBadRequest, err)
+				return nil
+			}
+
+			if part.FileName() == "" {
+				if fields++; fields > opts.MaxFields {
+					part.Close()
+					rw.RespondError(http.StatusRequestEntityTooLarge, ErrTooManyFields)
+					return nil
+				}
+
+				value, err := ioutil.ReadAll(io.LimitReader(part, opts.MaxFieldSize+1))
+				part.Close()
+
+				if err != nil {
+					rw.RespondError(http.StatusBadRequest, err)
+					return nil
+				}
+
+				if int64(len(value)) > opts.MaxFieldSize {
+					rw.RespondError(http.StatusRequestEntityTooLarge, errors.New("Upload field exceeds the allowed size"))
+					return nil
+				}
+
+				if fieldsSize += int64(len(value)); fieldsSize > opts.MaxFieldsSize {
+					rw.RespondError(http.StatusRequestEntityTooLarge, ErrFieldsTooLarge)
+					return nil
+				}
+
+				if result.Fields == nil {
+					result.Fields = make(map[string]string)
+				}
+
+				result.Fields[part.FormName()] = string(value)
+				continue
+			}
+
+			if opts.MaxFiles > 0 && len(result.Files) >= opts.MaxFiles {
+				part.Close()
+				rw.RespondError(http.StatusRequestEntityTooLarge, ErrTooManyFiles)
+				return nil
+			}
+
+			upload := &UploadPart{
+				Field:       part.FormName(),
+				Filename:    part.FileName(),
+				ContentType: part.Header.Get("Content-Type"),
+				reader:      part,
+				limit:       opts.MaxFileSize,
+			}
+
+			if opts.Checksum != "" {
+				if upload.hash, err = opts.Checksum.New(); err != nil {
+					part.Close()
+					return err
+				}
+			}
+
+			file := streamPart(ctx, opts, upload)
+			part.Close()
+
+			if file.Error != "" {
+				failed = true
+			}
+
+			result.Files = append(result.Files, file)
+		}
+
+		status := http.StatusOK
+		if failed {
+			status = http.StatusBadRequest
+		}
+
+		rw.Respond(status, result)
+		return nil
+	}
+}
+
+// streamPart streams the uploaded file into it's pipeline, returning the
+// outcome.
+func streamPart(ctx context.Context, opts UploadOptions, upload *UploadPart) UploadFileResult {
+	file := UploadFileResult{
+		Field:    upload.Field,
+		Filename: upload.Filename,
+	}
+
+	value, err := opts.Pipeline(upload)(ctx, nil, upload)
+
+	// Whatever the pipeline left unread still counts towards the size and
+	// checksum of the file.
+	if err == nil {
+		_, err = io.Copy(ioutil.Discard, upload)
+	}
+
+	file.Size = upload.Size()
+
+	if err != nil {
+		if opts.OnError != nil {
+			opts.OnError(upload, err)
+		}
+
+		// Only the size limit is reported as is, as other errors of the
+		// pipeline could expose details of the server, such as paths.
+		if err != ErrUploadTooLarge {
+			err = ErrUploadFailed
+		}
+
+		file.Error = err.Error()
+		return file
+	}
+
+	file.Result = value
+
+	if upload.hash != nil {
+		file.Checksum = hex.EncodeToString(upload.hash.Sum(nil))
+	}
+
+	return file
+}
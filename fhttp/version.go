@@ -0,0 +1,111 @@
+package fhttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influx6/faux/context"
+)
+
+// ErrVersionRetired is returned when a request is made to an API version past
+// it's sunset.
+var ErrVersionRetired = errors.New("API version has been retired")
+
+// versionKey defines the key the API version is stored under within the
+// context.
+const versionKey = "fhttp.version"
+
+// VersionOptions defines the lifecycle of an API version mounted with
+// HTTPDrive.Version.
+type VersionOptions struct {
+	// Deprecated marks the version as deprecated, sending the Deprecation
+	// header with every response.
+	Deprecated bool
+
+	// DeprecatedAt, if set, sets when the version was or will be deprecated,
+	// sent as the Deprecation header's date, which implies Deprecated.
+	DeprecatedAt time.Time
+
+	// Sunset, if set, sets when the version stops being served, sent as the
+	// Sunset header.
+	Sunset time.Time
+
+	// Link, if set, sets the URL documenting the deprecation, such as a
+	// migration guide, sent within a Link header.
+	Link string
+
+	// RejectAfterSunset responds to requests made past the Sunset with a 410
+	// status.
+	RejectAfterSunset bool
+}
+
+// Version returns a RouteGroup whoes endpoints are mounted under the version
+// as their path prefix, such as "/v1", and as their names' namespace, such as
+// "v1.user". The version is stored within the context, where it is available
+// through Request.APIVersion, and responses of deprecated versions carry the
+// Deprecation, Sunset and Link headers. The same endpoints can be mounted for
+// several versions with RouteGroup.HandleAll.
+func (hd *HTTPDrive) Version(version string, opts VersionOptions, mw ...DriveMiddleware) *RouteGroup {
+	version = strings.Trim(version, "/")
+
+	group := hd.Group("/"+version, append([]DriveMiddleware{versionMW(version, opts)}, mw...)...)
+	group.namespace = version
+
+	return group
+}
+
+// versionMW returns a DriveMiddleware which stores the version within the
+// context and signals it's deprecation.
+func versionMW(version string, opts VersionOptions) DriveMiddleware {
+	var deprecation string
+
+	switch {
+	case !opts.DeprecatedAt.IsZero():
+		deprecation = "@" + strconv.FormatInt(opts.DeprecatedAt.Unix(), 10)
+	case opts.Deprecated:
+		deprecation = "true"
+	}
+
+	return func(ctx context.Context, rw *Request) (*Request, error) {
+		ctx.Set(versionKey, version)
+
+		header := rw.Res.Header()
+
+		if deprecation != "" {
+			header.Set("Deprecation", deprecation)
+		}
+
+		if !opts.Sunset.IsZero() {
+			header.Set("Sunset", opts.Sunset.UTC().Format(http.TimeFormat))
+		}
+
+		if opts.Link != "" {
+			header.Add("Link", "<"+opts.Link+`>; rel="deprecation"`)
+		}
+
+		if opts.RejectAfterSunset && !opts.Sunset.IsZero() && time.Now().After(opts.Sunset) {
+			rw.RespondError(http.StatusGone, ErrVersionRetired)
+			return nil, ErrVersionRetired
+		}
+
+		return rw, nil
+	}
+}
+
+// APIVersion returns the API version added to the context by the group
+// returned from HTTPDrive.Version, returning an empty string if none was.
+func (r *Request) APIVersion(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	version, ok := ctx.Get(versionKey)
+	if !ok {
+		return ""
+	}
+
+	return version.(string)
+}
@@ -0,0 +1,585 @@
+package fhttp
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+var (
+	// ErrNotWebSocket is returned when a request to a WebSocket endpoint is
+	// not a valid WebSocket upgrade request.
+	ErrNotWebSocket = errors.New("Expected WebSocket upgrade request")
+
+	// ErrBadOrigin is returned when the Origin of a WebSocket upgrade request
+	// is not allowed.
+	ErrBadOrigin = errors.New("WebSocket request origin not allowed")
+
+	// ErrHijackUnsupported is returned when the ResponseWriter of a WebSocket
+	// upgrade request can not be hijacked.
+	ErrHijackUnsupported = errors.New("ResponseWriter does not support hijacking")
+
+	// ErrWSClosed is returned when reading from or writing to a closed
+	// WebSocket connection.
+	ErrWSClosed = errors.New("WebSocket connection closed")
+
+	// ErrWSProtocol is returned when a client violates the WebSocket protocol.
+	ErrWSProtocol = errors.New("WebSocket protocol error")
+
+	// ErrWSMessageTooLarge is returned when a client sends a message larger
+	// than allowed.
+	ErrWSMessageTooLarge = errors.New("WebSocket message too large")
+)
+
+// DefaultWSMaxMessageSize defines the size in bytes of the largest message
+// read from clients when WSOptions provides none.
+const DefaultWSMaxMessageSize = 1 << 20
+
+// WSMessageType defines the type of a WebSocket message.
+type WSMessageType int
+
+// contains the WebSocket message types.
+const (
+	WSText   WSMessageType = 1
+	WSBinary WSMessageType = 2
+)
+
+// contains the WebSocket close codes used by WSConn.
+const (
+	WSCloseNormal        = 1000
+	WSCloseGoingAway     = 1001
+	WSCloseProtocolError = 1002
+	WSCloseTooLarge      = 1009
+	WSCloseInternalError = 1011
+)
+
+// contains the opcodes of the WebSocket frames.
+const (
+	wsOpContinuation = 0
+	wsOpText         = 1
+	wsOpBinary       = 2
+	wsOpClose        = 8
+	wsOpPing         = 9
+	wsOpPong         = 10
+)
+
+// wsGUID defines the GUID used to compute the Sec-WebSocket-Accept header.
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WSMessage defines a single message received from or sent to a client.
+type WSMessage struct {
+	Type WSMessageType
+	Data []byte
+}
+
+// WSOptions defines the configuration used by WebSocketWith.
+type WSOptions struct {
+	// MaxMessageSize sets the size in bytes of the largest message read from
+	// clients, defaulting to DefaultWSMaxMessageSize.
+	MaxMessageSize int64
+
+	// CheckOrigin returns true/false if the request's Origin is allowed. If
+	// nil, only requests without an Origin or from the same host are allowed.
+	CheckOrigin func(*http.Request) bool
+}
+
+// WebSocket returns an Endpoint action which upgrades requests into WebSocket
+// connections using the default WSOptions, calling onConnect with the
+// connection. See WebSocketWith.
+func WebSocket(onConnect func(context.Context, *WSConn) error) func(context.Context, *Request) error {
+	return WebSocketWith(WSOptions{}, onConnect)
+}
+
+// WebSocketWith returns an Endpoint action which upgrades requests into
+// WebSocket connections, calling onConnect with the connection. Requests which
+// fail to upgrade are responded to with their error, while the connection is
+// closed once onConnect returns, with an error closing it with
+// WSCloseInternalError.
+func WebSocketWith(opts WSOptions, onConnect func(context.Context, *WSConn) error) func(context.Context, *Request) error {
+	if opts.MaxMessageSize <= 0 {
+		opts.MaxMessageSize = DefaultWSMaxMessageSize
+	}
+
+	if opts.CheckOrigin == nil {
+		opts.CheckOrigin = sameOrigin
+	}
+
+	return func(ctx context.Context, r *Request) error {
+		conn, err := upgradeWS(r, opts)
+		if err != nil {
+			return err
+		}
+
+		defer conn.Close()
+
+		if err := onConnect(ctx, conn); err != nil {
+			conn.CloseWith(WSCloseInternalError, err.Error())
+		}
+
+		return nil
+	}
+}
+
+// upgradeWS validates the WebSocket upgrade request, hijacking it's
+// connection and completing the handshake.
+func upgradeWS(r *Request, opts WSOptions) (*WSConn, error) {
+	if r.Req.Method != "GET" || !headerHasToken(r.Req.Header, "Connection", "upgrade") || !headerHasToken(r.Req.Header, "Upgrade", "websocket") {
+		return nil, ErrNotWebSocket
+	}
+
+	if r.Req.Header.Get("Sec-WebSocket-Version") != "13" {
+		r.Res.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, ErrNotWebSocket
+	}
+
+	key := r.Req.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, ErrNotWebSocket
+	}
+
+	if !opts.CheckOrigin(r.Req) {
+		return nil, ErrBadOrigin
+	}
+
+	hijacker, ok := r.Res.(http.Hijacker)
+	if !ok {
+		return nil, ErrHijackUnsupported
+	}
+
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAccept(key) + "\r\n\r\n"
+
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &WSConn{
+		req:     r,
+		conn:    netConn,
+		reader:  brw.Reader,
+		maxSize: opts.MaxMessageSize,
+	}, nil
+}
+
+// wsAccept returns the Sec-WebSocket-Accept value for the key.
+func wsAccept(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerHasToken returns true/false if the comma separated values of the
+// header contain the token.
+func headerHasToken(header http.Header, name string, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sameOrigin returns true/false if the request has no Origin or one matching
+// it's host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// WSConn defines a WebSocket connection to a single client. Messages may be
+// written concurrently, but must only be read from a single goroutine, either
+// through ReadMessage, Messages or Serve.
+type WSConn struct {
+	req     *Request
+	conn    net.Conn
+	reader  *bufio.Reader
+	maxSize int64
+
+	wl     sync.Mutex
+	closed bool
+
+	once     sync.Once
+	messages fractals.Observable
+}
+
+// Request returns the request the connection was upgraded from.
+func (c *WSConn) Request() *Request {
+	return c.req
+}
+
+// ReadMessage returns the next message sent by the client, answering pings
+// as they arrive. It returns ErrWSClosed once the client closes the
+// connection.
+func (c *WSConn) ReadMessage() (WSMessage, error) {
+	var message WSMessage
+	var started bool
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return WSMessage{}, c.fail(err)
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return WSMessage{}, err
+			}
+
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			code := WSCloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+
+			c.CloseWith(code, "")
+			return WSMessage{}, ErrWSClosed
+		case wsOpText, wsOpBinary:
+			if started {
+				return WSMessage{}, c.fail(ErrWSProtocol)
+			}
+
+			started = true
+			message.Type = WSMessageType(opcode)
+		case wsOpContinuation:
+			if !started {
+				return WSMessage{}, c.fail(ErrWSProtocol)
+			}
+		default:
+			return WSMessage{}, c.fail(ErrWSProtocol)
+		}
+
+		if int64(len(message.Data)+len(payload)) > c.maxSize {
+			return WSMessage{}, c.fail(ErrWSMessageTooLarge)
+		}
+
+		message.Data = append(message.Data, payload...)
+
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// WriteMessage writes the data to the client as a message of the provided
+// type.
+func (c *WSConn) WriteMessage(kind WSMessageType, data []byte) error {
+	return c.writeFrame(int(kind), data)
+}
+
+// Send writes the item to the client, sending strings and valid UTF-8 []byte
+// as text messages, other []byte as binary messages and all other values as
+// JSON text messages.
+func (c *WSConn) Send(item interface{}) error {
+	switch data := item.(type) {
+	case WSMessage:
+		return c.WriteMessage(data.Type, data.Data)
+	case string:
+		return c.WriteMessage(WSText, []byte(data))
+	case []byte:
+		if utf8.Valid(data) {
+			return c.WriteMessage(WSText, data)
+		}
+
+		return c.WriteMessage(WSBinary, data)
+	default:
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		return c.WriteMessage(WSText, encoded)
+	}
+}
+
+// Messages returns a Observable which emits the data of every message sent by
+// the client as a []byte, allowing the same handlers used on request bodies
+// to process them. The connection is read from within a goroutine once the
+// Observable receives it's first subscriber, signaling Done with true once
+// the client closes the connection and passing any other failure through
+// Error.
+func (c *WSConn) Messages() fractals.Observable {
+	c.once.Do(func() {
+		c.messages = &wsObservable{
+			Observable: fractals.NewObservable(fractals.IdentityBehaviour(), false),
+			conn:       c,
+		}
+	})
+
+	return c.messages
+}
+
+// Writer returns a Observable which writes every item it receives to the
+// client through Send, so it can subscribe to the Observables producing the
+// responses.
+func (c *WSConn) Writer() fractals.Observable {
+	return fractals.NewObservable(fractals.Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				return nil, err
+			}
+
+			if err := c.Send(item); err != nil {
+				return nil, err
+			}
+
+			return item, nil
+		},
+	}, false)
+}
+
+// Serve reads every message sent by the client, passing it's data as a
+// []byte to the Handler and sending any non-nil result back through Send. It
+// returns nil once the client closes the connection, or the first error
+// returned by the Handler.
+func (c *WSConn) Serve(ctx context.Context, h fractals.Handler) error {
+	for {
+		message, err := c.ReadMessage()
+		if err == ErrWSClosed {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		res, err := h(ctx, nil, message.Data)
+		if err != nil {
+			return err
+		}
+
+		if res == nil {
+			continue
+		}
+
+		if err := c.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the connection with WSCloseNormal.
+func (c *WSConn) Close() error {
+	return c.CloseWith(WSCloseNormal, "")
+}
+
+// CloseWith sends a close message with the code and reason to the client,
+// closing the connection. Closing an already closed connection does nothing.
+func (c *WSConn) CloseWith(code int, reason string) error {
+	c.wl.Lock()
+
+	if c.closed {
+		c.wl.Unlock()
+		return nil
+	}
+
+	// Control frames can not carry more than 125 bytes.
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+
+	werr := c.writeFrameLocked(wsOpClose, payload)
+	c.closed = true
+	c.wl.Unlock()
+
+	if err := c.conn.Close(); err != nil {
+		return err
+	}
+
+	return werr
+}
+
+// fail closes the connection with the close code matching err, returning
+// err or ErrWSClosed if the connection was closed.
+func (c *WSConn) fail(err error) error {
+	switch err {
+	case ErrWSProtocol:
+		c.CloseWith(WSCloseProtocolError, "")
+	case ErrWSMessageTooLarge:
+		c.CloseWith(WSCloseTooLarge, "")
+	case io.EOF, io.ErrUnexpectedEOF:
+		c.CloseWith(WSCloseGoingAway, "")
+		return ErrWSClosed
+	default:
+		c.wl.Lock()
+		closed := c.closed
+		c.wl.Unlock()
+
+		if closed {
+			return ErrWSClosed
+		}
+	}
+
+	return err
+}
+
+// readFrame reads a single frame sent by the client, unmasking it's payload.
+func (c *WSConn) readFrame() (bool, int, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7f)
+
+	// Clients must mask every frame and set no reserved bits.
+	if !masked || header[0]&0x70 != 0 {
+		return false, 0, nil, ErrWSProtocol
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+
+	if opcode >= wsOpClose && (!fin || length > 125) {
+		return false, 0, nil, ErrWSProtocol
+	}
+
+	if length < 0 || length > c.maxSize {
+		return false, 0, nil, ErrWSMessageTooLarge
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes the payload to the client as a single frame.
+func (c *WSConn) writeFrame(opcode int, payload []byte) error {
+	c.wl.Lock()
+	defer c.wl.Unlock()
+
+	return c.writeFrameLocked(opcode, payload)
+}
+
+// writeFrameLocked writes the payload to the client as a single frame,
+// expecting the write lock to be held.
+func (c *WSConn) writeFrameLocked(opcode int, payload []byte) error {
+	if c.closed {
+		return ErrWSClosed
+	}
+
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|byte(opcode))
+
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 126, byte(length>>8), byte(length))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(length))
+		frame = append(append(frame, 127), ext[:]...)
+	}
+
+	frame = append(frame, payload...)
+
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// wsObservable defines a Observable which starts reading messages from it's
+// connection on it's first subscription.
+type wsObservable struct {
+	fractals.Observable
+	conn *WSConn
+	once sync.Once
+}
+
+// Subscribe connects the giving Observer and starts reading messages if it
+// is the first subscription.
+func (w *wsObservable) Subscribe(b fractals.Observable, finalizers ...func()) *fractals.Subscription {
+	sub := w.Observable.Subscribe(b, finalizers...)
+
+	w.once.Do(func() {
+		go func() {
+			ctx := context.New()
+
+			for {
+				message, err := w.conn.ReadMessage()
+				if err == ErrWSClosed {
+					break
+				}
+
+				if err != nil {
+					w.Error(ctx, err)
+					return
+				}
+
+				w.Next(ctx, message.Data)
+			}
+
+			w.Done(ctx, true)
+		}()
+	})
+
+	return sub
+}
@@ -0,0 +1,237 @@
+package fhttp_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+	"github.com/influx6/fractals/fhttp"
+)
+
+func TestWebSocket(t *testing.T) {
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/ws",
+		Method: "GET",
+		Action: fhttp.WebSocket(func(ctx context.Context, conn *fhttp.WSConn) error {
+			return conn.Serve(ctx, fractals.MustWrap(func(ctx context.Context, data []byte) string {
+				return strings.ToUpper(string(data))
+			}))
+		}),
+	})
+
+	server := httptest.NewServer(drive)
+	defer server.Close()
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/ws", nil)
+	drive.ServeHTTP(record, request)
+
+	if record.Code != http.StatusBadRequest {
+		fatalFailed(t, "Should have rejected plain request but got %d", record.Code)
+	}
+	logPassed(t, "Should have rejected plain request")
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		fatalFailed(t, "Should have connected to server: %s", err)
+	}
+	defer conn.Close()
+
+	io.WriteString(conn, "GET /ws HTTP/1.1\r\n"+
+		"Host: "+strings.TrimPrefix(server.URL, "http://")+"\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n")
+
+	reader := bufio.NewReader(conn)
+
+	res, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		fatalFailed(t, "Should have read handshake response: %s", err)
+	}
+
+	if res.StatusCode != http.StatusSwitchingProtocols || res.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		fatalFailed(t, "Should have completed handshake but got %d %+v", res.StatusCode, res.Header)
+	}
+	logPassed(t, "Should have completed handshake")
+
+	writeClientFrame(conn, 0x9, []byte("ping"))
+
+	if opcode, data := readServerFrame(t, reader); opcode != 0xA || string(data) != "ping" {
+		fatalFailed(t, "Should have answered ping with pong but got %d %q", opcode, data)
+	}
+	logPassed(t, "Should have answered ping with pong")
+
+	writeClientFrame(conn, 0x1, []byte("hello"))
+
+	if opcode, data := readServerFrame(t, reader); opcode != 0x1 || string(data) != "HELLO" {
+		fatalFailed(t, "Should have responded through handler but got %d %q", opcode, data)
+	}
+	logPassed(t, "Should have responded through handler")
+
+	writeClientFrame(conn, 0x8, []byte{0x03, 0xE8})
+
+	if opcode, data := readServerFrame(t, reader); opcode != 0x8 || binary.BigEndian.Uint16(data) != fhttp.WSCloseNormal {
+		fatalFailed(t, "Should have answered close but got %d %v", opcode, data)
+	}
+	logPassed(t, "Should have answered close")
+}
+
+func TestWebSocketMiddlewares(t *testing.T) {
+	drive := fhttp.Drive(
+		fhttp.Timeout(time.Second),
+		fhttp.ResponseCache(fhttp.CacheOptions{TTL: time.Minute}),
+		fhttp.ETag(false),
+		fhttp.Compress(fhttp.CompressOptions{}),
+	)()
+
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/ws",
+		Method: "GET",
+		Action: fhttp.WebSocket(func(ctx context.Context, conn *fhttp.WSConn) error {
+			return conn.Serve(ctx, fractals.MustWrap(func(ctx context.Context, data []byte) string {
+				return strings.ToUpper(string(data))
+			}))
+		}),
+	})
+
+	server := httptest.NewServer(drive)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		fatalFailed(t, "Should have connected to server: %s", err)
+	}
+	defer conn.Close()
+
+	io.WriteString(conn, "GET /ws HTTP/1.1\r\n"+
+		"Host: "+strings.TrimPrefix(server.URL, "http://")+"\r\n"+
+		"Accept-Encoding: gzip\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n")
+
+	reader := bufio.NewReader(conn)
+
+	res, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		fatalFailed(t, "Should have read handshake response: %s", err)
+	}
+
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		fatalFailed(t, "Should have completed handshake through middlewares but got %d", res.StatusCode)
+	}
+	logPassed(t, "Should have completed handshake through middlewares")
+
+	writeClientFrame(conn, 0x1, []byte("hello"))
+
+	if opcode, data := readServerFrame(t, reader); opcode != 0x1 || string(data) != "HELLO" {
+		fatalFailed(t, "Should have responded through handler but got %d %q", opcode, data)
+	}
+	logPassed(t, "Should have responded through handler")
+
+	// The timeout must not write it's response to the hijacked connection,
+	// which is closed through WebSocket once the context ends.
+	time.Sleep(1500 * time.Millisecond)
+
+	writeClientFrame(conn, 0x1, []byte("hello"))
+
+	if opcode, data := readServerFrame(t, reader); opcode != 0x8 || len(data) < 2 || binary.BigEndian.Uint16(data) != fhttp.WSCloseInternalError {
+		fatalFailed(t, "Should have closed connection at deadline but got %d %q", opcode, data)
+	}
+	logPassed(t, "Should have closed connection at deadline")
+}
+
+// writeClientFrame writes a single masked frame as clients do.
+func writeClientFrame(w io.Writer, opcode byte, payload []byte) {
+	mask := []byte{1, 2, 3, 4}
+
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	w.Write(frame)
+}
+
+// readServerFrame reads a single small unmasked frame as sent by servers.
+func readServerFrame(t *testing.T, r io.Reader) (byte, []byte) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		fatalFailed(t, "Should have read frame header: %s", err)
+	}
+
+	payload := make([]byte, header[1]&0x7f)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		fatalFailed(t, "Should have read frame payload: %s", err)
+	}
+
+	return header[0] & 0x0f, payload
+}
+
+func TestProxyWebSocket(t *testing.T) {
+	backend := fhttp.Drive()()
+	fhttp.Route(backend)(fhttp.Endpoint{
+		Path:   "/ws",
+		Method: "GET",
+		Action: fhttp.WebSocket(func(ctx context.Context, conn *fhttp.WSConn) error {
+			return conn.Serve(ctx, fractals.MustWrap(func(ctx context.Context, data []byte) string {
+				return "echo:" + string(data)
+			}))
+		}),
+	})
+
+	upstream := httptest.NewServer(backend)
+	defer upstream.Close()
+
+	drive := fhttp.Drive()()
+	fhttp.Route(drive)(fhttp.Endpoint{
+		Path:   "/api/*path",
+		Method: "GET",
+		Action: fhttp.Proxy(upstream.URL, fhttp.ProxyOptions{StripPrefix: "/api"}),
+	})
+
+	server := httptest.NewServer(drive)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		fatalFailed(t, "Should have connected to server: %s", err)
+	}
+	defer conn.Close()
+
+	io.WriteString(conn, "GET /api/ws HTTP/1.1\r\n"+
+		"Host: "+strings.TrimPrefix(server.URL, "http://")+"\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n")
+
+	reader := bufio.NewReader(conn)
+
+	res, err := http.ReadResponse(reader, nil)
+	if err != nil || res.StatusCode != http.StatusSwitchingProtocols {
+		fatalFailed(t, "Should have passed upgrade through proxy: %v %+v", err, res)
+	}
+	logPassed(t, "Should have passed upgrade through proxy")
+
+	writeClientFrame(conn, 0x1, []byte("hi"))
+
+	if opcode, data := readServerFrame(t, reader); opcode != 0x1 || string(data) != "echo:hi" {
+		fatalFailed(t, "Should have passed messages through proxy but got %d %q", opcode, data)
+	}
+	logPassed(t, "Should have passed messages through proxy")
+}
@@ -6,6 +6,7 @@
 package fractals
 
 import (
+	stdcontext "context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -91,6 +92,11 @@ func MustWrap(node interface{}) Handler {
 // it matches its DataHandler, ErrorHandler, Handler or magic function type.
 // MagicFunction type is a function which follows this type form:
 // func(context.Context, error, <CustomType>).
+// Functions may use either the faux context.Context or the standard library
+// context.Context, where the later receives the context returned by StdContext.
+// If the standard library context associated with the received context has
+// been cancelled, the returned Handler does not call the function and returns
+// the context's error instead.
 func Wrap(node interface{}) Handler {
 	var hl Handler
 
@@ -133,6 +139,35 @@ func Wrap(node interface{}) Handler {
 
 			return d, err
 		}
+	case func(stdcontext.Context, error, interface{}) (interface{}, error):
+		hl = func(ctx context.Context, err error, d interface{}) (interface{}, error) {
+			return mh(StdContext(ctx), err, d)
+		}
+	case func(stdcontext.Context, interface{}) (interface{}, error):
+		hl = func(ctx context.Context, err error, d interface{}) (interface{}, error) {
+			if err != nil {
+				return nil, err
+			}
+
+			return mh(StdContext(ctx), d)
+		}
+	case func(stdcontext.Context, interface{}) interface{}:
+		hl = func(ctx context.Context, err error, d interface{}) (interface{}, error) {
+			if err != nil {
+				return nil, err
+			}
+
+			return mh(StdContext(ctx), d), nil
+		}
+	case func(stdcontext.Context, interface{}):
+		hl = func(ctx context.Context, err error, d interface{}) (interface{}, error) {
+			if err != nil {
+				return nil, err
+			}
+
+			mh(StdContext(ctx), d)
+			return d, nil
+		}
 	case func(interface{}) (interface{}, error):
 		hl = func(ctx context.Context, err error, d interface{}) (interface{}, error) {
 			if err != nil {
@@ -168,13 +203,14 @@ func Wrap(node interface{}) Handler {
 		var dZero reflect.Value
 
 		var useContext bool
+		var useStdContext bool
 		var useErr bool
 		var useData bool
 		var isCustom bool
 
 		// Check if this first item is a context.Context type.
 		if dLen < 2 {
-			useContext, _ = reflection.CanSetForType(ctxType, args[0])
+			useContext, useStdContext = contextArgument(args[0])
 			useErr, _ = reflection.CanSetForType(errorType, args[0])
 
 			if !useErr {
@@ -186,7 +222,7 @@ func Wrap(node interface{}) Handler {
 		}
 
 		if dLen == 2 {
-			useContext, _ = reflection.CanSetForType(ctxType, args[0])
+			useContext, useStdContext = contextArgument(args[0])
 			useErr, _ = reflection.CanSetForType(errorType, args[1])
 
 			if !useErr {
@@ -198,7 +234,7 @@ func Wrap(node interface{}) Handler {
 		}
 
 		if dLen > 2 {
-			useContext, _ = reflection.CanSetForType(ctxType, args[0])
+			useContext, useStdContext = contextArgument(args[0])
 			useErr, _ = reflection.CanSetForType(errorType, args[1])
 
 			data = args[2]
@@ -224,7 +260,7 @@ func Wrap(node interface{}) Handler {
 			md := dZero
 
 			if useContext {
-				mctx = reflect.ValueOf(ctx)
+				mctx = contextValue(ctx, useStdContext)
 			}
 
 			if err != nil {
@@ -323,6 +359,10 @@ func Wrap(node interface{}) Handler {
 		var newErr error
 		var res interface{}
 
+		if cerr := ContextErr(ctx); cerr != nil {
+			return nil, cerr
+		}
+
 		defer func() {
 			if err := recover(); err != nil {
 				if pErr, ok := err.(PanicError); ok {
@@ -603,6 +643,13 @@ func MagicApplier(handle interface{}) SubApplier {
 			return hld(d1, d2)
 		}
 
+	case func(stdcontext.Context, interface{}, interface{}) (interface{}, error):
+		hld := handle.(func(stdcontext.Context, interface{}, interface{}) (interface{}, error))
+
+		hl = func(ctx context.Context, d1 interface{}, d2 interface{}) (interface{}, error) {
+			return hld(StdContext(ctx), d1, d2)
+		}
+
 	default:
 		if !reflection.IsFuncType(handle) {
 			return nil
@@ -617,6 +664,7 @@ func MagicApplier(handle interface{}) SubApplier {
 		}
 
 		var useContext bool
+		var useStdContext bool
 		var useOne bool
 
 		var d1 reflect.Type
@@ -626,7 +674,7 @@ func MagicApplier(handle interface{}) SubApplier {
 		var d2Zero reflect.Value
 
 		if dLen == 2 {
-			useContext, _ = reflection.CanSetForType(ctxType, args[0])
+			useContext, useStdContext = contextArgument(args[0])
 			if useContext {
 				d1 = args[1]
 				d1Zero = reflect.Zero(d1)
@@ -641,7 +689,7 @@ func MagicApplier(handle interface{}) SubApplier {
 		}
 
 		if dLen > 2 {
-			useContext, _ = reflection.CanSetForType(ctxType, args[0])
+			useContext, useStdContext = contextArgument(args[0])
 			if !useContext {
 				return nil
 			}
@@ -658,7 +706,7 @@ func MagicApplier(handle interface{}) SubApplier {
 			var resArgs []reflect.Value
 
 			if useContext {
-				fnArgs = append(fnArgs, reflect.ValueOf(ctx))
+				fnArgs = append(fnArgs, contextValue(ctx, useStdContext))
 			}
 
 			var dv1 reflect.Value
@@ -1209,13 +1257,14 @@ func WrapStreamHandler(h interface{}) StreamHandler {
 		var dZero reflect.Value
 
 		var useContext bool
+		var useStdContext bool
 		var useBool bool
 		var useData bool
 		// var isCustom bool
 
 		// Check if this first item is a context.Context type.
 		if dLen < 2 {
-			useContext, _ = reflection.CanSetForType(ctxType, args[0])
+			useContext, useStdContext = contextArgument(args[0])
 			useBool, _ = reflection.CanSetForType(boolType, args[0])
 
 			if !useBool {
@@ -1227,7 +1276,7 @@ func WrapStreamHandler(h interface{}) StreamHandler {
 		}
 
 		if dLen == 2 {
-			useContext, _ = reflection.CanSetForType(ctxType, args[0])
+			useContext, useStdContext = contextArgument(args[0])
 			useBool, _ = reflection.CanSetForType(boolType, args[1])
 
 			if !useBool {
@@ -1239,7 +1288,7 @@ func WrapStreamHandler(h interface{}) StreamHandler {
 		}
 
 		if dLen > 2 {
-			useContext, _ = reflection.CanSetForType(ctxType, args[0])
+			useContext, useStdContext = contextArgument(args[0])
 			useBool, _ = reflection.CanSetForType(boolType, args[2])
 
 			data = args[1]
@@ -1257,7 +1306,7 @@ func WrapStreamHandler(h interface{}) StreamHandler {
 			md := dZero
 
 			if useContext {
-				mctx = reflect.ValueOf(ctx)
+				mctx = contextValue(ctx, useStdContext)
 			}
 
 			// Flag to skip function if data does not match.
@@ -1,6 +1,7 @@
 package fractals_test
 
 import (
+	stdcontext "context"
 	"errors"
 	"fmt"
 	"sync"
@@ -141,6 +142,32 @@ func TestMultiSelect(t *testing.T) {
 	wg.Wait()
 }
 
+func TestStdContextHandlers(t *testing.T) {
+	pos := fractals.RLift(func(ctx stdcontext.Context, number int) int {
+		if ctx == nil {
+			panic("Expected standard library context")
+		}
+
+		return number * 2
+	})()
+
+	std, cancel := stdcontext.WithCancel(stdcontext.Background())
+	ctx := fractals.NewContext(std)
+
+	res, err := pos(ctx, nil, 30)
+	if err != nil || res != 60 {
+		fatalFailed(t, "Should have returned %d given %d: %s", 60, 30, err)
+	}
+	logPassed(t, "Should have returned %d given %d", 60, 30)
+
+	cancel()
+
+	if _, err := pos(ctx, nil, 30); err != stdcontext.Canceled {
+		fatalFailed(t, "Should have recieved cancellation error but got %s", err)
+	}
+	logPassed(t, "Should have recieved cancellation error")
+}
+
 // BenchmarkWithReflect benches the performance of using the fractals pure functions
 // with using the reflection capibilities of Go to figure out the needed type.
 func BenchmarkWithReflect(b *testing.B) {
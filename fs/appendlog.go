@@ -0,0 +1,375 @@
+package fs
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// recordHeaderSize defines the size of the header written before every record,
+// which holds the length of the record, the CRC32 checksum of the length and
+// the CRC32 checksum of the length and contents together. The length is
+// checked on it's own, so a corrupted length is not mistaken for a record
+// torn at the end of the log.
+const recordHeaderSize = 12
+
+var (
+	// ErrLogClosed is returned when operations are performed against a closed
+	// RecordLog.
+	ErrLogClosed = errors.New("Record log is closed")
+
+	// ErrCorruptRecord is returned when a record's checksum does not match its
+	// contents, including by AppendLog for records before the end of the log.
+	ErrCorruptRecord = errors.New("Record checksum does not match contents")
+)
+
+// RecordLog defines a append only file of length-prefixed, checksummed records.
+// Appends from multiple goroutines are queued into a single writer, while any
+// number of LogCursors can read through the records, tailing new ones as they
+// are appended.
+type RecordLog struct {
+	path   string
+	size   int64
+	writer *os.File
+	reader *os.File
+	queue  chan appendRequest
+	wg     sync.WaitGroup
+
+	cl     sync.RWMutex
+	closed bool
+
+	nl     sync.Mutex
+	notify chan struct{}
+}
+
+type appendRequest struct {
+	data  []byte
+	reply chan appendReply
+}
+
+type appendReply struct {
+	offset int64
+	err    error
+}
+
+// AppendLog opens or creates the record log at the giving path. Any partial or
+// corrupted record at the end of an existing log, left behind by an
+// interrupted write, is truncated away, while corrupted records before it fail
+// with ErrCorruptRecord.
+func AppendLog(path string) (*RecordLog, error) {
+	writer, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := recoverLog(writer)
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+
+	if err := writer.Truncate(size); err != nil {
+		writer.Close()
+		return nil, err
+	}
+
+	reader, err := os.Open(path)
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+
+	rl := &RecordLog{
+		path:   path,
+		size:   size,
+		writer: writer,
+		reader: reader,
+		queue:  make(chan appendRequest),
+		notify: make(chan struct{}),
+	}
+
+	rl.wg.Add(1)
+	go rl.write()
+
+	return rl, nil
+}
+
+// recoverLog walks the records in the file, returning the offset at the end of
+// the last valid record. Only the last record may be torn, as writes only
+// ever get interrupted at the end of the log, being one which runs past the
+// end of the file or whoes contents end it. Corrupted records before it fail
+// with ErrCorruptRecord, leaving the file as is.
+func recoverLog(file *os.File) (int64, error) {
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	size := stat.Size()
+
+	var offset int64
+
+	for {
+		_, next, err := readRecord(file, offset, size)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return offset, nil
+		}
+
+		if err == ErrCorruptRecord && next == size {
+			return offset, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		offset = next
+	}
+}
+
+// readRecord reads the record at the giving offset, returning it's contents
+// and the offset of the record which follows, including for records failing
+// with ErrCorruptRecord whoes length is intact. Records ending past the limit,
+// such as the size of the file, are reported as io.EOF.
+func readRecord(r io.ReaderAt, offset int64, limit int64) ([]byte, int64, error) {
+	if offset+recordHeaderSize > limit {
+		return nil, offset, io.EOF
+	}
+
+	header := make([]byte, recordHeaderSize)
+	if n, err := r.ReadAt(header, offset); err != nil {
+		if err == io.EOF && n > 0 {
+			return nil, offset, io.ErrUnexpectedEOF
+		}
+
+		return nil, offset, err
+	}
+
+	if crc32.ChecksumIEEE(header[:4]) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, offset, ErrCorruptRecord
+	}
+
+	length := int64(binary.BigEndian.Uint32(header[:4]))
+	sum := binary.BigEndian.Uint32(header[8:])
+
+	end := offset + recordHeaderSize + length
+	if end > limit {
+		return nil, offset, io.EOF
+	}
+
+	data := make([]byte, length)
+	if _, err := r.ReadAt(data, offset+recordHeaderSize); err != nil {
+		if err == io.EOF {
+			return nil, offset, io.ErrUnexpectedEOF
+		}
+
+		return nil, offset, err
+	}
+
+	if recordChecksum(header[:4], data) != sum {
+		return nil, end, ErrCorruptRecord
+	}
+
+	return data, end, nil
+}
+
+// recordChecksum returns the checksum of the record's length and contents.
+func recordChecksum(length []byte, data []byte) uint32 {
+	return crc32.Update(crc32.ChecksumIEEE(length), crc32.IEEETable, data)
+}
+
+// write runs the single writer which serializes all appends into the file.
+func (rl *RecordLog) write() {
+	defer rl.wg.Done()
+
+	for req := range rl.queue {
+		offset := atomic.LoadInt64(&rl.size)
+
+		record := make([]byte, recordHeaderSize+len(req.data))
+		binary.BigEndian.PutUint32(record[:4], uint32(len(req.data)))
+		binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(record[:4]))
+		binary.BigEndian.PutUint32(record[8:12], recordChecksum(record[:4], req.data))
+		copy(record[recordHeaderSize:], req.data)
+
+		// Records are written at the known end of the log, which failed
+		// writes are truncated back to, so no partial record is left behind
+		// before the next one.
+		if _, err := rl.writer.WriteAt(record, offset); err != nil {
+			rl.writer.Truncate(offset)
+			req.reply <- appendReply{err: err}
+			continue
+		}
+
+		atomic.StoreInt64(&rl.size, offset+int64(len(record)))
+
+		rl.nl.Lock()
+		close(rl.notify)
+		rl.notify = make(chan struct{})
+		rl.nl.Unlock()
+
+		req.reply <- appendReply{offset: offset}
+	}
+}
+
+// Path returns the path of the log file.
+func (rl *RecordLog) Path() string {
+	return rl.path
+}
+
+// Size returns the total size in bytes of all records written to the log.
+func (rl *RecordLog) Size() int64 {
+	return atomic.LoadInt64(&rl.size)
+}
+
+// Append adds the giving data as a new record at the end of the log, returning
+// the offset at which the record starts.
+func (rl *RecordLog) Append(data []byte) (int64, error) {
+	reply := make(chan appendReply, 1)
+
+	rl.cl.RLock()
+	if rl.closed {
+		rl.cl.RUnlock()
+		return 0, ErrLogClosed
+	}
+
+	rl.queue <- appendRequest{data: data, reply: reply}
+	rl.cl.RUnlock()
+
+	res := <-reply
+	return res.offset, res.err
+}
+
+// Cursor returns a new LogCursor which starts reading from the giving offset,
+// which must be the start of a record, generally 0 or an offset returned by
+// Append or LogCursor.Offset.
+func (rl *RecordLog) Cursor(offset int64) *LogCursor {
+	return &LogCursor{log: rl, offset: offset}
+}
+
+// Close stops the log from accepting appends, waits for queued appends to be
+// written and closes the underline files.
+func (rl *RecordLog) Close() error {
+	rl.cl.Lock()
+	if rl.closed {
+		rl.cl.Unlock()
+		return nil
+	}
+
+	rl.closed = true
+	close(rl.queue)
+	rl.cl.Unlock()
+
+	rl.wg.Wait()
+
+	rl.nl.Lock()
+	close(rl.notify)
+	rl.nl.Unlock()
+
+	if err := rl.writer.Close(); err != nil {
+		rl.reader.Close()
+		return err
+	}
+
+	return rl.reader.Close()
+}
+
+// isClosed returns true/false if the log has been closed.
+func (rl *RecordLog) isClosed() bool {
+	rl.cl.RLock()
+	defer rl.cl.RUnlock()
+	return rl.closed
+}
+
+// changed returns a channel which gets closed when the next record is written
+// or the log is closed.
+func (rl *RecordLog) changed() <-chan struct{} {
+	rl.nl.Lock()
+	defer rl.nl.Unlock()
+	return rl.notify
+}
+
+// LogCursor defines a reader which walks through the records of a RecordLog
+// from a giving offset.
+type LogCursor struct {
+	log    *RecordLog
+	offset int64
+}
+
+// Offset returns the offset of the next record the cursor will read.
+func (c *LogCursor) Offset() int64 {
+	return c.offset
+}
+
+// Next returns the next record in the log, moving the cursor past it. It
+// returns io.EOF when no complete record is available yet.
+func (c *LogCursor) Next() ([]byte, error) {
+	if c.log.isClosed() {
+		return nil, ErrLogClosed
+	}
+
+	data, next, err := readRecord(c.log.reader, c.offset, c.log.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	c.offset = next
+	return data, nil
+}
+
+// Tail returns the next record in the log, blocking until one is appended if
+// the cursor has reached the end of the log. It returns io.EOF if the stop
+// channel gets closed before a record is available and ErrLogClosed if the
+// log gets closed.
+func (c *LogCursor) Tail(stop <-chan struct{}) ([]byte, error) {
+	for {
+		changed := c.log.changed()
+
+		data, err := c.Next()
+		if err != io.EOF {
+			return data, err
+		}
+
+		select {
+		case <-stop:
+			return nil, io.EOF
+		case <-changed:
+		}
+	}
+}
+
+// AppendRecord returns a Handler which appends the []byte it receives as a
+// record into the provided log, passing down the offset of the record.
+func AppendRecord(rl *RecordLog) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, data []byte) (int64, error) {
+		return rl.Append(data)
+	})
+}
+
+// ReadRecords returns a Handler which reads all available records from the
+// offset it receives, passing down the records as a [][]byte.
+func ReadRecords(rl *RecordLog) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, offset int64) ([][]byte, error) {
+		var records [][]byte
+
+		cursor := rl.Cursor(offset)
+		for {
+			data, err := cursor.Next()
+			if err == io.EOF {
+				return records, nil
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			records = append(records, data)
+		}
+	})
+}
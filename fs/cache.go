@@ -0,0 +1,162 @@
+package fs
+
+import (
+	"container/list"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// FileCache defines a cache of file contents and stat results, which are
+// reloaded once the file's modification time or size changes.
+type FileCache struct {
+	maxEntries int
+	ttl        time.Duration
+
+	ml      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// cacheEntry defines the cached details of a single file.
+type cacheEntry struct {
+	path    string
+	info    os.FileInfo
+	data    []byte
+	checked time.Time
+}
+
+// CachedReader returns a new FileCache holding at most maxEntries files,
+// evicting the least recently used ones once full, or without a limit if
+// maxEntries is zero or less. Cached stat results are trusted for ttl, after
+// which the file is checked for changes on it's next read.
+func CachedReader(maxEntries int, ttl time.Duration) *FileCache {
+	return &FileCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// ReadFile returns a Handler which works like the package's ReadFile, serving
+// the contents of the file at the path it receives from the cache.
+func (c *FileCache) ReadFile() fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, path string) ([]byte, error) {
+		entry, err := c.load(path, true)
+		if err != nil {
+			return nil, err
+		}
+
+		return entry.data, nil
+	})
+}
+
+// Stat returns a Handler which passes down the ExtendedFileInfo of the file at
+// the path it receives from the cache.
+func (c *FileCache) Stat() fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, path string) (ExtendedFileInfo, error) {
+		entry, err := c.load(path, false)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewExtendedFileInfo(entry.info, filepath.Dir(path)), nil
+	})
+}
+
+// Invalidate removes the file at path from the cache.
+func (c *FileCache) Invalidate(path string) {
+	c.ml.Lock()
+	defer c.ml.Unlock()
+
+	if elem, ok := c.entries[path]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, path)
+	}
+}
+
+// Purge removes all files from the cache.
+func (c *FileCache) Purge() {
+	c.ml.Lock()
+	defer c.ml.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// Len returns the total files held in the cache.
+func (c *FileCache) Len() int {
+	c.ml.Lock()
+	defer c.ml.Unlock()
+
+	return c.order.Len()
+}
+
+// load returns the cached entry for path, reloading it if it has changed. If
+// contents is true, the contents of the file are loaded if not yet cached.
+func (c *FileCache) load(path string, contents bool) (cacheEntry, error) {
+	now := time.Now()
+
+	c.ml.Lock()
+	var cached cacheEntry
+	elem, ok := c.entries[path]
+	if ok {
+		cached = *elem.Value.(*cacheEntry)
+		c.order.MoveToFront(elem)
+	}
+	c.ml.Unlock()
+
+	if ok && now.Sub(cached.checked) < c.ttl && (cached.data != nil || !contents) {
+		return cached, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		c.Invalidate(path)
+		return cacheEntry{}, err
+	}
+
+	entry := cacheEntry{path: path, info: info, checked: now}
+
+	// Unchanged files keep their cached contents.
+	if ok && info.ModTime().Equal(cached.info.ModTime()) && info.Size() == cached.info.Size() {
+		entry.data = cached.data
+	}
+
+	if contents && entry.data == nil {
+		if entry.data, err = ioutil.ReadFile(path); err != nil {
+			return cacheEntry{}, err
+		}
+	}
+
+	c.store(entry)
+
+	return entry, nil
+}
+
+// store adds the entry to the cache, evicting the least recently used entries
+// if the cache is full.
+func (c *FileCache) store(entry cacheEntry) {
+	c.ml.Lock()
+	defer c.ml.Unlock()
+
+	if elem, ok := c.entries[entry.path]; ok {
+		elem.Value = &entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.path] = c.order.PushFront(&entry)
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).path)
+	}
+}
@@ -0,0 +1,89 @@
+package fs
+
+import (
+	"io"
+	"os"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// DefaultChunkSize defines the size of the chunks read by ReadFileChunks when
+// no valid size is provided.
+const DefaultChunkSize = 32 * 1024
+
+// ReadFileChunks returns a cold Observable which reads the file at path for
+// every subscriber, emitting it's contents as []byte chunks of at most
+// chunkSize bytes, so large files never have to be held in memory as a whole.
+// Errors are passed through Error and Done is signaled with true once the
+// file is read or has failed.
+func ReadFileChunks(path string, chunkSize int) fractals.Observable {
+	return ReadFileChunksWithContext(context.New(), path, chunkSize)
+}
+
+// ReadFileChunksWithContext works like ReadFileChunks, emitting the chunks
+// with the provided context, whoes cancellation stops the read before the
+// next chunk.
+func ReadFileChunksWithContext(ctx context.Context, path string, chunkSize int) fractals.Observable {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	return fractals.NewColdObservable(func(o fractals.Observer) {
+		if err := readChunks(ctx, path, chunkSize, o); err != nil {
+			o.Error(ctx, err)
+		}
+
+		o.Done(ctx, true)
+	})
+}
+
+// readChunks reads the file at path, passing every chunk of it to the
+// observer until it is read or the context is cancelled.
+func readChunks(ctx context.Context, path string, chunkSize int, o fractals.Observer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	buf := make([]byte, chunkSize)
+
+	for {
+		if err := fractals.ContextErr(ctx); err != nil {
+			return err
+		}
+
+		n, err := file.Read(buf)
+		if n > 0 {
+			// Subscribers may hold on to the chunks, so each gets it's own.
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+
+			o.Next(ctx, chunk)
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ReadChunks returns a Handler which passes down the Observable returned by
+// ReadFileChunksWithContext for the path it receives, either as a string or a
+// ExtendedFileInfo, so the Handler's context can stop the read.
+func ReadChunks(chunkSize int) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, item interface{}) (fractals.Observable, error) {
+		path, err := sourcePath(item)
+		if err != nil {
+			return nil, err
+		}
+
+		return ReadFileChunksWithContext(ctx, path, chunkSize), nil
+	})
+}
@@ -0,0 +1,272 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// ErrInvalidPath is returned when a handler receives a value which is neither
+// a path string nor a ExtendedFileInfo.
+var ErrInvalidPath = errors.New("Expected a path string or ExtendedFileInfo")
+
+// OverwritePolicy defines how existing files at a destination are treated.
+type OverwritePolicy int
+
+const (
+	// Overwrite replaces existing files at the destination.
+	Overwrite OverwritePolicy = iota
+
+	// SkipExisting leaves existing files at the destination untouched.
+	SkipExisting
+
+	// FailIfExists returns an error when a file already exists at the
+	// destination.
+	FailIfExists
+)
+
+// CopyOptions defines the configuration used by CopyFileWith and CopyTreeWith.
+type CopyOptions struct {
+	// Overwrite sets how files already existing at the destination are
+	// treated.
+	Overwrite OverwritePolicy
+}
+
+// CopyFile returns a Handler which copies the file at the path it receives,
+// either as a string or a ExtendedFileInfo, to dst, keeping it's permissions
+// and replacing any existing file. If dst is an existing directory, the file
+// is copied into it. The destination path is passed down the pipeline.
+func CopyFile(dst string) fractals.Handler {
+	return CopyFileWith(dst, CopyOptions{})
+}
+
+// CopyFileWith works like CopyFile, using the provided options.
+func CopyFileWith(dst string, opts CopyOptions) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, item interface{}) (string, error) {
+		src, err := sourcePath(item)
+		if err != nil {
+			return "", err
+		}
+
+		target := dst
+		if info, err := os.Stat(dst); err == nil && info.IsDir() {
+			target = filepath.Join(dst, filepath.Base(src))
+		}
+
+		if err := copyFile(src, target, opts); err != nil {
+			return "", err
+		}
+
+		return target, nil
+	})
+}
+
+// CopyTree returns a Handler which copies the directory at the path it
+// receives, either as a string or a ExtendedFileInfo, with all it's contents
+// into dstRoot, keeping their permissions and replacing any existing files.
+// Symbolic links are copied as links. The dstRoot path is passed down the
+// pipeline.
+func CopyTree(dstRoot string) fractals.Handler {
+	return CopyTreeWith(dstRoot, CopyOptions{})
+}
+
+// CopyTreeWith works like CopyTree, using the provided options.
+func CopyTreeWith(dstRoot string, opts CopyOptions) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, item interface{}) (string, error) {
+		src, err := sourcePath(item)
+		if err != nil {
+			return "", err
+		}
+
+		err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if err := fractals.ContextErr(ctx); err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(src, path)
+			if err != nil {
+				return err
+			}
+
+			target := filepath.Join(dstRoot, rel)
+
+			switch {
+			case info.IsDir():
+				if err := os.MkdirAll(target, info.Mode().Perm()); err != nil {
+					return err
+				}
+
+				return os.Chmod(target, info.Mode().Perm())
+			case info.Mode()&os.ModeSymlink != 0:
+				return copySymlink(path, target, opts)
+			default:
+				return copyFile(path, target, opts)
+			}
+		})
+
+		if err != nil {
+			return "", err
+		}
+
+		return dstRoot, nil
+	})
+}
+
+// Move returns a Handler which moves the file or directory at the path it
+// receives, either as a string or a ExtendedFileInfo, to dst. If dst is an
+// existing directory, the path is moved into it. When the rename is not
+// possible because dst lies on another device, the path is copied over and
+// then removed. The new path is passed down the pipeline.
+func Move(dst string) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, item interface{}) (string, error) {
+		src, err := sourcePath(item)
+		if err != nil {
+			return "", err
+		}
+
+		target := dst
+		if info, err := os.Stat(dst); err == nil && info.IsDir() {
+			target = filepath.Join(dst, filepath.Base(src))
+		}
+
+		err = os.Rename(src, target)
+		if err == nil {
+			return target, nil
+		}
+
+		var linkErr *os.LinkError
+		if !errors.As(err, &linkErr) || linkErr.Err != syscall.EXDEV {
+			return "", err
+		}
+
+		info, err := os.Lstat(src)
+		if err != nil {
+			return "", err
+		}
+
+		if info.IsDir() {
+			_, err = CopyTree(target)(ctx, nil, src)
+		} else {
+			_, err = CopyFile(target)(ctx, nil, src)
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if err := os.RemoveAll(src); err != nil {
+			return "", err
+		}
+
+		return target, nil
+	})
+}
+
+// sourcePath returns the path held by the item, which must either be a string
+// or a ExtendedFileInfo.
+func sourcePath(item interface{}) (string, error) {
+	switch src := item.(type) {
+	case string:
+		return src, nil
+	case ExtendedFileInfo:
+		return src.Path(), nil
+	default:
+		return "", ErrInvalidPath
+	}
+}
+
+// exists returns true/false if the path can be found, without following
+// symbolic links.
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// checkOverwrite returns true/false if the copy to dst should go ahead, or an
+// error if the policy forbids it.
+func checkOverwrite(dst string, opts CopyOptions) (bool, error) {
+	if !exists(dst) {
+		return true, nil
+	}
+
+	switch opts.Overwrite {
+	case SkipExisting:
+		return false, nil
+	case FailIfExists:
+		return false, fmt.Errorf("Destination already exists {Path: %q}", dst)
+	default:
+		return true, nil
+	}
+}
+
+// copyFile copies the contents and permissions of the src file to dst.
+func copyFile(src, dst string, opts CopyOptions) error {
+	proceed, err := checkOverwrite(dst, opts)
+	if err != nil || !proceed {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("Expected a file but got a directory {Path: %q}", src)
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	// The umask may have stripped some of the permissions on creation.
+	return os.Chmod(dst, info.Mode().Perm())
+}
+
+// copySymlink creates a symbolic link at dst pointing to where the link at src
+// points.
+func copySymlink(src, dst string, opts CopyOptions) error {
+	proceed, err := checkOverwrite(dst, opts)
+	if err != nil || !proceed {
+		return err
+	}
+
+	link, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+
+	if exists(dst) {
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+	}
+
+	return os.Symlink(link, dst)
+}
@@ -242,93 +242,78 @@ func (e extendedFileInfo) Path() string {
 // ReadDir reads the giving path if indeed is a directory, else passing down
 // an error down the provided pipeline. It extends the provided os.FileInfo
 // with a structure that implements the ExtendedFileInfo interface. It sends the
-// individual fileInfo instead of the slice of FileInfos.
+// individual fileInfo instead of the slice of FileInfos. Symbolic links are
+// reported as they are.
 func ReadDir(path string) fractals.Handler {
-	return fractals.MustWrap(func(ctx context.Context, _ interface{}) ([]ExtendedFileInfo, error) {
-		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE, 0700)
-		if err != nil {
-			return nil, err
-		}
-
-		defer file.Close()
-
-		dirs, err := file.Readdir(-1)
-		if err != nil {
-			return nil, err
-		}
-
-		var edirs []ExtendedFileInfo
-
-		for _, dir := range dirs {
-			edirs = append(edirs, NewExtendedFileInfo(dir, path))
-		}
+	return ReadDirWith(path, ReportSymlinks)
+}
 
-		return edirs, nil
+// ReadDirWith works like ReadDir, treating symbolic links using the provided
+// policy.
+func ReadDirWith(path string, policy SymlinkPolicy) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, _ interface{}) ([]ExtendedFileInfo, error) {
+		return readDir(path, policy)
 	})
 }
 
 // ReadDirPath reads the giving path if indeed is a directory, else passing down
 // an error down the provided pipeline. It extends the provided os.FileInfo
 // with a structure that implements the ExtendedFileInfo interface. It sends the
-// individual fileInfo instead of the slice of FileInfos.
+// individual fileInfo instead of the slice of FileInfos. Symbolic links are
+// reported as they are.
 func ReadDirPath() fractals.Handler {
-	return fractals.MustWrap(func(ctx context.Context, path string) ([]ExtendedFileInfo, error) {
-		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE, 0700)
-		if err != nil {
-			return nil, err
-		}
-
-		defer file.Close()
-
-		dirs, err := file.Readdir(-1)
-		if err != nil {
-			return nil, err
-		}
-
-		var edirs []ExtendedFileInfo
-
-		for _, dir := range dirs {
-			edirs = append(edirs, NewExtendedFileInfo(dir, path))
-		}
+	return ReadDirPathWith(ReportSymlinks)
+}
 
-		return edirs, nil
+// ReadDirPathWith works like ReadDirPath, treating symbolic links using the
+// provided policy.
+func ReadDirPathWith(policy SymlinkPolicy) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, path string) ([]ExtendedFileInfo, error) {
+		return readDir(path, policy)
 	})
 }
 
 // WalkDir walks the giving path if indeed is a directory, else passing down
 // an error down the provided pipeline. It extends the provided os.FileInfo
 // with a structure that implements the ExtendedFileInfo interface. It sends the
-// individual fileInfo instead of the slice of FileInfos.
+// individual fileInfo instead of the slice of FileInfos. Symbolic links are
+// left out.
 func WalkDir(path string) fractals.Handler {
-	return fractals.MustWrap(func(ctx context.Context, _ interface{}) ([]ExtendedFileInfo, error) {
-		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE, 0700)
-		if err != nil {
-			return nil, err
-		}
+	return WalkDirWith(path, SkipSymlinks)
+}
 
-		defer file.Close()
+// WalkDirWith works like WalkDir, treating symbolic links using the provided
+// policy.
+func WalkDirWith(path string, policy SymlinkPolicy) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, _ interface{}) ([]ExtendedFileInfo, error) {
+		return readDir(path, policy)
+	})
+}
 
-		fdirs, err := file.Readdir(-1)
-		if err != nil {
-			return nil, err
-		}
+// readDir reads the entries of the directory at path, treating symbolic links
+// using the provided policy.
+func readDir(path string, policy SymlinkPolicy) ([]ExtendedFileInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
 
-		var dirs []ExtendedFileInfo
+	defer file.Close()
 
-		for _, dir := range fdirs {
-			dirInfo := NewExtendedFileInfo(dir, path)
+	fdirs, err := file.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
 
-			// If this is a sysmbol link, then continue we won't read through it.
-			if _, err := os.Readlink(dirInfo.Dir()); err == nil {
-				continue
-			}
+	var dirs []ExtendedFileInfo
 
+	for _, dir := range fdirs {
+		if dirInfo, ok := applySymlinkPolicy(dir, path, policy); ok {
 			dirs = append(dirs, dirInfo)
 		}
+	}
 
-		return dirs, nil
-	})
-
+	return dirs, nil
 }
 
 // Mkdir creates a directly returning the path down the pipeline. If the chain
@@ -350,8 +335,16 @@ func Mkdir(path string, chain bool) fractals.Handler {
 }
 
 // ResolvePathIn returns an ExtendedFileInfo for paths recieved if they match
-// a specific root directory once resolved using the root directory.
+// a specific root directory once resolved using the root directory. Symbolic
+// links are followed. Sandbox should be preferred, as symbolic links leading
+// outside of the root directory are not caught.
 func ResolvePathIn(rootDir string) fractals.Handler {
+	return ResolvePathInWith(rootDir, FollowSymlinks)
+}
+
+// ResolvePathInWith works like ResolvePathIn, treating symbolic links using
+// the provided policy, where skipped links return an error.
+func ResolvePathInWith(rootDir string, policy SymlinkPolicy) fractals.Handler {
 	return fractals.MustWrap(func(ctx context.Context, path string) (ExtendedFileInfo, error) {
 		absRoot, err := filepath.Abs(rootDir)
 		if err != nil {
@@ -365,22 +358,24 @@ func ResolvePathIn(rootDir string) fractals.Handler {
 			return nil, fmt.Errorf("Path is outside of root {Root: %q, Path: %q, Wanted: %q}", rootDir, path, finalPath)
 		}
 
-		file, err := os.Open(finalPath)
+		stat, err := os.Lstat(finalPath)
 		if err != nil {
 			return nil, err
 		}
 
-		stat, err := file.Stat()
-		if err != nil {
-			return nil, err
+		info, ok := applySymlinkPolicy(stat, filepath.Dir(finalPath), policy)
+		if !ok {
+			return nil, fmt.Errorf("Path is a skipped or broken symbolic link {Root: %q, Path: %q}", rootDir, path)
 		}
 
-		return NewExtendedFileInfo(stat, filepath.Base(finalPath)), nil
+		return info, nil
 	})
 }
 
 // ResolvePathStringIn returns the full valid path for paths recieved if they match
-// a specific root directory once resolved using the root directory.
+// a specific root directory once resolved using the root directory. Sandbox
+// should be preferred, as symbolic links leading outside of the root directory
+// are not caught.
 func ResolvePathStringIn(rootDir string) fractals.Handler {
 	return fractals.MustWrap(func(ctx context.Context, path string) (string, error) {
 		absRoot, err := filepath.Abs(rootDir)
@@ -1,7 +1,19 @@
 package fs_test
 
 import (
+	"bufio"
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/influx6/faux/context"
 	"github.com/influx6/fractals"
@@ -67,3 +79,1030 @@ func BenchmarkReadDirPath(b *testing.B) {
 		fractals.RLift(fractals.IdentityHandler())(fs.ReadDirPath(), fs.SkipStat(fs.IsDir), fs.UnwrapStats(), fs.ResolvePath())(ctx, nil, "../../..")
 	}
 }
+
+func TestAppendLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fractals-fs")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	rl, err := fs.AppendLog(filepath.Join(dir, "records.log"))
+	if err != nil {
+		t.Fatalf("%s Expected to open append log: %s", failedMark, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := rl.Append([]byte(fmt.Sprintf("record-%d", i))); err != nil {
+				t.Errorf("%s Expected to append record: %s", failedMark, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	records, err := fs.ReadRecords(rl)(context.New(), nil, int64(0))
+	if err != nil {
+		t.Fatalf("%s Expected to read records: %s", failedMark, err)
+	}
+
+	if total := len(records.([][]byte)); total != 10 {
+		t.Fatalf("%s Expected %d records but got %d", failedMark, 10, total)
+	}
+	t.Logf("%s Expected %d records", succeedMark, 10)
+
+	cursor := rl.Cursor(rl.Size())
+	go rl.Append([]byte("tailed"))
+
+	data, err := cursor.Tail(nil)
+	if err != nil || string(data) != "tailed" {
+		t.Fatalf("%s Expected to tail appended record: %s", failedMark, err)
+	}
+	t.Logf("%s Expected to tail appended record", succeedMark)
+
+	if err := rl.Close(); err != nil {
+		t.Fatalf("%s Expected to close log: %s", failedMark, err)
+	}
+
+	reopened, err := fs.AppendLog(filepath.Join(dir, "records.log"))
+	if err != nil {
+		t.Fatalf("%s Expected to reopen append log: %s", failedMark, err)
+	}
+	defer reopened.Close()
+
+	if reopened.Size() != cursor.Offset() {
+		t.Fatalf("%s Expected reopened log to keep all records", failedMark)
+	}
+	t.Logf("%s Expected reopened log to keep all records", succeedMark)
+
+	damaged := filepath.Join(dir, "damaged.log")
+
+	dl, err := fs.AppendLog(damaged)
+	if err != nil {
+		t.Fatalf("%s Expected to open append log: %s", failedMark, err)
+	}
+
+	dl.Append([]byte("first"))
+	second, _ := dl.Append([]byte("second"))
+	dl.Close()
+
+	// A torn last record, missing the end of it's contents, is truncated
+	// away.
+	stat, _ := os.Stat(damaged)
+	os.Truncate(damaged, stat.Size()-3)
+
+	dl, err = fs.AppendLog(damaged)
+	if err != nil {
+		t.Fatalf("%s Expected to recover torn last record: %s", failedMark, err)
+	}
+
+	if dl.Size() != second {
+		t.Fatalf("%s Expected torn last record to be truncated but got size %d", failedMark, dl.Size())
+	}
+	t.Logf("%s Expected torn last record to be truncated", succeedMark)
+
+	dl.Append([]byte("second"))
+	dl.Close()
+
+	stat, _ = os.Stat(damaged)
+
+	// A corrupted record before the end of the log is not, whether it's
+	// contents or it's length, pointing past the end of the file, are.
+	for _, damage := range []struct {
+		offset int64
+		data   []byte
+	}{
+		{12, []byte("F")},
+		{0, []byte{0x7f, 0xff, 0xff, 0xff}},
+	} {
+		original, _ := ioutil.ReadFile(damaged)
+
+		file, _ := os.OpenFile(damaged, os.O_RDWR, 0600)
+		file.WriteAt(damage.data, damage.offset)
+		file.Close()
+
+		if _, err := fs.AppendLog(damaged); err != fs.ErrCorruptRecord {
+			t.Fatalf("%s Expected corrupted record before the end to fail but got %v", failedMark, err)
+		}
+
+		if after, _ := os.Stat(damaged); after.Size() != stat.Size() {
+			t.Fatalf("%s Expected corrupted log to be left as is", failedMark)
+		}
+
+		ioutil.WriteFile(damaged, original, 0600)
+	}
+	t.Logf("%s Expected corrupted record before the end to fail", succeedMark)
+}
+
+func TestWatchObservable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-watch")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	interval := fs.WatchInterval
+	fs.WatchInterval = 10 * time.Millisecond
+	defer func() { fs.WatchInterval = interval }()
+
+	events := make(chan fs.FileEvent, 10)
+
+	ob := fs.WatchObservable(dir)
+	defer ob.End()
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(event fs.FileEvent) {
+		events <- event
+	}, nil, nil), false))
+
+	target := filepath.Join(dir, "app.go")
+
+	// Allow the watcher to take it's first snapshot.
+	time.Sleep(30 * time.Millisecond)
+
+	if err := ioutil.WriteFile(target, []byte("package app"), 0600); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	expectEvent(t, events, fs.FileEvent{Path: target, Op: fs.FileCreate})
+
+	if err := os.Remove(target); err != nil {
+		t.Fatalf("%s Expected to remove file: %s", failedMark, err)
+	}
+
+	expectEvent(t, events, fs.FileEvent{Path: target, Op: fs.FileRemove})
+}
+
+func expectEvent(t *testing.T, events chan fs.FileEvent, expected fs.FileEvent) {
+	for {
+		select {
+		case event := <-events:
+			// The directory itself gets modified alongside it's files.
+			if event.Path != expected.Path {
+				continue
+			}
+
+			if event != expected {
+				t.Fatalf("%s Expected %s event for %q but got %s", failedMark, expected.Op, expected.Path, event.Op)
+			}
+
+			t.Logf("%s Expected %s event for %q", succeedMark, expected.Op, expected.Path)
+			return
+		case <-time.After(time.Second):
+			t.Fatalf("%s Expected %s event for %q", failedMark, expected.Op, expected.Path)
+		}
+	}
+}
+
+func TestWalkTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-walk")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, path := range []string{"a/b/c", "skip/d"} {
+		if err := os.MkdirAll(filepath.Join(dir, path), 0700); err != nil {
+			t.Fatalf("%s Expected to create directories: %s", failedMark, err)
+		}
+	}
+
+	for _, path := range []string{"root.txt", "a/one.txt", "a/b/two.txt", "a/b/c/three.txt", "skip/d/four.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, path), []byte(path), 0600); err != nil {
+			t.Fatalf("%s Expected to write file: %s", failedMark, err)
+		}
+	}
+
+	walk := func(opts fs.WalkOptions) []string {
+		res, err := fs.WalkTree(dir, opts)(context.New(), nil, "")
+		if err != nil {
+			t.Fatalf("%s Expected to walk tree: %s", failedMark, err)
+		}
+
+		var paths []string
+		for _, info := range res.([]fs.ExtendedFileInfo) {
+			rel, _ := filepath.Rel(dir, info.Path())
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+
+		return paths
+	}
+
+	all := walk(fs.WalkOptions{})
+	if len(all) != 10 {
+		t.Fatalf("%s Expected to walk all 10 entries but got %+v", failedMark, all)
+	}
+	t.Logf("%s Expected to walk all 10 entries", succeedMark)
+
+	shallow := walk(fs.WalkOptions{
+		MaxDepth: 2,
+		Skip: func(info fs.ExtendedFileInfo) bool {
+			return info.Name() == "skip"
+		},
+	})
+
+	if fmt.Sprint(shallow) != "[a a/b a/one.txt root.txt]" {
+		t.Fatalf("%s Expected depth limited and skipped entries but got %+v", failedMark, shallow)
+	}
+	t.Logf("%s Expected depth limited and skipped entries", succeedMark)
+
+	if err := os.Symlink(filepath.Join(dir, "a"), filepath.Join(dir, "link")); err != nil {
+		t.Skipf("Unable to create symlink: %s", err)
+	}
+
+	if skipped := walk(fs.WalkOptions{}); len(skipped) != 10 {
+		t.Fatalf("%s Expected symlinks to be skipped but got %+v", failedMark, skipped)
+	}
+	t.Logf("%s Expected symlinks to be skipped", succeedMark)
+
+	if followed := walk(fs.WalkOptions{Symlinks: fs.FollowSymlinks}); len(followed) != 11 {
+		t.Fatalf("%s Expected followed symlink without walking visited directory twice but got %+v", failedMark, followed)
+	}
+	t.Logf("%s Expected followed symlink without walking visited directory twice", succeedMark)
+
+	var streamed int
+	fs.WalkObservable(dir, fs.WalkOptions{Symlinks: fs.ReportSymlinks}).Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(info fs.ExtendedFileInfo) {
+		streamed++
+	}, nil, nil), false))
+
+	if streamed != 11 {
+		t.Fatalf("%s Expected 11 streamed entries but got %d", failedMark, streamed)
+	}
+	t.Logf("%s Expected 11 streamed entries", succeedMark)
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-notify")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	events := make(chan fs.FileEvent, 10)
+
+	ob := fs.WatchWith(dir, fs.WatchOptions{Debounce: 50 * time.Millisecond})
+	defer ob.End()
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(event fs.FileEvent) {
+		events <- event
+	}, nil, nil), false))
+
+	target := filepath.Join(dir, "main.go")
+	for i := 0; i < 3; i++ {
+		if err := ioutil.WriteFile(target, []byte(fmt.Sprintf("package main // %d", i)), 0600); err != nil {
+			t.Fatalf("%s Expected to write file: %s", failedMark, err)
+		}
+	}
+
+	expectEvent(t, events, fs.FileEvent{Path: target, Op: fs.FileCreate})
+
+	select {
+	case event := <-events:
+		t.Fatalf("%s Expected writes to be debounced into a single event but got %+v", failedMark, event)
+	case <-time.After(100 * time.Millisecond):
+	}
+	t.Logf("%s Expected writes to be debounced into a single event", succeedMark)
+
+	missing := fs.Watch(filepath.Join(dir, "missing"))
+
+	var failed error
+	missing.Subscribe(fractals.NewObservable(fractals.Behaviour{
+		Next: fractals.IdentityHandler(),
+		Error: fractals.MustWrap(func(err error) {
+			failed = err
+		}),
+	}, false))
+
+	if failed == nil {
+		t.Fatalf("%s Expected error for missing watch path", failedMark)
+	}
+	t.Logf("%s Expected error for missing watch path", succeedMark)
+}
+
+func TestCopyTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-copy")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "bin"), 0700); err != nil {
+		t.Fatalf("%s Expected to create directories: %s", failedMark, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(src, "bin", "run.sh"), []byte("echo run"), 0750); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	res, err := fs.CopyTree(dst)(context.New(), nil, src)
+	if err != nil {
+		t.Fatalf("%s Expected to copy tree: %s", failedMark, err)
+	}
+
+	if res != dst {
+		t.Fatalf("%s Expected destination %q but got %+v", failedMark, dst, res)
+	}
+
+	copied := filepath.Join(dst, "bin", "run.sh")
+	info, err := os.Stat(copied)
+	if err != nil {
+		t.Fatalf("%s Expected copied file: %s", failedMark, err)
+	}
+
+	if info.Mode().Perm() != 0750 {
+		t.Fatalf("%s Expected copied file to keep permissions but got %s", failedMark, info.Mode())
+	}
+	t.Logf("%s Expected copied file to keep permissions", succeedMark)
+
+	if _, err := fs.CopyFileWith(copied, fs.CopyOptions{Overwrite: fs.FailIfExists})(context.New(), nil, filepath.Join(src, "bin", "run.sh")); err == nil {
+		t.Fatalf("%s Expected error copying over existing file", failedMark)
+	}
+	t.Logf("%s Expected error copying over existing file", succeedMark)
+
+	if err := ioutil.WriteFile(filepath.Join(src, "bin", "run.sh"), []byte("echo changed"), 0750); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	if _, err := fs.CopyFileWith(filepath.Join(dst, "bin"), fs.CopyOptions{Overwrite: fs.SkipExisting})(context.New(), nil, filepath.Join(src, "bin", "run.sh")); err != nil {
+		t.Fatalf("%s Expected to skip existing file: %s", failedMark, err)
+	}
+
+	if data, _ := ioutil.ReadFile(copied); string(data) != "echo run" {
+		t.Fatalf("%s Expected existing file to be left untouched but got %q", failedMark, data)
+	}
+	t.Logf("%s Expected existing file to be left untouched", succeedMark)
+
+	if _, err := fs.CopyFile(filepath.Join(dst, "bin"))(context.New(), nil, filepath.Join(src, "bin", "run.sh")); err != nil {
+		t.Fatalf("%s Expected to overwrite existing file: %s", failedMark, err)
+	}
+
+	if data, _ := ioutil.ReadFile(copied); string(data) != "echo changed" {
+		t.Fatalf("%s Expected existing file to be overwritten but got %q", failedMark, data)
+	}
+	t.Logf("%s Expected existing file to be overwritten", succeedMark)
+}
+
+func TestMove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-move")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "upload.tmp")
+	if err := ioutil.WriteFile(src, []byte("upload"), 0600); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	store := filepath.Join(dir, "store")
+	if err := os.Mkdir(store, 0700); err != nil {
+		t.Fatalf("%s Expected to create directory: %s", failedMark, err)
+	}
+
+	res, err := fs.Move(store)(context.New(), nil, src)
+	if err != nil {
+		t.Fatalf("%s Expected to move file: %s", failedMark, err)
+	}
+
+	moved := filepath.Join(store, "upload.tmp")
+	if res != moved {
+		t.Fatalf("%s Expected new path %q but got %+v", failedMark, moved, res)
+	}
+
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Fatalf("%s Expected source to be gone", failedMark)
+	}
+
+	if data, _ := ioutil.ReadFile(moved); string(data) != "upload" {
+		t.Fatalf("%s Expected moved file contents but got %q", failedMark, data)
+	}
+	t.Logf("%s Expected file to be moved into directory", succeedMark)
+}
+
+func TestHashFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-hash")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "artifact.bin")
+	if err := ioutil.WriteFile(target, []byte("hello"), 0600); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	digests := map[fs.HashAlgorithm]string{
+		fs.SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		fs.SHA1:   "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d",
+		fs.MD5:    "5d41402abc4b2a76b9719d911017c592",
+		fs.CRC32:  "3610a686",
+	}
+
+	for algo, expected := range digests {
+		digest, err := fs.HashFile(algo)(context.New(), nil, target)
+		if err != nil {
+			t.Fatalf("%s Expected to hash file with %s: %s", failedMark, algo, err)
+		}
+
+		if digest != expected {
+			t.Fatalf("%s Expected %s digest %q but got %q", failedMark, algo, expected, digest)
+		}
+
+		if digest, _ := fs.HashBytes(algo)(context.New(), nil, []byte("hello")); digest != expected {
+			t.Fatalf("%s Expected %s digest %q for bytes but got %q", failedMark, algo, expected, digest)
+		}
+		t.Logf("%s Expected %s digest %q", succeedMark, algo, expected)
+	}
+
+	if _, err := fs.VerifyDigest(fs.SHA256, digests[fs.SHA256])(context.New(), nil, target); err != nil {
+		t.Fatalf("%s Expected digest to verify: %s", failedMark, err)
+	}
+	t.Logf("%s Expected digest to verify", succeedMark)
+
+	if _, err := fs.VerifyDigest(fs.MD5, digests[fs.SHA1])(context.New(), nil, []byte("hello")); err != fs.ErrDigestMismatch {
+		t.Fatalf("%s Expected ErrDigestMismatch but got %v", failedMark, err)
+	}
+	t.Logf("%s Expected ErrDigestMismatch", succeedMark)
+}
+
+func TestReadFileChunks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-chunks")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "large.bin")
+	if err := ioutil.WriteFile(target, []byte("abcdefghij"), 0600); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	var chunks []string
+	var done bool
+
+	fs.ReadFileChunks(target, 4).Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(chunk []byte) {
+		chunks = append(chunks, string(chunk))
+	}, func(bool) {
+		done = true
+	}, nil), false))
+
+	if fmt.Sprint(chunks) != "[abcd efgh ij]" {
+		t.Fatalf("%s Expected file to be read in chunks but got %+v", failedMark, chunks)
+	}
+	t.Logf("%s Expected file to be read in chunks", succeedMark)
+
+	if !done {
+		t.Fatalf("%s Expected Done once file is read", failedMark)
+	}
+	t.Logf("%s Expected Done once file is read", succeedMark)
+
+	var failure error
+	done = false
+
+	fs.ReadFileChunks(filepath.Join(dir, "missing.bin"), 4).Subscribe(fractals.NewObservable(fractals.Behaviour{
+		Next: fractals.IdentityHandler(),
+		Error: fractals.MustWrap(func(err error) {
+			failure = err
+		}),
+		Done: fractals.MustWrap(func(bool) {
+			done = true
+		}),
+	}, false))
+
+	if !os.IsNotExist(failure) || !done {
+		t.Fatalf("%s Expected Error and Done for missing file but got %v %t", failedMark, failure, done)
+	}
+	t.Logf("%s Expected Error and Done for missing file", succeedMark)
+
+	// Reading /dev/zero never ends, so only the cancellation can stop it.
+	if _, err := os.Stat("/dev/zero"); err != nil {
+		t.Skipf("Unable to read endless file: %s", err)
+	}
+
+	std, cancel := stdcontext.WithCancel(stdcontext.Background())
+	stopped := make(chan struct{})
+	chunks = nil
+
+	go func() {
+		defer close(stopped)
+
+		fs.ReadFileChunksWithContext(fractals.NewContext(std), "/dev/zero", 4).Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(chunk []byte) {
+			chunks = append(chunks, string(chunk))
+			cancel()
+		}, nil, nil), false))
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s Expected cancelled read to stop", failedMark)
+	}
+
+	if len(chunks) != 1 {
+		t.Fatalf("%s Expected cancelled read to stop after first chunk but got %d chunks", failedMark, len(chunks))
+	}
+	t.Logf("%s Expected cancelled read to stop after first chunk", succeedMark)
+}
+
+func TestSymlinkPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-symlink")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(target, []byte("{}"), 0600); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	link := filepath.Join(dir, "current.json")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Unable to create symlink: %s", err)
+	}
+
+	read := func(policy fs.SymlinkPolicy) map[string]os.FileMode {
+		res, err := fs.WalkDirWith(dir, policy)(context.New(), nil, dir)
+		if err != nil {
+			t.Fatalf("%s Expected to read directory: %s", failedMark, err)
+		}
+
+		modes := make(map[string]os.FileMode)
+		for _, info := range res.([]fs.ExtendedFileInfo) {
+			modes[info.Name()] = info.Mode()
+		}
+
+		return modes
+	}
+
+	if skipped := read(fs.SkipSymlinks); len(skipped) != 1 {
+		t.Fatalf("%s Expected symlink to be skipped but got %+v", failedMark, skipped)
+	}
+	t.Logf("%s Expected symlink to be skipped", succeedMark)
+
+	if reported := read(fs.ReportSymlinks); reported["current.json"]&os.ModeSymlink == 0 {
+		t.Fatalf("%s Expected symlink to be reported but got %+v", failedMark, reported)
+	}
+	t.Logf("%s Expected symlink to be reported", succeedMark)
+
+	if followed := read(fs.FollowSymlinks); followed["current.json"]&os.ModeSymlink != 0 || !followed["current.json"].IsRegular() {
+		t.Fatalf("%s Expected symlink to be followed but got %+v", failedMark, followed)
+	}
+	t.Logf("%s Expected symlink to be followed", succeedMark)
+
+	if dest, err := fs.ReadLink()(context.New(), nil, link); err != nil || dest != target {
+		t.Fatalf("%s Expected link destination %q but got %+v: %v", failedMark, target, dest, err)
+	}
+	t.Logf("%s Expected link destination %q", succeedMark, target)
+
+	real, _ := filepath.EvalSymlinks(target)
+	if resolved, err := fs.EvalSymlinks()(context.New(), nil, link); err != nil || resolved != real {
+		t.Fatalf("%s Expected resolved path %q but got %+v: %v", failedMark, real, resolved, err)
+	}
+	t.Logf("%s Expected resolved path %q", succeedMark, real)
+
+	if _, err := fs.ResolvePathInWith(dir, fs.SkipSymlinks)(context.New(), nil, "current.json"); err == nil {
+		t.Fatalf("%s Expected error resolving skipped symlink", failedMark)
+	}
+	t.Logf("%s Expected error resolving skipped symlink", succeedMark)
+
+	resolved, err := fs.ResolvePathIn(dir)(context.New(), nil, "current.json")
+	if err != nil {
+		t.Fatalf("%s Expected to resolve followed symlink: %s", failedMark, err)
+	}
+
+	if info := resolved.(fs.ExtendedFileInfo); info.Path() != link || !info.Mode().IsRegular() {
+		t.Fatalf("%s Expected followed symlink at %q but got %q", failedMark, link, info.Path())
+	}
+	t.Logf("%s Expected to resolve followed symlink", succeedMark)
+
+	resolved, err = fs.ResolvePathIn(dir)(context.New(), nil, "config.json")
+	if err != nil {
+		t.Fatalf("%s Expected to resolve file: %s", failedMark, err)
+	}
+
+	if info := resolved.(fs.ExtendedFileInfo); info.Path() != target {
+		t.Fatalf("%s Expected file at %q but got %q", failedMark, target, info.Path())
+	}
+	t.Logf("%s Expected to resolve file", succeedMark)
+}
+
+func TestTempFile(t *testing.T) {
+	std, cancel := stdcontext.WithCancel(stdcontext.Background())
+
+	res, err := fs.TempFile("scratch")(fractals.NewContext(std), nil, "")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp file: %s", failedMark, err)
+	}
+
+	file := res.(*os.File)
+	if _, err := os.Stat(file.Name()); err != nil {
+		t.Fatalf("%s Expected temp file to exist: %s", failedMark, err)
+	}
+	t.Logf("%s Expected temp file to exist", succeedMark)
+
+	cancel()
+
+	if !eventually(func() bool {
+		_, err := os.Stat(file.Name())
+		return os.IsNotExist(err)
+	}) {
+		t.Fatalf("%s Expected temp file to be removed once context is done", failedMark)
+	}
+	t.Logf("%s Expected temp file to be removed once context is done", succeedMark)
+
+	ob := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+
+	dir, err := fs.TempDirWith("scratch", fs.TempOptions{Observable: ob})(context.New(), nil, "")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir.(string), "part"), []byte("part"), 0600); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	ob.End()
+
+	if _, err := os.Stat(dir.(string)); !os.IsNotExist(err) {
+		t.Fatalf("%s Expected temp directory to be removed once Observable is ended", failedMark)
+	}
+	t.Logf("%s Expected temp directory to be removed once Observable is ended", succeedMark)
+}
+
+// eventually returns true if the condition is met within a second.
+func eventually(cond func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return true
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestWithLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-lock")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	lock := filepath.Join(dir, "shared.lock")
+	held := make(chan struct{})
+	release := make(chan struct{})
+
+	go fs.WithLock(lock, fractals.MustWrap(func() {
+		close(held)
+		<-release
+	}))(context.New(), nil, "")
+
+	<-held
+
+	_, err = fs.WithLockOptions(lock, fs.LockOptions{Timeout: 50 * time.Millisecond}, fractals.IdentityHandler())(context.New(), nil, "")
+	if err != fs.ErrLockTimeout {
+		t.Fatalf("%s Expected ErrLockTimeout while lock is held but got %v", failedMark, err)
+	}
+	t.Logf("%s Expected ErrLockTimeout while lock is held", succeedMark)
+
+	close(release)
+
+	res, err := fs.WithLockOptions(lock, fs.LockOptions{Timeout: time.Second}, fractals.IdentityHandler())(context.New(), nil, "data")
+	if err != nil || res != "data" {
+		t.Fatalf("%s Expected lock to be acquired once released but got %v", failedMark, err)
+	}
+	t.Logf("%s Expected lock to be acquired once released", succeedMark)
+}
+
+func TestSandbox(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-sandbox")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "root")
+	for _, path := range []string{"root/public", "root/private", "outside"} {
+		if err := os.MkdirAll(filepath.Join(dir, path), 0700); err != nil {
+			t.Fatalf("%s Expected to create directories: %s", failedMark, err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(root, "public", "index.html"), []byte("index"), 0600); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	realRoot, _ := filepath.EvalSymlinks(root)
+
+	res, err := fs.Sandbox(root)(context.New(), nil, "/public/../public/index.html")
+	if err != nil || res != filepath.Join(realRoot, "public", "index.html") {
+		t.Fatalf("%s Expected path to resolve within sandbox but got %+v: %v", failedMark, res, err)
+	}
+	t.Logf("%s Expected path to resolve within sandbox", succeedMark)
+
+	if _, err := fs.Sandbox(filepath.Join(root, "public"))(context.New(), nil, "../private"); !errors.Is(err, fs.ErrOutsideSandbox) {
+		t.Fatalf("%s Expected traversal to be rejected but got %v", failedMark, err)
+	}
+	t.Logf("%s Expected traversal to be rejected", succeedMark)
+
+	if _, err := fs.Sandbox(root, fs.AllowPaths("public"))(context.New(), nil, "private"); !errors.Is(err, fs.ErrOutsideSandbox) {
+		t.Fatalf("%s Expected path outside allowed subpaths to be rejected but got %v", failedMark, err)
+	}
+	t.Logf("%s Expected path outside allowed subpaths to be rejected", succeedMark)
+
+	if _, err := fs.Sandbox(root)(context.New(), nil, "public/upload.bin"); err == nil {
+		t.Fatalf("%s Expected missing path to fail without AllowMissing", failedMark)
+	}
+
+	if _, err := fs.Sandbox(root, fs.AllowMissing())(context.New(), nil, "public/upload.bin"); err != nil {
+		t.Fatalf("%s Expected missing path to resolve with AllowMissing: %s", failedMark, err)
+	}
+	t.Logf("%s Expected missing path to resolve with AllowMissing", succeedMark)
+
+	if err := os.Symlink(filepath.Join(dir, "outside"), filepath.Join(root, "escape")); err != nil {
+		t.Skipf("Unable to create symlink: %s", err)
+	}
+
+	if _, err := fs.Sandbox(root, fs.AllowMissing())(context.New(), nil, "escape/secret"); !errors.Is(err, fs.ErrOutsideSandbox) {
+		t.Fatalf("%s Expected symlink escape to be rejected but got %v", failedMark, err)
+	}
+	t.Logf("%s Expected symlink escape to be rejected", succeedMark)
+
+	if err := os.Symlink(filepath.Join(dir, "outside", "newfile"), filepath.Join(root, "evil")); err != nil {
+		t.Fatalf("%s Expected to create symlink: %s", failedMark, err)
+	}
+
+	if _, err := fs.Sandbox(root, fs.AllowMissing())(context.New(), nil, "evil"); !errors.Is(err, fs.ErrOutsideSandbox) {
+		t.Fatalf("%s Expected dangling symlink escape to be rejected but got %v", failedMark, err)
+	}
+
+	if err := os.Symlink("public/new.txt", filepath.Join(root, "inner")); err != nil {
+		t.Fatalf("%s Expected to create symlink: %s", failedMark, err)
+	}
+
+	res, err = fs.Sandbox(root, fs.AllowMissing())(context.New(), nil, "inner")
+	if err != nil || res != filepath.Join(realRoot, "public", "new.txt") {
+		t.Fatalf("%s Expected dangling symlink within sandbox to resolve to it's target but got %+v: %v", failedMark, res, err)
+	}
+	t.Logf("%s Expected dangling symlinks to be checked against the root", succeedMark)
+}
+
+func TestWalkParallel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-walk-parallel")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 5; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("dir-%d", i), "nested")
+		if err := os.MkdirAll(sub, 0700); err != nil {
+			t.Fatalf("%s Expected to create directories: %s", failedMark, err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(sub, "file.txt"), []byte("file"), 0600); err != nil {
+			t.Fatalf("%s Expected to write file: %s", failedMark, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var done bool
+
+	fs.WalkParallel(dir, 3).Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(info fs.ExtendedFileInfo) {
+		seen[info.Path()] = true
+	}, func(bool) {
+		done = true
+	}, nil), false))
+
+	if len(seen) != 15 {
+		t.Fatalf("%s Expected 15 entries to be walked but got %d", failedMark, len(seen))
+	}
+	t.Logf("%s Expected 15 entries to be walked", succeedMark)
+
+	if !done {
+		t.Fatalf("%s Expected Done once walk is over", failedMark)
+	}
+	t.Logf("%s Expected Done once walk is over", succeedMark)
+}
+
+func TestCachedReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-cache")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{filepath.Join(dir, "a.css"), filepath.Join(dir, "b.css"), filepath.Join(dir, "c.css")}
+	for _, file := range files {
+		if err := ioutil.WriteFile(file, []byte("body{}"), 0600); err != nil {
+			t.Fatalf("%s Expected to write file: %s", failedMark, err)
+		}
+	}
+
+	cache := fs.CachedReader(2, time.Hour)
+	read := cache.ReadFile()
+
+	if data, err := read(context.New(), nil, files[0]); err != nil || string(data.([]byte)) != "body{}" {
+		t.Fatalf("%s Expected to read file contents: %v", failedMark, err)
+	}
+	t.Logf("%s Expected to read file contents", succeedMark)
+
+	if err := ioutil.WriteFile(files[0], []byte("body{color:red}"), 0600); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	if data, _ := read(context.New(), nil, files[0]); string(data.([]byte)) != "body{}" {
+		t.Fatalf("%s Expected cached contents within ttl but got %q", failedMark, data)
+	}
+	t.Logf("%s Expected cached contents within ttl", succeedMark)
+
+	read(context.New(), nil, files[1])
+	read(context.New(), nil, files[2])
+
+	if total := cache.Len(); total != 2 {
+		t.Fatalf("%s Expected cache to hold %d entries but got %d", failedMark, 2, total)
+	}
+	t.Logf("%s Expected cache to hold %d entries", succeedMark, 2)
+
+	if data, _ := read(context.New(), nil, files[0]); string(data.([]byte)) != "body{color:red}" {
+		t.Fatalf("%s Expected evicted file to be reloaded but got %q", failedMark, data)
+	}
+	t.Logf("%s Expected evicted file to be reloaded", succeedMark)
+
+	expiring := fs.CachedReader(0, 0).ReadFile()
+	expiring(context.New(), nil, files[1])
+
+	if err := ioutil.WriteFile(files[1], []byte("changed"), 0600); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	if data, _ := expiring(context.New(), nil, files[1]); string(data.([]byte)) != "changed" {
+		t.Fatalf("%s Expected changed file to be reloaded once ttl passed but got %q", failedMark, data)
+	}
+	t.Logf("%s Expected changed file to be reloaded once ttl passed", succeedMark)
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-write")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "logs", "app.log")
+
+	written, err := fs.WriteFile(target, fs.WriteOptions{MkdirParents: true, Perm: 0640, Sync: true})(context.New(), nil, "first")
+	if err != nil {
+		t.Fatalf("%s Expected to write file with parents: %s", failedMark, err)
+	}
+
+	if written != int64(5) {
+		t.Fatalf("%s Expected %d bytes written but got %+v", failedMark, 5, written)
+	}
+	t.Logf("%s Expected %d bytes written", succeedMark, 5)
+
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm()&^0640 != 0 {
+		t.Fatalf("%s Expected file to be created with permissions: %v", failedMark, err)
+	}
+	t.Logf("%s Expected file to be created with permissions", succeedMark)
+
+	if _, err := fs.WriteFile(target, fs.WriteOptions{Append: true})(context.New(), nil, []byte("-second")); err != nil {
+		t.Fatalf("%s Expected to append to file: %s", failedMark, err)
+	}
+
+	if data, _ := ioutil.ReadFile(target); string(data) != "first-second" {
+		t.Fatalf("%s Expected appended contents but got %q", failedMark, data)
+	}
+	t.Logf("%s Expected appended contents", succeedMark)
+
+	if _, err := fs.WriteFile(target, fs.WriteOptions{})(context.New(), nil, strings.NewReader("third")); err != nil {
+		t.Fatalf("%s Expected to truncate file: %s", failedMark, err)
+	}
+
+	if data, _ := ioutil.ReadFile(target); string(data) != "third" {
+		t.Fatalf("%s Expected truncated contents but got %q", failedMark, data)
+	}
+	t.Logf("%s Expected truncated contents", succeedMark)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-atomic")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "config.json")
+
+	if _, err := fs.WriteFileAtomic(target, fs.WriteOptions{Perm: 0600})(context.New(), nil, "first"); err != nil {
+		t.Fatalf("%s Expected to write file atomically: %s", failedMark, err)
+	}
+
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("%s Expected file to be created with permissions: %v", failedMark, err)
+	}
+	t.Logf("%s Expected file to be created with permissions", succeedMark)
+
+	failing := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("broken")))
+	if _, err := fs.WriteFileAtomic(target, fs.WriteOptions{})(context.New(), nil, failing); err == nil {
+		t.Fatalf("%s Expected failed write to return error", failedMark)
+	}
+
+	if data, _ := ioutil.ReadFile(target); string(data) != "first" {
+		t.Fatalf("%s Expected failed write to leave file intact but got %q", failedMark, data)
+	}
+
+	if items, _ := ioutil.ReadDir(dir); len(items) != 1 {
+		t.Fatalf("%s Expected failed write to remove temporary file but found %d files", failedMark, len(items))
+	}
+	t.Logf("%s Expected failed write to leave file intact", succeedMark)
+}
+
+func TestReadLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs-lines")
+	if err != nil {
+		t.Fatalf("%s Expected to create temp directory: %s", failedMark, err)
+	}
+	defer os.RemoveAll(dir)
+
+	target := filepath.Join(dir, "access.log")
+	if err := ioutil.WriteFile(target, []byte("GET /\r\nPOST /login\nGET /assets"), 0600); err != nil {
+		t.Fatalf("%s Expected to write file: %s", failedMark, err)
+	}
+
+	var lines []string
+	var done bool
+
+	fs.ReadLines(target).Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(line string) {
+		lines = append(lines, line)
+	}, func(bool) {
+		done = true
+	}, nil), false))
+
+	if fmt.Sprintf("%q", lines) != `["GET /" "POST /login" "GET /assets"]` || !done {
+		t.Fatalf("%s Expected file to be read line by line but got %q", failedMark, lines)
+	}
+	t.Logf("%s Expected file to be read line by line", succeedMark)
+
+	var failed error
+	fs.ReadLinesFromReader(strings.NewReader("short\nmuch longer line"), 8).Subscribe(fractals.NewObservable(fractals.Behaviour{
+		Next: fractals.IdentityHandler(),
+		Error: fractals.MustWrap(func(err error) {
+			failed = err
+		}),
+	}, false))
+
+	if failed != bufio.ErrTooLong {
+		t.Fatalf("%s Expected bufio.ErrTooLong for long line but got %v", failedMark, failed)
+	}
+	t.Logf("%s Expected bufio.ErrTooLong for long line", succeedMark)
+}
+
+func TestParseCSV(t *testing.T) {
+	data := "# users\nname;age\nalex; 30\nsam; 25\n"
+
+	rows, err := fs.ParseCSV(fs.CSVOptions{Comma: ';', Comment: '#', Header: true, TrimLeadingSpace: true})(context.New(), nil, data)
+	if err != nil {
+		t.Fatalf("%s Expected to parse csv: %s", failedMark, err)
+	}
+
+	if fmt.Sprint(rows) != "[map[age:30 name:alex] map[age:25 name:sam]]" {
+		t.Fatalf("%s Expected records keyed by header but got %+v", failedMark, rows)
+	}
+	t.Logf("%s Expected records keyed by header", succeedMark)
+
+	records, err := fs.ParseCSV(fs.CSVOptions{})(context.New(), nil, []byte("a,b\nc,d"))
+	if err != nil {
+		t.Fatalf("%s Expected to parse csv: %s", failedMark, err)
+	}
+
+	if fmt.Sprint(records) != "[[a b] [c d]]" {
+		t.Fatalf("%s Expected raw records but got %+v", failedMark, records)
+	}
+	t.Logf("%s Expected raw records", succeedMark)
+}
+
+func TestParseJSONL(t *testing.T) {
+	data := strings.NewReader("{\"event\":\"login\"}\n\n{\"event\":\"logout\"}")
+
+	records, err := fs.ParseJSONL()(context.New(), nil, data)
+	if err != nil {
+		t.Fatalf("%s Expected to parse json lines: %s", failedMark, err)
+	}
+
+	if fmt.Sprint(records) != "[map[event:login] map[event:logout]]" {
+		t.Fatalf("%s Expected a record per line but got %+v", failedMark, records)
+	}
+	t.Logf("%s Expected a record per line", succeedMark)
+
+	if _, err := fs.ParseJSONL()(context.New(), nil, "{\"event\":\"login\"}\n{broken"); err == nil {
+		t.Fatalf("%s Expected error for invalid line", failedMark)
+	}
+	t.Logf("%s Expected error for invalid line", succeedMark)
+}
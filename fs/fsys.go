@@ -0,0 +1,101 @@
+//go:build go1.16
+// +build go1.16
+
+package fs
+
+import (
+	"fmt"
+	iofs "io/fs"
+	"path"
+	"strings"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// ReadFileFS works like ReadFile, reading the file from the provided
+// filesystem, such as a embed.FS, instead of the operating system's.
+func ReadFileFS(fsys iofs.FS) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, target string) ([]byte, error) {
+		return iofs.ReadFile(fsys, target)
+	})
+}
+
+// ReadDirPathFS works like ReadDirPath, reading the directory from the
+// provided filesystem instead of the operating system's.
+func ReadDirPathFS(fsys iofs.FS) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, dir string) ([]ExtendedFileInfo, error) {
+		entries, err := iofs.ReadDir(fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		var edirs []ExtendedFileInfo
+
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+
+			edirs = append(edirs, newFSFileInfo(info, dir))
+		}
+
+		return edirs, nil
+	})
+}
+
+// ResolvePathInFS works like ResolvePathIn, resolving the path within the
+// rootDir of the provided filesystem instead of the operating system's.
+func ResolvePathInFS(fsys iofs.FS, rootDir string) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, target string) (ExtendedFileInfo, error) {
+		finalPath, err := resolveFSPath(rootDir, target)
+		if err != nil {
+			return nil, err
+		}
+
+		stat, err := iofs.Stat(fsys, finalPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return newFSFileInfo(stat, path.Dir(finalPath)), nil
+	})
+}
+
+// ResolvePathStringInFS works like ResolvePathStringIn, returning the path
+// within the rootDir of a io/fs.FS, which is always slash separated and
+// unrooted as the io/fs package expects.
+func ResolvePathStringInFS(rootDir string) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, target string) (string, error) {
+		return resolveFSPath(rootDir, target)
+	})
+}
+
+// resolveFSPath joins the path to the rootDir, returning an error if the
+// result is not a valid io/fs path within the rootDir.
+func resolveFSPath(rootDir string, target string) (string, error) {
+	rootDir = path.Clean(rootDir)
+
+	finalPath := path.Join(rootDir, strings.TrimPrefix(target, "/"))
+
+	if !iofs.ValidPath(finalPath) {
+		return "", fmt.Errorf("Path is outside of root {Root: %q, Path: %q, Wanted: %q}", rootDir, target, finalPath)
+	}
+
+	if rootDir != "." && finalPath != rootDir && !strings.HasPrefix(finalPath, rootDir+"/") {
+		return "", fmt.Errorf("Path is outside of root {Root: %q, Path: %q, Wanted: %q}", rootDir, target, finalPath)
+	}
+
+	return finalPath, nil
+}
+
+// newFSFileInfo returns a ExtendedFileInfo whoes path is slash separated as
+// the io/fs package expects.
+func newFSFileInfo(info iofs.FileInfo, root string) ExtendedFileInfo {
+	return extendedFileInfo{
+		FileInfo: info,
+		path:     path.Join(root, info.Name()),
+		root:     root,
+	}
+}
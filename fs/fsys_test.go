@@ -0,0 +1,56 @@
+//go:build go1.16
+// +build go1.16
+
+package fs_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+	"github.com/influx6/fractals/fs"
+)
+
+func TestFSHandlers(t *testing.T) {
+	fsys := fstest.MapFS{
+		"assets/index.html":  {Data: []byte("<html></html>")},
+		"assets/css/app.css": {Data: []byte("body{}")},
+		"private/key.pem":    {Data: []byte("key")},
+	}
+
+	res, err := fractals.Lift(fs.ResolvePathStringInFS("assets"), fs.ReadFileFS(fsys))(nil)(context.New(), nil, "/index.html")
+	if err != nil {
+		t.Fatalf("%s Expected to read file from filesystem: %s", failedMark, err)
+	}
+
+	if string(res.([]byte)) != "<html></html>" {
+		t.Fatalf("%s Expected file contents but got %q", failedMark, res)
+	}
+	t.Logf("%s Expected to read file from filesystem", succeedMark)
+
+	if _, err := fs.ResolvePathStringInFS("assets")(context.New(), nil, "../private/key.pem"); err == nil {
+		t.Fatalf("%s Expected error resolving path outside of root", failedMark)
+	}
+	t.Logf("%s Expected error resolving path outside of root", succeedMark)
+
+	info, err := fs.ResolvePathInFS(fsys, "assets")(context.New(), nil, "css/app.css")
+	if err != nil {
+		t.Fatalf("%s Expected to resolve path: %s", failedMark, err)
+	}
+
+	if path := info.(fs.ExtendedFileInfo).Path(); path != "assets/css/app.css" {
+		t.Fatalf("%s Expected resolved path %q but got %q", failedMark, "assets/css/app.css", path)
+	}
+	t.Logf("%s Expected resolved path %q", succeedMark, "assets/css/app.css")
+
+	entries, err := fs.ReadDirPathFS(fsys)(context.New(), nil, "assets")
+	if err != nil {
+		t.Fatalf("%s Expected to read directory: %s", failedMark, err)
+	}
+
+	if total := len(entries.([]fs.ExtendedFileInfo)); total != 2 {
+		t.Fatalf("%s Expected %d entries but got %d", failedMark, 2, total)
+	}
+	t.Logf("%s Expected %d entries", succeedMark, 2)
+}
@@ -0,0 +1,133 @@
+package fs
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// ErrDigestMismatch is returned when the digest of the data received does not
+// match the expected digest.
+var ErrDigestMismatch = errors.New("Digest does not match expected digest")
+
+// HashAlgorithm defines the name of a hashing algorithm supported by the hash
+// handlers.
+type HashAlgorithm string
+
+// Hashing algorithms supported by the hash handlers.
+const (
+	SHA256 HashAlgorithm = "sha256"
+	SHA1   HashAlgorithm = "sha1"
+	MD5    HashAlgorithm = "md5"
+	CRC32  HashAlgorithm = "crc32"
+)
+
+// New returns a new hash.Hash for the algorithm.
+func (algo HashAlgorithm) New() (hash.Hash, error) {
+	switch algo {
+	case SHA256:
+		return sha256.New(), nil
+	case SHA1:
+		return sha1.New(), nil
+	case MD5:
+		return md5.New(), nil
+	case CRC32:
+		return crc32.NewIEEE(), nil
+	default:
+		return nil, fmt.Errorf("Unknown hash algorithm {Algorithm: %q}", algo)
+	}
+}
+
+// HashFile returns a Handler which hashes the contents of the file at the path
+// it receives, either as a string or a ExtendedFileInfo, passing down the hex
+// encoded digest.
+func HashFile(algo HashAlgorithm) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, item interface{}) (string, error) {
+		path, err := sourcePath(item)
+		if err != nil {
+			return "", err
+		}
+
+		return hashFile(algo, path)
+	})
+}
+
+// HashBytes returns a Handler which hashes the []byte it receives, passing
+// down the hex encoded digest.
+func HashBytes(algo HashAlgorithm) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, data []byte) (string, error) {
+		return hashBytes(algo, data)
+	})
+}
+
+// VerifyDigest returns a Handler which hashes what it receives, either a
+// []byte or the contents of the file at a path given as a string or a
+// ExtendedFileInfo, returning ErrDigestMismatch if the hex encoded digest does
+// not match the expected one. Matching values are passed down as received.
+func VerifyDigest(algo HashAlgorithm, expected string) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, item interface{}) (interface{}, error) {
+		var digest string
+		var err error
+
+		if data, ok := item.([]byte); ok {
+			digest, err = hashBytes(algo, data)
+		} else {
+			var path string
+			if path, err = sourcePath(item); err == nil {
+				digest, err = hashFile(algo, path)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if digest != expected {
+			return nil, ErrDigestMismatch
+		}
+
+		return item, nil
+	})
+}
+
+// hashFile returns the hex encoded digest of the file at path.
+func hashFile(algo HashAlgorithm, path string) (string, error) {
+	h, err := algo.New()
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashBytes returns the hex encoded digest of data.
+func hashBytes(algo HashAlgorithm, data []byte) (string, error) {
+	h, err := algo.New()
+	if err != nil {
+		return "", err
+	}
+
+	h.Write(data)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
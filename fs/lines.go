@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// DefaultMaxLineSize defines the size of the longest line ReadLines accepts.
+const DefaultMaxLineSize = 64 * 1024
+
+// ReadLines returns a cold Observable which reads the file at path line by
+// line for every subscriber, emitting each line as a string without it's line
+// ending. Lines longer than DefaultMaxLineSize stop the read with
+// bufio.ErrTooLong passed through Error. Done is signaled with true once the
+// whole file is read.
+func ReadLines(path string) fractals.Observable {
+	return ReadLinesWith(path, DefaultMaxLineSize)
+}
+
+// ReadLinesWith works like ReadLines, accepting lines of up to maxLineSize
+// bytes.
+func ReadLinesWith(path string, maxLineSize int) fractals.Observable {
+	return fractals.NewColdObservable(func(o fractals.Observer) {
+		file, err := os.Open(path)
+		if err != nil {
+			o.Error(context.New(), err)
+			return
+		}
+
+		defer file.Close()
+
+		scanLines(file, maxLineSize, o)
+	})
+}
+
+// ReadLinesFromReader works like ReadLinesWith, reading the lines from r. As
+// the reader can only be read once, only the first subscriber receives it's
+// lines.
+func ReadLinesFromReader(r io.Reader, maxLineSize int) fractals.Observable {
+	return fractals.NewColdObservable(func(o fractals.Observer) {
+		scanLines(r, maxLineSize, o)
+	})
+}
+
+// scanLines emits every line read from r to the observer.
+func scanLines(r io.Reader, maxLineSize int, o fractals.Observer) {
+	if maxLineSize <= 0 {
+		maxLineSize = DefaultMaxLineSize
+	}
+
+	ctx := context.New()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, minInt(maxLineSize, bufio.MaxScanTokenSize)), maxLineSize)
+
+	for scanner.Scan() {
+		o.Next(ctx, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		o.Error(ctx, err)
+		return
+	}
+
+	o.Done(ctx, true)
+}
+
+// minInt returns the smaller of a and b.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,94 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+var (
+	// ErrLockTimeout is returned when a lock could not be acquired within the
+	// timeout provided.
+	ErrLockTimeout = errors.New("Timed out acquiring file lock")
+
+	// ErrLockUnsupported is returned when file locking is not supported on the
+	// current platform.
+	ErrLockUnsupported = errors.New("File locking is not supported on this platform")
+)
+
+// LockPollInterval defines the interval with which WithLock retries acquiring
+// a lock held by someone else.
+var LockPollInterval = 10 * time.Millisecond
+
+// LockOptions defines the configuration used by WithLockOptions.
+type LockOptions struct {
+	// Shared when true, acquires a shared lock which may be held by many at
+	// once, else an exclusive lock is acquired.
+	Shared bool
+
+	// Timeout sets how long to wait for the lock before failing with
+	// ErrLockTimeout. A zero value waits till the lock is acquired or the
+	// context is done.
+	Timeout time.Duration
+}
+
+// WithLock returns a Handler which runs h while holding an exclusive advisory
+// lock on the file at path, which is created if it does not exist. The lock
+// is only respected by others acquiring it the same way, which allows
+// pipelines in different processes to safely mutate shared files.
+func WithLock(path string, h fractals.Handler) fractals.Handler {
+	return WithLockOptions(path, LockOptions{}, h)
+}
+
+// WithLockOptions works like WithLock, using the provided options.
+func WithLockOptions(path string, opts LockOptions, h fractals.Handler) fractals.Handler {
+	return func(ctx context.Context, err error, data interface{}) (interface{}, error) {
+		file, ferr := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if ferr != nil {
+			return nil, ferr
+		}
+
+		defer file.Close()
+
+		if lerr := acquireLock(ctx, file, opts); lerr != nil {
+			return nil, lerr
+		}
+
+		defer unlockFile(file)
+
+		return h(ctx, err, data)
+	}
+}
+
+// acquireLock retries locking the file till it succeeds, the timeout passes
+// or the context is done.
+func acquireLock(ctx context.Context, file *os.File, opts LockOptions) error {
+	var deadline time.Time
+	if opts.Timeout > 0 {
+		deadline = time.Now().Add(opts.Timeout)
+	}
+
+	for {
+		locked, err := lockFile(file, opts.Shared)
+		if err != nil {
+			return err
+		}
+
+		if locked {
+			return nil
+		}
+
+		if err := fractals.ContextErr(ctx); err != nil {
+			return err
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+
+		time.Sleep(LockPollInterval)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+
+package fs
+
+import "os"
+
+// lockFile reports ErrLockUnsupported, as the platform has no advisory locks.
+func lockFile(file *os.File, shared bool) (bool, error) {
+	return false, ErrLockUnsupported
+}
+
+// unlockFile reports ErrLockUnsupported, as the platform has no advisory locks.
+func unlockFile(file *os.File) error {
+	return ErrLockUnsupported
+}
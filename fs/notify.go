@@ -0,0 +1,206 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// WatchOptions defines the configuration used by WatchWith.
+type WatchOptions struct {
+	// Debounce sets how long a path must stay unchanged before it's last
+	// event is emitted, which collapses the bursts of events editors and
+	// build tools generate. A zero value emits every event as it occurs.
+	Debounce time.Duration
+
+	// Recursive when true, watches all directories within the path,
+	// including those created after the watch started.
+	Recursive bool
+}
+
+// Watch returns a Observable which emits a FileEvent for every file created,
+// modified or removed at the giving path as reported by the operating system,
+// till the Observable is ended. If the watch can not be started, the error is
+// passed through Error once the Observable gets it's first subscriber.
+func Watch(path string) fractals.Observable {
+	return WatchWith(path, WatchOptions{})
+}
+
+// WatchHandler returns a Handler which starts a watch using the provided
+// options on every path it receives, passing down the fractals.Observable
+// emitting it's FileEvents.
+func WatchHandler(opts WatchOptions) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, path string) (fractals.Observable, error) {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addWatch(watcher, path, opts.Recursive); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+
+		return watchObservable(watcher, opts), nil
+	})
+}
+
+// WatchWith works like Watch, using the provided options.
+func WatchWith(path string, opts WatchOptions) fractals.Observable {
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		if err = addWatch(watcher, path, opts.Recursive); err != nil {
+			watcher.Close()
+		}
+	}
+
+	if err != nil {
+		return fractals.NewColdObservable(func(o fractals.Observer) {
+			o.Error(context.New(), err)
+		})
+	}
+
+	return watchObservable(watcher, opts)
+}
+
+// addWatch adds the path to the watcher, along with all directories within it
+// if recursive is true.
+func addWatch(watcher *fsnotify.Watcher, path string, recursive bool) error {
+	if !recursive {
+		return watcher.Add(path)
+	}
+
+	return filepath.Walk(path, func(target string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		return watcher.Add(target)
+	})
+}
+
+// watchObservable returns a Observable emitting the events of the watcher,
+// closing it once ended.
+func watchObservable(watcher *fsnotify.Watcher, opts WatchOptions) fractals.Observable {
+	ob := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+
+	var ml sync.Mutex
+	var stopped bool
+
+	timers := make(map[string]*time.Timer)
+	pending := make(map[string]FileEvent)
+
+	emit := func(event FileEvent) {
+		if opts.Debounce <= 0 {
+			ob.Next(context.New(), event)
+			return
+		}
+
+		ml.Lock()
+		defer ml.Unlock()
+
+		if stopped {
+			return
+		}
+
+		// A file created and then modified within the debounce window is
+		// still reported as created.
+		if last, ok := pending[event.Path]; ok && last.Op == FileCreate && event.Op == FileModify {
+			event = last
+		}
+
+		pending[event.Path] = event
+
+		if timer, ok := timers[event.Path]; ok {
+			timer.Stop()
+		}
+
+		// Timers stopped too late to keep their callback from running, by a
+		// newer event or the observable ending, find themselves replaced and
+		// emit nothing. The lock is held till the timer is stored.
+		var timer *time.Timer
+		timer = time.AfterFunc(opts.Debounce, func() {
+			ml.Lock()
+			last, ok := pending[event.Path]
+			if stopped || !ok || timers[event.Path] != timer {
+				ml.Unlock()
+				return
+			}
+
+			delete(pending, event.Path)
+			delete(timers, event.Path)
+			ml.Unlock()
+
+			ob.Next(context.New(), last)
+		})
+
+		timers[event.Path] = timer
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				op, ok := fileOp(event.Op)
+				if !ok {
+					continue
+				}
+
+				// Newly created directories need to be watched as well.
+				if opts.Recursive && op == FileCreate {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						addWatch(watcher, event.Name, true)
+					}
+				}
+
+				emit(FileEvent{Path: event.Name, Op: op})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				ob.Error(context.New(), err)
+			}
+		}
+	}()
+
+	ob.AddFinalizer(func() {
+		ml.Lock()
+		stopped = true
+		for _, timer := range timers {
+			timer.Stop()
+		}
+		ml.Unlock()
+
+		watcher.Close()
+	})
+
+	return ob
+}
+
+// fileOp returns the FileOp matching the fsnotify operation.
+func fileOp(op fsnotify.Op) (FileOp, bool) {
+	switch {
+	case op&fsnotify.Create != 0:
+		return FileCreate, true
+	case op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		return FileRemove, true
+	case op&(fsnotify.Write|fsnotify.Chmod) != 0:
+		return FileModify, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,113 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// CSVOptions defines the configuration used by ParseCSV.
+type CSVOptions struct {
+	// Comma sets the field delimiter, defaulting to ','.
+	Comma rune
+
+	// Comment when set, leaves out lines starting with it.
+	Comment rune
+
+	// Header when true, uses the first record as the names of the fields,
+	// turning every following record into a map.
+	Header bool
+
+	// TrimLeadingSpace when true, ignores leading white space in fields.
+	TrimLeadingSpace bool
+}
+
+// ParseCSV returns a Handler which parses the []byte, string or io.Reader it
+// receives as CSV, passing down the records as a [][]string, or as a
+// []map[string]string keyed by field names if the options use a header.
+func ParseCSV(opts CSVOptions) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, data interface{}) (interface{}, error) {
+		src, err := dataReader(data)
+		if err != nil {
+			return nil, err
+		}
+
+		reader := csv.NewReader(src)
+		reader.Comment = opts.Comment
+		reader.TrimLeadingSpace = opts.TrimLeadingSpace
+
+		if opts.Comma != 0 {
+			reader.Comma = opts.Comma
+		}
+
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+
+		if !opts.Header {
+			return records, nil
+		}
+
+		rows := make([]map[string]string, 0, len(records))
+		if len(records) == 0 {
+			return rows, nil
+		}
+
+		header := records[0]
+		for _, record := range records[1:] {
+			row := make(map[string]string, len(header))
+
+			for index, name := range header {
+				row[name] = record[index]
+			}
+
+			rows = append(rows, row)
+		}
+
+		return rows, nil
+	})
+}
+
+// ParseJSONL returns a Handler which parses the []byte, string or io.Reader it
+// receives as JSON lines, where every non-empty line holds a JSON object,
+// passing down the objects as a []map[string]interface{}.
+func ParseJSONL() fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, data interface{}) ([]map[string]interface{}, error) {
+		src, err := dataReader(data)
+		if err != nil {
+			return nil, err
+		}
+
+		var records []map[string]interface{}
+
+		reader := bufio.NewReader(src)
+
+		for line := 1; ; line++ {
+			content, err := reader.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+
+			if trimmed := bytes.TrimSpace(content); len(trimmed) != 0 {
+				record := make(map[string]interface{})
+
+				if jerr := json.Unmarshal(trimmed, &record); jerr != nil {
+					return nil, fmt.Errorf("Invalid JSON line {Line: %d}: %s", line, jerr)
+				}
+
+				records = append(records, record)
+			}
+
+			if err == io.EOF {
+				return records, nil
+			}
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// ErrOutsideSandbox is returned when a path resolves to a location outside of
+// the sandbox root or it's allowed subpaths.
+var ErrOutsideSandbox = errors.New("Path is outside of sandbox")
+
+// SandboxOption defines a function which configures the Sandbox handler.
+type SandboxOption func(*sandbox)
+
+// AllowPaths restricts the Sandbox to the provided subpaths of the root,
+// rejecting any path which does not resolve within one of them.
+func AllowPaths(subpaths ...string) SandboxOption {
+	return func(s *sandbox) {
+		s.allowed = append(s.allowed, subpaths...)
+	}
+}
+
+// AllowMissing allows the Sandbox to resolve paths which do not exist yet,
+// such as files about to be created, by resolving their closest existing
+// parent directory.
+func AllowMissing() SandboxOption {
+	return func(s *sandbox) {
+		s.missing = true
+	}
+}
+
+// sandbox defines the configuration of the Sandbox handler.
+type sandbox struct {
+	allowed []string
+	missing bool
+}
+
+// Sandbox returns a Handler which resolves the path it receives against root,
+// passing down the canonical absolute path with all symbolic links resolved.
+// Received paths are always treated as relative to root, and any path which
+// ends up outside of root, be it through ".." elements or symbolic links
+// pointing elsewhere, fails with an error wrapping ErrOutsideSandbox.
+func Sandbox(root string, opts ...SandboxOption) fractals.Handler {
+	var sb sandbox
+	for _, opt := range opts {
+		opt(&sb)
+	}
+
+	return fractals.MustWrap(func(ctx context.Context, path string) (string, error) {
+		realRoot, err := canonicalPath(root, false)
+		if err != nil {
+			return "", err
+		}
+
+		realPath, err := canonicalPath(filepath.Join(realRoot, path), sb.missing)
+		if err != nil {
+			return "", err
+		}
+
+		if !withinPath(realRoot, realPath) {
+			return "", fmt.Errorf("%w {Root: %q, Path: %q, Wanted: %q}", ErrOutsideSandbox, root, path, realPath)
+		}
+
+		if len(sb.allowed) == 0 {
+			return realPath, nil
+		}
+
+		for _, sub := range sb.allowed {
+			allowed, err := canonicalPath(filepath.Join(realRoot, sub), true)
+			if err != nil {
+				continue
+			}
+
+			if withinPath(realRoot, allowed) && withinPath(allowed, realPath) {
+				return realPath, nil
+			}
+		}
+
+		return "", fmt.Errorf("%w {Root: %q, Path: %q, Allowed: %q}", ErrOutsideSandbox, root, path, sb.allowed)
+	})
+}
+
+// canonicalPath returns the absolute path with all symbolic links resolved.
+// If missing is true, paths which do not exist are resolved through their
+// closest existing parent.
+func canonicalPath(path string, missing bool) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	real, err := filepath.EvalSymlinks(abs)
+	if err == nil || !missing || !os.IsNotExist(err) {
+		return real, err
+	}
+
+	parent := filepath.Dir(abs)
+	if parent == abs {
+		return "", err
+	}
+
+	realParent, err := canonicalPath(parent, true)
+	if err != nil {
+		return "", err
+	}
+
+	candidate := filepath.Join(realParent, filepath.Base(abs))
+
+	// A dangling symbolic link resolves to where it's target would be
+	// created, which must be checked against the root like any other path.
+	stat, err := os.Lstat(candidate)
+	if err != nil || stat.Mode()&os.ModeSymlink == 0 {
+		return candidate, nil
+	}
+
+	target, err := os.Readlink(candidate)
+	if err != nil {
+		return "", err
+	}
+
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(realParent, target)
+	}
+
+	return canonicalPath(target, true)
+}
+
+// withinPath returns true/false if path is root or lies within it.
+func withinPath(root string, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// SymlinkPolicy defines how symbolic links are treated by the handlers which
+// read through directories.
+type SymlinkPolicy int
+
+const (
+	// SkipSymlinks leaves out symbolic links.
+	SkipSymlinks SymlinkPolicy = iota
+
+	// FollowSymlinks resolves symbolic links, reporting and walking through
+	// what they point to.
+	FollowSymlinks
+
+	// ReportSymlinks reports symbolic links as they are, without walking
+	// through them.
+	ReportSymlinks
+)
+
+// ReadLink returns a Handler which passes down the destination of the
+// symbolic link at the path it receives, either as a string or a
+// ExtendedFileInfo.
+func ReadLink() fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, item interface{}) (string, error) {
+		path, err := sourcePath(item)
+		if err != nil {
+			return "", err
+		}
+
+		return os.Readlink(path)
+	})
+}
+
+// EvalSymlinks returns a Handler which passes down the path it receives,
+// either as a string or a ExtendedFileInfo, with all symbolic links within it
+// resolved.
+func EvalSymlinks() fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, item interface{}) (string, error) {
+		path, err := sourcePath(item)
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.EvalSymlinks(path)
+	})
+}
+
+// applySymlinkPolicy returns the ExtendedFileInfo for the entry found within
+// dir as dictated by the policy, returning false if the entry is to be left
+// out. Broken links have nothing to follow, so are left out when followed.
+func applySymlinkPolicy(info os.FileInfo, dir string, policy SymlinkPolicy) (ExtendedFileInfo, bool) {
+	entry := NewExtendedFileInfo(info, dir)
+
+	if info.Mode()&os.ModeSymlink == 0 {
+		return entry, true
+	}
+
+	switch policy {
+	case SkipSymlinks:
+		return nil, false
+	case FollowSymlinks:
+		target, err := os.Stat(entry.Path())
+		if err != nil {
+			return nil, false
+		}
+
+		return NewExtendedFileInfo(target, dir), true
+	default:
+		return entry, true
+	}
+}
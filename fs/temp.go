@@ -0,0 +1,88 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// TempOptions defines the configuration used by TempFileWith and TempDirWith.
+type TempOptions struct {
+	// Dir sets the directory the temporaries are created in, defaulting to
+	// os.TempDir.
+	Dir string
+
+	// Observable when set, has the temporaries removed once it is ended.
+	Observable fractals.Observable
+}
+
+// TempFile returns a Handler which creates a new temporary file whoes name
+// starts with prefix, passing down the opened *os.File. If the context it
+// receives carries a standard library context, the file is closed and removed
+// once that context is done.
+func TempFile(prefix string) fractals.Handler {
+	return TempFileWith(prefix, TempOptions{})
+}
+
+// TempFileWith works like TempFile, using the provided options.
+func TempFileWith(prefix string, opts TempOptions) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, _ interface{}) (*os.File, error) {
+		file, err := ioutil.TempFile(opts.Dir, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		cleanupTemp(ctx, opts, func() {
+			file.Close()
+			os.Remove(file.Name())
+		})
+
+		return file, nil
+	})
+}
+
+// TempDir returns a Handler which creates a new temporary directory whoes
+// name starts with prefix, passing down it's path. If the context it receives
+// carries a standard library context, the directory and all it's contents are
+// removed once that context is done.
+func TempDir(prefix string) fractals.Handler {
+	return TempDirWith(prefix, TempOptions{})
+}
+
+// TempDirWith works like TempDir, using the provided options.
+func TempDirWith(prefix string, opts TempOptions) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, _ interface{}) (string, error) {
+		dir, err := ioutil.TempDir(opts.Dir, prefix)
+		if err != nil {
+			return "", err
+		}
+
+		cleanupTemp(ctx, opts, func() {
+			os.RemoveAll(dir)
+		})
+
+		return dir, nil
+	})
+}
+
+// cleanupTemp registers the cleanup to run once, when either the standard
+// library context carried by ctx is done or the Observable in the options is
+// ended, whichever comes first.
+func cleanupTemp(ctx context.Context, opts TempOptions, cleanup func()) {
+	var once sync.Once
+	run := func() { once.Do(cleanup) }
+
+	if opts.Observable != nil {
+		opts.Observable.AddFinalizer(run)
+	}
+
+	if done := fractals.StdContext(ctx).Done(); done != nil {
+		go func() {
+			<-done
+			run()
+		}()
+	}
+}
@@ -0,0 +1,190 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// WalkOptions defines the configuration used by WalkTree and WalkObservable.
+type WalkOptions struct {
+	// MaxDepth sets how deep into the tree entries are read, where 1 only
+	// reads the entries of the root. A zero value has no limit.
+	MaxDepth int
+
+	// Symlinks sets how symbolic links are treated.
+	Symlinks SymlinkPolicy
+
+	// Skip when set, leaves out every entry for which it returns true,
+	// directories left out are not walked through.
+	Skip func(ExtendedFileInfo) bool
+}
+
+// WalkTree returns a Handler which recursively walks the root directory,
+// passing down all entries found as a []ExtendedFileInfo, ordered as they
+// were walked. If the Handler receives a non-empty string, then it is used
+// as the root instead.
+func WalkTree(root string, opts WalkOptions) fractals.Handler {
+	return fractals.MustWrap(func(ctx context.Context, path string) ([]ExtendedFileInfo, error) {
+		if path == "" {
+			path = root
+		}
+
+		var entries []ExtendedFileInfo
+
+		err := walkTree(ctx, path, opts, func(info ExtendedFileInfo) error {
+			entries = append(entries, info)
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return entries, nil
+	})
+}
+
+// WalkObservable returns a cold Observable which walks the root directory for
+// every subscriber, emitting every entry found as a ExtendedFileInfo as it is
+// found. Errors are passed through Error and Done is signaled with true once
+// the walk is over.
+func WalkObservable(root string, opts WalkOptions) fractals.Observable {
+	return fractals.NewColdObservable(func(o fractals.Observer) {
+		ctx := context.New()
+
+		err := walkTree(ctx, root, opts, func(info ExtendedFileInfo) error {
+			o.Next(ctx, info)
+			return nil
+		})
+
+		if err != nil {
+			o.Error(ctx, err)
+		}
+
+		o.Done(ctx, true)
+	})
+}
+
+// walkTree walks the root directory, calling emit for every entry allowed by
+// the options.
+func walkTree(ctx context.Context, root string, opts WalkOptions, emit func(ExtendedFileInfo) error) error {
+	visited := make(map[string]bool)
+
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		visited[real] = true
+	}
+
+	return walkDir(ctx, root, 1, opts, visited, emit)
+}
+
+func walkDir(ctx context.Context, dir string, depth int, opts WalkOptions, visited map[string]bool, emit func(ExtendedFileInfo) error) error {
+	if err := fractals.ContextErr(ctx); err != nil {
+		return err
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		entry, ok := applySymlinkPolicy(info, dir, opts.Symlinks)
+		if !ok {
+			continue
+		}
+
+		if opts.Skip != nil && opts.Skip(entry) {
+			continue
+		}
+
+		if err := emit(entry); err != nil {
+			return err
+		}
+
+		if !entry.IsDir() || (opts.MaxDepth > 0 && depth >= opts.MaxDepth) {
+			continue
+		}
+
+		// Reported symlinks are not walked through.
+		if info.Mode()&os.ModeSymlink != 0 && opts.Symlinks != FollowSymlinks {
+			continue
+		}
+
+		// Guard against symlinks which lead back up the tree.
+		if real, err := filepath.EvalSymlinks(entry.Path()); err == nil {
+			if visited[real] {
+				continue
+			}
+
+			visited[real] = true
+		}
+
+		if err := walkDir(ctx, entry.Path(), depth+1, opts, visited, emit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WalkParallel returns a cold Observable which walks the root directory for
+// every subscriber, reading up to workers directories at once and emitting
+// every entry found as a ExtendedFileInfo as soon as it's directory is read.
+// Entries are emitted one at a time, though in no particular order. Symbolic
+// links are reported but not walked through. Directories which can not be
+// read are passed through Error without stopping the walk, and Done is
+// signaled with true once it is over. If workers is zero or less, the number
+// of CPUs is used.
+func WalkParallel(root string, workers int) fractals.Observable {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return fractals.NewColdObservable(func(o fractals.Observer) {
+		ctx := context.New()
+
+		var ml sync.Mutex
+		var wg sync.WaitGroup
+
+		sem := make(chan struct{}, workers)
+
+		var visit func(dir string)
+		visit = func(dir string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			infos, err := ioutil.ReadDir(dir)
+			<-sem
+
+			ml.Lock()
+			defer ml.Unlock()
+
+			if err != nil {
+				o.Error(ctx, err)
+				return
+			}
+
+			for _, info := range infos {
+				entry := NewExtendedFileInfo(info, dir)
+				o.Next(ctx, entry)
+
+				if info.IsDir() {
+					wg.Add(1)
+					go visit(entry.Path())
+				}
+			}
+		}
+
+		wg.Add(1)
+		visit(root)
+		wg.Wait()
+
+		o.Done(ctx, true)
+	})
+}
@@ -0,0 +1,139 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// WatchInterval defines the interval with which WatchObservable checks the
+// watched path for changes.
+var WatchInterval = time.Second
+
+// FileOp defines the kind of change which occured to a file.
+type FileOp int
+
+// Kinds of changes reported through a FileEvent.
+const (
+	FileCreate FileOp = iota + 1
+	FileModify
+	FileRemove
+)
+
+// String returns the name of the operation.
+func (op FileOp) String() string {
+	switch op {
+	case FileCreate:
+		return "create"
+	case FileModify:
+		return "modify"
+	case FileRemove:
+		return "remove"
+	default:
+		return "unknown"
+	}
+}
+
+// FileEvent defines a change which occured to a file or directory.
+type FileEvent struct {
+	Path string
+	Op   FileOp
+}
+
+// WatchObservable returns a Observable which emits a FileEvent for every file
+// created, modified or removed at the giving path, which may be a file or a
+// directory, in which case all files within it are watched. Changes are
+// found by checking the path every WatchInterval, till the Observable is
+// ended.
+func WatchObservable(path string) fractals.Observable {
+	ob := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+
+	ticker := time.NewTicker(WatchInterval)
+	stop := make(chan struct{})
+
+	go func() {
+		previous := snapshotPath(path)
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				current := snapshotPath(path)
+
+				for _, event := range diffSnapshots(previous, current) {
+					ob.Next(context.New(), event)
+				}
+
+				previous = current
+			}
+		}
+	}()
+
+	var once sync.Once
+	ob.AddFinalizer(func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(stop)
+		})
+	})
+
+	return ob
+}
+
+// fileState defines the details used to find changes to a file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshotPath returns the state of all files and directories within the path.
+func snapshotPath(path string) map[string]fileState {
+	snapshot := make(map[string]fileState)
+
+	filepath.Walk(path, func(target string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		snapshot[target] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+
+	return snapshot
+}
+
+// diffSnapshots returns the events which turn the previous snapshot into the
+// current one, ordered by path.
+func diffSnapshots(previous, current map[string]fileState) []FileEvent {
+	var events []FileEvent
+
+	for path, state := range current {
+		old, ok := previous[path]
+		if !ok {
+			events = append(events, FileEvent{Path: path, Op: FileCreate})
+			continue
+		}
+
+		if !old.modTime.Equal(state.modTime) || old.size != state.size {
+			events = append(events, FileEvent{Path: path, Op: FileModify})
+		}
+	}
+
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			events = append(events, FileEvent{Path: path, Op: FileRemove})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Path < events[j].Path
+	})
+
+	return events
+}
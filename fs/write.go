@@ -0,0 +1,162 @@
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// ErrInvalidData is returned when a handler receives data it can not write.
+var ErrInvalidData = errors.New("Expected []byte, string or io.Reader")
+
+// DefaultFilePerm defines the permissions files are created with when
+// WriteOptions provides none.
+const DefaultFilePerm os.FileMode = 0644
+
+// WriteOptions defines the configuration used by WriteFile.
+type WriteOptions struct {
+	// Append when true, writes after the existing contents of the file,
+	// else the file is truncated first.
+	Append bool
+
+	// Perm sets the permissions the file is created with, defaulting to
+	// DefaultFilePerm.
+	Perm os.FileMode
+
+	// MkdirParents when true, creates the missing parent directories of the
+	// file.
+	MkdirParents bool
+
+	// Sync when true, flushes the written data to disk before returning.
+	Sync bool
+}
+
+// WriteFile returns a Handler which writes the []byte, string or io.Reader
+// it receives to the file at path, creating it if it does not exist, and
+// passes down the total bytes written as an int64.
+func WriteFile(path string, opts WriteOptions) fractals.Handler {
+	perm := opts.Perm
+	if perm == 0 {
+		perm = DefaultFilePerm
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if opts.Append {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	return fractals.MustWrap(func(ctx context.Context, data interface{}) (int64, error) {
+		src, err := dataReader(data)
+		if err != nil {
+			return 0, err
+		}
+
+		if opts.MkdirParents {
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return 0, err
+			}
+		}
+
+		file, err := os.OpenFile(path, flags, perm)
+		if err != nil {
+			return 0, err
+		}
+
+		written, err := io.Copy(file, src)
+		if err != nil {
+			file.Close()
+			return written, err
+		}
+
+		if opts.Sync {
+			if err := file.Sync(); err != nil {
+				file.Close()
+				return written, err
+			}
+		}
+
+		return written, file.Close()
+	})
+}
+
+// WriteFileAtomic returns a Handler which writes the []byte, string or
+// io.Reader it receives to a temporary file beside the file at path, renaming
+// it over the file once fully written, so readers never see a partially
+// written file. It passes down the total bytes written as an int64. The
+// options' Append is ignored, while Sync flushes the temporary file before
+// it is renamed.
+func WriteFileAtomic(path string, opts WriteOptions) fractals.Handler {
+	perm := opts.Perm
+	if perm == 0 {
+		perm = DefaultFilePerm
+	}
+
+	return fractals.MustWrap(func(ctx context.Context, data interface{}) (int64, error) {
+		src, err := dataReader(data)
+		if err != nil {
+			return 0, err
+		}
+
+		dir := filepath.Dir(path)
+
+		if opts.MkdirParents {
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return 0, err
+			}
+		}
+
+		file, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+		if err != nil {
+			return 0, err
+		}
+
+		written, err := io.Copy(file, src)
+
+		if err == nil && opts.Sync {
+			err = file.Sync()
+		}
+
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+
+		if err == nil {
+			err = os.Chmod(file.Name(), perm)
+		}
+
+		if err == nil {
+			err = os.Rename(file.Name(), path)
+		}
+
+		if err != nil {
+			os.Remove(file.Name())
+			return written, err
+		}
+
+		return written, nil
+	})
+}
+
+// dataReader returns a io.Reader for the []byte, string or io.Reader
+// provided.
+func dataReader(data interface{}) (io.Reader, error) {
+	switch item := data.(type) {
+	case []byte:
+		return bytes.NewReader(item), nil
+	case string:
+		return strings.NewReader(item), nil
+	case io.Reader:
+		return item, nil
+	default:
+		return nil, ErrInvalidData
+	}
+}
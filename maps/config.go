@@ -0,0 +1,256 @@
+package maps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influx6/fractals"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrMultipleDocuments is returned by FromYAML when the bytes hold more than
+// one YAML document.
+var ErrMultipleDocuments = errors.New("Expected a single document")
+
+// ErrNullValue is returned by ToTOML when the structure holds nil values,
+// which TOML has no form for.
+var ErrNullValue = errors.New("TOML can not hold null values")
+
+// FromJSON returns a Handler which parses incoming bytes as JSON, passing down
+// the map[string]interface{} and []interface{} forms Find and Save
+// understand, where integers are int64s and decimals float64s.
+func FromJSON() fractals.Handler {
+	return fractals.MustWrap(func(data []byte) (interface{}, error) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		var decoded interface{}
+		if err := decoder.Decode(&decoded); err != nil {
+			return nil, err
+		}
+
+		if _, err := decoder.Token(); err != io.EOF {
+			return nil, fmt.Errorf("Invalid JSON: unexpected data after the document")
+		}
+
+		return configValue(decoded), nil
+	})
+}
+
+// ToJSON returns a Handler which encodes incoming structures as indented JSON,
+// passing down the bytes.
+func ToJSON() fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		return json.MarshalIndent(stringKeys(reflect.ValueOf(target)), "", "  ")
+	})
+}
+
+// FromYAML returns a Handler which parses incoming bytes as a single YAML
+// document, passing down the map[string]interface{} and []interface{} forms
+// Find and Save understand, where integers are int64s and decimals float64s.
+// Keys of mappings which are not strings are turned into their string form.
+func FromYAML() fractals.Handler {
+	return fractals.MustWrap(func(data []byte) (interface{}, error) {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+		var decoded interface{}
+		if err := decoder.Decode(&decoded); err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		var next interface{}
+		if err := decoder.Decode(&next); err != io.EOF {
+			if err != nil {
+				return nil, err
+			}
+
+			return nil, ErrMultipleDocuments
+		}
+
+		return configValue(decoded), nil
+	})
+}
+
+// ToYAML returns a Handler which encodes incoming structures as block style
+// YAML, with the keys of maps in order, passing down the bytes.
+func ToYAML() fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		return yaml.Marshal(stringKeys(reflect.ValueOf(target)))
+	})
+}
+
+// FromTOML returns a Handler which parses incoming bytes as TOML, passing down
+// the map[string]interface{} and []interface{} forms Find and Save
+// understand, where integers are int64s, decimals float64s and date-times,
+// dates and times time.Times.
+func FromTOML() fractals.Handler {
+	return fractals.MustWrap(func(data []byte) (interface{}, error) {
+		decoded := make(map[string]interface{})
+		if _, err := toml.Decode(string(data), &decoded); err != nil {
+			return nil, err
+		}
+
+		return configValue(decoded), nil
+	})
+}
+
+// ToTOML returns a Handler which encodes incoming maps as TOML, with the keys
+// of tables in order, passing down the bytes. TOML has no null, so structures
+// holding nil values fail with ErrNullValue.
+func ToTOML() fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		doc, ok := stringKeys(reflect.ValueOf(target)).(map[string]interface{})
+		if !ok {
+			return nil, ErrTypeNotFound
+		}
+
+		if hasNull(doc) {
+			return nil, ErrNullValue
+		}
+
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	})
+}
+
+// configValue returns the decoded value with it's maps turned into
+// map[string]interface{} and it's integers into int64s, so every format
+// passes down the same forms.
+func configValue(value interface{}) interface{} {
+	switch item := value.(type) {
+	case map[string]interface{}:
+		for key, child := range item {
+			item[key] = configValue(child)
+		}
+
+		return item
+
+	case map[interface{}]interface{}:
+		mapped := make(map[string]interface{}, len(item))
+		for key, child := range item {
+			mapped[fmt.Sprint(key)] = configValue(child)
+		}
+
+		return mapped
+
+	case []interface{}:
+		for index, child := range item {
+			item[index] = configValue(child)
+		}
+
+		return item
+
+	case int:
+		return int64(item)
+
+	case json.Number:
+		if number, err := item.Int64(); err == nil {
+			return number
+		}
+
+		number, _ := item.Float64()
+		return number
+	}
+
+	return value
+}
+
+// hasNull returns true/false if the structure holds a nil value.
+func hasNull(value interface{}) bool {
+	switch item := value.(type) {
+	case nil:
+		return true
+
+	case map[string]interface{}:
+		for _, child := range item {
+			if hasNull(child) {
+				return true
+			}
+		}
+
+	case []interface{}:
+		for _, child := range item {
+			if hasNull(child) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// stringKeys returns a copy of the value whoes maps are all
+// map[string]interface{} and lists []interface{}, as the encoders expect.
+func stringKeys(value reflect.Value) interface{} {
+	value = unwrapValue(value)
+
+	switch value.Kind() {
+	case reflect.Invalid:
+		return nil
+
+	case reflect.Map:
+		if value.IsNil() {
+			return nil
+		}
+
+		copied := make(map[string]interface{}, value.Len())
+		for _, key := range value.MapKeys() {
+			copied[fmt.Sprint(key.Interface())] = stringKeys(value.MapIndex(key))
+		}
+
+		return copied
+
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil
+		}
+
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+			return string(value.Bytes())
+		}
+
+		copied := make([]interface{}, value.Len())
+		for i := range copied {
+			copied[i] = stringKeys(value.Index(i))
+		}
+
+		return copied
+
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+
+		return stringKeys(value.Elem())
+
+	case reflect.Struct:
+		if _, ok := value.Interface().(time.Time); ok {
+			return value.Interface()
+		}
+
+		// Structs are encoded as their JSON form, honouring their json tags.
+		encoded, err := json.Marshal(value.Interface())
+		if err != nil {
+			return value.Interface()
+		}
+
+		var decoded interface{}
+		json.Unmarshal(encoded, &decoded)
+		return decoded
+
+	case reflect.Interface:
+		return nil
+	}
+
+	return value.Interface()
+}
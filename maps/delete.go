@@ -0,0 +1,135 @@
+package maps
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/influx6/fractals"
+)
+
+// DeleteOptions defines the configuration used by DeleteWith.
+type DeleteOptions struct {
+	// KeepIndexes leaves the zero value in place of deleted list items,
+	// instead of compacting the list, so the indexes of the items after them
+	// do not change.
+	KeepIndexes bool
+}
+
+// Delete runs down the providded map removing the key or index at the end of
+// the path, returning the removed value else returning an error as failure to
+// retrieve the giving path. Lists are compacted. See DeleteWith.
+func Delete(path string) fractals.Handler {
+	return DeleteWith(path, DeleteOptions{})
+}
+
+// DeleteWith runs down the providded map removing the key or index at the end
+// of the path, returning the removed value. Lists are compacted unless the
+// options keep their indexes, where lists received by the handler itself are
+// compacted in place, leaving the zero value at their end, as their length
+// can not change.
+func DeleteWith(path string, opts DeleteOptions) fractals.Handler {
+	keys := Keys(path)
+
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		removed, _, err := deleteIn(target, keys, opts)
+		return removed, err
+	})
+}
+
+// deleteIn removes the value at the keys within target, returning it along
+// with target as it must be stored in it's parent, which differs from the one
+// received once a list has been compacted.
+func deleteIn(target interface{}, keys []interface{}, opts DeleteOptions) (interface{}, interface{}, error) {
+	key := keys[0]
+
+	if len(keys) == 1 {
+		return deleteKey(target, key, opts)
+	}
+
+	child, err := lookupKey(target, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removed, child, err := deleteIn(child, keys[1:], opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if target, err = assignKey(target, key, child); err != nil {
+		return nil, nil, err
+	}
+
+	return removed, target, nil
+}
+
+// deleteKey removes the key from target, returning the removed value and
+// target as it must be stored in it's parent.
+func deleteKey(target interface{}, key interface{}, opts DeleteOptions) (interface{}, interface{}, error) {
+	if index, ok := key.(int); ok && !isMap(target) {
+		return deleteIndex(target, index, opts)
+	}
+
+	if index, ok := key.(int); ok {
+		key = strconv.Itoa(index)
+	}
+
+	removed, err := getValue(target, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch to := target.(type) {
+	case map[interface{}]interface{}:
+		delete(to, key)
+	case map[interface{}]string:
+		delete(to, key)
+	case map[string]string:
+		delete(to, key.(string))
+	case map[string]interface{}:
+		delete(to, key.(string))
+	default:
+		if value := reflect.ValueOf(target); value.Kind() == reflect.Map {
+			mkey, err := reflectMapKey(value.Type(), key)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			value.SetMapIndex(mkey, reflect.Value{})
+		}
+	}
+
+	return removed, target, nil
+}
+
+// deleteIndex removes the index from the list, returning the removed value
+// and the list as it must be stored in it's parent.
+func deleteIndex(target interface{}, index int, opts DeleteOptions) (interface{}, interface{}, error) {
+	list := reflect.ValueOf(target)
+	if list.Kind() != reflect.Slice {
+		return nil, nil, ErrTypeNotFound
+	}
+
+	index, err := fromEnd(target, index)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size := list.Len()
+	if index >= size {
+		return nil, nil, ErrIndexOutOfBound
+	}
+
+	removed := list.Index(index).Interface()
+	zero := reflect.Zero(list.Type().Elem())
+
+	if opts.KeepIndexes {
+		list.Index(index).Set(zero)
+		return removed, target, nil
+	}
+
+	reflect.Copy(list.Slice(index, size), list.Slice(index+1, size))
+	list.Index(size - 1).Set(zero)
+
+	return removed, list.Slice(0, size-1).Interface(), nil
+}
@@ -0,0 +1,105 @@
+package maps
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/influx6/fractals"
+)
+
+// MapValues returns a Handler which passes down a new map[string]interface{}
+// holding the values returned by fn for every entry of incoming maps, whoes
+// keys are used as strings. The first error returned by fn fails the Handler.
+func MapValues(fn func(key string, value interface{}) (interface{}, error)) fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		entries, err := mapEntries(target)
+		if err != nil {
+			return nil, err
+		}
+
+		mapped := make(map[string]interface{}, len(entries))
+		for _, entry := range entries {
+			value, err := fn(entry.name, entry.value.Interface())
+			if err != nil {
+				return nil, err
+			}
+
+			mapped[entry.name] = value
+		}
+
+		return mapped, nil
+	})
+}
+
+// FilterEntries returns a Handler which passes down a new map, of the same
+// type as incoming maps, holding only the entries pred returns true for.
+func FilterEntries(pred func(key string, value interface{}) bool) fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		entries, err := mapEntries(target)
+		if err != nil {
+			return nil, err
+		}
+
+		filtered := reflect.MakeMap(reflect.ValueOf(target).Type())
+		for _, entry := range entries {
+			if pred(entry.name, entry.value.Interface()) {
+				filtered.SetMapIndex(entry.key, entry.value)
+			}
+		}
+
+		return filtered.Interface(), nil
+	})
+}
+
+// ReduceEntries returns a Handler which folds the entries of incoming maps,
+// in the order of their keys, into a single value, starting from the seed,
+// passing down the value returned by fn for the last entry. The first error
+// returned by fn fails the Handler.
+func ReduceEntries(seed interface{}, fn func(acc interface{}, key string, value interface{}) (interface{}, error)) fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		entries, err := mapEntries(target)
+		if err != nil {
+			return nil, err
+		}
+
+		acc := seed
+		for _, entry := range entries {
+			if acc, err = fn(acc, entry.name, entry.value.Interface()); err != nil {
+				return nil, err
+			}
+		}
+
+		return acc, nil
+	})
+}
+
+// mapEntry defines an entry of a map along with it's key as a string.
+type mapEntry struct {
+	name  string
+	key   reflect.Value
+	value reflect.Value
+}
+
+// mapEntries returns the entries of the map in the order of their keys.
+func mapEntries(target interface{}) ([]mapEntry, error) {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Map {
+		return nil, ErrTypeNotFound
+	}
+
+	entries := make([]mapEntry, 0, value.Len())
+	for _, key := range value.MapKeys() {
+		entries = append(entries, mapEntry{
+			name:  fmt.Sprint(key.Interface()),
+			key:   key,
+			value: value.MapIndex(key),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+
+	return entries, nil
+}
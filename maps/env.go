@@ -0,0 +1,157 @@
+package maps
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influx6/fractals"
+)
+
+// ExpandEnv returns a Handler which overlays the environment variables
+// starting with the prefix onto a copy of incoming maps, then expands the
+// ${VAR} references within it's strings, passing down the copy, as is common
+// for configuration following the twelve-factor app.
+//
+// With the "APP" prefix, APP_FOO_BAR is saved at "foo.bar", where keys are
+// lowercased, digits index lists and a double underscore stands for an
+// underscore within a key, so APP_DB_MAX__CONNS is saved at "db.max_conns".
+// Values replacing booleans or numbers are converted to their type when they
+// can be. An empty prefix overlays nothing, only expanding references.
+// References to unset variables expand to an empty string.
+func ExpandEnv(prefix string) fractals.Handler {
+	if prefix != "" {
+		prefix = strings.TrimSuffix(prefix, "_") + "_"
+	}
+
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		if reflect.ValueOf(target).Kind() != reflect.Map {
+			return nil, ErrTypeNotFound
+		}
+
+		doc := valueOf(copyValue(reflect.ValueOf(target)))
+
+		if prefix != "" {
+			for _, name := range envNames(prefix) {
+				keys := envKeys(strings.TrimPrefix(name, prefix))
+				if len(keys) == 0 {
+					continue
+				}
+
+				value := coerceEnv(lookupKeys(doc, keys), os.Getenv(name))
+				if _, err := saveWithCreate(doc, keys, value); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return valueOf(expandValue(reflect.ValueOf(doc))), nil
+	})
+}
+
+// envNames returns the names of the environment variables starting with the
+// prefix, in order, so parents are saved before their children.
+func envNames(prefix string) []string {
+	var names []string
+
+	for _, env := range os.Environ() {
+		name := strings.SplitN(env, "=", 2)[0]
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// envKeys returns the keys of the path the environment variable name, with
+// it's prefix trimmed, stands for.
+func envKeys(name string) []interface{} {
+	var keys []interface{}
+
+	for _, part := range strings.Split(strings.Replace(name, "__", "\x00", -1), "_") {
+		if part == "" {
+			return nil
+		}
+
+		keys = append(keys, parseKey(strings.ToLower(strings.Replace(part, "\x00", "_", -1))))
+	}
+
+	return keys
+}
+
+// lookupKeys returns the value at the keys within target, if any.
+func lookupKeys(target interface{}, keys []interface{}) interface{} {
+	for _, key := range keys {
+		var err error
+		if target, err = lookupKey(target, key); err != nil {
+			return nil
+		}
+	}
+
+	return target
+}
+
+// coerceEnv returns the value converted to the type of the current value it
+// replaces, if it is a boolean or number the value can be read as.
+func coerceEnv(current interface{}, value string) interface{} {
+	var converted interface{}
+	var err error
+
+	switch current.(type) {
+	case bool:
+		converted, err = strconv.ParseBool(value)
+	case int:
+		converted, err = strconv.Atoi(value)
+	case int64:
+		converted, err = strconv.ParseInt(value, 10, 64)
+	case float64:
+		converted, err = strconv.ParseFloat(value, 64)
+	default:
+		return value
+	}
+
+	if err != nil {
+		return value
+	}
+
+	return converted
+}
+
+// envReference matches the ${VAR} references expanded by ExpandEnv.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandValue expands the references within the strings of the value, which
+// must not be shared, as maps and lists are changed in place.
+func expandValue(value reflect.Value) reflect.Value {
+	inner := unwrapValue(value)
+
+	switch inner.Kind() {
+	case reflect.String:
+		expanded := envReference.ReplaceAllStringFunc(inner.String(), func(ref string) string {
+			return os.Getenv(ref[2 : len(ref)-1])
+		})
+
+		return reflect.ValueOf(expanded).Convert(inner.Type())
+
+	case reflect.Map:
+		for _, key := range inner.MapKeys() {
+			if item := expandValue(inner.MapIndex(key)); item.IsValid() {
+				inner.SetMapIndex(key, item)
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < inner.Len(); i++ {
+			if item := expandValue(inner.Index(i)); item.IsValid() && inner.Index(i).CanSet() {
+				inner.Index(i).Set(item)
+			}
+		}
+	}
+
+	return inner
+}
@@ -1,7 +1,12 @@
 package maps_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/influx6/fractals"
@@ -84,6 +89,916 @@ func TestMapSave(t *testing.T) {
 	find(t, nameFinder, "name", tree)
 }
 
+func TestMapSaveWithCreate(t *testing.T) {
+	tree := map[string]interface{}{
+		"name": "wonder",
+		"meta": map[string]interface{}{},
+	}
+
+	set(t, maps.SaveWithCreate("documents.0.metrics.name", "bunny"), "documents.0.metrics.name", tree)
+	set(t, maps.SaveWithCreate("documents.2.metrics.name", "tord"), "documents.2.metrics.name", tree)
+	set(t, maps.SaveWithCreate("meta.200", "code"), "meta.200", tree)
+
+	value, err := maps.Find("documents.2.metrics.name")(nil, nil, tree)
+	if err != nil || value != "tord" {
+		fatalFailed(t, "Should have created the nested structure: %#v", tree)
+	}
+
+	if documents := tree["documents"].([]interface{}); len(documents) != 3 || documents[1] != nil {
+		fatalFailed(t, "Should have grown the list to hold the index: %#v", documents)
+	}
+
+	if tree["meta"].(map[string]interface{})["200"] != "code" {
+		fatalFailed(t, "Should have saved numeric key into map: %#v", tree["meta"])
+	}
+	logPassed(t, "Should have created the nested structure")
+
+	if _, err := maps.SaveWithCreate("name.first", "wonder")(nil, nil, tree); err == nil {
+		fatalFailed(t, "Should have failed to save into a string")
+	}
+	logPassed(t, "Should have failed to save into a string")
+}
+
+func TestMapDelete(t *testing.T) {
+	tree := map[string]interface{}{
+		"name":   "wonder",
+		"prices": []int{1, 500, 433},
+		"documents": []map[string]interface{}{
+			{
+				"data": []float64{1.435, 5.00, 43.3},
+			},
+		},
+	}
+
+	removed, err := maps.Delete("name")(nil, nil, tree)
+	if err != nil || removed != "wonder" {
+		fatalFailed(t, "Should have deleted key from map: %+v", err)
+	}
+
+	if _, ok := tree["name"]; ok {
+		fatalFailed(t, "Should have removed key from map: %#v", tree)
+	}
+	logPassed(t, "Should have deleted key from map")
+
+	removed, err = maps.Delete("documents.0.data.1")(nil, nil, tree)
+	if err != nil || removed != 5.00 {
+		fatalFailed(t, "Should have deleted index from list: %+v", err)
+	}
+
+	if data := tree["documents"].([]map[string]interface{})[0]["data"].([]float64); len(data) != 2 || data[1] != 43.3 {
+		fatalFailed(t, "Should have compacted the list: %#v", data)
+	}
+	logPassed(t, "Should have compacted the list")
+
+	removed, err = maps.DeleteWith("prices.1", maps.DeleteOptions{KeepIndexes: true})(nil, nil, tree)
+	if err != nil || removed != 500 {
+		fatalFailed(t, "Should have deleted index from list: %+v", err)
+	}
+
+	if prices := tree["prices"].([]int); len(prices) != 3 || prices[1] != 0 || prices[2] != 433 {
+		fatalFailed(t, "Should have left a hole in the list: %#v", prices)
+	}
+	logPassed(t, "Should have left a hole in the list")
+
+	if _, err := maps.Delete("prices.10")(nil, nil, tree); err != maps.ErrIndexOutOfBound {
+		fatalFailed(t, "Should have failed to delete missing index: %+v", err)
+	}
+	logPassed(t, "Should have failed to delete missing index")
+}
+
+func TestMapMerge(t *testing.T) {
+	overlay := map[string]interface{}{
+		"name": "star-trek",
+		"tags": []string{"space"},
+		"meta": map[string]interface{}{
+			"desc": "weather bill of the decade",
+			"db":   map[string]interface{}{"port": 5432},
+		},
+	}
+
+	base := func() map[string]interface{} {
+		return map[string]interface{}{
+			"name": "wonder",
+			"tags": []string{"film"},
+			"meta": map[string]interface{}{
+				"mark": 300,
+				"desc": "weather bill of the year",
+			},
+		}
+	}
+
+	merged, err := maps.Merge(overlay, maps.MergeOptions{})(nil, nil, base())
+	if err != nil {
+		fatalFailed(t, "Should have merged overlay: %+v", err)
+	}
+
+	tree := merged.(map[string]interface{})
+	meta := tree["meta"].(map[string]interface{})
+	if tree["name"] != "star-trek" || meta["mark"] != 300 || meta["desc"] != "weather bill of the decade" || len(tree["tags"].([]string)) != 1 {
+		fatalFailed(t, "Should have deep merged overlay: %#v", tree)
+	}
+
+	meta["db"].(map[string]interface{})["port"] = 3306
+	if overlay["meta"].(map[string]interface{})["db"].(map[string]interface{})["port"] != 5432 {
+		fatalFailed(t, "Should have copied values out of the overlay")
+	}
+	logPassed(t, "Should have deep merged overlay")
+
+	merged, err = maps.Merge(overlay, maps.MergeOptions{Conflict: maps.MergeKeep, AppendLists: true})(nil, nil, base())
+	if err != nil {
+		fatalFailed(t, "Should have merged overlay: %+v", err)
+	}
+
+	tree = merged.(map[string]interface{})
+	meta = tree["meta"].(map[string]interface{})
+	if tree["name"] != "wonder" || meta["desc"] != "weather bill of the year" || meta["db"] == nil {
+		fatalFailed(t, "Should have kept conflicting values: %#v", tree)
+	}
+
+	if tags := tree["tags"].([]string); len(tags) != 2 || tags[1] != "space" {
+		fatalFailed(t, "Should have appended lists: %#v", tags)
+	}
+	logPassed(t, "Should have kept conflicts and appended lists")
+}
+
+func TestMapDiffPatch(t *testing.T) {
+	base := map[string]interface{}{
+		"name":   "wonder",
+		"prices": []interface{}{1, 500, 433},
+		"meta": map[string]interface{}{
+			"mark":     300,
+			"desc":     "weather bill of the year",
+			"sentries": []interface{}{"bh-300", "vh-10"},
+		},
+	}
+
+	target := map[string]interface{}{
+		"name":   "wonder",
+		"prices": []interface{}{1, 450},
+		"meta": map[string]interface{}{
+			"desc":     "weather bill of the decade",
+			"sentries": []interface{}{"bh-300", "vh-10", "bl-30"},
+			"a/b":      true,
+		},
+	}
+
+	changes, err := maps.Diff(base)(nil, nil, target)
+	if err != nil {
+		fatalFailed(t, "Should have diffed structures: %+v", err)
+	}
+
+	ops := changes.([]maps.Operation)
+	if len(ops) != 6 {
+		fatalFailed(t, "Should have produced 6 operations: %#v", ops)
+	}
+	logPassed(t, "Should have diffed structures: %+v", ops)
+
+	patched, err := maps.Patch(ops)(nil, nil, base)
+	if err != nil {
+		fatalFailed(t, "Should have patched structure: %+v", err)
+	}
+
+	if !reflect.DeepEqual(patched, target) {
+		fatalFailed(t, "Should have patched base into target: %#v", patched)
+	}
+
+	if len(base["prices"].([]interface{})) != 3 {
+		fatalFailed(t, "Should have left the base untouched: %#v", base)
+	}
+	logPassed(t, "Should have patched base into target")
+
+	var decoded []maps.Operation
+	encoded, _ := json.Marshal([]maps.Operation{{Op: maps.OpAdd, Path: "/meta/none"}, {Op: maps.OpRemove, Path: "/name"}})
+	if err := json.Unmarshal(encoded, &decoded); err != nil || string(encoded) != `[{"op":"add","path":"/meta/none","value":null},{"op":"remove","path":"/name"}]` {
+		fatalFailed(t, "Should have encoded operations as JSON Patch: %s", encoded)
+	}
+	logPassed(t, "Should have encoded operations as JSON Patch")
+
+	_, err = maps.Patch([]maps.Operation{
+		{Op: maps.OpMove, From: "/name", Path: "/meta/name"},
+		{Op: maps.OpTest, Path: "/meta/name", Value: "star-trek"},
+	})(nil, nil, base)
+	if err != maps.ErrTestFailed || base["name"] != "wonder" {
+		fatalFailed(t, "Should have failed test operation: %+v", err)
+	}
+	logPassed(t, "Should have failed test operation")
+
+	var tests []maps.Operation
+	if err := json.Unmarshal([]byte(`[{"op":"test","path":"/a","value":1},{"op":"test","path":"/b","value":{"c":[2.5,null]}}]`), &tests); err != nil {
+		fatalFailed(t, "Should have decoded test operations: %+v", err)
+	}
+
+	if _, err = maps.Patch(tests)(nil, nil, map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{"c": []interface{}{float32(2.5), nil}},
+	}); err != nil {
+		fatalFailed(t, "Should have compared numbers by value: %+v", err)
+	}
+
+	if _, err = maps.Patch(tests[:1])(nil, nil, map[string]interface{}{"a": "1"}); err != maps.ErrTestFailed {
+		fatalFailed(t, "Should have failed test against a string: %+v", err)
+	}
+	logPassed(t, "Should have compared numbers by value")
+
+	merged, err := maps.MergePatch(map[string]interface{}{
+		"name": nil,
+		"meta": map[string]interface{}{"mark": 400},
+	})(nil, nil, base)
+	if err != nil {
+		fatalFailed(t, "Should have applied merge patch: %+v", err)
+	}
+
+	tree := merged.(map[string]interface{})
+	if _, ok := tree["name"]; ok || tree["meta"].(map[string]interface{})["mark"] != 400 || tree["meta"].(map[string]interface{})["desc"] != "weather bill of the year" {
+		fatalFailed(t, "Should have applied merge patch: %#v", tree)
+	}
+	logPassed(t, "Should have applied merge patch")
+
+	nested, err := maps.Patch([]maps.Operation{
+		{Op: maps.OpAdd, Path: "/a/b", Value: 1},
+	})(nil, nil, map[string]map[string]int{"a": nil})
+	if err != nil || nested.(map[string]map[string]int)["a"]["b"] != 1 {
+		fatalFailed(t, "Should have added into nil map: %#v %+v", nested, err)
+	}
+	logPassed(t, "Should have added into nil map")
+
+	nested, err = maps.MergePatch(map[string]interface{}{
+		"a": map[string]interface{}{"b": 2},
+	})(nil, nil, map[string]map[string]int{"a": nil})
+	if err != nil || nested.(map[string]map[string]int)["a"]["b"] != 2 {
+		fatalFailed(t, "Should have merged into nil map: %#v %+v", nested, err)
+	}
+	logPassed(t, "Should have merged into nil map")
+}
+
+func TestMapFindWildcard(t *testing.T) {
+	tree := map[string]interface{}{
+		"documents": []map[string]interface{}{
+			{
+				"metrics": map[string]string{"name": "bunny"},
+			},
+			{
+				"metrics": map[string]string{"name": "tord"},
+			},
+			{
+				"data": []float64{1.435},
+			},
+		},
+		"meta": map[string]interface{}{
+			"owner": map[string]interface{}{"name": "wonder"},
+		},
+	}
+
+	found, err := maps.Find("documents.*.metrics.name")(nil, nil, tree)
+	if err != nil {
+		fatalFailed(t, "Should have found wildcard path: %+v", err)
+	}
+
+	expected := []maps.Match{
+		{Path: "documents.0.metrics.name", Value: "bunny"},
+		{Path: "documents.1.metrics.name", Value: "tord"},
+	}
+	if !reflect.DeepEqual(found, expected) {
+		fatalFailed(t, "Should have matched every document: %#v", found)
+	}
+	logPassed(t, "Should have matched every document")
+
+	found, err = maps.Find("**.name")(nil, nil, tree)
+	if err != nil {
+		fatalFailed(t, "Should have found wildcard path: %+v", err)
+	}
+
+	expected = append(expected, maps.Match{Path: "meta.owner.name", Value: "wonder"})
+	if !reflect.DeepEqual(found, expected) {
+		fatalFailed(t, "Should have matched names at any depth: %#v", found)
+	}
+	logPassed(t, "Should have matched names at any depth")
+}
+
+func TestMapPathParsers(t *testing.T) {
+	tree := map[string]interface{}{
+		"documents": []map[string]interface{}{
+			{
+				"metrics": map[string]string{"name": "bunny"},
+				"data":    []float64{1.435, 5.00, 43.3},
+			},
+		},
+		"a/b": map[string]interface{}{"c.d": "escaped"},
+	}
+
+	pointer := maps.PathOptions{Parser: maps.PointerKeys}
+	jsonPath := maps.PathOptions{Parser: maps.JSONPathKeys}
+
+	if value, err := maps.FindWith("/documents/0/data/1", pointer)(nil, nil, tree); err != nil || value != 5.00 {
+		fatalFailed(t, "Should have found JSON Pointer: %#v %+v", value, err)
+	}
+
+	if value, err := maps.FindWith("/a~1b/c.d", pointer)(nil, nil, tree); err != nil || value != "escaped" {
+		fatalFailed(t, "Should have found escaped JSON Pointer: %#v %+v", value, err)
+	}
+
+	if _, err := maps.SaveWith("/documents/0/data/2", 50.00, pointer)(nil, nil, tree); err != nil || tree["documents"].([]map[string]interface{})[0]["data"].([]float64)[2] != 50.00 {
+		fatalFailed(t, "Should have saved JSON Pointer: %+v", err)
+	}
+	logPassed(t, "Should have found and saved JSON Pointers")
+
+	if value, err := maps.FindWith("$.documents[0].metrics['name']", jsonPath)(nil, nil, tree); err != nil || value != "bunny" {
+		fatalFailed(t, "Should have found JSONPath: %#v %+v", value, err)
+	}
+
+	if value, err := maps.FindWith("$['a/b']['c.d']", jsonPath)(nil, nil, tree); err != nil || value != "escaped" {
+		fatalFailed(t, "Should have found quoted JSONPath: %#v %+v", value, err)
+	}
+
+	found, err := maps.FindWith("$..name", jsonPath)(nil, nil, tree)
+	if matches, ok := found.([]maps.Match); err != nil || !ok || len(matches) != 1 || matches[0].Value != "bunny" {
+		fatalFailed(t, "Should have found recursive JSONPath: %#v %+v", found, err)
+	}
+	logPassed(t, "Should have found JSONPaths")
+
+	if _, err := maps.FindWith("$.documents[0", jsonPath)(nil, nil, tree); err != maps.ErrInvalidPath {
+		fatalFailed(t, "Should have failed invalid JSONPath: %+v", err)
+	}
+	logPassed(t, "Should have failed invalid JSONPath")
+}
+
+type address struct {
+	City string `json:"city"`
+}
+
+type account struct {
+	address
+	Name    string `json:"name"`
+	Age     int
+	Secret  string `json:"-"`
+	Manager *account
+	Tags    map[string]string `json:"tags"`
+}
+
+func TestMapStructs(t *testing.T) {
+	tree := map[string]interface{}{
+		"owner": &account{
+			address: address{City: "lagos"},
+			Name:    "wonder",
+			Age:     30,
+			Manager: &account{Name: "tord"},
+			Tags:    map[string]string{"team": "core"},
+		},
+	}
+
+	for path, expected := range map[string]interface{}{
+		"owner.name":         "wonder",
+		"owner.age":          30,
+		"owner.city":         "lagos",
+		"owner.Manager.name": "tord",
+		"owner.tags.team":    "core",
+	} {
+		value, err := maps.Find(path)(nil, nil, tree)
+		if err != nil || value != expected {
+			fatalFailed(t, "Should have found %q within struct: %#v %+v", path, value, err)
+		}
+	}
+	logPassed(t, "Should have found paths within structs")
+
+	if _, err := maps.Find("owner.Secret")(nil, nil, tree); err != maps.ErrKeyNotFound {
+		fatalFailed(t, "Should have skipped ignored field: %+v", err)
+	}
+	logPassed(t, "Should have skipped ignored field")
+
+	set(t, maps.Save("owner.Manager.name", "star-trek"), "owner.Manager.name", tree)
+	if tree["owner"].(*account).Manager.Name != "star-trek" {
+		fatalFailed(t, "Should have saved into struct field: %#v", tree["owner"])
+	}
+
+	if _, err := maps.Save("owner.age", "thirty")(nil, nil, tree); err != maps.ErrTypeNotFound {
+		fatalFailed(t, "Should have failed to save mismatched type: %+v", err)
+	}
+	logPassed(t, "Should have saved into struct fields")
+}
+
+func TestMapListEnds(t *testing.T) {
+	tree := map[string]interface{}{
+		"prices": []int{1, 500, 433},
+		"documents": []map[string]interface{}{
+			{"data": []float64{1.435, 5.00}},
+		},
+	}
+
+	if value, err := maps.Find("prices.-1")(nil, nil, tree); err != nil || value != 433 {
+		fatalFailed(t, "Should have found last item: %#v %+v", value, err)
+	}
+
+	if _, err := maps.Find("prices.-4")(nil, nil, tree); err != maps.ErrIndexOutOfBound {
+		fatalFailed(t, "Should have failed beyond the start of the list: %+v", err)
+	}
+	logPassed(t, "Should have found items from the end of lists")
+
+	set(t, maps.Save("prices.-2", 450), "prices.-2", tree)
+	set(t, maps.Save("prices.+", 5000), "prices.+", tree)
+	set(t, maps.Save("documents.-1.data.+", 43.3), "documents.-1.data.+", tree)
+
+	if prices := tree["prices"].([]int); !reflect.DeepEqual(prices, []int{1, 450, 433, 5000}) {
+		fatalFailed(t, "Should have appended to list: %#v", prices)
+	}
+
+	if data := tree["documents"].([]map[string]interface{})[0]["data"].([]float64); len(data) != 3 || data[2] != 43.3 {
+		fatalFailed(t, "Should have appended to nested list: %#v", data)
+	}
+
+	if _, err := maps.Save("prices.+", "free")(nil, nil, tree); err != maps.ErrTypeNotFound {
+		fatalFailed(t, "Should have failed to append mismatched type: %+v", err)
+	}
+	logPassed(t, "Should have appended to lists")
+
+	set(t, maps.SaveWithCreate("items.+.name", "bunny"), "items.+.name", tree)
+	set(t, maps.SaveWithCreate("items.+.name", "tord"), "items.+.name", tree)
+
+	if value, err := maps.Find("items.-1.name")(nil, nil, tree); err != nil || value != "tord" || len(tree["items"].([]interface{})) != 2 {
+		fatalFailed(t, "Should have appended created maps: %#v", tree["items"])
+	}
+	logPassed(t, "Should have appended created maps")
+}
+
+func TestMapEscapedKeys(t *testing.T) {
+	tree := map[string]interface{}{
+		"hosts": map[string]interface{}{
+			"example.com": map[string]interface{}{"port": 443},
+			`c:\temp`:     "windows",
+		},
+	}
+
+	if value, err := maps.Find(`hosts.example\.com.port`)(nil, nil, tree); err != nil || value != 443 {
+		fatalFailed(t, "Should have found escaped key: %#v %+v", value, err)
+	}
+
+	if value, err := maps.Find(`hosts.c:\\temp`)(nil, nil, tree); err != nil || value != "windows" {
+		fatalFailed(t, "Should have found escaped backslash: %#v %+v", value, err)
+	}
+	logPassed(t, "Should have found escaped keys")
+
+	slash := maps.PathOptions{Parser: maps.KeysWithSeparator("/")}
+	if value, err := maps.FindWith("hosts/example.com/port", slash)(nil, nil, tree); err != nil || value != 443 {
+		fatalFailed(t, "Should have found key with custom separator: %#v %+v", value, err)
+	}
+	logPassed(t, "Should have found key with custom separator")
+
+	found, err := maps.Find("hosts.*.port")(nil, nil, tree)
+	matches, ok := found.([]maps.Match)
+	if err != nil || !ok || len(matches) != 1 || matches[0].Path != `hosts.example\.com.port` {
+		fatalFailed(t, "Should have escaped matched paths: %#v %+v", found, err)
+	}
+
+	if value, err := maps.Find(matches[0].Path)(nil, nil, tree); err != nil || value != 443 {
+		fatalFailed(t, "Should have found matched path: %#v %+v", value, err)
+	}
+	logPassed(t, "Should have escaped matched paths")
+}
+
+func TestMapStore(t *testing.T) {
+	store := maps.NewStore(map[string]interface{}{
+		"meta": map[string]interface{}{"mark": 300},
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			if _, err := store.Save("counts."+strconv.Itoa(i))(nil, nil, i); err != nil {
+				errs <- err
+			}
+
+			store.Find("meta.mark")(nil, nil, nil)
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		fatalFailed(t, "Should have saved into store: %+v", err)
+	}
+
+	if value, err := store.Find("counts.19")(nil, nil, nil); err != nil || value != 19 {
+		fatalFailed(t, "Should have found saved value: %#v %+v", value, err)
+	}
+	logPassed(t, "Should have saved into store concurrently")
+
+	meta, _ := store.Find("meta")(nil, nil, nil)
+	meta.(map[string]interface{})["mark"] = 400
+
+	if value, _ := store.Get("meta.mark"); value != 300 {
+		fatalFailed(t, "Should have copied values out of the store: %#v", value)
+	}
+	logPassed(t, "Should have copied values out of the store")
+
+	if removed, err := store.Delete("meta.mark")(nil, nil, nil); err != nil || removed != 300 {
+		fatalFailed(t, "Should have deleted from store: %#v %+v", removed, err)
+	}
+
+	if _, ok := store.Snapshot()["meta"].(map[string]interface{})["mark"]; ok {
+		fatalFailed(t, "Should have removed value from store")
+	}
+	logPassed(t, "Should have deleted from store")
+}
+
+func TestMapEntries(t *testing.T) {
+	prices := map[string]int{"apple": 5, "bread": 12, "cheese": 30}
+
+	mapped, err := maps.MapValues(func(key string, value interface{}) (interface{}, error) {
+		return value.(int) * 2, nil
+	})(nil, nil, prices)
+	if err != nil || !reflect.DeepEqual(mapped, map[string]interface{}{"apple": 10, "bread": 24, "cheese": 60}) {
+		fatalFailed(t, "Should have mapped values: %#v %+v", mapped, err)
+	}
+	logPassed(t, "Should have mapped values")
+
+	filtered, err := maps.FilterEntries(func(key string, value interface{}) bool {
+		return value.(int) > 10
+	})(nil, nil, prices)
+	if err != nil || !reflect.DeepEqual(filtered, map[string]int{"bread": 12, "cheese": 30}) || len(prices) != 3 {
+		fatalFailed(t, "Should have filtered entries: %#v %+v", filtered, err)
+	}
+	logPassed(t, "Should have filtered entries")
+
+	reduced, err := maps.ReduceEntries("", func(acc interface{}, key string, value interface{}) (interface{}, error) {
+		return acc.(string) + key + "=" + strconv.Itoa(value.(int)) + ";", nil
+	})(nil, nil, prices)
+	if err != nil || reduced != "apple=5;bread=12;cheese=30;" {
+		fatalFailed(t, "Should have reduced entries in order: %#v %+v", reduced, err)
+	}
+	logPassed(t, "Should have reduced entries in order")
+
+	if _, err := maps.MapValues(nil)(nil, nil, []int{1}); err != maps.ErrTypeNotFound {
+		fatalFailed(t, "Should have failed for lists: %+v", err)
+	}
+	logPassed(t, "Should have failed for lists")
+}
+
+func TestMapValidate(t *testing.T) {
+	validate := maps.Validate(maps.Schema{
+		{Path: "name", Required: true, Type: maps.TypeString, Min: maps.Bound(2)},
+		{Path: "age", Type: maps.TypeInteger, Min: maps.Bound(18), Max: maps.Bound(120)},
+		{Path: "role", Enum: []interface{}{"admin", "editor"}},
+		{Path: "tags.*", Type: maps.TypeString},
+	})
+
+	var body map[string]interface{}
+	json.Unmarshal([]byte(`{"name":"wonder","age":30,"role":"admin","tags":["a","b"]}`), &body)
+
+	if _, err := validate(nil, nil, body); err != nil {
+		fatalFailed(t, "Should have passed valid body: %+v", err)
+	}
+	logPassed(t, "Should have passed valid body")
+
+	json.Unmarshal([]byte(`{"age":12.5,"role":"guest","tags":["a",3]}`), &body)
+	delete(body, "name")
+
+	_, err := validate(nil, nil, body)
+	verr, ok := err.(*maps.ValidationError)
+	if !ok || len(verr.Fields) != 4 {
+		fatalFailed(t, "Should have failed every invalid field: %+v", err)
+	}
+
+	for index, expected := range []string{"name", "age", "role", "tags.1"} {
+		if verr.Fields[index].Name != expected {
+			fatalFailed(t, "Should have failed field %q: %#v", expected, verr.Fields)
+		}
+	}
+
+	if verr.Fields[0].Error != "is required" || verr.Fields[1].Error != "must be of type integer" {
+		fatalFailed(t, "Should have described failed rules: %#v", verr.Fields)
+	}
+	logPassed(t, "Should have failed every invalid field")
+}
+
+func TestMapConfig(t *testing.T) {
+	yamlDoc := `
+# server settings
+server:
+  host: "0.0.0.0"
+  port: 8080
+  debug: false
+  motd: |
+    hello
+    world
+users:
+  - name: alex
+    roles: [admin, dev]
+  - name: sam
+    roles: []
+ratio: 0.5
+`
+
+	tomlDoc := `
+ratio = 0.5
+
+[server]
+host = "0.0.0.0"
+port = 8080
+debug = false
+motd = """
+hello
+world
+"""
+
+[[users]]
+name = "alex"
+roles = ["admin", "dev"]
+
+[[users]]
+name = "sam"
+roles = []
+`
+
+	jsonDoc := `{"ratio":0.5,"server":{"host":"0.0.0.0","port":8080,"debug":false,"motd":"hello\nworld\n"},
+	"users":[{"name":"alex","roles":["admin","dev"]},{"name":"sam","roles":[]}]}`
+
+	formats := []struct {
+		name string
+		doc  string
+		from fractals.Handler
+		to   fractals.Handler
+	}{
+		{"YAML", yamlDoc, maps.FromYAML(), maps.ToYAML()},
+		{"TOML", tomlDoc, maps.FromTOML(), maps.ToTOML()},
+		{"JSON", jsonDoc, maps.FromJSON(), maps.ToJSON()},
+	}
+
+	for _, format := range formats {
+		config, err := format.from(nil, nil, []byte(format.doc))
+		if err != nil {
+			fatalFailed(t, "Should have parsed %s config: %+v", format.name, err)
+		}
+
+		for path, expected := range map[string]string{
+			"server.host":     "0.0.0.0",
+			"server.port":     "8080",
+			"server.debug":    "false",
+			"server.motd":     "hello\nworld\n",
+			"users.1.name":    "sam",
+			"users.0.roles.1": "dev",
+			"ratio":           "0.5",
+		} {
+			value, err := maps.Find(path)(nil, nil, config)
+			if err != nil || fmt.Sprint(value) != expected {
+				fatalFailed(t, "Should have found %q within %s config: %#v", path, format.name, value)
+			}
+		}
+		logPassed(t, "Should have parsed %s config", format.name)
+
+		encoded, err := format.to(nil, nil, config)
+		if err != nil {
+			fatalFailed(t, "Should have encoded %s config: %+v", format.name, err)
+		}
+
+		decoded, err := format.from(nil, nil, encoded)
+		if err != nil || !reflect.DeepEqual(decoded, config) {
+			fatalFailed(t, "Should have read back encoded %s config: %s", format.name, encoded)
+		}
+		logPassed(t, "Should have read back encoded %s config", format.name)
+	}
+
+	for _, doc := range []string{
+		"a: b: c\n",
+		"a: 1\nb: [1, 2\nc: 3\n",
+		"a: 1\n a: 2\n",
+		"a: 1\na: 2\n",
+		"a: \"open\n",
+		"a: 1\n---\nb: 2\n",
+	} {
+		if _, err := maps.FromYAML()(nil, nil, []byte(doc)); err == nil {
+			fatalFailed(t, "Should have failed invalid YAML %q", doc)
+		}
+	}
+
+	if _, err := maps.FromJSON()(nil, nil, []byte(`{"a": 1} {"b": 2}`)); err == nil {
+		fatalFailed(t, "Should have failed trailing JSON")
+	}
+
+	for _, format := range formats {
+		config, _ := format.from(nil, nil, []byte(format.doc))
+		if port, _ := maps.Find("server.port")(nil, nil, config); port != int64(8080) {
+			fatalFailed(t, "Should have read %s integers as int64: %#v", format.name, port)
+		}
+	}
+
+	if _, err := maps.ToTOML()(nil, nil, map[string]interface{}{
+		"a": []interface{}{1, "two", map[string]interface{}{"c": nil}},
+	}); err != maps.ErrNullValue {
+		fatalFailed(t, "Should have failed TOML null values: %+v", err)
+	}
+
+	escaped, err := maps.FromYAML()(nil, nil, []byte(`a: "\0\e\ \N\_\x41\u00e9\U0001F600\t\""`+"\n"))
+	if err != nil || escaped.(map[string]interface{})["a"] != "\x00\x1b \u0085\u00a0A\u00e9\U0001F600\t\"" {
+		fatalFailed(t, "Should have unescaped YAML double quoted scalar: %#v %+v", escaped, err)
+	}
+
+	for _, doc := range []string{
+		"a = 1\na = 2\n",
+		"a = 010\n",
+		"a = +0x10\n",
+		"a = 1__0\n",
+		"a = infinity\n",
+		"a = 1.\n",
+		"[a]\nb = 1\n[a]\nc = 2\n",
+		"a = {b = 1}\n[a]\nc = 2\n",
+	} {
+		if _, err := maps.FromTOML()(nil, nil, []byte(doc)); err == nil {
+			fatalFailed(t, "Should have failed invalid TOML %q", doc)
+		}
+	}
+
+	numbers, err := maps.FromTOML()(nil, nil, []byte("a = 0x1F\nb = -1_000\nc = 0o17\nd = 0b11\ne = -6.5e-1\nf = 0\ng = -inf\n[x]\n[x.y]\n[z.w]\n[z]\n"))
+	if err != nil || !reflect.DeepEqual(numbers, map[string]interface{}{
+		"a": int64(31), "b": int64(-1000), "c": int64(15), "d": int64(3), "e": -0.65, "f": int64(0), "g": math.Inf(-1),
+		"x": map[string]interface{}{"y": map[string]interface{}{}},
+		"z": map[string]interface{}{"w": map[string]interface{}{}},
+	}) {
+		fatalFailed(t, "Should have read TOML numbers and tables: %#v %+v", numbers, err)
+	}
+	logPassed(t, "Should have failed invalid configs")
+}
+
+func TestMapExpandEnv(t *testing.T) {
+	t.Setenv("FRACTALS_SERVER_PORT", "9090")
+	t.Setenv("FRACTALS_SERVER_DEBUG", "yes")
+	t.Setenv("FRACTALS_DB_MAX__CONNS", "20")
+	t.Setenv("FRACTALS_HOSTS_1", "beta")
+	t.Setenv("FRACTALS_TEST_USER", "admin")
+
+	config := map[string]interface{}{
+		"server": map[string]interface{}{"port": 8080, "debug": false},
+		"hosts":  []interface{}{"alpha", "gamma"},
+		"dsn":    "postgres://${FRACTALS_TEST_USER}@localhost/${FRACTALS_TEST_MISSING}db",
+	}
+
+	expanded, err := maps.ExpandEnv("FRACTALS")(nil, nil, config)
+	if err != nil {
+		fatalFailed(t, "Should have expanded environment: %+v", err)
+	}
+
+	for path, expected := range map[string]interface{}{
+		"server.port":  9090,
+		"server.debug": "yes",
+		"db.max_conns": "20",
+		"hosts.1":      "beta",
+		"test.user":    "admin",
+		"dsn":          "postgres://admin@localhost/db",
+		"hosts.0":      "alpha",
+	} {
+		value, err := maps.Find(path)(nil, nil, expanded)
+		if err != nil || value != expected {
+			fatalFailed(t, "Should have found %#v at %q: %#v", expected, path, value)
+		}
+	}
+
+	if config["server"].(map[string]interface{})["port"] != 8080 || config["hosts"].([]interface{})[1] != "gamma" {
+		fatalFailed(t, "Should have left incoming map untouched: %#v", config)
+	}
+	logPassed(t, "Should have overlaid and expanded environment")
+}
+
+func TestMapHasFindOr(t *testing.T) {
+	tree := map[string]interface{}{
+		"name":   "wonder",
+		"prices": []int{1, 500},
+		"meta":   map[string]interface{}{"mark": 300, "tags": []interface{}{}},
+	}
+
+	for path, expected := range map[string]bool{
+		"name":        true,
+		"prices.1":    true,
+		"meta.mark":   true,
+		"meta.desc":   false,
+		"prices.5":    false,
+		"name.first":  false,
+		"meta.*":      true,
+		"meta.tags.*": false,
+	} {
+		has, err := maps.Has(path)(nil, nil, tree)
+		if err != nil || has != expected {
+			fatalFailed(t, "Should have reported %q as %t: %#v", path, expected, has)
+		}
+	}
+	logPassed(t, "Should have reported existing paths")
+
+	value, err := maps.FindOr("meta.mark", 10)(nil, nil, tree)
+	if err != nil || value != 300 {
+		fatalFailed(t, "Should have found existing value: %#v", value)
+	}
+
+	for _, path := range []string{"meta.desc", "prices.5", "name.first", "meta.tags.*"} {
+		value, err := maps.FindOr(path, "none")(nil, nil, tree)
+		if err != nil || value != "none" {
+			fatalFailed(t, "Should have defaulted missing %q: %#v %+v", path, value, err)
+		}
+	}
+	logPassed(t, "Should have defaulted missing paths")
+}
+
+func TestMapGenericContainers(t *testing.T) {
+	tree := map[string]interface{}{
+		"accounts": []account{{Name: "wonder"}, {Name: "tord"}},
+		"codes":    map[int]string{200: "ok", 404: "missing"},
+		"scores":   map[string]int{"wonder": 10},
+		"matrix":   [][]int{{1, 2}, {3, 4}},
+	}
+
+	for path, expected := range map[string]interface{}{
+		"accounts.1.name": "tord",
+		"codes.404":       "missing",
+		"scores.wonder":   10,
+		"matrix.1.0":      3,
+		"matrix.-1.-1":    4,
+	} {
+		value, err := maps.Find(path)(nil, nil, tree)
+		if err != nil || value != expected {
+			fatalFailed(t, "Should have found %q within generic container: %#v %+v", path, value, err)
+		}
+	}
+	logPassed(t, "Should have found paths within generic containers")
+
+	set(t, maps.Save("codes.500", "failed"), "codes.500", tree)
+	set(t, maps.Save("scores.tord", 20), "scores.tord", tree)
+	set(t, maps.Save("matrix.0.1", 9), "matrix.0.1", tree)
+	set(t, maps.Save("accounts.0", account{Name: "star"}), "accounts.0", tree)
+
+	codes := tree["codes"].(map[int]string)
+	if codes[500] != "failed" || tree["scores"].(map[string]int)["tord"] != 20 || tree["matrix"].([][]int)[0][1] != 9 || tree["accounts"].([]account)[0].Name != "star" {
+		fatalFailed(t, "Should have saved into generic containers: %#v", tree)
+	}
+
+	if _, err := maps.Save("scores.tord", "twenty")(nil, nil, tree); err != maps.ErrTypeNotFound {
+		fatalFailed(t, "Should have failed to save mismatched type: %+v", err)
+	}
+
+	if _, err := maps.Delete("codes.200")(nil, nil, tree); err != nil || len(codes) != 2 {
+		fatalFailed(t, "Should have deleted from generic map: %#v %+v", codes, err)
+	}
+	logPassed(t, "Should have saved into generic containers")
+}
+
+func TestMapListPaths(t *testing.T) {
+	tree := map[string]interface{}{
+		"name": "wonder",
+		"db":   map[string]interface{}{"host": "localhost", "port": 5432, "opts": map[string]string{}},
+		"servers": []interface{}{
+			map[string]interface{}{"host": "alpha", "hostname": "alpha.example.com"},
+			map[string]interface{}{"host": "beta"},
+		},
+	}
+
+	paths := func(found interface{}) []string {
+		var names []string
+		for _, match := range found.([]maps.Match) {
+			names = append(names, match.Path)
+		}
+
+		return names
+	}
+
+	listed, err := maps.ListPaths()(nil, nil, tree)
+	if err != nil {
+		fatalFailed(t, "Should have listed leaf paths: %+v", err)
+	}
+
+	expected := []string{"db.host", "db.opts", "db.port", "name", "servers.0.host", "servers.0.hostname", "servers.1.host"}
+	if !reflect.DeepEqual(paths(listed), expected) {
+		fatalFailed(t, "Should have listed every leaf path in order: %#v", paths(listed))
+	}
+
+	if leaf := listed.([]maps.Match)[2]; leaf.Value != 5432 {
+		fatalFailed(t, "Should have listed leaf values: %#v", leaf)
+	}
+	logPassed(t, "Should have listed every leaf path in order")
+
+	listed, err = maps.ListPaths("servers.*.host*", "db.p*")(nil, nil, tree)
+	if err != nil {
+		fatalFailed(t, "Should have listed matching paths: %+v", err)
+	}
+
+	expected = []string{"db.port", "servers.0.host", "servers.0.hostname", "servers.1.host"}
+	if !reflect.DeepEqual(paths(listed), expected) {
+		fatalFailed(t, "Should have listed paths matching globs: %#v", paths(listed))
+	}
+
+	listed, _ = maps.ListPaths("**.host")(nil, nil, tree)
+	if expected = []string{"db.host", "servers.0.host", "servers.1.host"}; !reflect.DeepEqual(paths(listed), expected) {
+		fatalFailed(t, "Should have listed paths matching any depth: %#v", paths(listed))
+	}
+	logPassed(t, "Should have listed paths matching globs")
+
+	if _, err := maps.ListPaths("db.[")(nil, nil, tree); err != maps.ErrInvalidPath {
+		fatalFailed(t, "Should have failed invalid glob: %+v", err)
+	}
+	logPassed(t, "Should have failed invalid glob")
+}
+
 func find(t *testing.T, handler fractals.Handler, key string, target interface{}) {
 	value, err := handler(nil, nil, target)
 	if err != nil {
@@ -2,37 +2,96 @@ package maps
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/influx6/fractals"
 )
 
+// AppendKey defines the key which, ending a path given to Save, appends the
+// value to the list the path leads to, such as "prices.+".
+const AppendKey = "+"
+
 // Key takes a string of period delimited values and returns a slice of interface which contains each
 // piece. It converts numbers into integers ensuring to keep keys aligned.
+// Negative numbers index lists from their end, so "prices.-1" is the last
+// item of prices. Periods within keys, such as hostnames, are escaped with a
+// backslash, as in "hosts.example\\.com". See KeysWithSeparator.
 func Keys(m string) []interface{} {
-	var bkeys []interface{}
+	return splitKeys(m, ".")
+}
 
-	for _, item := range strings.Split(m, ".") {
-		numb, err := strconv.ParseInt(item, 10, 64)
-		if err != nil {
-			bkeys = append(bkeys, item)
-			continue
+// KeysWithSeparator returns a KeyParser which splits paths on the separator
+// instead of a period, such as "/" for "hosts/example.com/port", where the
+// separator is escaped with a backslash within keys.
+func KeysWithSeparator(sep string) KeyParser {
+	return func(path string) ([]interface{}, error) {
+		if sep == "" {
+			return nil, ErrInvalidPath
 		}
 
-		bkeys = append(bkeys, int(numb))
+		return splitKeys(path, sep), nil
+	}
+}
+
+// splitKeys splits the path on the separator, unescaping the separators and
+// backslashes escaped with a backslash.
+func splitKeys(path string, sep string) []interface{} {
+	var bkeys []interface{}
+	var item []byte
+
+	for i := 0; i < len(path); {
+		rest := path[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "\\"+sep):
+			item = append(item, sep...)
+			i += 1 + len(sep)
+
+		case strings.HasPrefix(rest, "\\\\"):
+			item = append(item, '\\')
+			i += 2
+
+		case strings.HasPrefix(rest, sep):
+			bkeys = append(bkeys, parseKey(string(item)))
+			item = item[:0]
+			i += len(sep)
+
+		default:
+			item = append(item, path[i])
+			i++
+		}
 	}
 
-	return bkeys
+	return append(bkeys, parseKey(string(item)))
+}
+
+// escapeKey escapes the periods and backslashes within the key, so it is a
+// single key of a path given to Keys.
+func escapeKey(key string) string {
+	return strings.Replace(strings.Replace(key, "\\", "\\\\", -1), ".", "\\.", -1)
 }
 
 // Find runs down the providded map attempting to retrieve the giving value and
 // root else returning an error as failure to retrieve the giving path.
+// Paths holding the "*" segment, matching every key or index of a level, or
+// the "**" segment, matching any number of levels, such as
+// "documents.*.metrics.name", pass down the []Match of every value matching
+// them instead, which is empty if none do.
 func Find(path string) fractals.Handler {
+	return findKeys(Keys(path))
+}
+
+// findKeys returns a Handler which runs down incoming maps through the keys.
+func findKeys(keys []interface{}) fractals.Handler {
+	if hasWildcard(keys) {
+		return findAll(keys)
+	}
 
 	var finders []fractals.Handler
 
-	keys := Keys(path)
 	for _, key := range keys {
 		switch ikey := key.(type) {
 		case int:
@@ -45,17 +104,84 @@ func Find(path string) fractals.Handler {
 	return fractals.Lift(finders...)(nil)
 }
 
+// Has returns a Handler which passes down true if the path leads to a value
+// within incoming maps, else false, never failing. Paths holding wildcards
+// lead to a value if any value matches them.
+func Has(path string) fractals.Handler {
+	finder := Find(path)
+
+	return fractals.MustWrap(func(target interface{}) interface{} {
+		found, err := finder(nil, nil, target)
+		if err != nil {
+			return false
+		}
+
+		if matches, ok := found.([]Match); ok {
+			return len(matches) != 0
+		}
+
+		return true
+	})
+}
+
+// FindOr returns a Handler which finds the value at the path like Find,
+// passing down the default instead when the path is missing, being when a
+// key or index along it is not found, or no value matches it's wildcards,
+// so optional settings need not fail pipelines. Other failures still fail.
+func FindOr(path string, def interface{}) fractals.Handler {
+	finder := Find(path)
+
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		found, err := finder(nil, nil, target)
+		if err == ErrKeyNotFound || err == ErrIndexOutOfBound {
+			return def, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if matches, ok := found.([]Match); ok && len(matches) == 0 {
+			return def, nil
+		}
+
+		return found, nil
+	})
+}
+
 // Save runs down the providded map attempting to retrieve the giving value and
 // root else returning an error as failure to retrieve the giving path.
+// Paths ending with AppendKey, such as "prices.+", append the value to the
+// list they lead to, which can not be the incoming list itself.
 func Save(path string, val interface{}) fractals.Handler {
+	return saveKeys(Keys(path), val)
+}
 
-	var finders []fractals.Handler
+// appendTo defines the key of a list appended to by Save.
+type appendTo struct {
+	key interface{}
+}
 
-	keys := Keys(path)
+// saveKeys returns a Handler which runs down incoming maps through the keys,
+// saving the value at the last.
+func saveKeys(keys []interface{}, val interface{}) fractals.Handler {
+
+	var finders []fractals.Handler
 
 	head := keys[len(keys)-1]
 	keys = keys[:len(keys)-1]
 
+	// Appending changes the list, so it is stored again within the
+	// container holding it.
+	if head == AppendKey {
+		if len(keys) == 0 {
+			return failWith(ErrTypeNotFound)
+		}
+
+		head = appendTo{key: keys[len(keys)-1]}
+		keys = keys[:len(keys)-1]
+	}
+
 	for _, key := range keys {
 		switch ikey := key.(type) {
 		case int:
@@ -73,9 +199,115 @@ func Save(path string, val interface{}) fractals.Handler {
 		finders = append(finders, AddInToList(rh, val))
 	}
 
+	if rh, ok := head.(appendTo); ok {
+		finders = append(finders, AppendInToList(rh.key, val))
+	}
+
 	return fractals.Lift(finders...)(nil)
 }
 
+// SaveWithCreate runs down the providded map like Save, creating the maps and
+// lists missing along the path, so saving "a.b.0.c" into an empty map builds
+// the nested structure. Missing keys create a map[string]interface{} and
+// missing indexes a []interface{}, where lists of that type are grown to hold
+// the index. Lists received by the handler itself must already hold it.
+// AppendKey appends to lists along the path too, so "items.+.name" appends a
+// new map holding the name.
+func SaveWithCreate(path string, val interface{}) fractals.Handler {
+	keys := Keys(path)
+
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		if _, err := saveWithCreate(target, keys, val); err != nil {
+			return nil, err
+		}
+
+		return val, nil
+	})
+}
+
+// saveWithCreate saves the value at the keys within target, creating the
+// containers missing along them, returning target as it must be stored in
+// it's parent, which differs from the one received once a list has grown.
+func saveWithCreate(target interface{}, keys []interface{}, val interface{}) (interface{}, error) {
+	key := keys[0]
+
+	if len(keys) == 1 {
+		return assignKey(target, key, val)
+	}
+
+	child, err := lookupKey(target, key)
+	if err != nil && err != ErrKeyNotFound && err != ErrIndexOutOfBound {
+		return nil, err
+	}
+
+	if child == nil {
+		child = newContainer(keys[1])
+	}
+
+	child, err = saveWithCreate(child, keys[1:], val)
+	if err != nil {
+		return nil, err
+	}
+
+	return assignKey(target, key, child)
+}
+
+// lookupKey returns the value of the key within target, where integer keys
+// of maps are used as strings.
+func lookupKey(target interface{}, key interface{}) (interface{}, error) {
+	switch ikey := key.(type) {
+	case int:
+		if isMap(target) {
+			return getValue(target, strconv.Itoa(ikey))
+		}
+
+		return getIndex(target, ikey)
+	default:
+		return getValue(target, key)
+	}
+}
+
+// assignKey sets the value of the key within target, growing lists of the
+// []interface{} type to hold the index, returning target as it must be stored
+// in it's parent.
+func assignKey(target interface{}, key interface{}, val interface{}) (interface{}, error) {
+	if key == AppendKey {
+		return appendValue(target, val)
+	}
+
+	ikey, ok := key.(int)
+	if !ok {
+		return target, setValue(target, key, val)
+	}
+
+	if isMap(target) {
+		return target, setValue(target, strconv.Itoa(ikey), val)
+	}
+
+	if list, ok := target.([]interface{}); ok && ikey >= len(list) {
+		grown := make([]interface{}, ikey+1)
+		copy(grown, list)
+		target = grown
+	}
+
+	return target, setIndex(target, ikey, val)
+}
+
+// newContainer returns the container created for the key, being a list for
+// integer keys and AppendKey and a map otherwise.
+func newContainer(key interface{}) interface{} {
+	if _, ok := key.(int); ok || key == AppendKey {
+		return []interface{}{}
+	}
+
+	return map[string]interface{}{}
+}
+
+// isMap returns true/false if the target is a map.
+func isMap(target interface{}) bool {
+	return reflect.ValueOf(target).Kind() == reflect.Map
+}
+
 // ErrKeyNotFound is returned when the key desired to be retrieved is not found.
 var ErrKeyNotFound = errors.New("Key not found")
 
@@ -141,7 +373,23 @@ func getValue(target interface{}, key interface{}) (interface{}, error) {
 		}
 	}
 
-	return nil, ErrKeyNotFound
+	// Other maps, structs, and the values pointers point to, are looked up
+	// by reflection.
+	if value := reflect.ValueOf(target); value.Kind() == reflect.Map {
+		mkey, err := reflectMapKey(value.Type(), key)
+		if err != nil {
+			return nil, ErrKeyNotFound
+		}
+
+		item := value.MapIndex(mkey)
+		if !item.IsValid() {
+			return nil, ErrKeyNotFound
+		}
+
+		return item.Interface(), nil
+	}
+
+	return getField(target, key)
 }
 
 // ErrTypeNotFound is returned when the giving type is either unknown or does not
@@ -167,7 +415,31 @@ func setValue(target interface{}, key interface{}, val interface{}) error {
 		return nil
 	}
 
-	return ErrTypeNotFound
+	if value := reflect.ValueOf(target); value.Kind() == reflect.Map {
+		if value.IsNil() {
+			return ErrTypeNotFound
+		}
+
+		mkey, err := reflectMapKey(value.Type(), key)
+		if err != nil {
+			return err
+		}
+
+		return setMapIndex(value, mkey, reflect.ValueOf(val), value.Type().Elem())
+	}
+
+	return setField(target, key, val)
+}
+
+// reflectMapKey returns the key as a key of the map type, converting it when
+// it is not of that type, so integer keys find the entries of maps with
+// string keys and the reverse.
+func reflectMapKey(mapType reflect.Type, key interface{}) (reflect.Value, error) {
+	if value := reflect.ValueOf(key); value.IsValid() && value.Type().AssignableTo(mapType.Key()) {
+		return value, nil
+	}
+
+	return mapKey(mapType, fmt.Sprint(key))
 }
 
 // ErrIndexOutOfBound returns this when  hte provided index is out of bounds/
@@ -214,6 +486,11 @@ func AddToIndexInList(target interface{}, val interface{}) fractals.Handler {
 }
 
 func getIndex(target interface{}, index int) (interface{}, error) {
+	index, err := fromEnd(target, index)
+	if err != nil {
+		return nil, err
+	}
+
 	switch mo := target.(type) {
 	case []map[uint]string:
 		if len(mo) <= index {
@@ -308,10 +585,29 @@ func getIndex(target interface{}, index int) (interface{}, error) {
 		return mo[index], nil
 	}
 
+	// Other lists are indexed, and maps looked up by the index, by
+	// reflection.
+	switch value := reflect.ValueOf(target); value.Kind() {
+	case reflect.Slice, reflect.Array:
+		if value.Len() <= index {
+			return nil, ErrIndexOutOfBound
+		}
+
+		return value.Index(index).Interface(), nil
+
+	case reflect.Map:
+		return getValue(target, index)
+	}
+
 	return nil, ErrKeyNotFound
 }
 
 func setIndex(target interface{}, index int, val interface{}) error {
+	index, err := fromEnd(target, index)
+	if err != nil {
+		return err
+	}
+
 	switch mo := target.(type) {
 	case []map[uint]string:
 		if len(mo) <= index {
@@ -425,5 +721,87 @@ func setIndex(target interface{}, index int, val interface{}) error {
 		return nil
 	}
 
+	switch value := reflect.ValueOf(target); value.Kind() {
+	case reflect.Slice:
+		if value.Len() <= index {
+			return ErrIndexOutOfBound
+		}
+
+		item := reflect.ValueOf(val)
+		if !item.IsValid() {
+			item = reflect.Zero(value.Type().Elem())
+		}
+
+		if !item.Type().AssignableTo(value.Type().Elem()) {
+			return ErrTypeNotFound
+		}
+
+		value.Index(index).Set(item)
+		return nil
+
+	case reflect.Map:
+		return setValue(target, index, val)
+	}
+
 	return ErrTypeNotFound
 }
+
+// fromEnd returns negative indexes as indexes counted from the end of the
+// list.
+func fromEnd(target interface{}, index int) (int, error) {
+	if index >= 0 {
+		return index, nil
+	}
+
+	list := reflect.ValueOf(target)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return index, ErrTypeNotFound
+	}
+
+	if index += list.Len(); index < 0 {
+		return index, ErrIndexOutOfBound
+	}
+
+	return index, nil
+}
+
+// AppendInToList appends the value to the list held under the key within
+// incoming maps, returning the value appended.
+func AppendInToList(key interface{}, val interface{}) fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		list, err := lookupKey(target, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if list, err = appendValue(list, val); err != nil {
+			return nil, err
+		}
+
+		if _, err := assignKey(target, key, list); err != nil {
+			return nil, err
+		}
+
+		return val, nil
+	})
+}
+
+// appendValue returns the list with the value appended, if the list can hold
+// it.
+func appendValue(target interface{}, val interface{}) (interface{}, error) {
+	list := reflect.ValueOf(target)
+	if list.Kind() != reflect.Slice {
+		return nil, ErrTypeNotFound
+	}
+
+	item := reflect.ValueOf(val)
+	if !item.IsValid() {
+		item = reflect.Zero(list.Type().Elem())
+	}
+
+	if !item.Type().AssignableTo(list.Type().Elem()) {
+		return nil, ErrTypeNotFound
+	}
+
+	return reflect.Append(list, item).Interface(), nil
+}
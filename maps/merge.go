@@ -0,0 +1,195 @@
+package maps
+
+import (
+	"reflect"
+
+	"github.com/influx6/fractals"
+)
+
+// MergeConflict defines how Merge resolves keys held by both the incoming
+// map and the overlay, whoes values can not be merged.
+type MergeConflict int
+
+const (
+	// MergeOverwrite replaces the incoming value with the overlay's.
+	MergeOverwrite MergeConflict = iota
+
+	// MergeKeep keeps the incoming value.
+	MergeKeep
+)
+
+// MergeOptions defines the configuration used by Merge.
+type MergeOptions struct {
+	// Conflict sets how keys held by both maps are resolved, when their
+	// values are not both maps, defaulting to MergeOverwrite.
+	Conflict MergeConflict
+
+	// AppendLists appends the overlay's lists to the incoming ones, instead
+	// of resolving them as conflicts.
+	AppendLists bool
+}
+
+// Merge returns a Handler which deep merges the overlay into incoming maps,
+// passing down the merged map. Maps held by both are merged key by key, while
+// other values held by both are resolved as the options set. Values are
+// copied out of the overlay, so it can be merged into many maps, such as in
+// layered configuration pipelines, without being changed by later merges.
+func Merge(overlay interface{}, opts MergeOptions) fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		into := reflect.ValueOf(target)
+		from := reflect.ValueOf(overlay)
+
+		if into.Kind() != reflect.Map || from.Kind() != reflect.Map {
+			return nil, ErrTypeNotFound
+		}
+
+		if err := mergeMaps(into, from, opts); err != nil {
+			return nil, err
+		}
+
+		return target, nil
+	})
+}
+
+// mergeMaps merges the overlay map into the target map.
+func mergeMaps(target reflect.Value, overlay reflect.Value, opts MergeOptions) error {
+	keyType := target.Type().Key()
+	elemType := target.Type().Elem()
+
+	for _, key := range overlay.MapKeys() {
+		if !key.Type().ConvertibleTo(keyType) {
+			return ErrTypeNotFound
+		}
+
+		tkey := key.Convert(keyType)
+		value := overlay.MapIndex(key)
+
+		current := target.MapIndex(tkey)
+		if !current.IsValid() {
+			if err := setMapIndex(target, tkey, copyValue(value), elemType); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		cval, oval := unwrapValue(current), unwrapValue(value)
+
+		if cval.Kind() == reflect.Map && oval.Kind() == reflect.Map && !cval.IsNil() {
+			if err := mergeMaps(cval, oval, opts); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if opts.AppendLists && cval.Kind() == reflect.Slice && oval.Kind() == reflect.Slice {
+			if list, ok := appendLists(cval, oval); ok {
+				if err := setMapIndex(target, tkey, list, elemType); err != nil {
+					return err
+				}
+
+				continue
+			}
+		}
+
+		if opts.Conflict == MergeKeep {
+			continue
+		}
+
+		if err := setMapIndex(target, tkey, copyValue(value), elemType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setMapIndex stores the value under the key, if the map can hold it.
+func setMapIndex(target reflect.Value, key reflect.Value, value reflect.Value, elemType reflect.Type) error {
+	if !value.IsValid() {
+		target.SetMapIndex(key, reflect.Zero(elemType))
+		return nil
+	}
+
+	if !value.Type().AssignableTo(elemType) {
+		return ErrTypeNotFound
+	}
+
+	target.SetMapIndex(key, value)
+	return nil
+}
+
+// appendLists returns the overlay list appended to the current one, if the
+// items of the overlay can be held by it.
+func appendLists(current reflect.Value, overlay reflect.Value) (reflect.Value, bool) {
+	elemType := current.Type().Elem()
+
+	list := reflect.MakeSlice(current.Type(), 0, current.Len()+overlay.Len())
+	list = reflect.AppendSlice(list, current)
+
+	for i := 0; i < overlay.Len(); i++ {
+		item := copyValue(overlay.Index(i))
+		if !item.IsValid() {
+			item = reflect.Zero(elemType)
+		}
+
+		if !item.Type().AssignableTo(elemType) {
+			return reflect.Value{}, false
+		}
+
+		list = reflect.Append(list, item)
+	}
+
+	return list, true
+}
+
+// unwrapValue returns the value held by interface values.
+func unwrapValue(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Interface && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	return value
+}
+
+// copyValue returns a deep copy of the maps and lists within the value.
+func copyValue(value reflect.Value) reflect.Value {
+	inner := unwrapValue(value)
+
+	switch inner.Kind() {
+	case reflect.Map:
+		if inner.IsNil() {
+			return inner
+		}
+
+		copied := reflect.MakeMapWithSize(inner.Type(), inner.Len())
+		for _, key := range inner.MapKeys() {
+			item := copyValue(inner.MapIndex(key))
+			if !item.IsValid() {
+				item = reflect.Zero(inner.Type().Elem())
+			}
+
+			copied.SetMapIndex(key, item)
+		}
+
+		return copied
+
+	case reflect.Slice:
+		if inner.IsNil() {
+			return inner
+		}
+
+		copied := reflect.MakeSlice(inner.Type(), inner.Len(), inner.Len())
+		for i := 0; i < inner.Len(); i++ {
+			if item := copyValue(inner.Index(i)); item.IsValid() {
+				copied.Index(i).Set(item)
+			}
+		}
+
+		return copied
+
+	default:
+		return inner
+	}
+}
@@ -0,0 +1,564 @@
+package maps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influx6/fractals"
+)
+
+// ErrInvalidPointer is returned when a path of an Operation is not a valid
+// JSON Pointer.
+var ErrInvalidPointer = errors.New("Invalid JSON Pointer")
+
+// ErrInvalidOperation is returned when an Operation is unknown or misses the
+// fields it requires.
+var ErrInvalidOperation = errors.New("Invalid patch operation")
+
+// ErrTestFailed is returned when the value tested by a test Operation does
+// not equal the expected one.
+var ErrTestFailed = errors.New("Patch test failed")
+
+// Operations of JSON Patch (RFC 6902) understood by Patch.
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// Operation defines a single change of a changeset, following the operations
+// of JSON Patch (RFC 6902), whoes paths are JSON Pointers (RFC 6901), so
+// changesets can be exchanged with other tools as JSON.
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON encodes the operation, including it's value, even when nil,
+// for the operations requiring one.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	type operation Operation
+
+	if o.Op != OpAdd && o.Op != OpReplace && o.Op != OpTest {
+		return json.Marshal(operation(o))
+	}
+
+	return json.Marshal(struct {
+		operation
+		Value interface{} `json:"value"`
+	}{operation(o), o.Value})
+}
+
+// Diff returns a Handler which compares incoming structures against the base,
+// passing down the []Operation which turns the base into the incoming one.
+// Maps are compared key by key and lists index by index, where items beyond
+// the length of the shorter list are added or removed, while other values
+// differing are replaced.
+func Diff(base interface{}) fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		return diffValues("", reflect.ValueOf(base), reflect.ValueOf(target), []Operation{}), nil
+	})
+}
+
+// diffValues appends the operations turning the base into the target at the
+// path.
+func diffValues(path string, base reflect.Value, target reflect.Value, ops []Operation) []Operation {
+	base, target = unwrapValue(base), unwrapValue(target)
+
+	if base.Kind() == reflect.Map && target.Kind() == reflect.Map && !base.IsNil() && !target.IsNil() {
+		baseKeys, targetKeys := keyedEntries(base), keyedEntries(target)
+
+		for _, name := range sortedNames(baseKeys) {
+			if _, ok := targetKeys[name]; !ok {
+				ops = append(ops, Operation{Op: OpRemove, Path: appendPointer(path, name)})
+			}
+		}
+
+		for _, name := range sortedNames(targetKeys) {
+			if item, ok := baseKeys[name]; ok {
+				ops = diffValues(appendPointer(path, name), item, targetKeys[name], ops)
+				continue
+			}
+
+			ops = append(ops, Operation{Op: OpAdd, Path: appendPointer(path, name), Value: valueOf(copyValue(targetKeys[name]))})
+		}
+
+		return ops
+	}
+
+	if base.Kind() == reflect.Slice && target.Kind() == reflect.Slice {
+		shared := base.Len()
+		if target.Len() < shared {
+			shared = target.Len()
+		}
+
+		for i := 0; i < shared; i++ {
+			ops = diffValues(appendPointer(path, strconv.Itoa(i)), base.Index(i), target.Index(i), ops)
+		}
+
+		for i := shared; i < target.Len(); i++ {
+			ops = append(ops, Operation{Op: OpAdd, Path: appendPointer(path, strconv.Itoa(i)), Value: valueOf(copyValue(target.Index(i)))})
+		}
+
+		// Items are removed from the end, so the indexes of the ones left do
+		// not change.
+		for i := base.Len() - 1; i >= shared; i-- {
+			ops = append(ops, Operation{Op: OpRemove, Path: appendPointer(path, strconv.Itoa(i))})
+		}
+
+		return ops
+	}
+
+	if !reflect.DeepEqual(valueOf(base), valueOf(target)) {
+		ops = append(ops, Operation{Op: OpReplace, Path: path, Value: valueOf(copyValue(target))})
+	}
+
+	return ops
+}
+
+// keyedEntries returns the entries of the map keyed by their keys as strings.
+func keyedEntries(m reflect.Value) map[string]reflect.Value {
+	entries := make(map[string]reflect.Value, m.Len())
+
+	for _, key := range m.MapKeys() {
+		entries[fmt.Sprint(key.Interface())] = m.MapIndex(key)
+	}
+
+	return entries
+}
+
+// sortedNames returns the keys of the entries in order.
+func sortedNames(entries map[string]reflect.Value) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Patch returns a Handler which applies the changes, such as those made by
+// Diff or decoded from a JSON Patch document, to incoming structures, passing
+// down the patched structure. The incoming structure is copied before it is
+// patched, so a failing change, such as a failed test, leaves it untouched.
+func Patch(changes []Operation) fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		doc := valueOf(copyValue(reflect.ValueOf(target)))
+
+		for _, change := range changes {
+			var err error
+			if doc, err = applyOperation(doc, change); err != nil {
+				return nil, err
+			}
+		}
+
+		return doc, nil
+	})
+}
+
+// applyOperation applies the operation to the document, returning the
+// document as changed.
+func applyOperation(doc interface{}, op Operation) (interface{}, error) {
+	path, err := pointerTokens(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case OpAdd:
+		return setPointer(doc, path, valueOf(copyValue(reflect.ValueOf(op.Value))), true)
+
+	case OpRemove:
+		_, doc, err := removePointer(doc, path)
+		return doc, err
+
+	case OpReplace:
+		if _, err := getPointer(doc, path); err != nil {
+			return nil, err
+		}
+
+		return setPointer(doc, path, valueOf(copyValue(reflect.ValueOf(op.Value))), false)
+
+	case OpMove, OpCopy:
+		from, err := pointerTokens(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := getPointer(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == OpMove {
+			if _, doc, err = removePointer(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = valueOf(copyValue(reflect.ValueOf(value)))
+		}
+
+		return setPointer(doc, path, value, true)
+
+	case OpTest:
+		value, err := getPointer(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !equalValues(value, op.Value) {
+			return nil, ErrTestFailed
+		}
+
+		return doc, nil
+	}
+
+	return nil, ErrInvalidOperation
+}
+
+// MergePatch returns a Handler which applies the JSON Merge Patch (RFC 7386)
+// to incoming structures, passing down the patched structure, where maps of
+// the patch are merged key by key, nil values remove their keys and other
+// values replace the incoming ones. The incoming structure is copied before
+// it is patched.
+func MergePatch(patch interface{}) fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		return mergePatch(valueOf(copyValue(reflect.ValueOf(target))), reflect.ValueOf(patch))
+	})
+}
+
+// mergePatch applies the merge patch to the target, returning it as patched.
+func mergePatch(target interface{}, patch reflect.Value) (interface{}, error) {
+	patch = unwrapValue(patch)
+	if patch.Kind() != reflect.Map {
+		return valueOf(copyValue(patch)), nil
+	}
+
+	doc := unwrapValue(reflect.ValueOf(target))
+	switch {
+	case doc.Kind() != reflect.Map:
+		target = map[string]interface{}{}
+		doc = reflect.ValueOf(target)
+	case doc.IsNil():
+		doc = reflect.MakeMap(doc.Type())
+		target = doc.Interface()
+	}
+
+	for _, key := range patch.MapKeys() {
+		tkey, err := mapKey(doc.Type(), fmt.Sprint(key.Interface()))
+		if err != nil {
+			return nil, err
+		}
+
+		value := unwrapValue(patch.MapIndex(key))
+		if !value.IsValid() || isNilValue(value) {
+			doc.SetMapIndex(tkey, reflect.Value{})
+			continue
+		}
+
+		var current interface{}
+		if item := doc.MapIndex(tkey); item.IsValid() {
+			current = item.Interface()
+		}
+
+		merged, err := mergePatch(current, value)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := setMapIndex(doc, tkey, reflect.ValueOf(merged), doc.Type().Elem()); err != nil {
+			return nil, err
+		}
+	}
+
+	return target, nil
+}
+
+// pointerTokens returns the reference tokens of the JSON Pointer (RFC 6901),
+// such as "/documents/0/data", unescaping "~1" and "~0" within them. The empty
+// pointer, refering to the whole document, has no tokens.
+func pointerTokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, ErrInvalidPointer
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for index, token := range tokens {
+		tokens[index] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	return tokens, nil
+}
+
+// appendPointer returns the JSON Pointer with the token appended, escaping
+// "~" and "/" within it.
+func appendPointer(pointer string, token string) string {
+	return pointer + "/" + strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+// getPointer returns the value at the tokens within the document.
+func getPointer(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		var err error
+		if doc, err = getToken(doc, token); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// setPointer sets the value at the tokens within the document, inserting it
+// into lists if insert is true, returning the document as changed.
+func setPointer(doc interface{}, tokens []string, value interface{}, insert bool) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	if len(tokens) == 1 {
+		return setToken(doc, tokens[0], value, insert)
+	}
+
+	child, err := getToken(doc, tokens[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if child, err = setPointer(child, tokens[1:], value, insert); err != nil {
+		return nil, err
+	}
+
+	return setToken(doc, tokens[0], child, false)
+}
+
+// removePointer removes the value at the tokens within the document,
+// returning it along with the document as changed.
+func removePointer(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return doc, nil, nil
+	}
+
+	if len(tokens) == 1 {
+		return removeToken(doc, tokens[0])
+	}
+
+	child, err := getToken(doc, tokens[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removed, child, err := removePointer(child, tokens[1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if doc, err = setToken(doc, tokens[0], child, false); err != nil {
+		return nil, nil, err
+	}
+
+	return removed, doc, nil
+}
+
+// getToken returns the value of the token within the map or list.
+func getToken(container interface{}, token string) (interface{}, error) {
+	value := unwrapValue(reflect.ValueOf(container))
+
+	switch value.Kind() {
+	case reflect.Map:
+		key, err := mapKey(value.Type(), token)
+		if err != nil {
+			return nil, err
+		}
+
+		item := value.MapIndex(key)
+		if !item.IsValid() {
+			return nil, ErrKeyNotFound
+		}
+
+		return item.Interface(), nil
+
+	case reflect.Slice:
+		index, err := listIndex(token, value.Len())
+		if err != nil {
+			return nil, err
+		}
+
+		return value.Index(index).Interface(), nil
+	}
+
+	return nil, ErrTypeNotFound
+}
+
+// setToken sets the value of the token within the map or list, inserting it
+// into lists if insert is true, where the "-" token appends it, returning the
+// container as changed.
+func setToken(container interface{}, token string, item interface{}, insert bool) (interface{}, error) {
+	value := unwrapValue(reflect.ValueOf(container))
+
+	switch value.Kind() {
+	case reflect.Map:
+		key, err := mapKey(value.Type(), token)
+		if err != nil {
+			return nil, err
+		}
+
+		// Nil maps are allocated, with the caller setting the new map back
+		// into it's parent.
+		if value.IsNil() {
+			value = reflect.MakeMap(value.Type())
+			container = value.Interface()
+		}
+
+		return container, setMapIndex(value, key, reflect.ValueOf(item), value.Type().Elem())
+
+	case reflect.Slice:
+		elem := reflect.ValueOf(item)
+		if !elem.IsValid() {
+			elem = reflect.Zero(value.Type().Elem())
+		}
+
+		if !elem.Type().AssignableTo(value.Type().Elem()) {
+			return nil, ErrTypeNotFound
+		}
+
+		if !insert {
+			index, err := listIndex(token, value.Len())
+			if err != nil {
+				return nil, err
+			}
+
+			value.Index(index).Set(elem)
+			return container, nil
+		}
+
+		index := value.Len()
+		if token != "-" {
+			var err error
+			if index, err = listIndex(token, value.Len()+1); err != nil {
+				return nil, err
+			}
+		}
+
+		list := reflect.MakeSlice(value.Type(), 0, value.Len()+1)
+		list = reflect.AppendSlice(list, value.Slice(0, index))
+		list = reflect.Append(list, elem)
+		list = reflect.AppendSlice(list, value.Slice(index, value.Len()))
+
+		return list.Interface(), nil
+	}
+
+	return nil, ErrTypeNotFound
+}
+
+// removeToken removes the token from the map or list, returning the removed
+// value along with the container as changed.
+func removeToken(container interface{}, token string) (interface{}, interface{}, error) {
+	value := unwrapValue(reflect.ValueOf(container))
+
+	switch value.Kind() {
+	case reflect.Map:
+		key, err := mapKey(value.Type(), token)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		item := value.MapIndex(key)
+		if !item.IsValid() {
+			return nil, nil, ErrKeyNotFound
+		}
+
+		value.SetMapIndex(key, reflect.Value{})
+		return item.Interface(), container, nil
+
+	case reflect.Slice:
+		index, err := listIndex(token, value.Len())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return deleteIndex(value.Interface(), index, DeleteOptions{})
+	}
+
+	return nil, nil, ErrTypeNotFound
+}
+
+// mapKey returns the token as a key of the map type.
+func mapKey(mapType reflect.Type, token string) (reflect.Value, error) {
+	keyType := mapType.Key()
+
+	switch keyType.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(token).Convert(keyType), nil
+
+	case reflect.Interface:
+		return reflect.ValueOf(token), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(token, 10, 64)
+		if err != nil {
+			return reflect.Value{}, ErrKeyNotFound
+		}
+
+		return reflect.ValueOf(number).Convert(keyType), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := strconv.ParseUint(token, 10, 64)
+		if err != nil {
+			return reflect.Value{}, ErrKeyNotFound
+		}
+
+		return reflect.ValueOf(number).Convert(keyType), nil
+	}
+
+	return reflect.Value{}, ErrTypeNotFound
+}
+
+// listIndex returns the token as an index below size.
+func listIndex(token string, size int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, ErrKeyNotFound
+	}
+
+	if index < 0 || index >= size {
+		return 0, ErrIndexOutOfBound
+	}
+
+	return index, nil
+}
+
+// valueOf returns the value held by the reflect.Value, or nil if it holds
+// none.
+func valueOf(value reflect.Value) interface{} {
+	if !value.IsValid() {
+		return nil
+	}
+
+	return value.Interface()
+}
+
+// isNilValue returns true/false if the value is a nil map, list, pointer or
+// interface.
+func isNilValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	default:
+		return false
+	}
+}
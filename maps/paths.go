@@ -0,0 +1,201 @@
+package maps
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// ErrInvalidPath is returned when a path can not be parsed by it's
+// KeyParser.
+var ErrInvalidPath = errors.New("Invalid path")
+
+// KeyParser defines a function which parses a path into the keys run down by
+// FindWith and SaveWith, where integer keys are indexes of lists.
+type KeyParser func(path string) ([]interface{}, error)
+
+// PathOptions defines the configuration used by FindWith and SaveWith.
+type PathOptions struct {
+	// Parser sets the parser of the paths, defaulting to DotKeys.
+	Parser KeyParser
+}
+
+// parse returns the keys of the path parsed by the options' parser.
+func (p PathOptions) parse(path string) ([]interface{}, error) {
+	if p.Parser == nil {
+		return DotKeys(path)
+	}
+
+	return p.Parser(path)
+}
+
+// FindWith works as Find, parsing the path with the options' parser, so paths
+// such as JSON Pointers emitted by other tools can be used. Paths failing to
+// parse return a Handler failing with their error.
+func FindWith(path string, opts PathOptions) fractals.Handler {
+	keys, err := opts.parse(path)
+	if err != nil {
+		return failWith(err)
+	}
+
+	return findKeys(keys)
+}
+
+// SaveWith works as Save, parsing the path with the options' parser. Paths
+// failing to parse return a Handler failing with their error.
+func SaveWith(path string, val interface{}, opts PathOptions) fractals.Handler {
+	keys, err := opts.parse(path)
+	if err != nil {
+		return failWith(err)
+	}
+
+	if len(keys) == 0 {
+		return failWith(ErrInvalidPath)
+	}
+
+	return saveKeys(keys, val)
+}
+
+// failWith returns a Handler which always fails with the error.
+func failWith(err error) fractals.Handler {
+	return func(ctx context.Context, _ error, _ interface{}) (interface{}, error) {
+		return nil, err
+	}
+}
+
+// DotKeys parses period delimited paths, such as "documents.0.data.1", as
+// Keys does.
+func DotKeys(path string) ([]interface{}, error) {
+	return Keys(path), nil
+}
+
+// PointerKeys parses JSON Pointers (RFC 6901), such as "/documents/0/data/1",
+// where tokens made of digits are indexes. The empty pointer refers to the
+// incoming structure itself.
+func PointerKeys(path string) ([]interface{}, error) {
+	tokens, err := pointerTokens(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]interface{}, 0, len(tokens))
+	for _, token := range tokens {
+		keys = append(keys, parseKey(token))
+	}
+
+	return keys, nil
+}
+
+// JSONPathKeys parses a subset of JSONPath, made of the root "$", child names
+// as ".name" or "['name']", indexes as "[0]", the "*" wildcard as ".*" or
+// "[*]" and recursive descent as "..name", such as "$.documents[*].data[1]"
+// or "$..name". The wildcards are those understood by Find.
+func JSONPathKeys(path string) ([]interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+
+	var keys []interface{}
+
+	for len(path) != 0 {
+		switch {
+		case strings.HasPrefix(path, ".."):
+			keys = append(keys, AnyDepth)
+			path = path[2:]
+
+			if strings.HasPrefix(path, "[") {
+				continue
+			}
+
+			name, rest := splitName(path)
+			if name == "" {
+				return nil, ErrInvalidPath
+			}
+
+			keys = append(keys, nameKey(name))
+			path = rest
+
+		case path[0] == '.':
+			name, rest := splitName(path[1:])
+			if name == "" {
+				return nil, ErrInvalidPath
+			}
+
+			keys = append(keys, nameKey(name))
+			path = rest
+
+		case path[0] == '[':
+			end := strings.Index(path, "]")
+			if end == -1 {
+				return nil, ErrInvalidPath
+			}
+
+			key, err := bracketKey(path[1:end])
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, key)
+			path = path[end+1:]
+
+		default:
+			return nil, ErrInvalidPath
+		}
+	}
+
+	return keys, nil
+}
+
+// splitName returns the name at the start of the path, ending before the
+// next "." or "[", along with the rest of the path.
+func splitName(path string) (string, string) {
+	end := strings.IndexAny(path, ".[")
+	if end == -1 {
+		return path, ""
+	}
+
+	return path[:end], path[end:]
+}
+
+// nameKey returns the key of a child name, where names made of digits are
+// indexes.
+func nameKey(name string) interface{} {
+	if name == AnyKey {
+		return AnyKey
+	}
+
+	return parseKey(name)
+}
+
+// bracketKey returns the key within brackets, being a quoted name, an index
+// or the "*" wildcard.
+func bracketKey(inner string) (interface{}, error) {
+	inner = strings.TrimSpace(inner)
+
+	if inner == AnyKey {
+		return AnyKey, nil
+	}
+
+	if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+		return inner[1 : len(inner)-1], nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return nil, ErrInvalidPath
+	}
+
+	return index, nil
+}
+
+// parseKey returns the token as an integer key if it is made of digits, else
+// as a string key.
+func parseKey(token string) interface{} {
+	numb, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return token
+	}
+
+	return int(numb)
+}
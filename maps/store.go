@@ -0,0 +1,106 @@
+package maps
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/influx6/fractals"
+)
+
+// Store defines a nested map safe for concurrent use, whoes Handlers let
+// pipelines, such as those of observables, share mutable state without
+// locking it themselves. Values are copied into and out of the store, so
+// changing them does not change the ones it holds.
+type Store struct {
+	ml   sync.RWMutex
+	data map[string]interface{}
+}
+
+// NewStore returns a new instance of a Store holding a copy of the data, if
+// any.
+func NewStore(data map[string]interface{}) *Store {
+	store := &Store{data: make(map[string]interface{})}
+
+	for key, value := range data {
+		store.data[key] = valueOf(copyValue(reflect.ValueOf(value)))
+	}
+
+	return store
+}
+
+// Get returns a copy of the value at the path, as found by Find.
+func (s *Store) Get(path string) (interface{}, error) {
+	s.ml.RLock()
+	defer s.ml.RUnlock()
+
+	value, err := findKeys(Keys(path))(nil, nil, s.data)
+	if err != nil {
+		return nil, err
+	}
+
+	if matches, ok := value.([]Match); ok {
+		copied := make([]Match, len(matches))
+		for index, match := range matches {
+			copied[index] = Match{Path: match.Path, Value: valueOf(copyValue(reflect.ValueOf(match.Value)))}
+		}
+
+		return copied, nil
+	}
+
+	return valueOf(copyValue(reflect.ValueOf(value))), nil
+}
+
+// Set saves a copy of the value at the path, creating the containers missing
+// along it, as SaveWithCreate does.
+func (s *Store) Set(path string, val interface{}) error {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	_, err := saveWithCreate(s.data, Keys(path), valueOf(copyValue(reflect.ValueOf(val))))
+	return err
+}
+
+// Remove removes the value at the path, returning it, as Delete does.
+func (s *Store) Remove(path string) (interface{}, error) {
+	s.ml.Lock()
+	defer s.ml.Unlock()
+
+	removed, _, err := deleteIn(s.data, Keys(path), DeleteOptions{})
+	return removed, err
+}
+
+// Snapshot returns a copy of all the data held by the store.
+func (s *Store) Snapshot() map[string]interface{} {
+	s.ml.RLock()
+	defer s.ml.RUnlock()
+
+	return valueOf(copyValue(reflect.ValueOf(s.data))).(map[string]interface{})
+}
+
+// Find returns a Handler which passes down the value at the path within the
+// store, ignoring what it receives.
+func (s *Store) Find(path string) fractals.Handler {
+	return fractals.MustWrap(func(_ interface{}) (interface{}, error) {
+		return s.Get(path)
+	})
+}
+
+// Save returns a Handler which saves what it receives at the path within the
+// store, passing it down.
+func (s *Store) Save(path string) fractals.Handler {
+	return fractals.MustWrap(func(val interface{}) (interface{}, error) {
+		if err := s.Set(path, val); err != nil {
+			return nil, err
+		}
+
+		return val, nil
+	})
+}
+
+// Delete returns a Handler which removes the value at the path within the
+// store, passing down the removed value.
+func (s *Store) Delete(path string) fractals.Handler {
+	return fractals.MustWrap(func(_ interface{}) (interface{}, error) {
+		return s.Remove(path)
+	})
+}
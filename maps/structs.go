@@ -0,0 +1,113 @@
+package maps
+
+import (
+	"reflect"
+	"strings"
+)
+
+// getField returns the field of the struct named by key, following pointers
+// to the struct, or the value of key within the value a pointer points to.
+func getField(target interface{}, key interface{}) (interface{}, error) {
+	name, ok := key.(string)
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr {
+		if value.Kind() != reflect.Struct {
+			return nil, ErrKeyNotFound
+		}
+
+		field, ok := fieldByKey(value, name)
+		if !ok {
+			return nil, ErrKeyNotFound
+		}
+
+		return field.Interface(), nil
+	}
+
+	if value.IsNil() {
+		return nil, ErrKeyNotFound
+	}
+
+	return getValue(value.Elem().Interface(), key)
+}
+
+// setField sets the field of the struct pointed to named by key, or the value
+// of key within the value a pointer points to. Structs held by value can not
+// be changed.
+func setField(target interface{}, key interface{}, val interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return ErrTypeNotFound
+	}
+
+	elem := value.Elem()
+	if elem.Kind() != reflect.Struct {
+		return setValue(elem.Interface(), key, val)
+	}
+
+	name, ok := key.(string)
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	field, ok := fieldByKey(elem, name)
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	item := reflect.ValueOf(val)
+	if !item.IsValid() {
+		item = reflect.Zero(field.Type())
+	}
+
+	if !field.CanSet() || !item.Type().AssignableTo(field.Type()) {
+		return ErrTypeNotFound
+	}
+
+	field.Set(item)
+	return nil
+}
+
+// fieldByKey returns the exported field of the struct named by key, matching
+// the name of it's json tag, or if it has none, it's own name regardless of
+// case, as encoding/json does. Fields of embedded structs are promoted.
+func fieldByKey(value reflect.Value, key string) (reflect.Value, bool) {
+	var fallback reflect.Value
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if inner, ok := fieldByKey(value.Field(i), key); ok && !fallback.IsValid() {
+				fallback = inner
+			}
+
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == key {
+			return value.Field(i), true
+		}
+
+		if name == "" && !fallback.IsValid() && strings.EqualFold(field.Name, key) {
+			fallback = value.Field(i)
+		}
+	}
+
+	return fallback, fallback.IsValid()
+}
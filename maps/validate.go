@@ -0,0 +1,273 @@
+package maps
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/influx6/fractals"
+)
+
+// Types checked by a Rule.
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBool    = "bool"
+	TypeMap     = "map"
+	TypeList    = "list"
+)
+
+// Rule defines the checks made against the value at a path by Validate.
+type Rule struct {
+	// Path sets the period delimited path of the value, which may hold the
+	// wildcards understood by Find, checking every value matching it.
+	Path string
+
+	// Required fails values which are missing or nil.
+	Required bool
+
+	// Type sets the type values must be, being one of TypeString,
+	// TypeNumber, TypeInteger, TypeBool, TypeMap or TypeList, where integers
+	// may be held by floats without a fraction, as decoded from JSON.
+	Type string
+
+	// Min and Max set the range of numbers, or of the length of strings,
+	// maps and lists. See Bound.
+	Min *float64
+	Max *float64
+
+	// Enum lists the values allowed, where numbers are compared by value
+	// regardless of their type.
+	Enum []interface{}
+}
+
+// Bound returns a pointer to n, for setting the Min and Max of a Rule.
+func Bound(n float64) *float64 {
+	return &n
+}
+
+// Schema defines the rules incoming maps are validated against.
+type Schema []Rule
+
+// FieldError defines a value which failed a Rule, matching the Field of
+// fhttp, which renders it within the fields of a JSONError.
+type FieldError struct {
+	Name     string      `json:"field_name"`
+	Value    string      `json:"field_value"`
+	Error    string      `json:"field_error"`
+	Expected interface{} `json:"expected_value"`
+}
+
+// ValidationError is returned by Validate with every value which failed it's
+// rules.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error returns the paths of the values which failed.
+func (v *ValidationError) Error() string {
+	names := make([]string, 0, len(v.Fields))
+	for _, field := range v.Fields {
+		names = append(names, field.Name)
+	}
+
+	return fmt.Sprintf("Validation failed for: %s", strings.Join(names, ", "))
+}
+
+// Validate returns a Handler which checks incoming maps, such as request
+// bodies decoded from JSON, against the rules of the schema, passing them down
+// if they pass, else failing with a *ValidationError holding every value
+// which failed.
+func Validate(schema Schema) fractals.Handler {
+	finders := make([]fractals.Handler, len(schema))
+	for index, rule := range schema {
+		finders[index] = Find(rule.Path)
+	}
+
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		var fields []FieldError
+
+		for index, rule := range schema {
+			found, err := finders[index](nil, nil, target)
+
+			matches, ok := found.([]Match)
+			if !ok {
+				if err != nil {
+					found = nil
+				}
+
+				matches = []Match{{Path: rule.Path, Value: found}}
+			}
+
+			for _, match := range matches {
+				if field, failed := checkRule(rule, match); failed {
+					fields = append(fields, field)
+				}
+			}
+		}
+
+		if len(fields) != 0 {
+			return nil, &ValidationError{Fields: fields}
+		}
+
+		return target, nil
+	})
+}
+
+// checkRule returns the FieldError of the match if it fails the rule.
+func checkRule(rule Rule, match Match) (FieldError, bool) {
+	field := FieldError{Name: match.Path}
+
+	value := reflect.ValueOf(match.Value)
+	if !value.IsValid() || isNilValue(value) {
+		if !rule.Required {
+			return field, false
+		}
+
+		field.Error = "is required"
+		field.Expected = "value"
+		return field, true
+	}
+
+	field.Value = fmt.Sprint(match.Value)
+
+	if rule.Type != "" && !isType(value, rule.Type) {
+		field.Error = "must be of type " + rule.Type
+		field.Expected = rule.Type
+		return field, true
+	}
+
+	if size, ok := measure(value); ok {
+		if rule.Min != nil && size < *rule.Min {
+			field.Error = fmt.Sprintf("must be at least %v", *rule.Min)
+			field.Expected = *rule.Min
+			return field, true
+		}
+
+		if rule.Max != nil && size > *rule.Max {
+			field.Error = fmt.Sprintf("must be at most %v", *rule.Max)
+			field.Expected = *rule.Max
+			return field, true
+		}
+	}
+
+	if len(rule.Enum) != 0 {
+		for _, allowed := range rule.Enum {
+			if equalValues(match.Value, allowed) {
+				return field, false
+			}
+		}
+
+		field.Error = "must be one of the allowed values"
+		field.Expected = rule.Enum
+		return field, true
+	}
+
+	return field, false
+}
+
+// isType returns true/false if the value is of the type.
+func isType(value reflect.Value, kind string) bool {
+	switch kind {
+	case TypeString:
+		return value.Kind() == reflect.String
+	case TypeBool:
+		return value.Kind() == reflect.Bool
+	case TypeMap:
+		return value.Kind() == reflect.Map || value.Kind() == reflect.Struct
+	case TypeList:
+		return value.Kind() == reflect.Slice || value.Kind() == reflect.Array
+	case TypeNumber:
+		_, ok := toFloat(value)
+		return ok
+	case TypeInteger:
+		number, ok := toFloat(value)
+		return ok && number == math.Trunc(number)
+	}
+
+	return false
+}
+
+// measure returns the number held by the value, or the length of strings,
+// maps and lists.
+func measure(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.String, reflect.Map, reflect.Slice, reflect.Array:
+		return float64(value.Len()), true
+	}
+
+	return toFloat(value)
+}
+
+// toFloat returns the number held by the value as a float64.
+func toFloat(value reflect.Value) (float64, bool) {
+	if value.IsValid() && value.Type() == reflect.TypeOf(json.Number("")) {
+		number, err := value.Interface().(json.Number).Float64()
+		return number, err == nil
+	}
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+
+	return 0, false
+}
+
+// equalValues returns true/false if the values are deeply equal, comparing
+// numbers by value regardless of their type, so an int equals the float64 or
+// json.Number decoded from JSON.
+func equalValues(a interface{}, b interface{}) bool {
+	av, bv := unwrapValue(reflect.ValueOf(a)), unwrapValue(reflect.ValueOf(b))
+
+	an, aok := toFloat(av)
+	bn, bok := toFloat(bv)
+
+	if aok && bok {
+		return an == bn
+	}
+
+	switch {
+	case av.Kind() == reflect.Map && bv.Kind() == reflect.Map:
+		if av.Len() != bv.Len() {
+			return false
+		}
+
+		bKeys := keyedEntries(bv)
+		for name, item := range keyedEntries(av) {
+			other, ok := bKeys[name]
+			if !ok || !equalValues(valueOf(item), valueOf(other)) {
+				return false
+			}
+		}
+
+		return true
+
+	case isList(av) && isList(bv):
+		if av.Len() != bv.Len() {
+			return false
+		}
+
+		for i := 0; i < av.Len(); i++ {
+			if !equalValues(valueOf(av.Index(i)), valueOf(bv.Index(i))) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// isList returns true/false if the value is a slice or array.
+func isList(value reflect.Value) bool {
+	return value.Kind() == reflect.Slice || value.Kind() == reflect.Array
+}
@@ -0,0 +1,205 @@
+package maps
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+
+	"github.com/influx6/fractals"
+)
+
+// Wildcard segments understood by Find.
+const (
+	// AnyKey matches every key or index of a single level.
+	AnyKey = "*"
+
+	// AnyDepth matches any number of levels, including none.
+	AnyDepth = "**"
+)
+
+// Match defines a value found by a wildcard path along with the period
+// delimited path it was found at, whoes keys are escaped as Keys expects.
+type Match struct {
+	Path  string
+	Value interface{}
+}
+
+// hasWildcard returns true/false if the keys hold a wildcard segment.
+func hasWildcard(keys []interface{}) bool {
+	for _, key := range keys {
+		if key == AnyKey || key == AnyDepth {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findAll returns a Handler which passes down the []Match of every value
+// matching the keys within incoming structures.
+func findAll(keys []interface{}) fractals.Handler {
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		return matchKeys(target, keys, "", []Match{}), nil
+	})
+}
+
+// matchKeys appends the values within target matching the keys, found under
+// the path.
+func matchKeys(target interface{}, keys []interface{}, path string, matches []Match) []Match {
+	if len(keys) == 0 {
+		return append(matches, Match{Path: path, Value: target})
+	}
+
+	switch keys[0] {
+	case AnyDepth:
+		matches = matchKeys(target, keys[1:], path, matches)
+
+		for _, child := range childEntries(target) {
+			matches = matchKeys(child.Value, keys, joinPath(path, child.Path), matches)
+		}
+
+		return matches
+
+	case AnyKey:
+		for _, child := range childEntries(target) {
+			matches = matchKeys(child.Value, keys[1:], joinPath(path, child.Path), matches)
+		}
+
+		return matches
+	}
+
+	child, err := lookupKey(target, keys[0])
+	if err != nil {
+		return matches
+	}
+
+	return matchKeys(child, keys[1:], joinPath(path, escapeKey(fmt.Sprint(keys[0]))), matches)
+}
+
+// childEntries returns the entries of the map, in the order of their keys,
+// or list, as Matches keyed by their escaped key or index.
+func childEntries(target interface{}) []Match {
+	value := unwrapValue(reflect.ValueOf(target))
+
+	switch value.Kind() {
+	case reflect.Map:
+		entries := keyedEntries(value)
+
+		children := make([]Match, 0, len(entries))
+		for _, name := range sortedNames(entries) {
+			children = append(children, Match{Path: escapeKey(name), Value: entries[name].Interface()})
+		}
+
+		return children
+
+	case reflect.Slice, reflect.Array:
+		children := make([]Match, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			children = append(children, Match{Path: strconv.Itoa(i), Value: value.Index(i).Interface()})
+		}
+
+		return children
+	}
+
+	return nil
+}
+
+// joinPath returns the key appended to the period delimited path.
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// ListPaths returns a Handler which passes down the []Match of every leaf
+// value within incoming structures, being the values which are not maps or
+// lists, or are empty ones, in the order of their paths, such as for
+// inspecting configurations or logging what changed. When given glob
+// patterns, only the paths matching one of them are passed down, where each
+// key of a pattern is matched as by path.Match and the "**" key matches any
+// number of keys, as in "db.**" or "servers.*.host*".
+func ListPaths(globs ...string) fractals.Handler {
+	patterns := make([][]string, len(globs))
+	for index, glob := range globs {
+		for _, key := range Keys(glob) {
+			pattern := fmt.Sprint(key)
+			if _, err := path.Match(pattern, ""); err != nil {
+				return failWith(ErrInvalidPath)
+			}
+
+			patterns[index] = append(patterns[index], pattern)
+		}
+	}
+
+	return fractals.MustWrap(func(target interface{}) (interface{}, error) {
+		leaves := leafEntries(target, "", []Match{})
+		if len(patterns) == 0 {
+			return leaves, nil
+		}
+
+		matched := []Match{}
+		for _, leaf := range leaves {
+			var keys []string
+			for _, key := range Keys(leaf.Path) {
+				keys = append(keys, fmt.Sprint(key))
+			}
+
+			for _, pattern := range patterns {
+				if matchGlob(pattern, keys) {
+					matched = append(matched, leaf)
+					break
+				}
+			}
+		}
+
+		return matched, nil
+	})
+}
+
+// leafEntries appends the leaf values within target, found under the path.
+func leafEntries(target interface{}, path string, leaves []Match) []Match {
+	children := childEntries(target)
+	if len(children) == 0 {
+		if path == "" {
+			return leaves
+		}
+
+		return append(leaves, Match{Path: path, Value: target})
+	}
+
+	for _, child := range children {
+		leaves = leafEntries(child.Value, joinPath(path, child.Path), leaves)
+	}
+
+	return leaves
+}
+
+// matchGlob returns true/false if the keys match the keys of the pattern.
+func matchGlob(pattern []string, keys []string) bool {
+	if len(pattern) == 0 {
+		return len(keys) == 0
+	}
+
+	if pattern[0] == AnyDepth {
+		for skip := 0; skip <= len(keys); skip++ {
+			if matchGlob(pattern[1:], keys[skip:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(keys) == 0 {
+		return false
+	}
+
+	if ok, _ := path.Match(pattern[0], keys[0]); !ok {
+		return false
+	}
+
+	return matchGlob(pattern[1:], keys[1:])
+}
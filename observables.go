@@ -1,6 +1,10 @@
 package fractals
 
 import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influx6/faux/context"
@@ -8,14 +12,35 @@ import (
 
 var identity = IdentityHandler()
 
+// ErrCompleted is the reason passed to the OnEnd callbacks of subscriptions
+// which ended because their source completed.
+var ErrCompleted = errors.New("Observable completed")
+
 // Behaviour exposes a struct which defines a type which allows creating a set of
 // pure structure for calling behaviours based on reactions like a stream.
 type Behaviour struct {
-	Next Handler
-	Done Handler
-	End  func()
+	Next  Handler
+	Done  Handler
+	Error Handler
+	End   func()
+
+	// ErrorPolicy decides if the observer ends once it passes on an error
+	// received through it's Error method.
+	ErrorPolicy ErrorPolicy
 }
 
+// ErrorPolicy defines what a observer does after passing on an error.
+type ErrorPolicy int
+
+const (
+	// ContinueOnError keeps the observer running after an error.
+	ContinueOnError ErrorPolicy = iota
+
+	// TerminateOnError ends the observer after an error, disconnecting it's
+	// subscribers and running it's finalizers.
+	TerminateOnError
+)
+
 // NewBehaviour returns a new instance of a Behaviour struct.
 func NewBehaviour(next, done interface{}, end func()) Behaviour {
 	var b Behaviour
@@ -48,12 +73,42 @@ type Observable interface {
 	DoneVal(interface{})
 	Done(context.Context, interface{})
 	Next(context.Context, interface{})
+	Error(context.Context, error)
+	OnComplete(func())
 	AddFinalizer(func())
+	Unsubscribe(*Subscription)
 	Subscribe(Observable, ...func()) *Subscription
 }
 
-// NewObservable returns a new instance of a Observable.
+// Observer defines the emitting side of an Observable, it is what producers
+// of cold observables use to deliver values to their subscriber.
+type Observer interface {
+	NextVal(interface{})
+	DoneVal(interface{})
+	Done(context.Context, interface{})
+	Next(context.Context, interface{})
+	Error(context.Context, error)
+}
+
+// NewObservable returns a new instance of a Observable. If async is true, then
+// the behaviour runs within a goroutine through the GoroutineScheduler, else
+// it runs within the current goroutine through the ImmediateScheduler.
 func NewObservable(behaviour Behaviour, async bool) Observable {
+	if async {
+		return NewScheduledObservable(behaviour, GoroutineScheduler)
+	}
+
+	return NewScheduledObservable(behaviour, ImmediateScheduler)
+}
+
+// NewScheduledObservable returns a new instance of a Observable which runs
+// it's behaviour and delivers to it's subscribers through the giving
+// Scheduler. If scheduler is nil, the ImmediateScheduler is used.
+func NewScheduledObservable(behaviour Behaviour, scheduler Scheduler) Observable {
+	if scheduler == nil {
+		scheduler = ImmediateScheduler
+	}
+
 	if behaviour.Next == nil {
 		panic("No next Handler provided")
 	}
@@ -64,16 +119,141 @@ func NewObservable(behaviour Behaviour, async bool) Observable {
 
 	return &IndefiniteObserver{
 		behaviour: behaviour,
-		doAsync:   async,
+		scheduler: scheduler,
 	}
 }
 
-// ReplayObservable returns a new instance of a Observable which replaces it's
-// events down it's subscribers line.
-func ReplayObservable() Observable {
-	return &IndefiniteObserver{
-		behaviour: IdentityBehaviour(),
+// ReplayObservable returns a new instance of a Observable which replays it's
+// events down it's subscribers line. It buffers the last n values it emitted
+// and replays them to any subscriber added afterwards, before that subscriber
+// receives any new value. If n is zero or less, all emitted values are kept.
+func ReplayObservable(n int) Observable {
+	return &ReplayObserver{
+		IndefiniteObserver: &IndefiniteObserver{
+			behaviour: IdentityBehaviour(),
+		},
+		size: n,
+	}
+}
+
+// FromSlice returns a cold Observable which emits every element of the
+// provided slice to each of it's subscribers, signaling Done with true once all
+// elements have been emitted. It panics if items is not a slice or array.
+func FromSlice(items interface{}) Observable {
+	list := reflect.ValueOf(items)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		panic("Expected a slice or array type")
+	}
+
+	return NewColdObservable(func(o Observer) {
+		ctx := context.New()
+
+		for i := 0; i < list.Len(); i++ {
+			o.Next(ctx, list.Index(i).Interface())
+		}
+
+		o.Done(ctx, true)
+	})
+}
+
+// FromChannel returns a Observable which emits every value received from the
+// provided channel, signaling Done with true once the channel is closed. The
+// channel is only read from once the Observable receives it's first subscriber
+// and it is read within a goroutine. It panics if ch is not a channel which
+// can be received from.
+func FromChannel(ch interface{}) Observable {
+	chn := reflect.ValueOf(ch)
+	if chn.Kind() != reflect.Chan || chn.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic("Expected a receivable channel type")
+	}
+
+	return &channelObserver{
+		IndefiniteObserver: &IndefiniteObserver{
+			behaviour: IdentityBehaviour(),
+		},
+		chn: chn,
+	}
+}
+
+// channelObserver defines a Observable which starts draining a channel on
+// it's first subscription.
+type channelObserver struct {
+	*IndefiniteObserver
+	chn  reflect.Value
+	once sync.Once
+}
+
+// Subscribe connects the giving Observer and starts reading the channel if
+// it is the first subscription.
+func (c *channelObserver) Subscribe(b Observable, finalizers ...func()) *Subscription {
+	sub := c.IndefiniteObserver.Subscribe(b, finalizers...)
+
+	c.once.Do(func() {
+		go func() {
+			ctx := context.New()
+
+			for {
+				item, ok := c.chn.Recv()
+				if !ok {
+					break
+				}
+
+				c.Next(ctx, item.Interface())
+			}
+
+			c.Done(ctx, true)
+		}()
+	})
+
+	return sub
+}
+
+// IntervalObservable returns a Observable which emits an increasing count,
+// starting at 0, every time the giving duration elapses, using the
+// SystemClock. It keeps emitting until it is ended.
+func IntervalObservable(dr time.Duration) Observable {
+	return IntervalWithClock(dr, SystemClock)
+}
+
+// IntervalWithClock works like IntervalObservable, using the provided Clock to
+// measure the duration between emissions.
+func IntervalWithClock(dr time.Duration, clock Clock) Observable {
+	ob := NewObservable(IdentityBehaviour(), false)
+
+	var ml sync.Mutex
+	var timer Timer
+	var stopped bool
+	var count int
+
+	var tick func()
+	tick = func() {
+		ml.Lock()
+		if stopped {
+			ml.Unlock()
+			return
+		}
+
+		current := count
+		count++
+		timer = clock.AfterFunc(dr, tick)
+		ml.Unlock()
+
+		ob.Next(context.New(), current)
 	}
+
+	ml.Lock()
+	timer = clock.AfterFunc(dr, tick)
+	ml.Unlock()
+
+	ob.AddFinalizer(func() {
+		ml.Lock()
+		defer ml.Unlock()
+
+		stopped = true
+		timer.Stop()
+	})
+
+	return ob
 }
 
 // MapWithObserver applies the giving predicate to all values the target observer
@@ -85,39 +265,52 @@ func MapWithObserver(mapPredicate Behaviour, target Observable) Observable {
 }
 
 // DebounceWithObserver applies the giving predicate to all values the target observer
-// provides returning only values which matches and uses the time.Ticker.
+// provides returning only values which matches and uses the SystemClock.
 func DebounceWithObserver(target Observable, dr time.Duration) Observable {
-	var allowed bool
+	return DebounceWithClock(target, dr, SystemClock)
+}
 
-	ticker := time.NewTicker(dr)
+// DebounceWithClock works like DebounceWithObserver, only allowing a value
+// through every time the giving duration elapses on the provided Clock.
+func DebounceWithClock(target Observable, dr time.Duration, clock Clock) Observable {
+	var allowed int32
 
-	go func() {
-		for {
-			_, open := <-ticker.C
-			if !open {
-				break
-			}
+	var tl sync.Mutex
+	var timer Timer
+	var stopped bool
+
+	var tick func()
+	tick = func() {
+		atomic.StoreInt32(&allowed, 1)
+
+		tl.Lock()
+		defer tl.Unlock()
 
-			allowed = true
+		if !stopped {
+			timer = clock.AfterFunc(dr, tick)
 		}
-	}()
+	}
+
+	tl.Lock()
+	timer = clock.AfterFunc(dr, tick)
+	tl.Unlock()
 
 	ob := NewObservable(Behaviour{
 		Next: MustWrap(func(item interface{}) interface{} {
-			if !allowed {
+			if !atomic.CompareAndSwapInt32(&allowed, 1, 0) {
 				return nil
 			}
 
-			allowed = false
 			return item
 		}),
 	}, false)
 
 	ob.AddFinalizer(func() {
-		if ticker != nil {
-			ticker.Stop()
-			ticker = nil
-		}
+		tl.Lock()
+		defer tl.Unlock()
+
+		stopped = true
+		timer.Stop()
 	})
 
 	target.Subscribe(ob)
@@ -125,6 +318,61 @@ func DebounceWithObserver(target Observable, dr time.Duration) Observable {
 	return ob
 }
 
+// ThrottleWithObserver returns a Observable which emits the first value the
+// target observer provides and then ignores every value received within the
+// giving duration, after which the next value received is emitted and starts
+// a new window. Unlike DebounceWithObserver, no ticker is used, the window
+// starts at the moment a value is emitted. Errors are always passed on.
+func ThrottleWithObserver(target Observable, dr time.Duration) Observable {
+	return ThrottleWithClock(target, dr, SystemClock)
+}
+
+// ThrottleWithClock works like ThrottleWithObserver, using the provided Clock
+// to measure the duration of every window.
+func ThrottleWithClock(target Observable, dr time.Duration, clock Clock) Observable {
+	var ml sync.Mutex
+	var last time.Time
+
+	ob := NewObservable(IdentityBehaviour(), false)
+
+	sub := target.Subscribe(NewObservable(Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				ob.Next(ctx, err)
+				return nil, err
+			}
+
+			now := clock.Now()
+
+			ml.Lock()
+			if !last.IsZero() && now.Sub(last) < dr {
+				ml.Unlock()
+				return nil, nil
+			}
+
+			last = now
+			ml.Unlock()
+
+			ob.Next(ctx, item)
+			return item, nil
+		},
+		Done: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				ob.Done(ctx, err)
+			} else {
+				ob.Done(ctx, item)
+			}
+
+			return item, err
+		},
+		Error: forwardError(ob),
+	}, false))
+
+	ob.AddFinalizer(sub.End)
+
+	return ob
+}
+
 // FilterWithObserver applies the giving predicate to all values the target observer
 // provides returning only values which match.
 func FilterWithObserver(predicate func(interface{}) bool, target Observable) Observable {
@@ -142,204 +390,1222 @@ func FilterWithObserver(predicate func(interface{}) bool, target Observable) Obs
 	return ob
 }
 
-// IndefiniteObserver defines a structure which implements the concrete structure
-// of the Observable interface. It provides a baseline interface which others
-// can inherit from.
-type IndefiniteObserver struct {
-	behaviour  Behaviour
-	subs       []*Subscription
-	finalizers []func()
-	doAsync    bool
-}
+// CombineLatestWithObserver returns a Observable which calls the combiner's
+// behaviour with a []interface{} holding the latest value from each source,
+// ordered as the sources where provided, whenever any source emits once every
+// source has emitted at least once. Errors from any source are passed on as
+// they are received. Done is signaled with true once all sources have
+// signaled Done.
+func CombineLatestWithObserver(combiner Behaviour, sources ...Observable) Observable {
+	ob := NewObservable(combiner, false)
 
-// Subscribe connects the giving Observer with the provide observer and returns a
-// subscription object which disconnects the giving event stream.
-func (in *IndefiniteObserver) Subscribe(b Observable, finalizers ...func()) *Subscription {
-	var sub Subscription
-	sub.observer = b
-	sub.handlers = finalizers
+	var ml sync.Mutex
+	var ended int
 
-	in.subs = append(in.subs, &sub)
+	latest := make([]interface{}, len(sources))
+	received := make([]bool, len(sources))
 
-	return &sub
-}
+	var subs []*Subscription
 
-// Subscription defines the structure which holds the connection between two
-// observers.
-type Subscription struct {
-	observer Observable
-	handlers []func()
-}
+	for index, source := range sources {
+		index := index
 
-// End defines a function to disconnect the observer from a giving subscription.
-func (sub *Subscription) End() {
-	sub.observer = nil
+		sub := source.Subscribe(NewObservable(Behaviour{
+			Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+				if err != nil {
+					ob.Next(ctx, err)
+					return nil, err
+				}
 
-	// Run finalizers for subscription.
-	for _, fl := range sub.handlers {
-		fl()
+				ml.Lock()
+				latest[index] = item
+				received[index] = true
+
+				for _, ok := range received {
+					if !ok {
+						ml.Unlock()
+						return item, nil
+					}
+				}
+
+				values := make([]interface{}, len(latest))
+				copy(values, latest)
+				ml.Unlock()
+
+				ob.Next(ctx, values)
+				return item, nil
+			},
+			Done: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+				ml.Lock()
+				ended++
+				all := ended == len(sources)
+				ml.Unlock()
+
+				if all {
+					ob.Done(ctx, true)
+				}
+
+				return item, err
+			},
+			Error: forwardError(ob),
+		}, false))
+
+		subs = append(subs, sub)
 	}
+
+	ob.AddFinalizer(func() {
+		for _, sub := range subs {
+			sub.End()
+		}
+	})
+
+	return ob
 }
 
-// End discloses all subscription to the observer, calling their appropriate
-// finalizers.
-func (in *IndefiniteObserver) End() {
-	defer func() {
-		in.finalizers = nil
-		in.subs = nil
-	}()
+// ZipWithObserver returns a Observable which pairs the nth emission of the
+// first source with the nth emission of the second source, calling the
+// zipper's behaviour with a []interface{} holding both values. Emissions of
+// either source are buffered until the other source provides it's pair.
+// Errors from either source are passed on as they are received. Done is
+// signaled with true once both sources have signaled Done.
+func ZipWithObserver(a, b Observable, zipper Behaviour) Observable {
+	ob := NewObservable(zipper, false)
 
-	for _, fl := range in.finalizers {
-		fl()
+	var ml sync.Mutex
+	var ended int
+
+	queues := make([][]interface{}, 2)
+
+	var subs []*Subscription
+
+	for index, source := range []Observable{a, b} {
+		index := index
+		other := 1 - index
+
+		sub := source.Subscribe(NewObservable(Behaviour{
+			Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+				if err != nil {
+					ob.Next(ctx, err)
+					return nil, err
+				}
+
+				ml.Lock()
+				if len(queues[other]) == 0 {
+					queues[index] = append(queues[index], item)
+					ml.Unlock()
+					return item, nil
+				}
+
+				pair := make([]interface{}, 2)
+				pair[index] = item
+				pair[other] = queues[other][0]
+				queues[other] = queues[other][1:]
+				ml.Unlock()
+
+				ob.Next(ctx, pair)
+				return item, nil
+			},
+			Done: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+				ml.Lock()
+				ended++
+				all := ended == 2
+				ml.Unlock()
+
+				if all {
+					ob.Done(ctx, true)
+				}
+
+				return item, err
+			},
+			Error: forwardError(ob),
+		}, false))
+
+		subs = append(subs, sub)
 	}
 
-	for _, sub := range in.subs {
-		if sub.observer == nil {
-			continue
+	ob.AddFinalizer(func() {
+		for _, sub := range subs {
+			sub.End()
 		}
+	})
 
-		sub.End()
+	return ob
+}
+
+// ScanWithObserver returns a Observable which emits the running accumulation
+// of the values the target observer provides, starting from the seed. For
+// every value, the accumulator's behaviour is called with a []interface{}
+// holding the current accumulated value and the received value, and it's
+// result becomes the new accumulated value which gets emitted. Errors, either
+// from the target or returned by the accumulator, are passed on and leave the
+// accumulated value untouched.
+func ScanWithObserver(seed interface{}, acc Behaviour, target Observable) Observable {
+	if acc.Next == nil {
+		panic("No next Handler provided")
 	}
+
+	var ml sync.Mutex
+	state := seed
+
+	ob := NewObservable(Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				return nil, err
+			}
+
+			ml.Lock()
+			defer ml.Unlock()
+
+			res, err := acc.Next(ctx, nil, []interface{}{state, item})
+			if err != nil {
+				return nil, err
+			}
+
+			state = res
+			return res, nil
+		},
+		Done:        acc.Done,
+		Error:       acc.Error,
+		End:         acc.End,
+		ErrorPolicy: acc.ErrorPolicy,
+	}, false)
+
+	target.Subscribe(ob, ob.End)
+	return ob
 }
 
-// AddFinalizer adds a giving finalizer which will be runned when the giving
-// observer has ended.
-func (in *IndefiniteObserver) AddFinalizer(val func()) {
-	in.finalizers = append(in.finalizers, val)
+// TakeWithObserver returns a Observable which emits only the first n values
+// the target observer provides. Once the nth value is emitted, Done is
+// signaled with true and the returned Observable is ended, disconnecting it
+// from the target and running it's finalizers.
+func TakeWithObserver(n int, target Observable) Observable {
+	var count int32
+
+	ob, finish := takeWithObserver(target, func(item interface{}) (bool, bool) {
+		taken := int(atomic.AddInt32(&count, 1))
+		return taken <= n, taken >= n
+	})
+
+	if n <= 0 {
+		finish(context.New())
+	}
+
+	return ob
 }
 
-// NextVal receives the value to be passed to the Observer.Next function and
-// creates a new context for call.
-func (in *IndefiniteObserver) NextVal(val interface{}) {
-	in.Next(context.New(), val)
+// TakeUntilWithObserver returns a Observable which emits the values the target
+// observer provides until the notifier emits it's first value, after which
+// Done is signaled with true and the returned Observable is ended.
+func TakeUntilWithObserver(notifier Observable, target Observable) Observable {
+	ob, finish := takeWithObserver(target, func(item interface{}) (bool, bool) {
+		return true, false
+	})
+
+	sub := notifier.Subscribe(NewObservable(Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			finish(ctx)
+			return item, err
+		},
+	}, false))
+
+	ob.AddFinalizer(sub.End)
+
+	return ob
 }
 
-// Next receives the next input for the observer to run it's internal
-// calls against and which then passes to all it's next subscribers.
-func (in *IndefiniteObserver) Next(ctx context.Context, val interface{}) {
-	if in.doAsync {
-		go func() {
-			var err error
-			var res interface{}
+// TakeWhileWithObserver returns a Observable which emits the values the target
+// observer provides for as long as the predicate returns true. The first value
+// failing the predicate is not emitted, instead Done is signaled with true and
+// the returned Observable is ended.
+func TakeWhileWithObserver(predicate func(interface{}) bool, target Observable) Observable {
+	ob, _ := takeWithObserver(target, func(item interface{}) (bool, bool) {
+		ok := predicate(item)
+		return ok, !ok
+	})
 
-			if errx, ok := val.(error); ok {
-				res, err = in.behaviour.Next(ctx, errx, nil)
-			} else {
-				res, err = in.behaviour.Next(ctx, nil, val)
+	return ob
+}
+
+// takeWithObserver returns a Observable which emits the values of the target
+// for which allow reports true, until allow reports the stream should stop or
+// the returned finish function is called. Errors from the target are passed on
+// as they are received.
+func takeWithObserver(target Observable, allow func(interface{}) (emit bool, stop bool)) (Observable, func(context.Context)) {
+	ob := NewObservable(IdentityBehaviour(), false)
+
+	var stopped int32
+
+	finish := func(ctx context.Context) {
+		if !atomic.CompareAndSwapInt32(&stopped, 0, 1) {
+			return
+		}
+
+		ob.Done(ctx, true)
+		ob.End()
+	}
+
+	sub := target.Subscribe(NewObservable(Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if atomic.LoadInt32(&stopped) == 1 {
+				return nil, nil
 			}
 
-			for _, sub := range in.subs {
-				if sub.observer == nil {
-					continue
-				}
+			if err != nil {
+				ob.Next(ctx, err)
+				return nil, err
+			}
+
+			emit, stop := allow(item)
+			if emit {
+				ob.Next(ctx, item)
+			}
+
+			if stop {
+				finish(ctx)
+			}
 
+			return item, nil
+		},
+		Done: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if atomic.LoadInt32(&stopped) == 0 {
 				if err != nil {
-					sub.observer.Next(ctx, err)
-					continue
+					ob.Done(ctx, err)
+				} else {
+					ob.Done(ctx, item)
 				}
-
-				sub.observer.Next(ctx, res)
 			}
-		}()
-		return
-	}
 
-	var err error
-	var res interface{}
+			return item, err
+		},
+		Error: forwardError(ob),
+	}, false))
 
-	if errx, ok := val.(error); ok {
-		res, err = in.behaviour.Next(ctx, errx, nil)
-	} else {
-		res, err = in.behaviour.Next(ctx, nil, val)
+	ob.AddFinalizer(sub.End)
+
+	// The target may have already triggered the stop while subscribing, in
+	// which case the finalizer above was added after the observer ended.
+	if atomic.LoadInt32(&stopped) == 1 {
+		sub.End()
 	}
 
-	for _, sub := range in.subs {
-		if sub.observer == nil {
-			continue
+	return ob, finish
+}
+
+// RetryWithObserver returns a Observable which passes on the values of the
+// target, resubscribing to it whenever it errors, up to max times after the
+// first subscription, each attempt waiting for the duration the backoff
+// returns for it, which may be nil for no wait. This is generally useful with
+// cold observables where every subscription reruns the producer. Once the
+// attempts are exhausted, the error is passed on. It uses the SystemClock.
+func RetryWithObserver(target Observable, max int, backoff func(attempt int) time.Duration) Observable {
+	return RetryWithClock(target, max, backoff, SystemClock)
+}
+
+// RetryWithClock works like RetryWithObserver, waiting for the backoff of
+// every attempt on the provided Clock.
+func RetryWithClock(target Observable, max int, backoff func(attempt int) time.Duration, clock Clock) Observable {
+	return RetryWhenWithClock(target, func(attempt int, err error) (time.Duration, bool) {
+		if attempt > max {
+			return 0, false
 		}
 
-		if err != nil {
-			sub.observer.Next(ctx, err)
-			continue
+		if backoff == nil {
+			return 0, true
 		}
 
-		sub.observer.Next(ctx, res)
-	}
+		return backoff(attempt), true
+	}, clock)
 }
 
-// DoneVal receives the value to be passed to the Observer.Done function and
-// creates a new context for call.
-func (in *IndefiniteObserver) DoneVal(val interface{}) {
-	in.Done(context.New(), val)
+// RetryWhenWithObserver returns a Observable which passes on the values of the
+// target, calling the when function with the attempt number, starting at 1,
+// and the error whenever the target errors. If when returns true, the target
+// is resubscribed to after the returned duration, else the error is passed
+// on. Errors are both those received through Error and those passed to Next.
+// It uses the SystemClock.
+func RetryWhenWithObserver(target Observable, when func(attempt int, err error) (time.Duration, bool)) Observable {
+	return RetryWhenWithClock(target, when, SystemClock)
 }
 
-// Done receives the done input for the observer to run it's internal
-// calls against and which then passes to all it's next subscribers.
-func (in *IndefiniteObserver) Done(ctx context.Context, val interface{}) {
-	if in.doAsync {
-		go func() {
-			var err error
-			var res interface{}
+// RetryWhenWithClock works like RetryWhenWithObserver, waiting for the
+// duration returned by when on the provided Clock.
+func RetryWhenWithClock(target Observable, when func(attempt int, err error) (time.Duration, bool), clock Clock) Observable {
+	ob := NewObservable(IdentityBehaviour(), false)
+
+	var ml sync.Mutex
+	var ended bool
+	var attempt, current int
+	var sub *Subscription
+	var timer Timer
+
+	var subscribe func()
+
+	// retry decides what happens with an error received from the subscription
+	// of the giving generation, ignoring errors from stale subscriptions.
+	retry := func(ctx context.Context, generation int, err error) {
+		ml.Lock()
+		if ended || generation != current {
+			ml.Unlock()
+			return
+		}
 
-			if errx, ok := val.(error); ok {
-				res, err = in.behaviour.Done(ctx, errx, nil)
-			} else {
-				res, err = in.behaviour.Done(ctx, nil, val)
-			}
+		attempt++
+		current++
+		last := sub
+		sub = nil
+
+		delay, ok := when(attempt, err)
+		if ok {
+			timer = clock.AfterFunc(delay, subscribe)
+		}
+		ml.Unlock()
+
+		if last != nil {
+			last.End()
+		}
+
+		if !ok {
+			ob.Error(ctx, err)
+		}
+	}
+
+	subscribe = func() {
+		ml.Lock()
+		if ended {
+			ml.Unlock()
+			return
+		}
 
-			for _, sub := range in.subs {
-				if sub.observer == nil {
-					continue
+		generation := current
+		ml.Unlock()
+
+		gate := NewObservable(Behaviour{
+			Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+				if err != nil {
+					retry(ctx, generation, err)
+					return nil, err
 				}
 
+				ob.Next(ctx, item)
+				return item, nil
+			},
+			Done: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
 				if err != nil {
-					sub.observer.Done(ctx, err)
-					continue
+					ob.Done(ctx, err)
+				} else {
+					ob.Done(ctx, item)
 				}
 
-				sub.observer.Done(ctx, res)
-			}
+				return item, err
+			},
+			Error: func(ctx context.Context, err error, _ interface{}) (interface{}, error) {
+				retry(ctx, generation, err)
+				return nil, err
+			},
+		}, false)
+
+		next := target.Subscribe(gate)
+
+		ml.Lock()
+		if ended || generation != current {
+			ml.Unlock()
+			next.End()
+			return
+		}
 
-		}()
-		return
+		sub = next
+		ml.Unlock()
 	}
 
-	var err error
-	var res interface{}
-
-	if errx, ok := val.(error); ok {
-		res, err = in.behaviour.Done(ctx, errx, nil)
-	} else {
-		res, err = in.behaviour.Done(ctx, nil, val)
-	}
+	ob.AddFinalizer(func() {
+		ml.Lock()
+		ended = true
+		last := sub
+		sub = nil
 
-	for _, sub := range in.subs {
-		if sub.observer == nil {
-			continue
+		if timer != nil {
+			timer.Stop()
 		}
+		ml.Unlock()
 
-		if err != nil {
-			sub.observer.Done(ctx, err)
-			continue
+		if last != nil {
+			last.End()
 		}
+	})
 
-		sub.observer.Done(ctx, res)
-	}
+	subscribe()
+
+	return ob
 }
 
-// Async returns a new observer which runs its behaviour in a goroutine to provide
+// OnErrorResumeNextWithObserver returns a Observable which passes on the values
+// of the target until it errors, after which it disconnects from the target
+// and passes on the values of the fallback instead.
+func OnErrorResumeNextWithObserver(target Observable, fallback Observable) Observable {
+	ob := NewObservable(IdentityBehaviour(), false)
+
+	var ml sync.Mutex
+	var switched bool
+	var subs []*Subscription
+
+	resume := func(ctx context.Context, err error) {
+		ml.Lock()
+		if switched {
+			ml.Unlock()
+			return
+		}
+
+		switched = true
+		previous := subs
+		subs = nil
+		ml.Unlock()
+
+		for _, sub := range previous {
+			sub.End()
+		}
+
+		sub := fallback.Subscribe(ob)
+
+		ml.Lock()
+		subs = append(subs, sub)
+		ml.Unlock()
+	}
+
+	sub := target.Subscribe(NewObservable(Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				resume(ctx, err)
+				return nil, err
+			}
+
+			ob.Next(ctx, item)
+			return item, nil
+		},
+		Done: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				ob.Done(ctx, err)
+			} else {
+				ob.Done(ctx, item)
+			}
+
+			return item, err
+		},
+		Error: func(ctx context.Context, err error, _ interface{}) (interface{}, error) {
+			resume(ctx, err)
+			return nil, err
+		},
+	}, false))
+
+	ml.Lock()
+	if switched {
+		ml.Unlock()
+		sub.End()
+	} else {
+		subs = append(subs, sub)
+		ml.Unlock()
+	}
+
+	ob.AddFinalizer(func() {
+		ml.Lock()
+		previous := subs
+		subs = nil
+		ml.Unlock()
+
+		for _, sub := range previous {
+			sub.End()
+		}
+	})
+
+	return ob
+}
+
+// RetryHandler returns a Handler which reruns the giving Handler whenever it
+// returns an error, up to max times after the first call, each attempt
+// waiting for the duration the backoff returns for it, which may be nil for no
+// wait. The last error is returned once the attempts are exhausted, while the
+// context's error is returned if it is cancelled, including during a wait.
+func RetryHandler(h Handler, max int, backoff func(attempt int) time.Duration) Handler {
+	return func(ctx context.Context, err error, data interface{}) (interface{}, error) {
+		res, rerr := h(ctx, err, data)
+
+		for attempt := 1; rerr != nil && attempt <= max; attempt++ {
+			if cerr := ContextErr(ctx); cerr != nil {
+				return nil, cerr
+			}
+
+			if backoff != nil {
+				timer := time.NewTimer(backoff(attempt))
+
+				select {
+				case <-StdContext(ctx).Done():
+					timer.Stop()
+					return nil, ContextErr(ctx)
+				case <-timer.C:
+				}
+			}
+
+			res, rerr = h(ctx, err, data)
+		}
+
+		return res, rerr
+	}
+}
+
+// ExponentialBackoff returns a backoff function for the retry operators which
+// doubles the base duration on every attempt, never exceeding max.
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+
+		if delay > max {
+			return max
+		}
+
+		return delay
+	}
+}
+
+// forwardError returns a Handler which passes every error it receives to the
+// Error method of the giving observer.
+func forwardError(ob Observable) Handler {
+	return func(ctx context.Context, err error, _ interface{}) (interface{}, error) {
+		ob.Error(ctx, err)
+		return nil, err
+	}
+}
+
+// PublishWithObserver returns a ConnectableObserver which multicasts the values
+// of the target to all it's subscribers through a single subscription to the
+// target, which is only made once Connect is called.
+func PublishWithObserver(target Observable) *ConnectableObserver {
+	return &ConnectableObserver{
+		IndefiniteObserver: &IndefiniteObserver{
+			behaviour: IdentityBehaviour(),
+		},
+		target: target,
+	}
+}
+
+// ShareWithObserver returns a Observable which multicasts the values of the
+// target to all it's subscribers through a single subscription to the target.
+// The target is subscribed to when the first subscriber arrives and the
+// subscription is ended once the last subscriber leaves. Once the target
+// completes, the subscribers receive it's Done and later subscribers
+// subscribe to the target afresh.
+func ShareWithObserver(target Observable) Observable {
+	return PublishWithObserver(target).RefCount()
+}
+
+// ConnectableObserver defines a Observable which shares a single subscription
+// to it's target between all it's subscribers, where the subscription to the
+// target is controlled through Connect and Disconnect.
+type ConnectableObserver struct {
+	*IndefiniteObserver
+	target Observable
+	cl     sync.Mutex
+	conn   *Subscription
+}
+
+// Connect subscribes the observer to it's target if not already connected,
+// returning the subscription to the target.
+func (c *ConnectableObserver) Connect() *Subscription {
+	c.cl.Lock()
+	defer c.cl.Unlock()
+
+	if c.conn == nil {
+		c.conn = c.target.Subscribe(c.IndefiniteObserver)
+	}
+
+	return c.conn
+}
+
+// Disconnect ends the subscription to the target if connected. Subscribers
+// of the observer are left intact.
+func (c *ConnectableObserver) Disconnect() {
+	c.cl.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.cl.Unlock()
+
+	if conn != nil {
+		conn.End()
+	}
+}
+
+// Connected returns true/false if the observer is subscribed to it's target.
+func (c *ConnectableObserver) Connected() bool {
+	c.cl.Lock()
+	defer c.cl.Unlock()
+	return c.conn != nil
+}
+
+// End ends all subscriptions, runs the observer's finalizers and then
+// disconnects from the target.
+func (c *ConnectableObserver) End() {
+	c.IndefiniteObserver.End()
+	c.Disconnect()
+}
+
+// RefCount returns a Observable which connects the ConnectableObserver when it
+// gets it's first subscriber and disconnects it when the last subscriber ends
+// it's subscription. Once the target completes, the subscribers receive it's
+// Done and are ended, after which the next subscriber connects again.
+func (c *ConnectableObserver) RefCount() Observable {
+	return &refCountObserver{
+		ConnectableObserver: c,
+		subs:                make(map[*Subscription]bool),
+	}
+}
+
+// refCountObserver defines a Observable which tracks the subscriptions of a
+// ConnectableObserver, connecting and disconnecting it as required. Every
+// connection has it's own generation, so a connection which is no longer
+// wanted, by the time the target's subscription is made or completes, is
+// ignored.
+type refCountObserver struct {
+	*ConnectableObserver
+	rl         sync.Mutex
+	subs       map[*Subscription]bool
+	generation int
+}
+
+// Subscribe connects the giving Observer, connecting to the target if it is
+// the first subscriber.
+func (r *refCountObserver) Subscribe(b Observable, finalizers ...func()) *Subscription {
+	sub := r.IndefiniteObserver.Subscribe(b, finalizers...)
+
+	// Route the subscription's End through the refCountObserver so it gets
+	// to count it.
+	sub.ml.Lock()
+	sub.source = r
+	sub.ml.Unlock()
+
+	r.rl.Lock()
+	r.subs[sub] = true
+	first := len(r.subs) == 1
+	if first {
+		r.generation++
+	}
+	generation := r.generation
+	r.rl.Unlock()
+
+	if first {
+		r.connect(generation)
+	}
+
+	return sub
+}
+
+// connect subscribes to the target for the giving generation. The target is
+// subscribed to without holding the lock, as synchronous targets deliver,
+// and may complete, within Subscribe, so the connection is only kept if it's
+// generation is still the current one afterwards.
+func (r *refCountObserver) connect(generation int) {
+	conn := r.target.Subscribe(NewObservable(Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				r.IndefiniteObserver.Next(ctx, err)
+				return nil, err
+			}
+
+			r.IndefiniteObserver.Next(ctx, item)
+			return item, nil
+		},
+		Done: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				r.complete(ctx, generation, err)
+			} else {
+				r.complete(ctx, generation, item)
+			}
+
+			return item, err
+		},
+		Error: forwardError(r.IndefiniteObserver),
+	}, false))
+
+	r.rl.Lock()
+	current := generation == r.generation
+	if current {
+		r.cl.Lock()
+		r.conn = conn
+		r.cl.Unlock()
+	}
+	r.rl.Unlock()
+
+	if !current {
+		conn.End()
+	}
+}
+
+// complete passes the target's Done to the subscribers of the giving
+// generation and ends their subscriptions, leaving the observer free to
+// connect again for later subscribers.
+func (r *refCountObserver) complete(ctx context.Context, generation int, val interface{}) {
+	r.rl.Lock()
+	if generation != r.generation {
+		r.rl.Unlock()
+		return
+	}
+
+	r.generation++
+	subs := r.subs
+	r.subs = make(map[*Subscription]bool)
+
+	r.cl.Lock()
+	r.conn = nil
+	r.cl.Unlock()
+	r.rl.Unlock()
+
+	for _, sub := range r.subscriptions() {
+		if !subs[sub] {
+			continue
+		}
+
+		if observer := sub.Observer(); observer != nil {
+			observer.Done(ctx, val)
+		}
+
+		sub.EndWith(ErrCompleted)
+	}
+}
+
+// Unsubscribe removes the giving subscription, disconnecting from the target
+// if it was the last subscriber.
+func (r *refCountObserver) Unsubscribe(sub *Subscription) {
+	var conn *Subscription
+
+	r.rl.Lock()
+	_, ok := r.subs[sub]
+	delete(r.subs, sub)
+
+	if ok && len(r.subs) == 0 {
+		r.generation++
+
+		r.cl.Lock()
+		conn, r.conn = r.conn, nil
+		r.cl.Unlock()
+	}
+	r.rl.Unlock()
+
+	r.IndefiniteObserver.Unsubscribe(sub)
+
+	if conn != nil {
+		conn.End()
+	}
+}
+
+// End disconnects from the target, ending all subscriptions and running the
+// observer's finalizers.
+func (r *refCountObserver) End() {
+	r.rl.Lock()
+	r.generation++
+	r.rl.Unlock()
+
+	r.ConnectableObserver.End()
+
+	r.rl.Lock()
+	r.subs = make(map[*Subscription]bool)
+	r.rl.Unlock()
+}
+
+// IndefiniteObserver defines a structure which implements the concrete structure
+// of the Observable interface. It provides a baseline interface which others
+// can inherit from. It is safe for concurrent use, the subscription and
+// finalizer lists are guarded by a mutex and replaced on every change (copy on
+// write), which allows Next and Done to deliver to a stable snapshot without
+// holding any lock while subscribers run.
+type IndefiniteObserver struct {
+	behaviour   Behaviour
+	scheduler   Scheduler
+	completed   int32
+	ml          sync.RWMutex
+	subs        []*Subscription
+	finalizers  []func()
+	finished    bool
+	completions []func()
+}
+
+// Subscribe connects the giving Observer with the provide observer and returns a
+// subscription object which disconnects the giving event stream.
+func (in *IndefiniteObserver) Subscribe(b Observable, finalizers ...func()) *Subscription {
+	var sub Subscription
+	sub.source = in
+	sub.observer = b
+	sub.handlers = finalizers
+
+	in.ml.Lock()
+	{
+		subs := make([]*Subscription, len(in.subs), len(in.subs)+1)
+		copy(subs, in.subs)
+		in.subs = append(subs, &sub)
+	}
+	in.ml.Unlock()
+
+	return &sub
+}
+
+// Unsubscribe removes the giving subscription from the observer's subscription
+// list, ending the subscription if it has not yet been ended. Other
+// subscriptions are left untouched.
+func (in *IndefiniteObserver) Unsubscribe(sub *Subscription) {
+	if sub == nil {
+		return
+	}
+
+	in.ml.Lock()
+	{
+		// Build a new slice so any iteration over the previous list remains intact.
+		subs := make([]*Subscription, 0, len(in.subs))
+		for _, item := range in.subs {
+			if item == sub {
+				continue
+			}
+
+			subs = append(subs, item)
+		}
+
+		in.subs = subs
+	}
+	in.ml.Unlock()
+
+	if sub.Observer() != nil {
+		sub.End()
+	}
+}
+
+// subscriptions returns the current snapshot of the observer's subscriptions.
+func (in *IndefiniteObserver) subscriptions() []*Subscription {
+	in.ml.RLock()
+	defer in.ml.RUnlock()
+	return in.subs
+}
+
+// Subscription defines the structure which holds the connection between two
+// observers.
+type Subscription struct {
+	ml       sync.Mutex
+	source   Observable
+	observer Observable
+	handlers []func()
+	onEnd    []func(error)
+	ended    bool
+	reason   error
+}
+
+// Observer returns the Observable receiving events through this subscription,
+// or nil if the subscription has ended.
+func (sub *Subscription) Observer() Observable {
+	sub.ml.Lock()
+	defer sub.ml.Unlock()
+	return sub.observer
+}
+
+// End defines a function to disconnect the observer from a giving subscription.
+// It removes the subscription from the observer it was created from, ensuring
+// the source no longer holds on to it. Calling End more than once does nothing.
+func (sub *Subscription) End() {
+	sub.EndWith(nil)
+}
+
+// EndWith ends the subscription as End does, passing the reason to the
+// callbacks added through OnEnd. The subscription's finalizers are runned in
+// the order they were provided, before the OnEnd callbacks.
+func (sub *Subscription) EndWith(reason error) {
+	sub.ml.Lock()
+	if sub.observer == nil {
+		sub.ml.Unlock()
+		return
+	}
+
+	source := sub.source
+
+	sub.observer = nil
+	sub.source = nil
+	sub.ended = true
+	sub.reason = reason
+	sub.ml.Unlock()
+
+	if source != nil {
+		source.Unsubscribe(sub)
+	}
+
+	// Run finalizers for subscription.
+	for _, fl := range sub.handlers {
+		fl()
+	}
+
+	sub.ml.Lock()
+	onEnd := sub.onEnd
+	sub.onEnd = nil
+	sub.ml.Unlock()
+
+	for _, fn := range onEnd {
+		fn(reason)
+	}
+}
+
+// OnEnd adds a callback which will be called with the reason the subscription
+// ended, which is nil when ended through End, ErrCompleted when the source
+// completed or the error which terminated the source. If the subscription has
+// already ended, the callback is called immediately.
+func (sub *Subscription) OnEnd(fn func(reason error)) {
+	sub.ml.Lock()
+	if !sub.ended {
+		sub.onEnd = append(sub.onEnd, fn)
+		sub.ml.Unlock()
+		return
+	}
+
+	reason := sub.reason
+	sub.ml.Unlock()
+
+	fn(reason)
+}
+
+// Reason returns the reason the subscription ended with, if it has ended.
+func (sub *Subscription) Reason() error {
+	sub.ml.Lock()
+	defer sub.ml.Unlock()
+	return sub.reason
+}
+
+// End discloses all subscription to the observer, calling their appropriate
+// finalizers. Subscriptions are ended first, so the subscribers release their
+// resources before the observer's own finalizers are runned, in the order they
+// were added.
+func (in *IndefiniteObserver) End() {
+	in.endWith(nil)
+}
+
+// endWith ends the observer, passing the reason to it's subscriptions.
+func (in *IndefiniteObserver) endWith(reason error) {
+	in.ml.Lock()
+	finalizers := in.finalizers
+	subs := in.subs
+	in.finalizers = nil
+	in.subs = nil
+	in.ml.Unlock()
+
+	for _, sub := range subs {
+		sub.EndWith(reason)
+	}
+
+	for _, fl := range finalizers {
+		fl()
+	}
+}
+
+// AddFinalizer adds a giving finalizer which will be runned when the giving
+// observer has ended.
+func (in *IndefiniteObserver) AddFinalizer(val func()) {
+	in.ml.Lock()
+	in.finalizers = append(in.finalizers, val)
+	in.ml.Unlock()
+}
+
+// NextVal receives the value to be passed to the Observer.Next function and
+// creates a new context for call.
+func (in *IndefiniteObserver) NextVal(val interface{}) {
+	in.Next(context.New(), val)
+}
+
+// Next receives the next input for the observer to run it's internal
+// calls against and which then passes to all it's next subscribers.
+func (in *IndefiniteObserver) Next(ctx context.Context, val interface{}) {
+	if in.Completed() {
+		return
+	}
+
+	if in.immediate() {
+		in.next(ctx, val)
+		return
+	}
+
+	in.scheduler.Schedule(func() {
+		in.next(ctx, val)
+	})
+}
+
+func (in *IndefiniteObserver) next(ctx context.Context, val interface{}) {
+	// Skip the behaviour and subscribers once the context is cancelled.
+	if ContextErr(ctx) != nil {
+		return
+	}
+
+	res := process(in.behaviour.Next, ctx, val)
+
+	for _, sub := range in.subscriptions() {
+		if observer := sub.Observer(); observer != nil {
+			observer.Next(ctx, res)
+		}
+	}
+}
+
+// DoneVal receives the value to be passed to the Observer.Done function and
+// creates a new context for call.
+func (in *IndefiniteObserver) DoneVal(val interface{}) {
+	in.Done(context.New(), val)
+}
+
+// Done receives the done input for the observer to run it's internal
+// calls against and which then passes to all it's next subscribers. Done marks
+// the observer as completed, after which all calls to Next, Error and Done are
+// ignored. Once the subscribers have received the done value, the completion
+// callbacks added through OnComplete are called and the observer is ended.
+func (in *IndefiniteObserver) Done(ctx context.Context, val interface{}) {
+	if !atomic.CompareAndSwapInt32(&in.completed, 0, 1) {
+		return
+	}
+
+	if in.immediate() {
+		in.done(ctx, val)
+		return
+	}
+
+	in.scheduler.Schedule(func() {
+		in.done(ctx, val)
+	})
+}
+
+func (in *IndefiniteObserver) done(ctx context.Context, val interface{}) {
+	// A cancelled context still completes the observer, only the behaviour and
+	// subscribers are skipped.
+	if ContextErr(ctx) != nil {
+		in.complete()
+		return
+	}
+
+	res := process(in.behaviour.Done, ctx, val)
+
+	for _, sub := range in.subscriptions() {
+		if observer := sub.Observer(); observer != nil {
+			observer.Done(ctx, res)
+		}
+	}
+
+	in.complete()
+}
+
+// complete runs the completion callbacks and ends the observer.
+func (in *IndefiniteObserver) complete() {
+	in.ml.Lock()
+	completions := in.completions
+	in.completions = nil
+	in.finished = true
+	in.ml.Unlock()
+
+	for _, fn := range completions {
+		fn()
+	}
+
+	in.endWith(ErrCompleted)
+}
+
+// Completed returns true/false if the observer has received it's Done call.
+func (in *IndefiniteObserver) Completed() bool {
+	return atomic.LoadInt32(&in.completed) == 1
+}
+
+// OnComplete adds a callback which will be called once the observer has
+// completed through Done, after all subscribers received the done value. If
+// the observer has already completed, the callback is called immediately.
+func (in *IndefiniteObserver) OnComplete(fn func()) {
+	in.ml.Lock()
+	if !in.finished {
+		in.completions = append(in.completions, fn)
+		in.ml.Unlock()
+		return
+	}
+	in.ml.Unlock()
+
+	fn()
+}
+
+// Error receives an error for the observer to run it's error behaviour
+// against. If the behaviour returns an error, or the observer has no error
+// behaviour, then the error is passed to the Error method of all it's
+// subscribers, else the error is treated as handled and any non-nil value
+// returned is passed on through Next. Once an error is passed on, an observer
+// with the TerminateOnError policy is ended.
+func (in *IndefiniteObserver) Error(ctx context.Context, err error) {
+	if err == nil || in.Completed() {
+		return
+	}
+
+	if in.immediate() {
+		in.error(ctx, err)
+		return
+	}
+
+	in.scheduler.Schedule(func() {
+		in.error(ctx, err)
+	})
+}
+
+func (in *IndefiniteObserver) error(ctx context.Context, err error) {
+	if ContextErr(ctx) != nil {
+		return
+	}
+
+	if in.behaviour.Error != nil {
+		res, herr := in.behaviour.Error(ctx, err, nil)
+		if herr == nil {
+			if res != nil {
+				in.next(ctx, res)
+			}
+
+			return
+		}
+
+		err = herr
+	}
+
+	for _, sub := range in.subscriptions() {
+		if observer := sub.Observer(); observer != nil {
+			observer.Error(ctx, err)
+		}
+	}
+
+	if in.behaviour.ErrorPolicy == TerminateOnError {
+		in.endWith(err)
+	}
+}
+
+// process runs the giving value through the handler, passing errors in as the
+// error argument, and returns either the handler's result or it's error.
+func process(h Handler, ctx context.Context, val interface{}) interface{} {
+	var err error
+	var res interface{}
+
+	if errx, ok := val.(error); ok {
+		res, err = h(ctx, errx, nil)
+	} else {
+		res, err = h(ctx, nil, val)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return res
+}
+
+// Async returns a new observer which runs its behaviour in a goroutine to provide
 // asynchronouse processing. No copy is made, all subscriptions are left intact
 // with the core synchronouse version. Any effect which occurs with this version
 // occurs with the asynchronouse version. This is an intended effect.
 func (in *IndefiniteObserver) Async() Observable {
-	if in.doAsync {
+	if in.scheduler == GoroutineScheduler {
 		return in
 	}
 
 	return &IndefiniteObserver{
 		behaviour: in.behaviour,
-		subs:      in.subs[:len(in.subs)],
-		doAsync:   true,
+		subs:      in.subscriptions(),
+		scheduler: GoroutineScheduler,
 	}
 }
 
@@ -348,13 +1614,247 @@ func (in *IndefiniteObserver) Async() Observable {
 // with the first asynchronouse version. Any effect which occurs with this version
 // occurs with the non-asynchronouse version. This is an intended effect.
 func (in *IndefiniteObserver) Sync() Observable {
-	if !in.doAsync {
+	if in.immediate() {
 		return in
 	}
 
 	return &IndefiniteObserver{
 		behaviour: in.behaviour,
-		subs:      in.subs[:len(in.subs)],
-		doAsync:   false,
+		subs:      in.subscriptions(),
+		scheduler: ImmediateScheduler,
+	}
+}
+
+// immediate returns true/false if the observer runs it's behaviour within the
+// current goroutine.
+func (in *IndefiniteObserver) immediate() bool {
+	return in.scheduler == nil || in.scheduler == ImmediateScheduler
+}
+
+// ReplayObserver defines a Observable which keeps a history of the values
+// it emitted and replays them to new subscribers.
+type ReplayObserver struct {
+	*IndefiniteObserver
+	size    int
+	rl      sync.Mutex
+	history []interface{}
+}
+
+// Subscribe connects the giving Observer and replays the buffered history to
+// it before returning the subscription.
+func (r *ReplayObserver) Subscribe(b Observable, finalizers ...func()) *Subscription {
+	r.rl.Lock()
+	sub := r.IndefiniteObserver.Subscribe(b, finalizers...)
+	history := append([]interface{}(nil), r.history...)
+	r.rl.Unlock()
+
+	// The history is replayed without the lock held, so the observer may
+	// emit into the observable it subscribed to.
+	ctx := context.New()
+	for _, item := range history {
+		b.Next(ctx, item)
+	}
+
+	return sub
+}
+
+// NextVal receives the value to be passed to the Observer.Next function and
+// creates a new context for call.
+func (r *ReplayObserver) NextVal(val interface{}) {
+	r.Next(context.New(), val)
+}
+
+// Next runs the value through the observer's behaviour, records the result
+// into the history and then passes it to all subscribers.
+func (r *ReplayObserver) Next(ctx context.Context, val interface{}) {
+	if r.Completed() || ContextErr(ctx) != nil {
+		return
+	}
+
+	res := process(r.behaviour.Next, ctx, val)
+
+	r.rl.Lock()
+	r.history = append(r.history, res)
+	if r.size > 0 && len(r.history) > r.size {
+		r.history = r.history[len(r.history)-r.size:]
+	}
+
+	subs := r.subscriptions()
+	r.rl.Unlock()
+
+	for _, sub := range subs {
+		if observer := sub.Observer(); observer != nil {
+			observer.Next(ctx, res)
+		}
+	}
+}
+
+// History returns a copy of the values currently buffered for replay.
+func (r *ReplayObserver) History() []interface{} {
+	r.rl.Lock()
+	defer r.rl.Unlock()
+	return append([]interface{}(nil), r.history...)
+}
+
+// NewColdObservable returns a new Observable which runs the producer once for
+// every subscriber, giving each subscriber it's own independent sequence of
+// emissions. The producer is called within the Subscribe call, unless the
+// observable is turned asynchronouse through Async, in which case it runs
+// within a goroutine.
+func NewColdObservable(producer func(Observer)) Observable {
+	if producer == nil {
+		panic("No producer provided")
+	}
+
+	return &ColdObserver{producer: producer}
+}
+
+// ColdObserver defines a Observable which only emits values when subscribed
+// to, where each subscription gets a fresh run of it's producer.
+type ColdObserver struct {
+	producer    func(Observer)
+	scheduler   Scheduler
+	ml          sync.Mutex
+	subs        []*Subscription
+	finalizers  []func()
+	completions []func()
+}
+
+// Subscribe runs the producer for the giving observer, returning the
+// subscription which stops delivery of the producer's values when ended. The
+// subscription ends by itself once the producer completes.
+func (c *ColdObserver) Subscribe(b Observable, finalizers ...func()) *Subscription {
+	pipe := &IndefiniteObserver{behaviour: IdentityBehaviour()}
+	pipe.Subscribe(b)
+
+	var sub Subscription
+	sub.source = c
+	sub.observer = b
+	sub.handlers = append([]func(){pipe.End}, finalizers...)
+
+	c.ml.Lock()
+	for _, fn := range c.completions {
+		pipe.OnComplete(fn)
+	}
+	c.ml.Unlock()
+
+	// Completed producers end their subscription, so the observable no
+	// longer holds on to it.
+	pipe.OnComplete(func() {
+		sub.EndWith(ErrCompleted)
+	})
+
+	c.ml.Lock()
+	c.subs = append(c.subs, &sub)
+	c.ml.Unlock()
+
+	if c.scheduler == nil || c.scheduler == ImmediateScheduler {
+		c.producer(pipe)
+		return &sub
+	}
+
+	c.scheduler.Schedule(func() {
+		c.producer(pipe)
+	})
+
+	return &sub
+}
+
+// Unsubscribe removes the giving subscription, ending it if it has not yet
+// been ended.
+func (c *ColdObserver) Unsubscribe(sub *Subscription) {
+	if sub == nil {
+		return
+	}
+
+	c.ml.Lock()
+	{
+		subs := make([]*Subscription, 0, len(c.subs))
+		for _, item := range c.subs {
+			if item == sub {
+				continue
+			}
+
+			subs = append(subs, item)
+		}
+
+		c.subs = subs
+	}
+	c.ml.Unlock()
+
+	if sub.Observer() != nil {
+		sub.End()
+	}
+}
+
+// End ends all subscriptions and then runs the observer's finalizers.
+func (c *ColdObserver) End() {
+	c.ml.Lock()
+	finalizers := c.finalizers
+	subs := c.subs
+	c.finalizers = nil
+	c.subs = nil
+	c.ml.Unlock()
+
+	for _, sub := range subs {
+		sub.End()
+	}
+
+	for _, fl := range finalizers {
+		fl()
+	}
+}
+
+// AddFinalizer adds a giving finalizer which will be runned when the giving
+// observer has ended.
+func (c *ColdObserver) AddFinalizer(val func()) {
+	c.ml.Lock()
+	c.finalizers = append(c.finalizers, val)
+	c.ml.Unlock()
+}
+
+// NextVal does nothing, cold observables only emit what their producer
+// delivers.
+func (c *ColdObserver) NextVal(interface{}) {}
+
+// DoneVal does nothing, cold observables only emit what their producer
+// delivers.
+func (c *ColdObserver) DoneVal(interface{}) {}
+
+// Next does nothing, cold observables only emit what their producer delivers.
+func (c *ColdObserver) Next(context.Context, interface{}) {}
+
+// Done does nothing, cold observables only emit what their producer delivers.
+func (c *ColdObserver) Done(context.Context, interface{}) {}
+
+// Error does nothing, cold observables only emit what their producer delivers.
+func (c *ColdObserver) Error(context.Context, error) {}
+
+// OnComplete adds a callback which will be called whenever the producer of a
+// subscriber signals Done. It only applies to subscriptions made after it was
+// added.
+func (c *ColdObserver) OnComplete(fn func()) {
+	c.ml.Lock()
+	c.completions = append(c.completions, fn)
+	c.ml.Unlock()
+}
+
+// Async returns a new cold observable which runs it's producer within a
+// goroutine for every subscriber.
+func (c *ColdObserver) Async() Observable {
+	if c.scheduler == GoroutineScheduler {
+		return c
+	}
+
+	return &ColdObserver{producer: c.producer, scheduler: GoroutineScheduler}
+}
+
+// Sync returns a new cold observable which runs it's producer within the
+// Subscribe call for every subscriber.
+func (c *ColdObserver) Sync() Observable {
+	if c.scheduler == nil || c.scheduler == ImmediateScheduler {
+		return c
 	}
+
+	return &ColdObserver{producer: c.producer}
 }
@@ -0,0 +1,433 @@
+// Package observablestest provides a virtual time scheduler, a recording
+// observer and assertion helpers for testing fractals.Observable graphs,
+// including the time based operators, without relying on real time.
+package observablestest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+)
+
+// Epoch defines the time at which all VirtualSchedulers start.
+var Epoch = time.Unix(0, 0).UTC()
+
+// VirtualScheduler defines a fractals.Scheduler and fractals.Clock whose time
+// only moves forward when Advance is called. All scheduled work and timers are
+// runned within the goroutine calling Advance or Flush, in the order of their
+// due time and then in the order they where scheduled.
+type VirtualScheduler struct {
+	ml    sync.Mutex
+	now   time.Time
+	seq   int
+	tasks []*virtualTask
+}
+
+// NewVirtualScheduler returns a new VirtualScheduler starting at Epoch.
+func NewVirtualScheduler() *VirtualScheduler {
+	return &VirtualScheduler{now: Epoch}
+}
+
+// Now returns the current virtual time.
+func (v *VirtualScheduler) Now() time.Time {
+	v.ml.Lock()
+	defer v.ml.Unlock()
+	return v.now
+}
+
+// Elapsed returns the virtual time elapsed since Epoch.
+func (v *VirtualScheduler) Elapsed() time.Duration {
+	return v.Now().Sub(Epoch)
+}
+
+// Schedule queues the work to run at the current virtual time, the next time
+// Flush or Advance is called.
+func (v *VirtualScheduler) Schedule(work func()) {
+	v.add(0, work)
+}
+
+// AfterFunc queues the function to run once the virtual time has advanced by
+// the giving duration.
+func (v *VirtualScheduler) AfterFunc(d time.Duration, fn func()) fractals.Timer {
+	return v.add(d, fn)
+}
+
+// Flush runs all work due at the current virtual time.
+func (v *VirtualScheduler) Flush() {
+	v.Advance(0)
+}
+
+// Advance moves the virtual time forward by the giving duration, running all
+// work and timers which become due, including those they schedule in turn.
+func (v *VirtualScheduler) Advance(d time.Duration) {
+	v.ml.Lock()
+	target := v.now.Add(d)
+	v.ml.Unlock()
+
+	for {
+		v.ml.Lock()
+		if len(v.tasks) == 0 || v.tasks[0].at.After(target) {
+			v.now = target
+			v.ml.Unlock()
+			return
+		}
+
+		task := v.tasks[0]
+		v.tasks = v.tasks[1:]
+		v.now = task.at
+		v.ml.Unlock()
+
+		task.fn()
+	}
+}
+
+// Pending returns the number of work and timers waiting to be runned.
+func (v *VirtualScheduler) Pending() int {
+	v.ml.Lock()
+	defer v.ml.Unlock()
+	return len(v.tasks)
+}
+
+func (v *VirtualScheduler) add(d time.Duration, fn func()) *virtualTask {
+	v.ml.Lock()
+	defer v.ml.Unlock()
+
+	v.seq++
+
+	task := &virtualTask{
+		scheduler: v,
+		at:        v.now.Add(d),
+		seq:       v.seq,
+		fn:        fn,
+	}
+
+	v.tasks = append(v.tasks, task)
+	sort.Slice(v.tasks, func(i, j int) bool {
+		if v.tasks[i].at.Equal(v.tasks[j].at) {
+			return v.tasks[i].seq < v.tasks[j].seq
+		}
+
+		return v.tasks[i].at.Before(v.tasks[j].at)
+	})
+
+	return task
+}
+
+func (v *VirtualScheduler) remove(task *virtualTask) bool {
+	v.ml.Lock()
+	defer v.ml.Unlock()
+
+	for index, item := range v.tasks {
+		if item == task {
+			v.tasks = append(v.tasks[:index], v.tasks[index+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// virtualTask defines a function due at a giving virtual time.
+type virtualTask struct {
+	scheduler *VirtualScheduler
+	at        time.Time
+	seq       int
+	fn        func()
+}
+
+// Stop removes the task from it's scheduler, returning false if it has
+// already been runned or stopped.
+func (t *virtualTask) Stop() bool {
+	return t.scheduler.remove(t)
+}
+
+//==============================================================================
+
+// EventKind defines the kind of a recorded event.
+type EventKind int
+
+// Kinds of events recorded by a Recorder.
+const (
+	NextEvent EventKind = iota
+	ErrorEvent
+	DoneEvent
+)
+
+// Event defines a single value, error or completion received by a Recorder,
+// along with the time it was received at relative to the Recorder's creation.
+type Event struct {
+	At    time.Duration
+	Kind  EventKind
+	Value interface{}
+	Err   error
+}
+
+// String returns a readable version of the event.
+func (e Event) String() string {
+	switch e.Kind {
+	case ErrorEvent:
+		return fmt.Sprintf("%s: error(%s)", e.At, e.Err)
+	case DoneEvent:
+		return fmt.Sprintf("%s: done", e.At)
+	default:
+		return fmt.Sprintf("%s: next(%#v)", e.At, e.Value)
+	}
+}
+
+// Recorder defines a observer which records every value, error and completion
+// it receives from the Observable it subscribes to.
+type Recorder struct {
+	clock  fractals.Clock
+	start  time.Time
+	sub    *fractals.Subscription
+	ml     sync.Mutex
+	events []Event
+}
+
+// Record subscribes a new Recorder to the target, using the clock to timestamp
+// events. If clock is nil, fractals.SystemClock is used.
+func Record(target fractals.Observable, clock fractals.Clock) *Recorder {
+	if clock == nil {
+		clock = fractals.SystemClock
+	}
+
+	r := &Recorder{clock: clock, start: clock.Now()}
+
+	r.sub = target.Subscribe(fractals.NewObservable(fractals.Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				r.add(Event{Kind: ErrorEvent, Err: err})
+				return nil, err
+			}
+
+			r.add(Event{Kind: NextEvent, Value: item})
+			return item, nil
+		},
+		Done: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			r.add(Event{Kind: DoneEvent, Value: item})
+			return item, err
+		},
+		Error: func(ctx context.Context, err error, _ interface{}) (interface{}, error) {
+			r.add(Event{Kind: ErrorEvent, Err: err})
+			return nil, err
+		},
+	}, false))
+
+	return r
+}
+
+func (r *Recorder) add(event Event) {
+	event.At = r.clock.Now().Sub(r.start)
+
+	r.ml.Lock()
+	r.events = append(r.events, event)
+	r.ml.Unlock()
+}
+
+// End ends the Recorder's subscription.
+func (r *Recorder) End() {
+	r.sub.End()
+}
+
+// Events returns a copy of all recorded events.
+func (r *Recorder) Events() []Event {
+	r.ml.Lock()
+	defer r.ml.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// Values returns the recorded values, in the order they where received.
+func (r *Recorder) Values() []interface{} {
+	var values []interface{}
+	for _, event := range r.Events() {
+		if event.Kind == NextEvent {
+			values = append(values, event.Value)
+		}
+	}
+
+	return values
+}
+
+// Errors returns the recorded errors, in the order they where received.
+func (r *Recorder) Errors() []error {
+	var errs []error
+	for _, event := range r.Events() {
+		if event.Kind == ErrorEvent {
+			errs = append(errs, event.Err)
+		}
+	}
+
+	return errs
+}
+
+// Completed returns true/false if the Recorder received a completion.
+func (r *Recorder) Completed() bool {
+	for _, event := range r.Events() {
+		if event.Kind == DoneEvent {
+			return true
+		}
+	}
+
+	return false
+}
+
+//==============================================================================
+
+// ExpectEmissions fails the test if the values recorded differ from the
+// expected values.
+func ExpectEmissions(t testing.TB, r *Recorder, values ...interface{}) {
+	t.Helper()
+
+	if got := r.Values(); !reflect.DeepEqual(got, values) {
+		t.Fatalf("Expected emissions %#v but got %#v", values, got)
+	}
+}
+
+// ExpectNoEmissions fails the test if any value was recorded.
+func ExpectNoEmissions(t testing.TB, r *Recorder) {
+	t.Helper()
+
+	if got := r.Values(); len(got) != 0 {
+		t.Fatalf("Expected no emissions but got %#v", got)
+	}
+}
+
+// ExpectCompleted fails the test if no completion was recorded.
+func ExpectCompleted(t testing.TB, r *Recorder) {
+	t.Helper()
+
+	if !r.Completed() {
+		t.Fatalf("Expected observable to have completed, recorded:\n%s", formatEvents(r.Events()))
+	}
+}
+
+// ExpectNotCompleted fails the test if a completion was recorded.
+func ExpectNotCompleted(t testing.TB, r *Recorder) {
+	t.Helper()
+
+	if r.Completed() {
+		t.Fatalf("Expected observable not to have completed, recorded:\n%s", formatEvents(r.Events()))
+	}
+}
+
+// ExpectError fails the test if no error was recorded.
+func ExpectError(t testing.TB, r *Recorder) {
+	t.Helper()
+
+	if len(r.Errors()) == 0 {
+		t.Fatalf("Expected observable to have errored, recorded:\n%s", formatEvents(r.Events()))
+	}
+}
+
+// ExpectMarbles fails the test if the recorded events do not match the marble
+// diagram, where every character is a frame of the giving duration. A '-' is
+// a frame where nothing happens, a letter is a frame where the value for that
+// letter within the values map is emitted, a '#' is a frame where an error is
+// received and a '|' is a frame where the observable completes. Characters
+// within parentheses, like "(ab)", all happen within a single frame. Spaces
+// are ignored and a event recorded at a time between two frames belongs to
+// the earlier frame.
+func ExpectMarbles(t testing.TB, r *Recorder, frame time.Duration, marbles string, values map[string]interface{}) {
+	t.Helper()
+
+	expected, err := parseMarbles(marbles, frame, values)
+	if err != nil {
+		t.Fatalf("Invalid marbles %q: %s", marbles, err)
+	}
+
+	got := r.Events()
+	for index := range got {
+		got[index].At = (got[index].At / frame) * frame
+	}
+
+	if !matchEvents(expected, got) {
+		t.Fatalf("Expected marbles %q:\n%s\nbut recorded:\n%s", marbles, formatEvents(expected), formatEvents(got))
+	}
+}
+
+func parseMarbles(marbles string, frame time.Duration, values map[string]interface{}) ([]Event, error) {
+	var events []Event
+	var at time.Duration
+	var group bool
+
+	for _, char := range strings.Replace(marbles, " ", "", -1) {
+		switch char {
+		case '-':
+		case '(':
+			if group {
+				return nil, fmt.Errorf("nested group")
+			}
+
+			group = true
+			continue
+		case ')':
+			if !group {
+				return nil, fmt.Errorf("unopened group")
+			}
+
+			group = false
+		case '#':
+			events = append(events, Event{At: at, Kind: ErrorEvent})
+		case '|':
+			events = append(events, Event{At: at, Kind: DoneEvent})
+		default:
+			value, ok := values[string(char)]
+			if !ok {
+				return nil, fmt.Errorf("no value for %q", char)
+			}
+
+			events = append(events, Event{At: at, Kind: NextEvent, Value: value})
+		}
+
+		if !group {
+			at += frame
+		}
+	}
+
+	if group {
+		return nil, fmt.Errorf("unclosed group")
+	}
+
+	return events, nil
+}
+
+// matchEvents compares the expected events to those recorded, where errors and
+// completion values are not compared.
+func matchEvents(expected, got []Event) bool {
+	if len(expected) != len(got) {
+		return false
+	}
+
+	for index, event := range expected {
+		item := got[index]
+		if item.At != event.At || item.Kind != event.Kind {
+			return false
+		}
+
+		if event.Kind == NextEvent && !reflect.DeepEqual(item.Value, event.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatEvents(events []Event) string {
+	if len(events) == 0 {
+		return "\t(none)"
+	}
+
+	lines := make([]string, len(events))
+	for index, event := range events {
+		lines[index] = "\t" + event.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,142 @@
+package observablestest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influx6/faux/context"
+	"github.com/influx6/fractals"
+	"github.com/influx6/fractals/observablestest"
+)
+
+func TestIntervalWithClock(t *testing.T) {
+	vs := observablestest.NewVirtualScheduler()
+
+	interval := fractals.IntervalWithClock(10*time.Millisecond, vs)
+	ob := fractals.TakeWithObserver(3, interval)
+	rec := observablestest.Record(ob, vs)
+
+	vs.Advance(25 * time.Millisecond)
+	observablestest.ExpectEmissions(t, rec, 0, 1)
+	observablestest.ExpectNotCompleted(t, rec)
+
+	vs.Advance(time.Second)
+	observablestest.ExpectMarbles(t, rec, 10*time.Millisecond, "-ab(c|)", map[string]interface{}{
+		"a": 0,
+		"b": 1,
+		"c": 2,
+	})
+
+	interval.End()
+
+	if vs.Pending() != 0 {
+		t.Fatalf("Expected interval timer to be stopped but %d tasks are pending", vs.Pending())
+	}
+}
+
+func TestThrottleWithClock(t *testing.T) {
+	vs := observablestest.NewVirtualScheduler()
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	rec := observablestest.Record(fractals.ThrottleWithClock(source, 20*time.Millisecond, vs), vs)
+
+	for _, item := range []string{"a", "b", "c", "d", "e"} {
+		source.NextVal(item)
+		vs.Advance(10 * time.Millisecond)
+	}
+
+	observablestest.ExpectMarbles(t, rec, 10*time.Millisecond, "a-c-e", map[string]interface{}{
+		"a": "a",
+		"c": "c",
+		"e": "e",
+	})
+}
+
+func TestDebounceWithClock(t *testing.T) {
+	vs := observablestest.NewVirtualScheduler()
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	ob := fractals.DebounceWithClock(source, 10*time.Millisecond, vs)
+	rec := observablestest.Record(ob, vs)
+
+	source.NextVal("Thunder")
+	source.NextVal("Thunder2")
+
+	vs.Advance(10 * time.Millisecond)
+	source.NextVal("Lightening")
+	source.NextVal("Thunder3")
+
+	vs.Advance(10 * time.Millisecond)
+	source.NextVal("Slickering")
+
+	values := rec.Values()
+	var seen []interface{}
+	for _, value := range values {
+		if value != nil {
+			seen = append(seen, value)
+		}
+	}
+
+	if len(seen) != 2 || seen[0] != "Lightening" || seen[1] != "Slickering" {
+		t.Fatalf("Expected only values after each tick but got %#v", seen)
+	}
+
+	ob.End()
+
+	if vs.Pending() != 0 {
+		t.Fatalf("Expected debounce timer to be stopped but %d tasks are pending", vs.Pending())
+	}
+}
+
+func TestRetryWithClock(t *testing.T) {
+	vs := observablestest.NewVirtualScheduler()
+
+	var runs int
+	source := fractals.NewColdObservable(func(o fractals.Observer) {
+		runs++
+		if runs < 3 {
+			o.Error(context.New(), errors.New("failed run"))
+			return
+		}
+
+		o.NextVal(runs)
+		o.DoneVal(true)
+	})
+
+	ob := fractals.RetryWithClock(source, 3, fractals.ExponentialBackoff(10*time.Millisecond, time.Second), vs)
+	rec := observablestest.Record(ob, vs)
+
+	vs.Advance(15 * time.Millisecond)
+	if runs != 2 {
+		t.Fatalf("Expected a single retry after the first backoff but got %d runs", runs)
+	}
+	observablestest.ExpectNoEmissions(t, rec)
+
+	vs.Advance(20 * time.Millisecond)
+	observablestest.ExpectEmissions(t, rec, 3)
+
+	ob.End()
+
+	if vs.Pending() != 0 {
+		t.Fatalf("Expected retry timer to be stopped but %d tasks are pending", vs.Pending())
+	}
+}
+
+func TestVirtualScheduler(t *testing.T) {
+	vs := observablestest.NewVirtualScheduler()
+
+	ob := fractals.NewScheduledObservable(fractals.IdentityBehaviour(), vs)
+	rec := observablestest.Record(ob, vs)
+
+	ob.NextVal(1)
+	ob.NextVal(2)
+	observablestest.ExpectNoEmissions(t, rec)
+
+	vs.Flush()
+	observablestest.ExpectEmissions(t, rec, 1, 2)
+
+	ob.DoneVal(true)
+	vs.Flush()
+	observablestest.ExpectCompleted(t, rec)
+}
@@ -1,8 +1,12 @@
 package fractals_test
 
 import (
+	stdcontext "context"
+	"errors"
 	"fmt"
+	"os"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -105,3 +109,972 @@ func TestObserver(t *testing.T) {
 	ob.End()
 	ob2.End()
 }
+
+func TestObserverUnsubscribe(t *testing.T) {
+	var count int64
+
+	ob := fractals.NewObservable(fractals.NewBehaviour(func(name string) string {
+		return "Mr." + name
+	}, nil, nil), false)
+
+	first := ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(name string) {
+		atomic.AddInt64(&count, 1)
+	}, nil, nil), false))
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(name string) {
+		atomic.AddInt64(&count, 10)
+	}, nil, nil), false))
+
+	ob.Next(context.New(), "Thunder")
+	if total := atomic.LoadInt64(&count); total != 11 {
+		fatalFailed(t, "Should have recieved %d but got %d", 11, total)
+	}
+	logPassed(t, "Should have delivered to all subscribers")
+
+	ob.Unsubscribe(first)
+	ob.Next(context.New(), "Thunder")
+
+	if total := atomic.LoadInt64(&count); total != 21 {
+		fatalFailed(t, "Should have recieved %d but got %d", 21, total)
+	}
+	logPassed(t, "Should have delivered only to remaining subscriber")
+
+	ob.End()
+}
+
+func TestObserverConcurrentAccess(t *testing.T) {
+	var wg sync.WaitGroup
+
+	ob := fractals.NewObservable(fractals.NewBehaviour(func(name string) string {
+		return "Mr." + name
+	}, nil, nil), false).Async()
+
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+
+		go func() {
+			defer wg.Done()
+			ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(name string) {}, nil, nil), false))
+		}()
+
+		go func() {
+			defer wg.Done()
+			ob.Next(context.New(), "Thunder")
+		}()
+
+		go func() {
+			defer wg.Done()
+			sub := ob.Subscribe(fractals.ReplayObservable(0))
+			sub.End()
+		}()
+	}
+
+	wg.Wait()
+	ob.End()
+	logPassed(t, "Should have handled concurrent Subscribe/Next/End")
+}
+
+func TestReplayObservable(t *testing.T) {
+	var names []string
+
+	ob := fractals.ReplayObservable(2)
+	ob.NextVal("Thunder")
+	ob.NextVal("Lightening")
+	ob.NextVal("Slickering")
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(name string) {
+		names = append(names, name)
+	}, nil, nil), false))
+
+	if len(names) != 2 || names[0] != "Lightening" || names[1] != "Slickering" {
+		fatalFailed(t, "Should have replayed last %d values but got %+q", 2, names)
+	}
+	logPassed(t, "Should have replayed last %d values", 2)
+
+	ob.NextVal("Rain")
+	if len(names) != 3 || names[2] != "Rain" {
+		fatalFailed(t, "Should have recieved new value after replay but got %+q", names)
+	}
+	logPassed(t, "Should have recieved new value after replay")
+
+	var echoes []string
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(name string) {
+		echoes = append(echoes, name)
+		if name == "Rain" {
+			ob.NextVal("Storm")
+		}
+	}, nil, nil), false))
+
+	if fmt.Sprint(echoes) != "[Slickering Rain Storm]" {
+		fatalFailed(t, "Should have emitted from within replay but got %+q", echoes)
+	}
+	logPassed(t, "Should have emitted from within replay")
+
+	ob.End()
+}
+
+func TestColdObservable(t *testing.T) {
+	var runs int64
+
+	ob := fractals.NewColdObservable(func(o fractals.Observer) {
+		atomic.AddInt64(&runs, 1)
+		o.NextVal("Thunder")
+		o.NextVal("Lightening")
+		o.DoneVal(true)
+	})
+
+	var first, second []string
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(name string) {
+		first = append(first, name)
+	}, nil, nil), false))
+
+	sub := ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(name string) {
+		second = append(second, name)
+	}, nil, nil), false))
+
+	if sub.Observer() != nil || sub.Reason() != fractals.ErrCompleted {
+		fatalFailed(t, "Should have ended subscription once producer completed")
+	}
+	logPassed(t, "Should have ended subscription once producer completed")
+
+	if atomic.LoadInt64(&runs) != 2 {
+		fatalFailed(t, "Should have run producer %d times but ran %d", 2, runs)
+	}
+	logPassed(t, "Should have run producer once per subscriber")
+
+	if len(first) != 2 || len(second) != 2 {
+		fatalFailed(t, "Should have recieved full sequence per subscriber: %+q %+q", first, second)
+	}
+	logPassed(t, "Should have recieved full sequence per subscriber")
+
+	ob.End()
+}
+
+func TestFromSlice(t *testing.T) {
+	var names []string
+	var done bool
+
+	ob := fractals.FromSlice([]string{"Thunder", "Lightening"})
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(name string) {
+		names = append(names, name)
+	}, func(ended bool) {
+		done = ended
+	}, nil), false))
+
+	if len(names) != 2 || !done {
+		fatalFailed(t, "Should have recieved all items and done signal: %+q", names)
+	}
+	logPassed(t, "Should have recieved all items and done signal")
+}
+
+func TestFromChannel(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	items := make(chan int, 2)
+	items <- 1
+	items <- 2
+	close(items)
+
+	ob := fractals.FromChannel(items)
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(number int) {
+		wg.Done()
+	}, func(ended bool) {
+		wg.Done()
+	}, nil), false))
+
+	wg.Wait()
+	logPassed(t, "Should have recieved all items and done signal")
+}
+
+func TestCombineLatestWithObserver(t *testing.T) {
+	var combined []string
+
+	config := fractals.ReplayObservable(1)
+	requests := fractals.ReplayObservable(1)
+
+	ob := fractals.CombineLatestWithObserver(fractals.NewBehaviour(func(values []interface{}) string {
+		return fmt.Sprintf("%s:%s", values[0], values[1])
+	}, nil, nil), config, requests)
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item string) {
+		combined = append(combined, item)
+	}, nil, nil), false))
+
+	config.NextVal("debug")
+	if len(combined) != 0 {
+		fatalFailed(t, "Should not have emitted until all sources emitted: %+q", combined)
+	}
+	logPassed(t, "Should not have emitted until all sources emitted")
+
+	requests.NextVal("/index")
+	requests.NextVal("/about")
+	config.NextVal("release")
+
+	expected := []string{"debug:/index", "debug:/about", "release:/about"}
+	if fmt.Sprint(combined) != fmt.Sprint(expected) {
+		fatalFailed(t, "Should have recieved %+q but got %+q", expected, combined)
+	}
+	logPassed(t, "Should have recieved %+q", expected)
+
+	ob.End()
+}
+
+func TestZipWithObserver(t *testing.T) {
+	var zipped []string
+
+	requests := fractals.ReplayObservable(1)
+	responses := fractals.ReplayObservable(1)
+
+	ob := fractals.ZipWithObserver(requests, responses, fractals.NewBehaviour(func(pair []interface{}) string {
+		return fmt.Sprintf("%s=%s", pair[0], pair[1])
+	}, nil, nil))
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item string) {
+		zipped = append(zipped, item)
+	}, nil, nil), false))
+
+	requests.NextVal("req1")
+	requests.NextVal("req2")
+	responses.NextVal("res1")
+	responses.NextVal("res2")
+	responses.NextVal("res3")
+
+	expected := []string{"req1=res1", "req2=res2"}
+	if fmt.Sprint(zipped) != fmt.Sprint(expected) {
+		fatalFailed(t, "Should have recieved %+q but got %+q", expected, zipped)
+	}
+	logPassed(t, "Should have recieved %+q", expected)
+
+	requests.NextVal("req3")
+	if len(zipped) != 3 || zipped[2] != "req3=res3" {
+		fatalFailed(t, "Should have paired buffered emission but got %+q", zipped)
+	}
+	logPassed(t, "Should have paired buffered emission")
+
+	ob.End()
+}
+
+func TestTakeWithObserver(t *testing.T) {
+	var items []int
+	var done, ended bool
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	ob := fractals.TakeWithObserver(2, source)
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item int) {
+		items = append(items, item)
+	}, func(item interface{}) {
+		done = true
+	}, nil), false), func() {
+		ended = true
+	})
+
+	for i := 1; i <= 4; i++ {
+		source.NextVal(i)
+	}
+
+	if fmt.Sprint(items) != "[1 2]" {
+		fatalFailed(t, "Should have recieved only the first 2 values but got %+v", items)
+	}
+	logPassed(t, "Should have recieved only the first 2 values")
+
+	if !done || !ended {
+		fatalFailed(t, "Should have signaled done and ended subscription: done(%t) ended(%t)", done, ended)
+	}
+	logPassed(t, "Should have signaled done and ended subscription")
+}
+
+func TestTakeUntilWithObserver(t *testing.T) {
+	var items []int
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	notifier := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	ob := fractals.TakeUntilWithObserver(notifier, source)
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item int) {
+		items = append(items, item)
+	}, nil, nil), false))
+
+	source.NextVal(1)
+	source.NextVal(2)
+	notifier.NextVal(true)
+	source.NextVal(3)
+
+	if fmt.Sprint(items) != "[1 2]" {
+		fatalFailed(t, "Should have stopped emitting once notified but got %+v", items)
+	}
+	logPassed(t, "Should have stopped emitting once notified")
+}
+
+func TestTakeWhileWithObserver(t *testing.T) {
+	var items []int
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	ob := fractals.TakeWhileWithObserver(func(item interface{}) bool {
+		return item.(int) < 3
+	}, source)
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item int) {
+		items = append(items, item)
+	}, nil, nil), false))
+
+	for _, item := range []int{1, 2, 3, 1} {
+		source.NextVal(item)
+	}
+
+	if fmt.Sprint(items) != "[1 2]" {
+		fatalFailed(t, "Should have stopped emitting once predicate failed but got %+v", items)
+	}
+	logPassed(t, "Should have stopped emitting once predicate failed")
+}
+
+func TestScanWithObserver(t *testing.T) {
+	var totals []int
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	ob := fractals.ScanWithObserver(0, fractals.NewBehaviour(func(pair []interface{}) int {
+		return pair[0].(int) + pair[1].(int)
+	}, nil, nil), source)
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(total int) {
+		totals = append(totals, total)
+	}, nil, nil), false))
+
+	for i := 1; i <= 4; i++ {
+		source.NextVal(i)
+	}
+
+	if fmt.Sprint(totals) != "[1 3 6 10]" {
+		fatalFailed(t, "Should have recieved running totals but got %+v", totals)
+	}
+	logPassed(t, "Should have recieved running totals")
+}
+
+func TestThrottleWithObserver(t *testing.T) {
+	var ml sync.Mutex
+	var items []int
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	ob := fractals.ThrottleWithObserver(source, 50*time.Millisecond)
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item int) {
+		ml.Lock()
+		items = append(items, item)
+		ml.Unlock()
+	}, nil, nil), false))
+
+	source.NextVal(1)
+	source.NextVal(2)
+	source.NextVal(3)
+
+	time.Sleep(70 * time.Millisecond)
+
+	source.NextVal(4)
+	source.NextVal(5)
+
+	ml.Lock()
+	defer ml.Unlock()
+
+	if fmt.Sprint(items) != "[1 4]" {
+		fatalFailed(t, "Should have recieved the first value of each window but got %+v", items)
+	}
+	logPassed(t, "Should have recieved the first value of each window")
+}
+
+func TestScheduledObservable(t *testing.T) {
+	var pending []func()
+	var items []int
+
+	scheduler := fractals.SchedulerFunc(func(work func()) {
+		pending = append(pending, work)
+	})
+
+	ob := fractals.NewScheduledObservable(fractals.NewBehaviour(func(item int) int {
+		items = append(items, item)
+		return item
+	}, nil, nil), scheduler)
+
+	ob.NextVal(1)
+	ob.NextVal(2)
+
+	if len(items) != 0 || len(pending) != 2 {
+		fatalFailed(t, "Should have scheduled work without running it: items(%+v) pending(%d)", items, len(pending))
+	}
+	logPassed(t, "Should have scheduled work without running it")
+
+	for _, work := range pending {
+		work()
+	}
+
+	if fmt.Sprint(items) != "[1 2]" {
+		fatalFailed(t, "Should have runned scheduled work in order but got %+v", items)
+	}
+	logPassed(t, "Should have runned scheduled work in order")
+}
+
+func TestQueueScheduler(t *testing.T) {
+	var ml sync.Mutex
+	var items []int
+	var wg sync.WaitGroup
+
+	ob := fractals.NewScheduledObservable(fractals.NewBehaviour(func(item int) {
+		ml.Lock()
+		items = append(items, item)
+		ml.Unlock()
+		wg.Done()
+	}, nil, nil), fractals.NewQueueScheduler())
+
+	wg.Add(100)
+	for i := 0; i < 100; i++ {
+		ob.NextVal(i)
+	}
+	wg.Wait()
+
+	ml.Lock()
+	defer ml.Unlock()
+
+	for index, item := range items {
+		if index != item {
+			fatalFailed(t, "Should have runned work in the order it was scheduled but got %d at %d", item, index)
+		}
+	}
+	logPassed(t, "Should have runned work in the order it was scheduled")
+}
+
+func TestPoolSchedulerStop(t *testing.T) {
+	var ran int32
+
+	pool := fractals.NewPoolScheduler(1)
+	release := make(chan struct{})
+
+	// The worker schedules more work while the backlog is full, waiting on
+	// itself till the pool is stopped.
+	pool.Schedule(func() {
+		<-release
+		pool.Schedule(func() {
+			atomic.AddInt32(&ran, 1)
+		})
+	})
+
+	pool.Schedule(func() {
+		atomic.AddInt32(&ran, 1)
+	})
+
+	close(release)
+
+	stopped := make(chan struct{})
+	go func() {
+		pool.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		fatalFailed(t, "Should have stopped pool with work waiting on the backlog")
+	}
+	logPassed(t, "Should have stopped pool with work waiting on the backlog")
+
+	for i := 0; i < 100 && atomic.LoadInt32(&ran) != 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	if atomic.LoadInt32(&ran) != 2 {
+		fatalFailed(t, "Should have runned all scheduled work but ran %d", ran)
+	}
+	logPassed(t, "Should have runned all scheduled work")
+}
+
+func TestFrameScheduler(t *testing.T) {
+	var frames []func()
+	var items []int
+
+	scheduler := fractals.NewFrameScheduler(func(frame func()) {
+		frames = append(frames, frame)
+	})
+
+	ob := fractals.NewScheduledObservable(fractals.NewBehaviour(func(item int) {
+		items = append(items, item)
+	}, nil, nil), scheduler)
+
+	ob.NextVal(1)
+	ob.NextVal(2)
+	ob.NextVal(3)
+
+	if len(frames) != 1 || len(items) != 0 {
+		fatalFailed(t, "Should have requested a single frame: frames(%d) items(%+v)", len(frames), items)
+	}
+	logPassed(t, "Should have requested a single frame")
+
+	frames[0]()
+
+	if fmt.Sprint(items) != "[1 2 3]" {
+		fatalFailed(t, "Should have runned all work within the frame but got %+v", items)
+	}
+	logPassed(t, "Should have runned all work within the frame")
+}
+
+func TestObserverError(t *testing.T) {
+	var errs []error
+	var items []string
+	var ended bool
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+
+	recovered := fractals.NewObservable(fractals.Behaviour{
+		Next: fractals.IdentityHandler(),
+		Error: fractals.MustWrap(func(ctx context.Context, err error) (interface{}, error) {
+			if err.Error() == "recoverable" {
+				return "recovered", nil
+			}
+
+			return nil, err
+		}),
+		ErrorPolicy: fractals.TerminateOnError,
+	}, false)
+
+	source.Subscribe(recovered)
+
+	recovered.Subscribe(fractals.NewObservable(fractals.Behaviour{
+		Next: fractals.MustWrap(func(item string) {
+			items = append(items, item)
+		}),
+		Error: fractals.MustWrap(func(err error) {
+			errs = append(errs, err)
+		}),
+	}, false), func() {
+		ended = true
+	})
+
+	source.NextVal("first")
+	source.Error(context.New(), errors.New("recoverable"))
+
+	if fmt.Sprint(items) != "[first recovered]" || len(errs) != 0 || ended {
+		fatalFailed(t, "Should have recovered from error: items(%+v) errs(%+v) ended(%t)", items, errs, ended)
+	}
+	logPassed(t, "Should have recovered from error")
+
+	source.Error(context.New(), errors.New("fatal"))
+
+	if len(errs) != 1 || !ended {
+		fatalFailed(t, "Should have passed on error and terminated: errs(%+v) ended(%t)", errs, ended)
+	}
+	logPassed(t, "Should have passed on error and terminated")
+
+	source.NextVal("last")
+
+	if len(items) != 2 {
+		fatalFailed(t, "Should not have recieved values after termination: %+v", items)
+	}
+	logPassed(t, "Should not have recieved values after termination")
+}
+
+func TestRetryWithObserver(t *testing.T) {
+	var runs int32
+
+	source := fractals.NewColdObservable(func(o fractals.Observer) {
+		run := atomic.AddInt32(&runs, 1)
+
+		o.NextVal(int(run))
+		if run < 3 {
+			o.Error(context.New(), errors.New("failed run"))
+			return
+		}
+
+		o.DoneVal(true)
+	})
+
+	done := make(chan struct{})
+
+	var ml sync.Mutex
+	var items []int
+
+	ob := fractals.RetryWithObserver(source, 3, fractals.ExponentialBackoff(time.Millisecond, 5*time.Millisecond))
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item int) {
+		ml.Lock()
+		items = append(items, item)
+		ml.Unlock()
+	}, func() {
+		close(done)
+	}, nil), false))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		fatalFailed(t, "Should have completed after retrying")
+	}
+
+	ml.Lock()
+	defer ml.Unlock()
+
+	if fmt.Sprint(items) != "[2 3]" || atomic.LoadInt32(&runs) != 3 {
+		fatalFailed(t, "Should have resubscribed till success: runs(%d) items(%+v)", runs, items)
+	}
+	logPassed(t, "Should have resubscribed till success")
+
+	ob.End()
+}
+
+func TestRetryWithObserverExhausted(t *testing.T) {
+	source := fractals.NewColdObservable(func(o fractals.Observer) {
+		o.Error(context.New(), errors.New("always fails"))
+	})
+
+	failed := make(chan error, 1)
+
+	ob := fractals.RetryWithObserver(source, 2, nil)
+	ob.Subscribe(fractals.NewObservable(fractals.Behaviour{
+		Next: fractals.IdentityHandler(),
+		Error: fractals.MustWrap(func(err error) {
+			failed <- err
+		}),
+	}, false))
+
+	select {
+	case err := <-failed:
+		logPassed(t, "Should have passed on error after exhausting attempts: %+q", err)
+	case <-time.After(time.Second):
+		fatalFailed(t, "Should have passed on error after exhausting attempts")
+	}
+
+	ob.End()
+}
+
+func TestOnErrorResumeNextWithObserver(t *testing.T) {
+	var items []string
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	fallback := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+
+	ob := fractals.OnErrorResumeNextWithObserver(source, fallback)
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item string) {
+		items = append(items, item)
+	}, nil, nil), false))
+
+	source.NextVal("source")
+	fallback.NextVal("ignored")
+	source.Error(context.New(), errors.New("bad source"))
+	source.NextVal("ignored")
+	fallback.NextVal("fallback")
+
+	if fmt.Sprint(items) != "[source fallback]" {
+		fatalFailed(t, "Should have switched to fallback on error but got %+v", items)
+	}
+	logPassed(t, "Should have switched to fallback on error")
+}
+
+func TestRetryHandler(t *testing.T) {
+	var calls int
+
+	hl := fractals.RetryHandler(fractals.MustWrap(func(item int) (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errors.New("not yet")
+		}
+
+		return item * 2, nil
+	}), 5, nil)
+
+	res, err := hl(nil, nil, 2)
+	if err != nil || res != 4 || calls != 3 {
+		fatalFailed(t, "Should have retried handler till success: res(%+v) err(%+q) calls(%d)", res, err, calls)
+	}
+	logPassed(t, "Should have retried handler till success")
+
+	calls = 0
+	failing := fractals.RetryHandler(fractals.MustWrap(func(item int) (int, error) {
+		calls++
+		return 0, errors.New("never")
+	}), 2, nil)
+
+	if _, err := failing(nil, nil, 2); err == nil || calls != 3 {
+		fatalFailed(t, "Should have retried handler max times after first call: err(%+q) calls(%d)", err, calls)
+	}
+	logPassed(t, "Should have retried handler max times after first call")
+
+	std, cancel := stdcontext.WithCancel(stdcontext.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	waiting := fractals.RetryHandler(fractals.MustWrap(func(item int) (int, error) {
+		return 0, errors.New("never")
+	}), 2, func(int) time.Duration { return time.Minute })
+
+	started := time.Now()
+	if _, err := waiting(fractals.NewContext(std), nil, 2); err != stdcontext.Canceled || time.Since(started) > 5*time.Second {
+		fatalFailed(t, "Should have stopped waiting once cancelled: %+q", err)
+	}
+	logPassed(t, "Should have stopped waiting once cancelled")
+}
+
+func TestShareWithObserver(t *testing.T) {
+	var first, second []int
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+
+	shared := fractals.ShareWithObserver(fractals.MapWithObserver(fractals.NewBehaviour(func(item int) int {
+		return item * 10
+	}, nil, nil), source))
+
+	sub1 := shared.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item int) {
+		first = append(first, item)
+	}, nil, nil), false))
+
+	sub2 := shared.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item int) {
+		second = append(second, item)
+	}, nil, nil), false))
+
+	source.NextVal(1)
+	source.NextVal(2)
+
+	if fmt.Sprint(first) != "[10 20]" || fmt.Sprint(second) != "[10 20]" {
+		fatalFailed(t, "Should have multicasted values: first(%+v) second(%+v)", first, second)
+	}
+	logPassed(t, "Should have multicasted values")
+
+	sub1.End()
+	source.NextVal(3)
+
+	if fmt.Sprint(second) != "[10 20 30]" {
+		fatalFailed(t, "Should have stayed connected with remaining subscriber: %+v", second)
+	}
+	logPassed(t, "Should have stayed connected with remaining subscriber")
+
+	sub2.End()
+	source.NextVal(4)
+
+	if fmt.Sprint(first) != "[10 20]" || fmt.Sprint(second) != "[10 20 30]" {
+		fatalFailed(t, "Should have disconnected after last subscriber left: first(%+v) second(%+v)", first, second)
+	}
+	logPassed(t, "Should have disconnected after last subscriber left")
+
+	var runs int
+	cold := fractals.ShareWithObserver(fractals.NewColdObservable(func(o fractals.Observer) {
+		runs++
+		o.NextVal(runs)
+		o.DoneVal(true)
+	}))
+
+	var items []int
+	var completions int
+
+	for i := 0; i < 2; i++ {
+		cold.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item int) {
+			items = append(items, item)
+		}, func(bool) {
+			completions++
+		}, nil), false))
+	}
+
+	if fmt.Sprint(items) != "[1 2]" || completions != 2 || runs != 2 {
+		fatalFailed(t, "Should have resubscribed after target completed: items(%+v) completions(%d) runs(%d)", items, completions, runs)
+	}
+	logPassed(t, "Should have resubscribed after target completed")
+}
+
+func TestPublishWithObserver(t *testing.T) {
+	var items []int
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	published := fractals.PublishWithObserver(source)
+
+	published.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(item int) {
+		items = append(items, item)
+	}, nil, nil), false))
+
+	source.NextVal(1)
+	published.Connect()
+	source.NextVal(2)
+	published.Disconnect()
+	source.NextVal(3)
+
+	if fmt.Sprint(items) != "[2]" {
+		fatalFailed(t, "Should have only recieved values while connected but got %+v", items)
+	}
+	logPassed(t, "Should have only recieved values while connected")
+}
+
+func TestObserverCompletion(t *testing.T) {
+	var items []string
+	var completed, finalized int
+
+	ob := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	ob.AddFinalizer(func() {
+		finalized++
+	})
+
+	ob.OnComplete(func() {
+		completed++
+	})
+
+	sub := fractals.NewObservable(fractals.NewBehaviour(func(item string) {
+		items = append(items, item)
+	}, nil, nil), false)
+
+	var subCompleted bool
+	sub.OnComplete(func() {
+		subCompleted = true
+	})
+
+	ob.Subscribe(sub)
+
+	ob.NextVal("first")
+	ob.DoneVal(true)
+	ob.DoneVal(true)
+	ob.NextVal("second")
+	ob.End()
+
+	if fmt.Sprint(items) != "[first]" {
+		fatalFailed(t, "Should have ignored values after completion but got %+v", items)
+	}
+	logPassed(t, "Should have ignored values after completion")
+
+	if completed != 1 || finalized != 1 || !subCompleted {
+		fatalFailed(t, "Should have completed and finalized once: completed(%d) finalized(%d) subscriber(%t)", completed, finalized, subCompleted)
+	}
+	logPassed(t, "Should have completed and finalized once")
+
+	var late bool
+	ob.OnComplete(func() {
+		late = true
+	})
+
+	if !late {
+		fatalFailed(t, "Should have called completion callback added after completion")
+	}
+	logPassed(t, "Should have called completion callback added after completion")
+}
+
+func TestObserverContextCancellation(t *testing.T) {
+	var ml sync.Mutex
+	var items []string
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+
+	std, cancel := stdcontext.WithCancel(stdcontext.Background())
+	ended := make(chan struct{})
+
+	fractals.SubscribeWithContext(std, source, fractals.NewObservable(fractals.NewBehaviour(func(item string) {
+		ml.Lock()
+		items = append(items, item)
+		ml.Unlock()
+	}, nil, nil), false), func() {
+		close(ended)
+	})
+
+	reqCtx, reqCancel := stdcontext.WithCancel(stdcontext.Background())
+	ctx := fractals.NewContext(reqCtx)
+
+	source.Next(ctx, "first")
+	reqCancel()
+	source.Next(ctx, "skipped")
+
+	ml.Lock()
+	if fmt.Sprint(items) != "[first]" {
+		ml.Unlock()
+		fatalFailed(t, "Should have skipped values with cancelled context but got %+v", items)
+	}
+	ml.Unlock()
+	logPassed(t, "Should have skipped values with cancelled context")
+
+	cancel()
+
+	select {
+	case <-ended:
+	case <-time.After(time.Second):
+		fatalFailed(t, "Should have ended subscription when context was cancelled")
+	}
+	logPassed(t, "Should have ended subscription when context was cancelled")
+
+	source.NextVal("after")
+
+	ml.Lock()
+	defer ml.Unlock()
+
+	if len(items) != 1 {
+		fatalFailed(t, "Should not have recieved values after subscription ended: %+v", items)
+	}
+	logPassed(t, "Should not have recieved values after subscription ended")
+}
+
+func TestSubscriptionOnEnd(t *testing.T) {
+	var order []string
+
+	parent := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	parent.AddFinalizer(func() {
+		order = append(order, "parent")
+	})
+
+	child := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	child.AddFinalizer(func() {
+		order = append(order, "child")
+	})
+
+	var reason error
+	sub := parent.Subscribe(child, child.End)
+	sub.OnEnd(func(err error) {
+		reason = err
+	})
+
+	parent.DoneVal(true)
+
+	if fmt.Sprint(order) != "[child parent]" {
+		fatalFailed(t, "Should have finalized children before parents but got %+v", order)
+	}
+	logPassed(t, "Should have finalized children before parents")
+
+	if reason != fractals.ErrCompleted {
+		fatalFailed(t, "Should have ended with completion reason but got %+q", reason)
+	}
+	logPassed(t, "Should have ended with completion reason")
+
+	failing := fractals.NewObservable(fractals.Behaviour{
+		Next:        fractals.IdentityHandler(),
+		ErrorPolicy: fractals.TerminateOnError,
+	}, false)
+
+	failure := errors.New("failed")
+	failSub := failing.Subscribe(fractals.NewObservable(fractals.IdentityBehaviour(), false))
+	failing.Error(context.New(), failure)
+
+	var lateReason error
+	failSub.OnEnd(func(err error) {
+		lateReason = err
+	})
+
+	if lateReason != failure {
+		fatalFailed(t, "Should have ended with terminating error but got %+q", lateReason)
+	}
+	logPassed(t, "Should have ended with terminating error")
+}
+
+func TestSignalObservable(t *testing.T) {
+	received := make(chan os.Signal, 1)
+
+	ob := fractals.SignalObservable(os.Interrupt)
+	defer ob.End()
+
+	ob.Subscribe(fractals.NewObservable(fractals.NewBehaviour(func(sig os.Signal) {
+		received <- sig
+	}, nil, nil), false))
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		fatalFailed(t, "Should have found current process: %s", err)
+	}
+
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Skipf("Unable to signal current process: %s", err)
+	}
+
+	select {
+	case sig := <-received:
+		if sig != os.Interrupt {
+			fatalFailed(t, "Should have recieved interrupt signal but got %s", sig)
+		}
+	case <-time.After(time.Second):
+		fatalFailed(t, "Should have recieved interrupt signal")
+	}
+	logPassed(t, "Should have recieved interrupt signal")
+}
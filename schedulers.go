@@ -0,0 +1,260 @@
+package fractals
+
+import (
+	"sync"
+	"time"
+)
+
+// Scheduler defines a type which decides where and when the work of an
+// Observable gets runned, be it within the current goroutine, a new goroutine
+// or a queue of work.
+type Scheduler interface {
+	Schedule(func())
+}
+
+// SchedulerFunc defines a function type which implements the Scheduler
+// interface.
+type SchedulerFunc func(func())
+
+// Schedule calls the function with the giving work.
+func (fn SchedulerFunc) Schedule(work func()) {
+	fn(work)
+}
+
+var (
+	// ImmediateScheduler runs work within the current goroutine, as it is
+	// scheduled.
+	ImmediateScheduler Scheduler = immediateScheduler{}
+
+	// GoroutineScheduler runs every scheduled work within a new goroutine.
+	GoroutineScheduler Scheduler = goroutineScheduler{}
+)
+
+type immediateScheduler struct{}
+
+// Schedule runs the work within the current goroutine.
+func (immediateScheduler) Schedule(work func()) {
+	work()
+}
+
+type goroutineScheduler struct{}
+
+// Schedule runs the work within a new goroutine.
+func (goroutineScheduler) Schedule(work func()) {
+	go work()
+}
+
+//==============================================================================
+
+// PoolScheduler defines a Scheduler which runs work on a fixed set of
+// goroutines, blocking Schedule calls when all workers are busy and the
+// backlog is full.
+type PoolScheduler struct {
+	work    chan func()
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	senders sync.WaitGroup
+
+	ml     sync.RWMutex
+	closed bool
+}
+
+// NewPoolScheduler returns a new PoolScheduler running the giving number of
+// workers, with a backlog of the same size. If workers is less than 1, a
+// single worker is used.
+func NewPoolScheduler(workers int) *PoolScheduler {
+	if workers < 1 {
+		workers = 1
+	}
+
+	pool := &PoolScheduler{work: make(chan func(), workers), stop: make(chan struct{})}
+
+	pool.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go pool.run()
+	}
+
+	return pool
+}
+
+func (p *PoolScheduler) run() {
+	defer p.wg.Done()
+
+	for work := range p.work {
+		work()
+	}
+}
+
+// Schedule queues the work to be runned by one of the pool's workers. If the
+// pool has been stopped, including while waiting for room in the backlog, the
+// work is runned within a new goroutine.
+func (p *PoolScheduler) Schedule(work func()) {
+	p.ml.RLock()
+	if p.closed {
+		p.ml.RUnlock()
+		go work()
+		return
+	}
+
+	p.senders.Add(1)
+	p.ml.RUnlock()
+
+	defer p.senders.Done()
+
+	select {
+	case p.work <- work:
+	case <-p.stop:
+		go work()
+	}
+}
+
+// Stop stops the pool's workers once all queued work is done, waiting for
+// them to finish.
+func (p *PoolScheduler) Stop() {
+	p.ml.Lock()
+	if p.closed {
+		p.ml.Unlock()
+		return
+	}
+
+	p.closed = true
+	close(p.stop)
+	p.ml.Unlock()
+
+	// Work is no longer sent once the waiting senders give up, so the
+	// backlog can be closed for the workers to drain.
+	p.senders.Wait()
+	close(p.work)
+
+	p.wg.Wait()
+}
+
+//==============================================================================
+
+// QueueScheduler defines a Scheduler which runs work one after the other in
+// the order it was scheduled, within a single goroutine which only lives for
+// as long as there is work queued. This ensures the behaviours of an Observable
+// never run concurrently while not blocking the caller.
+type QueueScheduler struct {
+	ml      sync.Mutex
+	running bool
+	queue   []func()
+}
+
+// NewQueueScheduler returns a new instance of a QueueScheduler.
+func NewQueueScheduler() *QueueScheduler {
+	return &QueueScheduler{}
+}
+
+// Schedule adds the work to the end of the queue.
+func (q *QueueScheduler) Schedule(work func()) {
+	q.ml.Lock()
+	defer q.ml.Unlock()
+
+	q.queue = append(q.queue, work)
+
+	if !q.running {
+		q.running = true
+		go q.drain()
+	}
+}
+
+func (q *QueueScheduler) drain() {
+	for {
+		q.ml.Lock()
+		if len(q.queue) == 0 {
+			q.running = false
+			q.ml.Unlock()
+			return
+		}
+
+		work := q.queue[0]
+		q.queue = q.queue[1:]
+		q.ml.Unlock()
+
+		work()
+	}
+}
+
+//==============================================================================
+
+// FrameScheduler defines a Scheduler which batches work until the next frame,
+// where the request function decides when that frame occurs, generally a
+// binding to the browser's requestAnimationFrame. All work scheduled before a
+// frame is runned within that frame in the order it was scheduled.
+type FrameScheduler struct {
+	request   func(func())
+	ml        sync.Mutex
+	requested bool
+	queue     []func()
+}
+
+// NewFrameScheduler returns a new FrameScheduler which uses the request
+// function to ask for the next frame.
+func NewFrameScheduler(request func(func())) *FrameScheduler {
+	if request == nil {
+		panic("No frame request function provided")
+	}
+
+	return &FrameScheduler{request: request}
+}
+
+// Schedule adds the work to run on the next frame, requesting the frame if
+// none is pending.
+func (f *FrameScheduler) Schedule(work func()) {
+	f.ml.Lock()
+	f.queue = append(f.queue, work)
+
+	if f.requested {
+		f.ml.Unlock()
+		return
+	}
+
+	f.requested = true
+	f.ml.Unlock()
+
+	f.request(f.flush)
+}
+
+// flush runs all work queued before the frame.
+func (f *FrameScheduler) flush() {
+	f.ml.Lock()
+	queue := f.queue
+	f.queue = nil
+	f.requested = false
+	f.ml.Unlock()
+
+	for _, work := range queue {
+		work()
+	}
+}
+
+//==============================================================================
+
+// Clock defines the source of time used by the time based operators, which
+// allows replacing real time with a virtual one within tests.
+type Clock interface {
+	Now() time.Time
+	AfterFunc(time.Duration, func()) Timer
+}
+
+// Timer defines a pending call created by a Clock's AfterFunc.
+type Timer interface {
+	Stop() bool
+}
+
+// SystemClock defines the Clock which uses the real time from the time
+// package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+// Now returns the current local time.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc calls the function within it's own goroutine after the duration.
+func (systemClock) AfterFunc(d time.Duration, fn func()) Timer {
+	return time.AfterFunc(d, fn)
+}
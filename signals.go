@@ -0,0 +1,38 @@
+package fractals
+
+import (
+	"os"
+	"os/signal"
+
+	"github.com/influx6/faux/context"
+)
+
+// SignalObservable returns a Observable which emits every os.Signal of the
+// giving types the process receives, or all signals if none are provided.
+// Once ended, the process stops relaying the signals to it.
+func SignalObservable(sig ...os.Signal) Observable {
+	ob := NewObservable(IdentityBehaviour(), false)
+
+	signals := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+
+	signal.Notify(signals, sig...)
+
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case item := <-signals:
+				ob.Next(context.New(), item)
+			}
+		}
+	}()
+
+	ob.AddFinalizer(func() {
+		signal.Stop(signals)
+		close(stop)
+	})
+
+	return ob
+}
@@ -0,0 +1,222 @@
+//go:build go1.18
+// +build go1.18
+
+package fractals
+
+import (
+	"fmt"
+
+	"github.com/influx6/faux/context"
+)
+
+// TypedObservable defines a Observable which only emits values of type T. It
+// wraps a dynamic Observable, which can be retrieved through Observable, so
+// typed and dynamic observers can be mixed within the same graph.
+type TypedObservable[T any] struct {
+	ob Observable
+}
+
+// NewTypedObservable returns a new instance of a TypedObservable which emits
+// values of type T.
+func NewTypedObservable[T any](async bool) *TypedObservable[T] {
+	return &TypedObservable[T]{ob: NewObservable(IdentityBehaviour(), async)}
+}
+
+// AsTyped returns a TypedObservable which emits the values of the dynamic
+// Observable which are of type T. Values of any other type are not silently
+// dropped, instead they are passed on as errors wrapping ErrInvalidType, while
+// errors passed to Next are passed on as errors.
+func AsTyped[T any](target Observable) *TypedObservable[T] {
+	ob := NewObservable(IdentityBehaviour(), false)
+
+	sub := target.Subscribe(NewObservable(Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				ob.Error(ctx, err)
+				return nil, err
+			}
+
+			// A nil value is what filtering observers emit for dropped values.
+			if item == nil {
+				return nil, nil
+			}
+
+			if _, ok := item.(T); !ok {
+				err := invalidType[T](item)
+				ob.Error(ctx, err)
+				return nil, err
+			}
+
+			ob.Next(ctx, item)
+			return item, nil
+		},
+		Done: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			ob.Done(ctx, item)
+			return item, err
+		},
+		Error: forwardError(ob),
+	}, false))
+
+	ob.AddFinalizer(sub.End)
+
+	return &TypedObservable[T]{ob: ob}
+}
+
+// Observable returns the dynamic Observable the TypedObservable wraps.
+func (t *TypedObservable[T]) Observable() Observable {
+	return t.ob
+}
+
+// Next emits the giving value to all subscribers using the provided context.
+func (t *TypedObservable[T]) Next(ctx context.Context, val T) {
+	t.ob.Next(ctx, val)
+}
+
+// NextVal emits the giving value to all subscribers using a new context.
+func (t *TypedObservable[T]) NextVal(val T) {
+	t.ob.NextVal(val)
+}
+
+// Error passes the giving error to all subscribers.
+func (t *TypedObservable[T]) Error(ctx context.Context, err error) {
+	t.ob.Error(ctx, err)
+}
+
+// Done completes the observable.
+func (t *TypedObservable[T]) Done(ctx context.Context) {
+	t.ob.Done(ctx, true)
+}
+
+// End disconnects all subscribers, running the observable's finalizers.
+func (t *TypedObservable[T]) End() {
+	t.ob.End()
+}
+
+// AddFinalizer adds a function to be called once the observable has ended.
+func (t *TypedObservable[T]) AddFinalizer(fn func()) {
+	t.ob.AddFinalizer(fn)
+}
+
+// OnComplete adds a function to be called once the observable has completed.
+func (t *TypedObservable[T]) OnComplete(fn func()) {
+	t.ob.OnComplete(fn)
+}
+
+// Subscribe calls the giving function with every value emitted, returning the
+// subscription which disconnects it.
+func (t *TypedObservable[T]) Subscribe(next func(T), finalizers ...func()) *Subscription {
+	return t.SubscribeWithError(next, nil, finalizers...)
+}
+
+// SubscribeWithError calls the next function with every value emitted and the
+// fail function, if not nil, with every error, returning the subscription which
+// disconnects both.
+func (t *TypedObservable[T]) SubscribeWithError(next func(T), fail func(error), finalizers ...func()) *Subscription {
+	behaviour := Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				if fail != nil {
+					fail(err)
+				}
+
+				return nil, err
+			}
+
+			if val, ok := item.(T); ok {
+				next(val)
+			}
+
+			return item, nil
+		},
+	}
+
+	if fail != nil {
+		behaviour.Error = func(ctx context.Context, err error, _ interface{}) (interface{}, error) {
+			fail(err)
+			return nil, err
+		}
+	}
+
+	return t.ob.Subscribe(NewObservable(behaviour, false), finalizers...)
+}
+
+// Filter returns a TypedObservable which only emits the values for which the
+// predicate returns true.
+func (t *TypedObservable[T]) Filter(predicate func(T) bool) *TypedObservable[T] {
+	return AsTyped[T](FilterWithObserver(func(item interface{}) bool {
+		val, ok := item.(T)
+		return ok && predicate(val)
+	}, t.ob))
+}
+
+// Take returns a TypedObservable which only emits the first n values.
+func (t *TypedObservable[T]) Take(n int) *TypedObservable[T] {
+	return &TypedObservable[T]{ob: TakeWithObserver(n, t.ob)}
+}
+
+// Share returns a TypedObservable which multicasts the values through a
+// single subscription, as done by ShareWithObserver.
+func (t *TypedObservable[T]) Share() *TypedObservable[T] {
+	return &TypedObservable[T]{ob: ShareWithObserver(t.ob)}
+}
+
+// MapTyped returns a TypedObservable which emits the result of the mapper for
+// every value the source emits. Values of any other type, such as those
+// emitted through the source's dynamic Observable, are passed on as errors
+// wrapping ErrInvalidType.
+func MapTyped[T, U any](source *TypedObservable[T], mapper func(T) U) *TypedObservable[U] {
+	return &TypedObservable[U]{ob: MapWithObserver(Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			if err != nil {
+				return nil, err
+			}
+
+			// A nil value is what filtering observers emit for dropped values.
+			if item == nil {
+				return nil, nil
+			}
+
+			val, ok := item.(T)
+			if !ok {
+				return nil, invalidType[T](item)
+			}
+
+			return mapper(val), nil
+		},
+		Done: identity,
+	}, source.ob)}
+}
+
+// ScanTyped returns a TypedObservable which emits the running accumulation of
+// the values the source emits, starting from the seed. Values of any other
+// type are passed on as errors wrapping ErrInvalidType, leaving the
+// accumulation as it was.
+func ScanTyped[T, U any](source *TypedObservable[T], seed U, acc func(U, T) U) *TypedObservable[U] {
+	return &TypedObservable[U]{ob: ScanWithObserver(seed, Behaviour{
+		Next: func(ctx context.Context, err error, item interface{}) (interface{}, error) {
+			pair := item.([]interface{})
+
+			// A nil seed holds no type to assert, leaving the zero value of
+			// the type.
+			total, ok := pair[0].(U)
+			if !ok && pair[0] != nil {
+				return nil, invalidType[U](pair[0])
+			}
+
+			val, ok := pair[1].(T)
+			if !ok {
+				return nil, invalidType[T](pair[1])
+			}
+
+			return acc(total, val), nil
+		},
+		Done: identity,
+	}, source.ob)}
+}
+
+// invalidType returns the error wrapping ErrInvalidType for an item which is
+// not of type T.
+func invalidType[T any](item interface{}) error {
+	var zero T
+	return fmt.Errorf("%w: expected %T got %T", ErrInvalidType, zero, item)
+}
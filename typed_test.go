@@ -0,0 +1,130 @@
+//go:build go1.18
+// +build go1.18
+
+package fractals_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/influx6/fractals"
+)
+
+func TestTypedObservable(t *testing.T) {
+	var totals []int
+
+	numbers := fractals.NewTypedObservable[int](false)
+
+	evens := numbers.Filter(func(n int) bool {
+		return n%2 == 0
+	})
+
+	labels := fractals.MapTyped(fractals.ScanTyped(evens, 0, func(total, n int) int {
+		return total + n
+	}), func(total int) string {
+		return fmt.Sprintf("total:%d", total)
+	})
+
+	evens.Subscribe(func(n int) {
+		totals = append(totals, n)
+	})
+
+	var results []string
+	labels.Subscribe(func(label string) {
+		results = append(results, label)
+	})
+
+	for i := 1; i <= 6; i++ {
+		numbers.NextVal(i)
+	}
+
+	if fmt.Sprint(totals) != "[2 4 6]" {
+		fatalFailed(t, "Should have recieved filtered values but got %+v", totals)
+	}
+	logPassed(t, "Should have recieved filtered values")
+
+	if fmt.Sprint(results) != "[total:2 total:6 total:12]" {
+		fatalFailed(t, "Should have recieved mapped totals but got %+v", results)
+	}
+	logPassed(t, "Should have recieved mapped totals")
+
+	var failures []error
+
+	fractals.ScanTyped[int, error](numbers, nil, func(last error, n int) error {
+		if last == nil && n > 6 {
+			return fmt.Errorf("overflow at %d", n)
+		}
+		return last
+	}).SubscribeWithError(func(err error) {
+		failures = append(failures, err)
+	}, func(err error) {
+		failures = append(failures, err)
+	})
+
+	numbers.NextVal(7)
+	numbers.NextVal(8)
+
+	if fmt.Sprint(failures) != "[overflow at 7 overflow at 7]" {
+		fatalFailed(t, "Should have scanned from nil seed but got %+v", failures)
+	}
+	logPassed(t, "Should have scanned from nil seed")
+}
+
+func TestAsTypedMismatch(t *testing.T) {
+	var items []string
+	var errs []error
+
+	source := fractals.NewObservable(fractals.IdentityBehaviour(), false)
+	typed := fractals.AsTyped[string](source)
+
+	typed.SubscribeWithError(func(item string) {
+		items = append(items, item)
+	}, func(err error) {
+		errs = append(errs, err)
+	})
+
+	source.NextVal("bob")
+	source.NextVal(20)
+
+	if fmt.Sprint(items) != "[bob]" || len(errs) != 1 || !errors.Is(errs[0], fractals.ErrInvalidType) {
+		fatalFailed(t, "Should have reported mismatched type as error: items(%+v) errs(%+v)", items, errs)
+	}
+	logPassed(t, "Should have reported mismatched type as error")
+}
+
+func TestTypedOperatorsMismatch(t *testing.T) {
+	var labels, totals []string
+	var errs []error
+
+	numbers := fractals.NewTypedObservable[int](false)
+
+	fractals.MapTyped(numbers, func(n int) string {
+		return fmt.Sprintf("label:%d", n)
+	}).SubscribeWithError(func(label string) {
+		labels = append(labels, label)
+	}, func(err error) {
+		errs = append(errs, err)
+	})
+
+	fractals.ScanTyped(numbers, 0, func(total, n int) int {
+		return total + n
+	}).SubscribeWithError(func(total int) {
+		totals = append(totals, fmt.Sprint(total))
+	}, func(err error) {
+		errs = append(errs, err)
+	})
+
+	numbers.NextVal(2)
+	numbers.Observable().NextVal("wrong")
+	numbers.NextVal(3)
+
+	if fmt.Sprint(labels) != "[label:2 label:3]" || fmt.Sprint(totals) != "[2 5]" {
+		fatalFailed(t, "Should have kept mapping and scanning past mismatch: labels(%+v) totals(%+v)", labels, totals)
+	}
+
+	if len(errs) != 2 || !errors.Is(errs[0], fractals.ErrInvalidType) || !errors.Is(errs[1], fractals.ErrInvalidType) {
+		fatalFailed(t, "Should have reported mismatched type as error: %+v", errs)
+	}
+	logPassed(t, "Should have reported mismatched type as error")
+}